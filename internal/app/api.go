@@ -1,13 +1,40 @@
 package app
 
 import (
+	"context"
+	"time"
+
+	auditRepo "github.com/mdanialr/pwman_backend/internal/domain/audit/repository"
 	auth "github.com/mdanialr/pwman_backend/internal/domain/auth/delivery"
 	authRepo "github.com/mdanialr/pwman_backend/internal/domain/auth/repository"
 	authUC "github.com/mdanialr/pwman_backend/internal/domain/auth/usecase"
+	backup "github.com/mdanialr/pwman_backend/internal/domain/backup/delivery"
+	backupUC "github.com/mdanialr/pwman_backend/internal/domain/backup/usecase"
+	emergency "github.com/mdanialr/pwman_backend/internal/domain/emergency/delivery"
+	emRepo "github.com/mdanialr/pwman_backend/internal/domain/emergency/repository"
+	emUC "github.com/mdanialr/pwman_backend/internal/domain/emergency/usecase"
+	events "github.com/mdanialr/pwman_backend/internal/domain/event/delivery"
+	org "github.com/mdanialr/pwman_backend/internal/domain/org/delivery"
+	orgRepo "github.com/mdanialr/pwman_backend/internal/domain/org/repository"
+	orgUC "github.com/mdanialr/pwman_backend/internal/domain/org/usecase"
 	pw "github.com/mdanialr/pwman_backend/internal/domain/password/delivery"
 	pwRepo "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
 	pwUC "github.com/mdanialr/pwman_backend/internal/domain/password/usecase"
+	report "github.com/mdanialr/pwman_backend/internal/domain/report/delivery"
+	reportUC "github.com/mdanialr/pwman_backend/internal/domain/report/usecase"
+	syncDelivery "github.com/mdanialr/pwman_backend/internal/domain/sync/delivery"
+	syncUC "github.com/mdanialr/pwman_backend/internal/domain/sync/usecase"
+	vault "github.com/mdanialr/pwman_backend/internal/domain/vault/delivery"
+	vaultRepo "github.com/mdanialr/pwman_backend/internal/domain/vault/repository"
+	vaultUC "github.com/mdanialr/pwman_backend/internal/domain/vault/usecase"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	"github.com/mdanialr/pwman_backend/pkg/breach"
+	"github.com/mdanialr/pwman_backend/pkg/event"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/notifier"
+	"github.com/mdanialr/pwman_backend/pkg/scheduler"
 	"github.com/mdanialr/pwman_backend/pkg/storage"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/viper"
@@ -17,7 +44,13 @@ import (
 
 // HttpHandler handler that use HTTP in the delivery layer.
 type HttpHandler struct {
-	R       fiber.Router
+	// Ctx the app context that will be done when the app is shutting down.
+	// Used to stop all background jobs.
+	Ctx context.Context
+	R   fiber.Router
+	// Public router that's not under the api prefix, for links that are
+	// handed to those without an account.
+	Public  fiber.Router
 	Log     *zap.Logger
 	Storage storage.Port
 	DB      *gorm.DB
@@ -36,15 +69,90 @@ func (h *HttpHandler) SetupRouter() {
 	// currently use v1
 	v1 := h.R.Group("/v1")
 
+	// keep the data key that encrypt the secrets while the vault is unlocked
+	keeper := vk.NewKeeper(h.interval("vault.idle_timeout", 15*time.Minute))
+
 	// init repositories
 	authRepository := authRepo.NewRepository(h.DB)
-	pwRepository := pwRepo.NewRepository(h.DB)
+	pwRepository := pwRepo.NewRepository(h.DB, keeper)
+	auditRepository := auditRepo.NewRepository(h.DB)
+	orgRepository := orgRepo.NewRepository(h.DB)
+	emRepository := emRepo.NewRepository(h.DB)
+	vaultRepository := vaultRepo.NewRepository(h.DB)
+
+	// init breach checker, notifier and event bus
+	br := h.setupBreach()
+	nt := notifier.NewWithConfig(h.Config, h.Log)
+	ev := event.NewBus(h.Config.GetInt("events.history"))
 
 	// init use cases
 	authUseCase := authUC.NewUseCase(h.Config, h.Log, authRepository)
-	pwUseCase := pwUC.NewUseCase(h.Config, h.Log, h.Storage, pwRepository)
+	// check the role of the caller in the organizations first
+	pwUseCase := pwUC.NewPolicy(pwRepository, orgRepository, pwUC.NewUseCase(h.Config, h.Log, h.Storage, br, nt, ev, pwRepository))
+	reportUseCase := reportUC.NewUseCase(h.Config, h.Log, pwRepository)
+	backupUseCase := backupUC.NewUseCase(h.Config, h.Log, pwRepository, auditRepository)
+	syncUseCase := syncUC.NewUseCase(h.Config, h.Log, pwRepository)
+	orgUseCase := orgUC.NewUseCase(h.Config, h.Log, orgRepository)
+	emUseCase := emUC.NewUseCase(h.Config, h.Log, nt, emRepository)
+	vaultUseCase := vaultUC.NewUseCase(h.Config, h.Log, keeper, vaultRepository)
+
+	// start locked if the master password is set
+	if err := vaultUseCase.Setup(h.Ctx); err != nil {
+		h.Log.Fatal(help.Pad("failed to set up the vault:", err.Error()))
+	}
+	// the endpoints that use the secrets are not available while locked
+	for _, prefix := range []string{"/password", "/report", "/export", "/sync"} {
+		v1.Use(prefix, md.Unlocked(keeper.Locked))
+	}
 
 	// init handlers
-	auth.NewDelivery(v1, authUseCase)       // - /auth/*
-	pw.NewDelivery(v1, h.Config, pwUseCase) // - /category/*
+	auth.NewDelivery(v1, h.Config, authUseCase)                  // - /auth/*
+	pw.NewDelivery(v1, h.Config, pwUseCase)                      // - /category/*
+	report.NewDelivery(v1, h.Config, reportUseCase)              // - /report/*
+	backup.NewDelivery(v1, h.Config, backupUseCase, authUseCase) // - /export/*
+	syncDelivery.NewDelivery(v1, h.Config, syncUseCase)          // - /sync/*
+	events.NewDelivery(h.Ctx, v1, h.Config, ev, pwUseCase)       // - /events/*
+	org.NewDelivery(v1, h.Config, orgUseCase)                    // - /org/*
+	emergency.NewDelivery(v1, h.Config, emUseCase)               // - /emergency/*
+	vault.NewDelivery(v1, h.Config, vaultUseCase)                // - /vault/*
+	pw.NewPublicDelivery(h.Public, pwUseCase)                    // - /s/*
+
+	// run background jobs
+	go scheduler.Every(h.Ctx, h.interval("rotation.interval", time.Hour), func(ctx context.Context) {
+		pwUseCase.NotifyRotation(ctx)
+	})
+	// run the persisted jobs such as the end of emergency access waiting
+	// period
+	jobs := scheduler.NewQueue(emRepository, h.Log)
+	jobs.Handle(emUC.JobApprove, emUseCase.ApproveAccess)
+	go scheduler.Every(h.Ctx, h.interval("jobs.interval", time.Minute), jobs.Run)
+	// forget the password logins that's never verified
+	go scheduler.Every(h.Ctx, time.Minute, authUseCase.ExpireSRP)
+	// pick up the data key that's added by the rotation
+	go scheduler.Every(h.Ctx, h.interval("vault.sync_interval", time.Minute), vaultUseCase.Sync)
+}
+
+// interval retrieve given config key as duration in minutes. Fallback to given
+// default duration if it's not set.
+func (h *HttpHandler) interval(key string, def time.Duration) time.Duration {
+	if m := h.Config.GetInt(key); m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return def
+}
+
+// setupBreach init breach.Port using the Pwned Passwords file from config.
+// Fallback to the one that never report any breach if the file is not set or
+// failed to be opened.
+func (h *HttpHandler) setupBreach() breach.Port {
+	path := h.Config.GetString("breach.path")
+	if path == "" {
+		return breach.NewNoop()
+	}
+	br, err := breach.NewFile(path)
+	if err != nil {
+		h.Log.Error(help.Pad("failed to open breach file, breach check is disabled:", err.Error()))
+		return breach.NewNoop()
+	}
+	return br
 }
@@ -0,0 +1,372 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	auditRepo "github.com/mdanialr/pwman_backend/internal/domain/audit/repository"
+	"github.com/mdanialr/pwman_backend/internal/domain/auth"
+	authRepo "github.com/mdanialr/pwman_backend/internal/domain/auth/repository"
+	authUC "github.com/mdanialr/pwman_backend/internal/domain/auth/usecase"
+	"github.com/mdanialr/pwman_backend/internal/domain/backup"
+	backupUC "github.com/mdanialr/pwman_backend/internal/domain/backup/usecase"
+	pwRepo "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	vaultRepo "github.com/mdanialr/pwman_backend/internal/domain/vault/repository"
+	vaultUC "github.com/mdanialr/pwman_backend/internal/domain/vault/usecase"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	conf "github.com/mdanialr/pwman_backend/pkg/config"
+	gl "github.com/mdanialr/pwman_backend/pkg/gorm"
+	"github.com/mdanialr/pwman_backend/pkg/postgresql"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"golang.org/x/term"
+	"gorm.io/gorm"
+)
+
+const (
+	// PassphraseEnv the environment variable that's used as the backup
+	// passphrase in CLI. The passphrase is asked from stdin if it's not set.
+	PassphraseEnv = "PWMAN_BACKUP_PASSPHRASE"
+	// MasterEnv the environment variable that's used as the current master
+	// password in CLI. The password is asked from stdin if it's not set.
+	MasterEnv = "PWMAN_MASTER_PASSWORD"
+	// NewMasterEnv the environment variable that's used as the new master
+	// password in CLI. The password is asked from stdin if it's not set.
+	NewMasterEnv = "PWMAN_NEW_MASTER_PASSWORD"
+)
+
+// CLI handler that's used by the command line flags.
+type CLI struct {
+	Log    *zap.Logger
+	DB     *gorm.DB
+	Config *viper.Viper
+}
+
+// NewCLI init CLI along with its dependencies using the app config.
+func NewCLI() (*CLI, error) {
+	v, err := conf.InitConfigYml()
+	if err != nil {
+		return nil, err
+	}
+	log, err := zap.NewDevelopment()
+	if err != nil {
+		return nil, err
+	}
+	db, err := postgresql.NewGorm(v,
+		postgresql.WithCustomLogger(gl.New(os.Stdout, gl.WithLogLevel(2), gl.WithIgnoreRecordNotFound())),
+		postgresql.WithSingularTableName(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CLI{Log: log, DB: db, Config: v}, nil
+}
+
+// Export write the encrypted backup of the whole vault to given path.
+func (c *CLI) Export(path string, all bool) error {
+	req := backup.RequestExport{Passphrase: readSecret(PassphraseEnv, "Backup passphrase: ", true), All: all}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	uc, err := c.backupUseCase()
+	if err != nil {
+		return err
+	}
+	ctx, err := c.ownerContext()
+	if err != nil {
+		return err
+	}
+
+	// write to temporary file first, so failed export never leave a broken
+	// backup in given path
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".pwman-export-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	write, err := uc.Export(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err = write(ctx, tmp); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ImportBackup restore the encrypted backup from given path using given
+// conflict strategy.
+func (c *CLI) ImportBackup(path, conflict string) error {
+	req := backup.RequestRestore{Passphrase: readSecret(PassphraseEnv, "Backup passphrase: ", false), Conflict: conflict}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	uc, err := c.backupUseCase()
+	if err != nil {
+		return err
+	}
+
+	fl, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	// restore into the vault of the owner
+	ctx, err := c.ownerContext()
+	if err != nil {
+		return err
+	}
+	res, err := uc.Restore(ctx, fl, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Categories: %d created, %d updated, %d skipped\n", res.Categories.Created, res.Categories.Updated, res.Categories.Skipped)
+	fmt.Printf("Passwords: %d created, %d updated, %d skipped\n", res.Passwords.Created, res.Passwords.Updated, res.Passwords.Skipped)
+	if len(res.MissingFiles) > 0 {
+		fmt.Println("Missing media files:", strings.Join(res.MissingFiles, ", "))
+	}
+	return nil
+}
+
+// AddUser register new user with given username then print the OTP secret
+// that should be added to the 2FA app of the user.
+func (c *CLI) AddUser(username string) error {
+	req := auth.RequestUser{Username: username}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	uc := authUC.NewUseCase(c.Config, c.Log, authRepo.NewRepository(c.DB))
+	res, err := uc.CreateUser(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("User:", res.Username)
+	fmt.Println("Secret:", res.Secret)
+	return nil
+}
+
+// ChangeMaster change the master password of the vault by wrapping the data
+// key again, or set it for the first time then encrypt the existing passwords,
+// given batch of them in each transaction.
+func (c *CLI) ChangeMaster(batch int) error {
+	ctx := context.Background()
+	keeper := vk.NewKeeper(0)
+	uc := c.vaultUseCase(keeper)
+	if err := uc.Setup(ctx); err != nil {
+		return err
+	}
+
+	var req vault.RequestChangeMaster
+	if keeper.Enabled() {
+		req.Current = readSecret(MasterEnv, "Current master password: ", false)
+	}
+	req.New = readSecret(NewMasterEnv, "New master password: ", true)
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	first := !keeper.Enabled()
+	if err := uc.ChangeMaster(ctx, req); err != nil || !first {
+		return err
+	}
+
+	// the passwords that's saved before are still stored as is
+	res, err := uc.Reencrypt(ctx, batch, func(p *vault.ResponseRotate) {
+		fmt.Printf("Checked %d of %d passwords, encrypted %d\n", p.Done, p.Total, p.Rekeyed)
+	})
+	if err != nil {
+		return fmt.Errorf("master password is set but some passwords are not encrypted yet, run -rotate-key to encrypt them: %w", err)
+	}
+	fmt.Printf("Verified every password using data key version %d\n", res.Version)
+	return nil
+}
+
+// SplitKey split the data key into given number of shares, any given threshold
+// of which may recover it, then print them.
+func (c *CLI) SplitKey(shares, threshold int) error {
+	req := vault.RequestSplit{Master: readSecret(MasterEnv, "Master password: ", false), Shares: shares, Threshold: threshold}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	res, err := c.vaultUseCase(vk.NewKeeper(0)).SplitKey(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	for i, s := range res {
+		fmt.Printf("Share %d: %s\n", i+1, s)
+	}
+	fmt.Printf("Any %d of the %d shares recover the data key. Hand each of them to a different person.\n", threshold, shares)
+	return nil
+}
+
+// RecoverKey recover the data key from the shares that's read from stdin, one
+// per line until an empty line, then set new master password.
+func (c *CLI) RecoverKey() error {
+	var req vault.RequestRecover
+	for {
+		s := strings.TrimSpace(readHidden(fmt.Sprintf("Share %d (empty to finish): ", len(req.Shares)+1)))
+		if s == "" {
+			break
+		}
+		req.Shares = append(req.Shares, s)
+	}
+	req.New = readSecret(NewMasterEnv, "New master password: ", true)
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	return c.vaultUseCase(vk.NewKeeper(0)).RecoverKey(context.Background(), req)
+}
+
+// RotateKey replace the data key with new one, then re-encrypt every password
+// using it, given batch of them in each transaction. Resume the previous
+// rotation instead if it's interrupted. The data key that's split into shares
+// is only rotated if discard is true.
+func (c *CLI) RotateKey(batch int, discard bool) error {
+	req := vault.RequestRotate{Master: readSecret(MasterEnv, "Master password: ", false), Batch: batch, DiscardShares: discard}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	uc := c.vaultUseCase(vk.NewKeeper(0))
+	res, err := uc.RotateKey(ctx, req)
+	if err != nil {
+		return err
+	}
+	if res.Created {
+		// let the running servers pick up the new data key first, so they do
+		// not keep encrypting using the old one
+		wait := time.Minute
+		if m := c.Config.GetInt("vault.sync_interval"); m > 0 {
+			wait = time.Duration(m) * time.Minute
+		}
+		fmt.Printf("Created data key version %d, waiting %s for the running servers to pick it up\n", res.Version, wait)
+		time.Sleep(wait)
+	} else {
+		fmt.Printf("Resuming the rotation to data key version %d\n", res.Version)
+	}
+
+	res, err = uc.Reencrypt(ctx, batch, func(p *vault.ResponseRotate) {
+		fmt.Printf("Checked %d of %d passwords, re-encrypted %d\n", p.Done, p.Total, p.Rekeyed)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Verified every password using data key version %d\n", res.Version)
+	if len(res.Retired) > 0 {
+		fmt.Println("Retired data key versions:", res.Retired)
+	}
+	if res.Discarded {
+		fmt.Fprintln(os.Stderr, "WARNING: the shares of the retired data key no longer work, run -split-key to hand out new ones")
+	}
+	return nil
+}
+
+// ownerContext return context that carry the owner user, which is created by
+// the migration.
+func (c *CLI) ownerContext() (context.Context, error) {
+	usr, err := authRepo.NewRepository(c.DB).GetUserByUsername(context.Background(), auth.OwnerUsername(c.Config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the owner, make sure the migration has been run: %w", err)
+	}
+	return identity.NewContext(context.Background(), identity.User{ID: usr.ID, Admin: usr.IsAdmin}), nil
+}
+
+// backupUseCase init the use case of backup domain using the unlocked vault.
+func (c *CLI) backupUseCase() (backupUC.UseCase, error) {
+	keeper, err := c.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return backupUC.NewUseCase(c.Config, c.Log, pwRepo.NewRepository(c.DB, keeper), auditRepo.NewRepository(c.DB)), nil
+}
+
+// vaultUseCase init the use case of vault domain that keep the data key in
+// given vk.Keeper.
+func (c *CLI) vaultUseCase(keeper *vk.Keeper) vaultUC.UseCase {
+	return vaultUC.NewUseCase(c.Config, c.Log, keeper, vaultRepo.NewRepository(c.DB))
+}
+
+// unlock return vk.Keeper that's unlocked using the master password if it's
+// set, so the passwords can be used.
+func (c *CLI) unlock() (*vk.Keeper, error) {
+	ctx := context.Background()
+	keeper := vk.NewKeeper(0)
+	uc := c.vaultUseCase(keeper)
+	if err := uc.Setup(ctx); err != nil {
+		return nil, err
+	}
+	if !keeper.Enabled() {
+		return keeper, nil
+	}
+
+	req := vault.RequestUnlock{MasterPassword: readSecret(MasterEnv, "Master password: ", false)}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := uc.Unlock(ctx, req); err != nil {
+		return nil, err
+	}
+	return keeper, nil
+}
+
+// stdin shared by all prompts, so the lines that's piped into it are not
+// lost in the buffer of the previous prompt.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine print given prompt to stderr then read a line from stdin.
+func readLine(prompt string) string {
+	fmt.Fprint(os.Stderr, prompt)
+	s, _ := stdin.ReadString('\n')
+	return strings.TrimRight(s, "\r\n")
+}
+
+// readHidden same as readLine but without echoing the input if stdin is a
+// terminal.
+func readHidden(prompt string) string {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readLine(prompt)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	b, _ := term.ReadPassword(fd)
+	// the newline is not echoed either
+	fmt.Fprintln(os.Stderr)
+	return string(b)
+}
+
+// readSecret read the secret from given environment variable or ask it from
+// stdin using given prompt. Ask it twice if confirm is true.
+func readSecret(env, prompt string, confirm bool) string {
+	if pass := os.Getenv(env); pass != "" {
+		return pass
+	}
+
+	pass := readHidden(prompt)
+	if confirm && readHidden("Confirm: ") != pass {
+		fmt.Fprintln(os.Stderr, errors.New("does not match"))
+		return ""
+	}
+	return pass
+}
@@ -5,4 +5,9 @@ const (
 	InvalidOTP     = "INVALID_OTP"
 	DepsErr        = "DEPS_ERROR"
 	InvalidPayload = "INVALID_PAYLOAD"
+	InProgress     = "IN_PROGRESS"
+	Conflict       = "CONFLICT"
+	Forbidden      = "FORBIDDEN"
+	InvalidCred    = "INVALID_CREDENTIAL"
+	TooMany        = "TOO_MANY_REQUESTS"
 )
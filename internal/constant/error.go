@@ -10,4 +10,24 @@ var (
 	ErrAlreadyExist   = errors.New("data is already exist")
 	ErrNotFound       = errors.New("data not found")
 	ErrDataInUse      = errors.New("data still in use")
+	ErrInProgress     = errors.New("process is still in progress")
+	ErrCyclicParent   = errors.New("can not be moved into itself or its descendant")
+	ErrInvalidToken   = errors.New("invalid sync token")
+	ErrStaleRevision  = errors.New("data has been changed since it was retrieved")
+	ErrForbidden      = errors.New("not allowed to do this")
+	ErrSelfShare      = errors.New("can not share to yourself")
+	ErrLastOwner      = errors.New("organization should have at least one owner")
+	ErrNoVaultKey     = errors.New("zero-knowledge vault is not set up yet")
+	ErrNoMaster       = errors.New("master password is not set yet")
+	ErrWrongMaster    = errors.New("invalid master password")
+	ErrInvalidCred    = errors.New("invalid username or password")
+	ErrInvalidSRP     = errors.New("invalid salt or verifier")
+	ErrSRPBusy        = errors.New("too many logins in progress, try again later")
+	ErrNotSplit       = errors.New("data key is not split into shares yet")
+	ErrInvalidShares  = errors.New("invalid shares")
+	ErrShareTaken     = errors.New("another share with the same index is already submitted")
+	ErrRotating       = errors.New("data key rotation is in progress")
+	ErrNotRotating    = errors.New("data key rotation is not started yet")
+	ErrRotationStale  = errors.New("passwords are not re-encrypted using the new data key yet")
+	ErrSplitRotate    = errors.New("data key is split into shares that can not recover the new one, discard them to rotate")
 )
@@ -0,0 +1,14 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+)
+
+// Repository signature that's used in audit domain for repository layer.
+type Repository interface {
+	// CreateAuditLog create new entity.AuditLog and return the newly created
+	// object along with assigned id as primary key.
+	CreateAuditLog(ctx context.Context, obj entity.AuditLog) (*entity.AuditLog, error)
+}
@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+// NewRepository return concrete implementation of Repository that use gorm.DB
+// as the data source.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func (r *repository) CreateAuditLog(ctx context.Context, obj entity.AuditLog) (*entity.AuditLog, error) {
+	return &obj, r.db.WithContext(ctx).Create(&obj).Error
+}
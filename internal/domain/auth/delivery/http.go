@@ -1,19 +1,38 @@
 package delivery
 
 import (
+	"time"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
 	"github.com/mdanialr/pwman_backend/internal/domain/auth"
 	authUC "github.com/mdanialr/pwman_backend/internal/domain/auth/usecase"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
 	resp "github.com/mdanialr/pwman_backend/pkg/response"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
 )
 
+// defaultSRPRate the number of password logins that may be started every
+// minute from the same ip if it's not set in config.
+const defaultSRPRate = 10
+
 // NewDelivery setup endpoints in domain auth as delivery layer.
-func NewDelivery(app fiber.Router, uc authUC.UseCase) {
+func NewDelivery(app fiber.Router, conf *viper.Viper, uc authUC.UseCase) {
 	d := &delivery{uc: uc}
+	rate := defaultSRPRate
+	if n := conf.GetInt("srp.rate_limit"); n > 0 {
+		rate = n
+	}
 
 	api := app.Group("/auth")
 	api.Post("/otp", d.LoginOTP)
+	// every login hold a session in memory until it's verified or expired
+	api.Post("/srp/init", md.RateLimit(rate, time.Minute), d.InitSRP)
+	api.Post("/srp/verify", d.VerifySRP)
+	// setting the password need fresh OTP code on top of the access token
+	api.Post("/srp/register", md.JWT(conf), md.StepUp(uc.VerifyStepUp), d.RegisterSRP)
 }
 
 type delivery struct {
@@ -35,3 +54,49 @@ func (d *delivery) LoginOTP(c *fiber.Ctx) error {
 
 	return resp.Success(c, resp.WithData(usr))
 }
+
+func (d *delivery) InitSRP(c *fiber.Ctx) error {
+	var req auth.RequestSRPInit
+	c.BodyParser(&req)
+
+	res, err := d.uc.InitSRP(c.Context(), req)
+	if err != nil {
+		if e, ok := err.(*stderr.UC); ok && e.Code == cons.TooMany {
+			return resp.ErrorCode(c, fiber.StatusTooManyRequests, resp.WithErr(err))
+		}
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) VerifySRP(c *fiber.Ctx) error {
+	var req auth.RequestSRPVerify
+	c.BodyParser(&req)
+
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.VerifySRP(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) RegisterSRP(c *fiber.Ctx) error {
+	var req auth.RequestSRPRegister
+	c.BodyParser(&req)
+
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.SaveSRP(c.Context(), req); err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithMsg("password is set successfully"))
+}
@@ -8,12 +8,22 @@ import (
 
 // Repository signature that's used in auth domain for repository layer.
 type Repository interface {
-	// GetByCode retrieve an entity.RegisteredOTP by given code, also return
-	// error if any including record not found.
-	GetByCode(ctx context.Context, code string) (*entity.RegisteredOTP, error)
+	// GetByCode retrieve an entity.RegisteredOTP of given user id by given
+	// code, also return error if any including record not found.
+	GetByCode(ctx context.Context, userID uint, code string) (*entity.RegisteredOTP, error)
 	// Create save new instance of entity.RegisteredOTP that's only need given
-	// code.
-	Create(ctx context.Context, code string) (*entity.RegisteredOTP, error)
-	// DeleteAll batch delete all records of entity.RegisteredOTP.
-	DeleteAll(ctx context.Context) error
+	// user id and code.
+	Create(ctx context.Context, userID uint, code string) (*entity.RegisteredOTP, error)
+	// DeleteAll batch delete all records of entity.RegisteredOTP that belong
+	// to given user id.
+	DeleteAll(ctx context.Context, userID uint) error
+	// GetUserByID retrieve an entity.User by given id.
+	GetUserByID(ctx context.Context, id uint) (*entity.User, error)
+	// GetUserByUsername retrieve an entity.User by given username.
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	// CreateUser create new entity.User and return the newly created object
+	// along with assigned id as primary key.
+	CreateUser(ctx context.Context, obj entity.User) (*entity.User, error)
+	// UpdateUser update existing entity.User that match given id.
+	UpdateUser(ctx context.Context, id uint, obj entity.User) error
 }
@@ -18,16 +18,34 @@ type repository struct {
 	db *gorm.DB
 }
 
-func (r *repository) GetByCode(ctx context.Context, code string) (*entity.RegisteredOTP, error) {
-	ro := entity.RegisteredOTP{Code: code}
+func (r *repository) GetByCode(ctx context.Context, userID uint, code string) (*entity.RegisteredOTP, error) {
+	ro := entity.RegisteredOTP{UserID: userID, Code: code}
 	return &ro, r.db.WithContext(ctx).Where(&ro).Select("id").First(&ro).Error
 }
 
-func (r *repository) Create(ctx context.Context, code string) (*entity.RegisteredOTP, error) {
-	ro := entity.RegisteredOTP{Code: code}
+func (r *repository) Create(ctx context.Context, userID uint, code string) (*entity.RegisteredOTP, error) {
+	ro := entity.RegisteredOTP{UserID: userID, Code: code}
 	return &ro, r.db.WithContext(ctx).Create(&ro).Error
 }
 
-func (r *repository) DeleteAll(ctx context.Context) error {
-	return r.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&entity.RegisteredOTP{}).Error
+func (r *repository) DeleteAll(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entity.RegisteredOTP{}).Error
+}
+
+func (r *repository) GetUserByID(ctx context.Context, id uint) (*entity.User, error) {
+	usr := entity.User{ID: id}
+	return &usr, r.db.WithContext(ctx).First(&usr).Error
+}
+
+func (r *repository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var usr entity.User
+	return &usr, r.db.WithContext(ctx).Where("username = ?", username).First(&usr).Error
+}
+
+func (r *repository) CreateUser(ctx context.Context, obj entity.User) (*entity.User, error) {
+	return &obj, r.db.WithContext(ctx).Create(&obj).Error
+}
+
+func (r *repository) UpdateUser(ctx context.Context, id uint, obj entity.User) error {
+	return r.db.WithContext(ctx).Model(&entity.User{ID: id}).Updates(obj).Error
 }
@@ -1,10 +1,28 @@
 package auth
 
-import "github.com/go-playground/validator/v10"
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+)
+
+// DefaultUsername username of the owner user if it's not set in config.
+const DefaultUsername = "admin"
+
+// OwnerUsername return the username of the owner user, the admin whose OTP
+// secret is the one in config.
+func OwnerUsername(v *viper.Viper) string {
+	if usr := v.GetString("cred.username"); usr != "" {
+		return usr
+	}
+	return DefaultUsername
+}
 
 // Request standard request object that may be used in auth domain.
 type Request struct {
-	Code string `json:"code" validate:"required,numeric"`
+	// Username optional username of the user who log in. Fallback to the
+	// owner user if it's empty.
+	Username string `json:"username"`
+	Code     string `json:"code" validate:"required,numeric"`
 }
 
 // Validate apply validation rules for Request.
@@ -14,3 +32,60 @@ func (r *Request) Validate() validator.ValidationErrors {
 	}
 	return nil
 }
+
+// RequestUser request object to register new user.
+type RequestUser struct {
+	Username string `json:"username" validate:"required,max=64"`
+}
+
+// Validate apply validation rules for RequestUser.
+func (r *RequestUser) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestSRPInit request object to start logging in using the password.
+type RequestSRPInit struct {
+	// Username optional username of the user who log in. Fallback to the
+	// owner user if it's empty.
+	Username string `json:"username"`
+}
+
+// RequestSRPVerify request object to finish logging in using the password.
+type RequestSRPVerify struct {
+	// Session the session from the response of RequestSRPInit.
+	Session string `json:"session" validate:"required"`
+	// A the public ephemeral of the client.
+	A []byte `json:"a" validate:"required"`
+	// Proof the proof M1 of the client.
+	Proof []byte `json:"proof" validate:"required"`
+	// Code the OTP code as the second factor.
+	Code string `json:"code" validate:"required,numeric"`
+}
+
+// Validate apply validation rules for RequestSRPVerify.
+func (r *RequestSRPVerify) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestSRPRegister request object to set the password of the caller. Both
+// are computed by the client, so the password is never sent.
+type RequestSRPRegister struct {
+	// Salt the random salt of the verifier.
+	Salt []byte `json:"salt" validate:"required,min=16"`
+	// Verifier the SRP-6a verifier of the password.
+	Verifier []byte `json:"verifier" validate:"required"`
+}
+
+// Validate apply validation rules for RequestSRPRegister.
+func (r *RequestSRPRegister) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
@@ -7,3 +7,29 @@ type Response struct {
 	AccessToken string    `json:"access_token"`
 	ExpiredAt   time.Time `json:"expired_at"`
 }
+
+// ResponseUser response of newly registered user.
+type ResponseUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	// Secret the OTP secret that should be added to the 2FA app of the user.
+	Secret string `json:"secret"`
+}
+
+// ResponseSRPInit response of starting the password login.
+type ResponseSRPInit struct {
+	// Session should be sent back when finishing the login.
+	Session string `json:"session"`
+	// Salt the salt of the verifier of the user.
+	Salt []byte `json:"salt"`
+	// B the public ephemeral of the server.
+	B []byte `json:"b"`
+}
+
+// ResponseSRP response of finishing the password login.
+type ResponseSRP struct {
+	Response
+	// Proof the proof M2 of the server, so the client may make sure the
+	// server knows the verifier.
+	Proof []byte `json:"proof"`
+}
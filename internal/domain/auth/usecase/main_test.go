@@ -0,0 +1,46 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	authMock "github.com/mdanialr/pwman_backend/internal/domain/auth/repository/mocks"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// caller is the id of the user that calls the use cases in the tests.
+const caller = uint(1)
+
+type (
+	deps struct {
+		config *viper.Viper
+		log    *zap.Logger
+		repo   *authMock.MockauthRepository
+	}
+	helperSetup struct {
+		Dep deps
+	}
+)
+
+func setupTestHelper(t *testing.T) *helperSetup {
+	v := viper.New()
+	v.Set("jwt.secret", "secret")
+	d := deps{
+		config: v,
+		log:    zaptest.NewLogger(t),
+		repo:   new(authMock.MockauthRepository),
+	}
+
+	return &helperSetup{
+		Dep: d,
+	}
+}
+
+// callerCtx returns a context that holds the identity of the caller.
+func callerCtx() context.Context {
+	return identity.NewContext(context.Background(), identity.User{ID: caller})
+}
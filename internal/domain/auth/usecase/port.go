@@ -4,12 +4,40 @@ import (
 	"context"
 
 	"github.com/mdanialr/pwman_backend/internal/domain/auth"
+	"github.com/mdanialr/pwman_backend/internal/identity"
 )
 
 // UseCase a use case spec that's used in authentication domain.
 type UseCase interface {
-	// ValidateOTP return a Response by given request.
+	// ValidateOTP return a Response by given request. The code is verified
+	// using the OTP secret of the user in request. Rejected if the user has
+	// set the password, which should log in using InitSRP instead.
 	ValidateOTP(ctx context.Context, req auth.Request) (*auth.Response, error)
-	// CreateJWT create new jwt claims, then append the token to Response.
-	CreateJWT(ctx context.Context) (*auth.Response, error)
+	// CreateJWT create new jwt claims for given user, then append the token
+	// to Response.
+	CreateJWT(ctx context.Context, usr identity.User) (*auth.Response, error)
+	// VerifyStepUp verify given fresh otp code of the user in given ctx
+	// before allowing sensitive action. The code is regarded as used
+	// afterward.
+	VerifyStepUp(ctx context.Context, code string) error
+	// CreateUser register new user along with newly generated OTP secret.
+	// The username should be unique.
+	CreateUser(ctx context.Context, req auth.RequestUser) (*auth.ResponseUser, error)
+	// InitSRP start logging in the user in given request using the password.
+	// Return the salt and the public ephemeral of the server along with the
+	// session that should be sent back to VerifySRP. Unknown user, or the one
+	// without password, get a fake salt so the usernames can not be guessed.
+	// Rejected if there are too many logins in progress.
+	InitSRP(ctx context.Context, req auth.RequestSRPInit) (*auth.ResponseSRPInit, error)
+	// VerifySRP finish the login that's started by InitSRP. The proof of the
+	// password is checked first, then the OTP code as the second factor. Each
+	// session may only be tried once.
+	VerifySRP(ctx context.Context, req auth.RequestSRPVerify) (*auth.ResponseSRP, error)
+	// SaveSRP set the password of the caller using the verifier in given
+	// request, after which the caller may not log in using only the OTP.
+	SaveSRP(ctx context.Context, req auth.RequestSRPRegister) error
+	// ExpireSRP remove the sessions of the password logins that's expired, so
+	// they do not count toward the logins in progress. Should be run
+	// periodically.
+	ExpireSRP(ctx context.Context)
 }
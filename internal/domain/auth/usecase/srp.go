@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/auth"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/srp"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// srpTTL how long the session of the password login is valid.
+	srpTTL = 2 * time.Minute
+	// defaultSRPMax the number of password logins that may be in progress at
+	// once if it's not set in config.
+	defaultSRPMax = 1000
+)
+
+// srpSession the server side of a password login that's waiting for the proof
+// of the client.
+type srpSession struct {
+	// usr the user who log in. Nil for unknown user, which always fail.
+	usr       *entity.User
+	server    *srp.Server
+	expiredAt time.Time
+}
+
+// srpSessions the ongoing password logins by their id, up to the given max
+// number of them.
+type srpSessions struct {
+	mu  sync.Mutex
+	max int
+	m   map[string]*srpSession
+}
+
+// put save given session under new id. Return false if there are already max
+// number of sessions, which are only removed once they're used or expired.
+func (s *srpSessions) put(sess *srpSession) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.m) >= s.max {
+		return "", false
+	}
+	id := uuid.NewString()
+	s.m[id] = sess
+	return id, true
+}
+
+// expire remove the sessions that's expired by given time.
+func (s *srpSessions) expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, sess := range s.m {
+		if now.After(sess.expiredAt) {
+			delete(s.m, id)
+		}
+	}
+}
+
+// take remove then return the session that match given id. Return nil if it
+// does not exist or expired.
+func (s *srpSessions) take(id string) *srpSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.m[id]
+	if !ok {
+		return nil
+	}
+	delete(s.m, id)
+	if time.Now().After(sess.expiredAt) {
+		return nil
+	}
+	return sess
+}
+
+func (u *useCase) InitSRP(ctx context.Context, req auth.RequestSRPInit) (*auth.ResponseSRPInit, error) {
+	if req.Username == "" {
+		req.Username = auth.OwnerUsername(u.conf)
+	}
+
+	// the user that can not log in using the password look the same as the
+	// one that can, so the usernames can not be guessed
+	salt, verifier := u.fakeSRP(req.Username)
+	usr, err := u.repo.GetUserByUsername(ctx, req.Username)
+	if err == nil && len(usr.SrpVerifier) > 0 {
+		salt, verifier = usr.SrpSalt, usr.SrpVerifier
+	} else {
+		usr = nil
+	}
+
+	server, err := srp.NewServer(verifier)
+	if err != nil {
+		u.zap.Error(help.Pad("failed to start srp exchange:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	id, ok := u.srp.put(&srpSession{usr: usr, server: server, expiredAt: time.Now().Add(srpTTL)})
+	if !ok {
+		return nil, stderr.NewUCErr(cons.TooMany, cons.ErrSRPBusy)
+	}
+
+	return &auth.ResponseSRPInit{Session: id, Salt: salt, B: server.Public()}, nil
+}
+
+func (u *useCase) VerifySRP(ctx context.Context, req auth.RequestSRPVerify) (*auth.ResponseSRP, error) {
+	sess := u.srp.take(req.Session)
+	if sess == nil || sess.usr == nil {
+		return nil, stderr.NewUCErr(cons.InvalidCred, cons.ErrInvalidCred)
+	}
+	proof, err := sess.server.Verify(req.A, req.Proof)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidCred, cons.ErrInvalidCred)
+	}
+
+	// the password is right, then check the second factor
+	if err = u.verifyOTP(ctx, sess.usr, req.Code); err != nil {
+		return nil, err
+	}
+	res, err := u.CreateJWT(ctx, identity.User{ID: sess.usr.ID, Admin: sess.usr.IsAdmin})
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.ResponseSRP{Response: *res, Proof: proof}, nil
+}
+
+func (u *useCase) SaveSRP(ctx context.Context, req auth.RequestSRPRegister) error {
+	if !srp.ValidVerifier(req.Verifier) {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrInvalidSRP)
+	}
+
+	obj := entity.User{SrpSalt: req.Salt, SrpVerifier: req.Verifier}
+	if err := u.repo.UpdateUser(ctx, identity.FromContext(ctx).ID, obj); err != nil {
+		u.zap.Error(help.Pad("failed to save srp verifier:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	return nil
+}
+
+func (u *useCase) ExpireSRP(_ context.Context) {
+	u.srp.expire(time.Now())
+}
+
+// fakeSRP return the salt and verifier for given username that can not log in
+// using the password. The same username always get the same salt.
+func (u *useCase) fakeSRP(username string) (salt, verifier []byte) {
+	mac := func(label string) []byte {
+		h := hmac.New(sha256.New, []byte(u.conf.GetString("jwt.secret")))
+		h.Write([]byte(label + ":" + username))
+		return h.Sum(nil)
+	}
+	return mac("srp-salt")[:srp.SaltSize], mac("srp-verifier")
+}
@@ -0,0 +1,213 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/auth"
+	authUC "github.com/mdanialr/pwman_backend/internal/domain/auth/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/pkg/srp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseCase_InitSRP(t *testing.T) {
+	salt, verifier, err := srp.NewVerifier("john", "correct horse")
+	require.NoError(t, err)
+
+	t.Run("Given user with password should return the salt of the user", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetUserByUsername(mock.Anything, "john").
+			Return(&entity.User{ID: 2, Username: "john", SrpSalt: salt, SrpVerifier: verifier}, nil).
+			Once()
+
+		newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		res, err := newUC.InitSRP(context.Background(), auth.RequestSRPInit{Username: "john"})
+
+		require.NoError(t, err)
+		assert.Equal(t, salt, res.Salt)
+		assert.NotEmpty(t, res.Session)
+		assert.NotEmpty(t, res.B)
+	})
+
+	t.Run("Given unknown user should return the same fake salt every time", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetUserByUsername(mock.Anything, "doe").
+			Return(&entity.User{}, errors.New("record not found")).
+			Twice()
+
+		newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		res1, err := newUC.InitSRP(context.Background(), auth.RequestSRPInit{Username: "doe"})
+		require.NoError(t, err)
+		res2, err := newUC.InitSRP(context.Background(), auth.RequestSRPInit{Username: "doe"})
+		require.NoError(t, err)
+
+		assert.Len(t, res1.Salt, srp.SaltSize)
+		assert.Equal(t, res1.Salt, res2.Salt)
+		assert.NotEqual(t, res1.Session, res2.Session)
+	})
+
+	t.Run("Given too many logins in progress should return UC instance, TOO_MANY_REQUESTS as code and too "+
+		"many logins in progress, try again later as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.config.Set("srp.max_sessions", 1)
+		h.Dep.repo.EXPECT().
+			GetUserByUsername(mock.Anything, "doe").
+			Return(&entity.User{}, errors.New("record not found")).
+			Times(3)
+
+		newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		_, err := newUC.InitSRP(context.Background(), auth.RequestSRPInit{Username: "doe"})
+		require.NoError(t, err)
+		_, err = newUC.InitSRP(context.Background(), auth.RequestSRPInit{Username: "doe"})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "TOO_MANY_REQUESTS", err.(*stderr.UC).Code)
+		assert.Equal(t, "too many logins in progress, try again later", err.(*stderr.UC).Msg)
+
+		// the one in progress is not expired yet
+		newUC.ExpireSRP(context.Background())
+		_, err = newUC.InitSRP(context.Background(), auth.RequestSRPInit{Username: "doe"})
+		assert.Error(t, err)
+	})
+}
+
+func TestUseCase_VerifySRP(t *testing.T) {
+	salt, verifier, err := srp.NewVerifier("john", "correct horse")
+	require.NoError(t, err)
+
+	// start the login as given username then prove given password
+	login := func(t *testing.T, uc authUC.UseCase, username, password string) auth.RequestSRPVerify {
+		res, err := uc.InitSRP(context.Background(), auth.RequestSRPInit{Username: username})
+		require.NoError(t, err)
+		client, err := srp.NewClient(username, password)
+		require.NoError(t, err)
+		proof, err := client.Proof(res.Salt, res.B)
+		require.NoError(t, err)
+		return auth.RequestSRPVerify{Session: res.Session, A: client.Public(), Proof: proof, Code: "123456"}
+	}
+
+	testCases := []struct {
+		name     string
+		setup    func(h *helperSetup)
+		username string
+		password string
+		// session replace the session of the request if it's set.
+		session string
+	}{
+		{
+			name: "Given wrong password should return UC instance, INVALID_CREDENTIAL as code and " +
+				"invalid username or password as message",
+			setup: func(h *helperSetup) {
+				h.Dep.repo.EXPECT().
+					GetUserByUsername(mock.Anything, "john").
+					Return(&entity.User{ID: 2, Username: "john", SrpSalt: salt, SrpVerifier: verifier}, nil).
+					Once()
+			},
+			username: "john",
+			password: "battery staple",
+		},
+		{
+			name: "Given unknown user should return UC instance, INVALID_CREDENTIAL as code and " +
+				"invalid username or password as message",
+			setup: func(h *helperSetup) {
+				h.Dep.repo.EXPECT().
+					GetUserByUsername(mock.Anything, "doe").
+					Return(&entity.User{}, errors.New("record not found")).
+					Once()
+			},
+			username: "doe",
+			password: "correct horse",
+		},
+		{
+			name: "Given unknown session should return UC instance, INVALID_CREDENTIAL as code and " +
+				"invalid username or password as message",
+			setup: func(h *helperSetup) {
+				h.Dep.repo.EXPECT().
+					GetUserByUsername(mock.Anything, "john").
+					Return(&entity.User{ID: 2, Username: "john", SrpSalt: salt, SrpVerifier: verifier}, nil).
+					Once()
+			},
+			username: "john",
+			password: "correct horse",
+			session:  "unknown",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h)
+
+			newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+			req := login(t, newUC, tc.username, tc.password)
+			if tc.session != "" {
+				req.Session = tc.session
+			}
+			_, err := newUC.VerifySRP(context.Background(), req)
+
+			require.IsType(t, &stderr.UC{}, err)
+			assert.Equal(t, "INVALID_CREDENTIAL", err.(*stderr.UC).Code)
+			assert.Equal(t, "invalid username or password", err.(*stderr.UC).Msg)
+		})
+	}
+
+	t.Run("Given session that's already tried should not be tried again", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetUserByUsername(mock.Anything, "john").
+			Return(&entity.User{ID: 2, Username: "john", SrpSalt: salt, SrpVerifier: verifier}, nil).
+			Once()
+
+		newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		req := login(t, newUC, "john", "battery staple")
+		_, err := newUC.VerifySRP(context.Background(), req)
+		require.Error(t, err)
+
+		// the right proof is too late for the same session
+		client, err := srp.NewClient("john", "correct horse")
+		require.NoError(t, err)
+		req.A = client.Public()
+		_, err = newUC.VerifySRP(context.Background(), req)
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_CREDENTIAL", err.(*stderr.UC).Code)
+	})
+}
+
+func TestUseCase_SaveSRP(t *testing.T) {
+	salt, verifier, err := srp.NewVerifier("john", "correct horse")
+	require.NoError(t, err)
+
+	t.Run("Given invalid verifier should return UC instance, INVALID_PAYLOAD as code and "+
+		"invalid salt or verifier as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+
+		newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		err := newUC.SaveSRP(callerCtx(), auth.RequestSRPRegister{Salt: salt, Verifier: make([]byte, 256)})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "invalid salt or verifier", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given valid verifier should save it for the caller", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			UpdateUser(mock.Anything, caller, entity.User{SrpSalt: salt, SrpVerifier: verifier}).
+			Return(nil).
+			Once()
+
+		newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		err := newUC.SaveSRP(callerCtx(), auth.RequestSRPRegister{Salt: salt, Verifier: verifier})
+
+		assert.NoError(t, err)
+		h.Dep.repo.AssertExpectations(t)
+	})
+}
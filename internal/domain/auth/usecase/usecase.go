@@ -2,13 +2,17 @@ package auth
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	cons "github.com/mdanialr/pwman_backend/internal/constant"
 	"github.com/mdanialr/pwman_backend/internal/domain/auth"
 	authRepo "github.com/mdanialr/pwman_backend/internal/domain/auth/repository"
+	"github.com/mdanialr/pwman_backend/internal/entity"
 	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
 	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/otp"
 	"github.com/mdanialr/pwman_backend/pkg/twofa"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -18,55 +22,109 @@ import (
 
 // NewUseCase return concrete implementation of UseCase in auth domain.
 func NewUseCase(conf *viper.Viper, zap *zap.Logger, repo authRepo.Repository) UseCase {
-	return &useCase{conf: conf, zap: zap, repo: repo}
+	max := defaultSRPMax
+	if n := conf.GetInt("srp.max_sessions"); n > 0 {
+		max = n
+	}
+	return &useCase{conf: conf, zap: zap, repo: repo, srp: &srpSessions{max: max, m: make(map[string]*srpSession)}}
 }
 
 type useCase struct {
 	conf *viper.Viper
 	zap  *zap.Logger
 	repo authRepo.Repository
+	srp  *srpSessions
 }
 
 func (u *useCase) ValidateOTP(ctx context.Context, req auth.Request) (*auth.Response, error) {
-	// init totp from pkg using given config
-	ot, err := twofa.InitOTPWithConfig(u.conf)
+	if req.Username == "" {
+		req.Username = auth.OwnerUsername(u.conf)
+	}
+	// unknown user is reported the same as invalid code, so the usernames
+	// can not be guessed
+	usr, err := u.repo.GetUserByUsername(ctx, req.Username)
+	// the OTP is only the second factor once the password is set, which is
+	// also reported the same without checking the code, so the code is not
+	// used up nor its validity is revealed
+	if err != nil || len(usr.SrpVerifier) > 0 {
+		return nil, stderr.NewUC(cons.InvalidOTP, cons.ErrInvalidOTP.Error())
+	}
+	if err = u.verifyOTP(ctx, usr, req.Code); err != nil {
+		return nil, err
+	}
+	// create new jwt
+	return u.CreateJWT(ctx, identity.User{ID: usr.ID, Admin: usr.IsAdmin})
+}
+
+func (u *useCase) VerifyStepUp(ctx context.Context, code string) error {
+	usr, err := u.repo.GetUserByID(ctx, identity.FromContext(ctx).ID)
+	if err != nil {
+		return stderr.NewUC(cons.InvalidOTP, cons.ErrInvalidOTP.Error())
+	}
+	return u.verifyOTP(ctx, usr, code)
+}
+
+func (u *useCase) CreateUser(ctx context.Context, req auth.RequestUser) (*auth.ResponseUser, error) {
+	// make sure given username is not taken yet
+	if old, _ := u.repo.GetUserByUsername(ctx, req.Username); old != nil && old.ID != 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
+	}
+
+	sec, err := otp.NewSecret()
+	if err != nil {
+		u.zap.Error(help.Pad("failed to generate otp secret:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	usr, err := u.repo.CreateUser(ctx, entity.User{Username: req.Username, Secret: sec})
+	if err != nil {
+		u.zap.Error(help.Pad("failed to create new user:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return &auth.ResponseUser{ID: usr.ID, Username: usr.Username, Secret: usr.Secret}, nil
+}
+
+// verifyOTP make sure given code is valid for given user and never used
+// before, then record it as the last used code.
+func (u *useCase) verifyOTP(ctx context.Context, usr *entity.User, code string) error {
+	// init totp from pkg using the secret of the user
+	ot, err := twofa.InitOTPWithSecret(u.conf, usr.Secret)
 	if err != nil {
 		u.zap.Error(help.Pad("failed to init otp with config from app:", err.Error()))
-		return nil, stderr.NewUC(cons.DepsErr, cons.ErrInternalServer.Error())
+		return stderr.NewUC(cons.DepsErr, cons.ErrInternalServer.Error())
 	}
 
 	// verify the validity of given totp code from request
-	valid, err := ot.VerifyCode(req.Code)
+	valid, err := ot.VerifyCode(code)
 	if err != nil {
-		u.zap.Error(help.Pad("failed to verify otp with code", req.Code, "and error:", err.Error()))
+		u.zap.Error(help.Pad("failed to verify otp with code", code, "and error:", err.Error()))
 	}
 
 	// make sure otp never used before
 	if valid {
-		if ro, _ := u.repo.GetByCode(ctx, req.Code); ro != nil {
+		if ro, _ := u.repo.GetByCode(ctx, usr.ID, code); ro != nil {
 			// return false if it's exist in db
 			if ro.ID != 0 {
-				return nil, stderr.NewUC(cons.UsedOTP, cons.ErrUsedOTP.Error())
+				return stderr.NewUC(cons.UsedOTP, cons.ErrUsedOTP.Error())
 			}
 			// delete all past records
-			if err = u.repo.DeleteAll(ctx); err != nil {
+			if err = u.repo.DeleteAll(ctx, usr.ID); err != nil {
 				u.zap.Error(help.Pad("failed to delete all records of RegisteredCode:", err.Error()))
-				return nil, stderr.NewUC(cons.DepsErr, cons.ErrInternalServer.Error())
+				return stderr.NewUC(cons.DepsErr, cons.ErrInternalServer.Error())
 			}
 			// then save the recent one
-			if _, err = u.repo.Create(ctx, req.Code); err != nil {
+			if _, err = u.repo.Create(ctx, usr.ID, code); err != nil {
 				u.zap.Error(help.Pad("failed to save new RegisteredCode:", err.Error()))
-				return nil, stderr.NewUC(cons.DepsErr, cons.ErrInternalServer.Error())
+				return stderr.NewUC(cons.DepsErr, cons.ErrInternalServer.Error())
 			}
-			// create new jwt
-			return u.CreateJWT(ctx)
+			return nil
 		}
 	}
 
-	return nil, stderr.NewUC(cons.InvalidOTP, cons.ErrInvalidOTP.Error())
+	return stderr.NewUC(cons.InvalidOTP, cons.ErrInvalidOTP.Error())
 }
 
-func (u *useCase) CreateJWT(_ context.Context) (*auth.Response, error) {
+func (u *useCase) CreateJWT(_ context.Context, usr identity.User) (*auth.Response, error) {
 	// count the token's expiry time
 	dur, _ := time.ParseDuration(u.conf.GetString("jwt.duration") + "m")
 	exp := time.Now().Add(dur)
@@ -74,6 +132,8 @@ func (u *useCase) CreateJWT(_ context.Context) (*auth.Response, error) {
 	// prepare the claims
 	claims := jwt.MapClaims{
 		"exp": exp.Unix(),
+		"sub": strconv.FormatUint(uint64(usr.ID), 10),
+		"adm": usr.Admin,
 	}
 
 	// generate and sign the token
@@ -0,0 +1,70 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/auth"
+	authUC "github.com/mdanialr/pwman_backend/internal/domain/auth/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/pkg/otp"
+	"github.com/mdanialr/pwman_backend/pkg/srp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseCase_ValidateOTP(t *testing.T) {
+	t.Run("Given user with password should return UC instance, INVALID_OTP as code and invalid otp as "+
+		"message without using up the code, so it still works as the second factor", func(t *testing.T) {
+		sec, err := otp.NewSecret()
+		require.NoError(t, err)
+		code, err := otp.NewHOTP(sec).CreateHOTPCode(1)
+		require.NoError(t, err)
+		salt, verifier, err := srp.NewVerifier("john", "correct horse")
+		require.NoError(t, err)
+		usr := &entity.User{ID: 2, Username: "john", Secret: sec, SrpSalt: salt, SrpVerifier: verifier}
+
+		h := setupTestHelper(t)
+		h.Dep.config.Set("cred.type", "hotp")
+		h.Dep.repo.EXPECT().
+			GetUserByUsername(mock.Anything, "john").
+			Return(usr, nil).
+			Twice()
+
+		newUC := authUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		_, err = newUC.ValidateOTP(context.Background(), auth.Request{Username: "john", Code: code})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_OTP", err.(*stderr.UC).Code)
+		assert.Equal(t, "invalid otp", err.(*stderr.UC).Msg)
+		h.Dep.repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+
+		// then the same code is accepted along with the password
+		h.Dep.repo.EXPECT().
+			GetByCode(mock.Anything, uint(2), code).
+			Return(&entity.RegisteredOTP{}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			DeleteAll(mock.Anything, uint(2)).
+			Return(nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			Create(mock.Anything, uint(2), code).
+			Return(&entity.RegisteredOTP{}, nil).
+			Once()
+		res, err := newUC.InitSRP(context.Background(), auth.RequestSRPInit{Username: "john"})
+		require.NoError(t, err)
+		client, err := srp.NewClient("john", "correct horse")
+		require.NoError(t, err)
+		proof, err := client.Proof(res.Salt, res.B)
+		require.NoError(t, err)
+		tok, err := newUC.VerifySRP(context.Background(), auth.RequestSRPVerify{
+			Session: res.Session, A: client.Public(), Proof: proof, Code: code,
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, tok.Proof)
+	})
+}
@@ -0,0 +1,89 @@
+package delivery
+
+import (
+	"bufio"
+	"context"
+	"time"
+
+	authUC "github.com/mdanialr/pwman_backend/internal/domain/auth/usecase"
+	"github.com/mdanialr/pwman_backend/internal/domain/backup"
+	backupUC "github.com/mdanialr/pwman_backend/internal/domain/backup/usecase"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	"github.com/mdanialr/pwman_backend/pkg/exporter"
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// NewDelivery setup endpoints in domain backup as delivery layer.
+func NewDelivery(app fiber.Router, conf *viper.Viper, uc backupUC.UseCase, authUC authUC.UseCase) {
+	d := &delivery{uc: uc}
+
+	// only the admin is allowed to make the encrypted backup, even though just
+	// the personal vault of the admin is exported, while anyone may take
+	// their passwords away in plain format
+	api := app.Group("/export", md.JWT(conf))
+	api.Post("/", md.Admin(), d.Export)
+	api.Post("/plain", md.StepUp(authUC.VerifyStepUp), d.ExportPlain)
+}
+
+type delivery struct {
+	uc backupUC.UseCase
+}
+
+func (d *delivery) Export(c *fiber.Ctx) error {
+	var req backup.RequestExport
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	// everything that may fail before the backup is written is done here, so
+	// it can still be sent as usual response
+	write, err := d.uc.Export(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	// stream the backup, so the media files are never held in memory. The
+	// request context is no longer valid once the handler returned, hence
+	// new one. The use case log the errors, since the status is already sent.
+	c.Attachment("pwman-" + time.Now().Format("20060102-150405") + ".pwbak")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		write(context.Background(), w)
+		w.Flush()
+	})
+	return nil
+}
+
+func (d *delivery) ExportPlain(c *fiber.Ctx) error {
+	var req backup.RequestExportPlain
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+	req.IP = c.IP()
+
+	// everything that may fail before the export is written is done here, so
+	// it can still be sent as usual response
+	write, err := d.uc.ExportPlain(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	// stream the export, so large vault is never held in memory. The request
+	// context is no longer valid once the handler returned, hence new one.
+	// The use case log the errors, since the status is already sent.
+	c.Attachment("pwman-" + time.Now().Format("20060102-150405") + exporter.Ext(req.ExportFormat()))
+	c.Set(fiber.HeaderContentType, exporter.ContentType(req.ExportFormat()))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		write(context.Background(), w)
+		w.Flush()
+	})
+	return nil
+}
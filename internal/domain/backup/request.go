@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"github.com/mdanialr/pwman_backend/pkg/exporter"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// ConflictSkip keep the existing password and skip the one from backup.
+	ConflictSkip = "skip"
+	// ConflictOverwrite replace the existing password with the one from
+	// backup.
+	ConflictOverwrite = "overwrite"
+	// ConflictDuplicate keep both the existing password and the one from
+	// backup.
+	ConflictDuplicate = "duplicate"
+)
+
+// RequestExport request object that's used to export the personal vault of
+// the caller as an encrypted backup.
+type RequestExport struct {
+	// Passphrase the secret that's used to encrypt the backup. The same
+	// passphrase is needed to restore the backup.
+	Passphrase string `json:"passphrase" validate:"required,min=12"`
+	// All whether to export every vault instead, including those of the
+	// other users and the organizations. Only set by the CLI.
+	All bool `json:"-"`
+}
+
+// Validate apply validation rules for RequestExport.
+func (r *RequestExport) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestExportPlain request object that's used to export the passwords that
+// the caller may reveal in plain format that's compatible with other password
+// managers.
+type RequestExportPlain struct {
+	// Format the plain export format.
+	Format string `json:"format" validate:"required,oneof=bitwarden csv"`
+	// IP the address where the export is requested from, recorded in the
+	// audit log. Should be set manually from delivery.
+	IP string `json:"-"`
+}
+
+// Validate apply validation rules for RequestExportPlain.
+func (r *RequestExportPlain) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ExportFormat return Format as exporter.Format.
+func (r *RequestExportPlain) ExportFormat() exporter.Format {
+	return exporter.Format(r.Format)
+}
+
+// RequestRestore request object that's used to restore an encrypted backup.
+type RequestRestore struct {
+	// Passphrase the secret that was used to encrypt the backup.
+	Passphrase string `validate:"required"`
+	// Conflict what to do with password from backup that has the same
+	// category and username with existing password.
+	Conflict string `validate:"required,oneof=skip overwrite duplicate"`
+}
+
+// Validate apply validation rules for RequestRestore.
+func (r *RequestRestore) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
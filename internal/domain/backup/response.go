@@ -0,0 +1,20 @@
+package backup
+
+// ResponseRestore response that's used in use case Restore.
+type ResponseRestore struct {
+	// Categories the restore result of the categories.
+	Categories ResponseRestoreCount `json:"categories"`
+	// Passwords the restore result of the passwords.
+	Passwords ResponseRestoreCount `json:"passwords"`
+	// MissingFiles the media files that's referenced by categories in the
+	// backup but not found inside it.
+	MissingFiles []string `json:"missing_files"`
+}
+
+// ResponseRestoreCount the number of restored objects grouped by what
+// happened to them.
+type ResponseRestoreCount struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
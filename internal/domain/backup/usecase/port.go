@@ -0,0 +1,30 @@
+package backup
+
+import (
+	"context"
+	"io"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/backup"
+)
+
+// UseCase signature that's used in backup domain for use case layer.
+type UseCase interface {
+	// Export retrieve the categories, tags and passwords in the personal
+	// vault of the caller, so errors are returned before anything is
+	// written. Every vault is exported instead if it's requested, which is
+	// recorded in the audit log. Return the func that then write them along
+	// with the media files of the categories as an encrypted backup to given
+	// w.
+	Export(ctx context.Context, req backup.RequestExport) (func(ctx context.Context, w io.Writer) error, error)
+	// ExportPlain record the plain export of the passwords that the caller
+	// may reveal outside of the organizations in the audit log and retrieve the categories along with the first batch of passwords, so
+	// errors are returned before anything is written. Return the func that
+	// then write the export to given w, using the categories as the folders
+	// and streaming the rest of the passwords in batches.
+	ExportPlain(ctx context.Context, req backup.RequestExportPlain) (func(ctx context.Context, w io.Writer) error, error)
+	// Restore read the encrypted backup from given r then save its content
+	// into the vault of the user in given ctx. Categories are matched by
+	// their name, while passwords that has the same category and username
+	// are handled using the conflict strategy from given req.
+	Restore(ctx context.Context, r io.Reader, req backup.RequestRestore) (*backup.ResponseRestore, error)
+}
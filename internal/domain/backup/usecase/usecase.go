@@ -0,0 +1,592 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	audit "github.com/mdanialr/pwman_backend/internal/domain/audit/repository"
+	"github.com/mdanialr/pwman_backend/internal/domain/backup"
+	"github.com/mdanialr/pwman_backend/internal/domain/password"
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	bak "github.com/mdanialr/pwman_backend/pkg/backup"
+	"github.com/mdanialr/pwman_backend/pkg/exporter"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// batchSize the number of passwords that's retrieved at once.
+const batchSize = 500
+
+// NewUseCase return concrete implementation of UseCase in backup domain.
+func NewUseCase(conf *viper.Viper, log *zap.Logger, repo pw.Repository, audit audit.Repository) UseCase {
+	return &useCase{conf: conf, log: log, repo: repo, audit: audit}
+}
+
+type useCase struct {
+	conf  *viper.Viper
+	log   *zap.Logger
+	repo  pw.Repository
+	audit audit.Repository
+}
+
+func (u *useCase) Export(ctx context.Context, req backup.RequestExport) (func(context.Context, io.Writer) error, error) {
+	m := bak.Manifest{CreatedAt: time.Now()}
+
+	// only the personal vault of the caller unless every vault is requested,
+	// which is recorded first
+	vault, tagVault := ownVaultCond(ctx), tagOwnerCond(ctx)
+	if req.All {
+		vault, tagVault = nil, nil
+		obj := entity.AuditLog{UserID: identity.FromContext(ctx).ID, Action: entity.AuditExportAll}
+		if _, err := u.audit.CreateAuditLog(ctx, obj); err != nil {
+			u.log.Error(help.Pad("failed to record audit log for export:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+	}
+
+	// collect the categories, tags and passwords
+	cats, err := u.repo.FindCategories(ctx, withVault(vault, repo.Order("id ASC"))...)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve categories for export:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	for _, c := range cats {
+		m.Categories = append(m.Categories, bak.Category{
+			ID:        c.ID,
+			ParentID:  c.ParentID,
+			Name:      c.Name,
+			ImagePath: c.ImagePath,
+			IconPath:  c.IconPath,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+		})
+	}
+	tags, err := u.repo.FindTags(ctx, withVault(tagVault, repo.Order("id ASC"))...)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve tags for export:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	for _, t := range tags {
+		m.Tags = append(m.Tags, bak.Tag{ID: t.ID, Name: t.Name, Color: t.Color})
+	}
+	err = pw.EachPassword(ctx, u.repo, batchSize, func(pws []*entity.Password) error {
+		for _, p := range pws {
+			var tagIDs []uint
+			for _, t := range p.Tags {
+				tagIDs = append(tagIDs, t.ID)
+			}
+			m.Passwords = append(m.Passwords, bak.Password{
+				ID:           p.ID,
+				Username:     p.Username,
+				Password:     p.Password,
+				URL:          p.URL,
+				Notes:        p.Notes,
+				CategoryID:   p.CategoryID,
+				Strength:     p.Strength,
+				Breached:     p.Breached,
+				Favorite:     p.Favorite,
+				TagIDs:       tagIDs,
+				ExpiresAt:    p.ExpiresAt,
+				RotationDays: p.RotationDays,
+				NotifiedAt:   p.NotifiedAt,
+				CreatedAt:    p.CreatedAt,
+				UpdatedAt:    p.UpdatedAt,
+			})
+		}
+		return nil
+	}, withVault(vault, repo.EagerLoad("Tags"))...)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve passwords for export:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	// the response is already sent once this is called, so the errors can
+	// only be logged. Write the manifest then stream the media files of each
+	// category.
+	return func(_ context.Context, w io.Writer) error {
+		bw, err := bak.NewWriter(w, req.Passphrase)
+		if err != nil {
+			u.log.Error(help.Pad("failed to init backup writer:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		if err = bw.WriteManifest(m); err != nil {
+			u.log.Error(help.Pad("failed to write backup manifest:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		for _, c := range m.Categories {
+			for _, fn := range []string{c.ImagePath, c.IconPath} {
+				if err = u.exportFile(bw, fn); err != nil {
+					u.log.Error(help.Pad("failed to write media file", fn, "to backup:", err.Error()))
+					return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+				}
+			}
+		}
+		if err = bw.Close(); err != nil {
+			u.log.Error(help.Pad("failed to finish backup:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+
+		return nil
+	}, nil
+}
+
+func (u *useCase) ExportPlain(ctx context.Context, req backup.RequestExportPlain) (func(context.Context, io.Writer) error, error) {
+	format := req.ExportFormat()
+	if !exporter.Supported(format) {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, exporter.ErrUnsupportedFormat)
+	}
+
+	// record the export before anything is written, so even the failed one
+	// is recorded
+	obj := entity.AuditLog{
+		UserID: identity.FromContext(ctx).ID,
+		Action: entity.AuditExportPlain,
+		Detail: help.Pad("format:", req.Format),
+		IP:     req.IP,
+	}
+	if _, err := u.audit.CreateAuditLog(ctx, obj); err != nil {
+		u.log.Error(help.Pad("failed to record audit log for plain export:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	// only those that the caller may reveal, using the categories as the
+	// folders
+	uid := identity.FromContext(ctx).ID
+	vault := password.PasswordRevealCond(uid)
+	cats, err := u.repo.FindCategories(ctx, repo.Cols("id", "parent_id", "name"), password.CategoryRevealCond(uid), repo.Order("id ASC"))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve categories for plain export:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	// nested categories use the full path as the folder name
+	names := categoryPaths(cats)
+	folders := make([]exporter.Folder, 0, len(cats))
+	for _, c := range cats {
+		folders = append(folders, exporter.Folder{ID: c.ID, Name: names[c.ID]})
+	}
+	first, err := u.repo.FindPassword(ctx, vault, repo.Order("id ASC"), repo.Limit(batchSize))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve passwords for plain export:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	// the response is already sent once this is called, so the errors can
+	// only be logged
+	return func(ctx context.Context, w io.Writer) error {
+		ew, _ := exporter.NewWriter(format, w)
+		if err := ew.WriteFolders(folders); err != nil {
+			u.log.Error(help.Pad("failed to write folders for plain export:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+
+		// stream the passwords batch by batch
+		write := func(pws []*entity.Password) error {
+			for _, p := range pws {
+				it := exporter.Item{
+					FolderID: folderOf(names, p.CategoryID),
+					Name:     names[p.CategoryID],
+					Username: p.Username,
+					Password: p.Password,
+					URL:      p.URL,
+					Notes:    p.Notes,
+					Favorite: p.Favorite,
+				}
+				if err := ew.WriteItem(it); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		err := write(first)
+		if err == nil && len(first) == batchSize {
+			err = pw.EachPassword(ctx, u.repo, batchSize, write, vault, repo.Where("id > ?", first[len(first)-1].ID))
+		}
+		if err != nil {
+			u.log.Error(help.Pad("failed to write passwords for plain export:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		if err = ew.Close(); err != nil {
+			u.log.Error(help.Pad("failed to finish plain export:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+
+		return nil
+	}, nil
+}
+
+func (u *useCase) Restore(ctx context.Context, r io.Reader, req backup.RequestRestore) (*backup.ResponseRestore, error) {
+	// decrypt the backup while restoring the media files
+	restored := make(map[string]bool)
+	m, err := bak.Read(r, req.Passphrase, func(name string, r io.Reader) error {
+		if err := u.restoreFile(name, r); err != nil {
+			return err
+		}
+		restored[name] = true
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, bak.ErrDecrypt) || errors.Is(err, bak.ErrInvalidFormat) || errors.Is(err, bak.ErrUnsupportedVersion) {
+			return nil, stderr.NewUCErr(cons.InvalidPayload, err)
+		}
+		u.log.Error(help.Pad("failed to read backup:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	res := &backup.ResponseRestore{MissingFiles: []string{}}
+	for _, c := range m.Categories {
+		for _, fn := range []string{c.ImagePath, c.IconPath} {
+			if fn != "" && !restored[fn] {
+				res.MissingFiles = append(res.MissingFiles, fn)
+			}
+		}
+	}
+
+	// save everything in a single transaction, so a failed restore never
+	// leave the vault half restored
+	err = u.repo.Transaction(ctx, func(tx pw.Repository) error {
+		ids, err := u.restoreCategories(ctx, tx, m.Categories, req.Conflict, res)
+		if err != nil {
+			return err
+		}
+		tagIDs, err := u.restoreTags(ctx, tx, m.Tags, req.Conflict)
+		if err != nil {
+			return err
+		}
+		return u.restorePasswords(ctx, tx, m.Passwords, ids, tagIDs, req.Conflict, res)
+	})
+	if err != nil {
+		u.log.Error(help.Pad("failed to restore backup:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return res, nil
+}
+
+// ownVaultCond return repo option that only match the categories or passwords
+// in the personal vault of the caller, leaving out those that's shared to
+// them and those in the organizations.
+func ownVaultCond(ctx context.Context) repo.Options {
+	return repo.Where("owner_id = ? AND org_id IS NULL", identity.FromContext(ctx).ID)
+}
+
+// tagOwnerCond return repo option that only match the tags of the caller.
+func tagOwnerCond(ctx context.Context) repo.Options {
+	return repo.Where("owner_id = ?", identity.FromContext(ctx).ID)
+}
+
+// withVault return given opts along with given vault cond if it's not nil.
+func withVault(vault repo.Options, opts ...repo.Options) []repo.Options {
+	if vault == nil {
+		return opts
+	}
+	return append([]repo.Options{vault}, opts...)
+}
+
+// folderOf return given category id if it's one of the folders in given names,
+// otherwise zero, since the passwords that's shared directly may be in the
+// categories that the caller can not reveal.
+func folderOf(names map[uint]string, catID uint) uint {
+	if _, ok := names[catID]; ok {
+		return catID
+	}
+	return 0
+}
+
+// categoryPaths return the mapping of category id to its full path that's
+// joined by / e.g. TEAM/PROD/DB.
+func categoryPaths(cats []*entity.Category) map[uint]string {
+	byID := make(map[uint]*entity.Category, len(cats))
+	for _, c := range cats {
+		byID[c.ID] = c
+	}
+
+	res := make(map[uint]string, len(cats))
+	for _, c := range cats {
+		pt := c.Name
+		// keep track of the visited ids in case the data is already cyclic
+		seen := map[uint]bool{c.ID: true}
+		for cur := c; cur.ParentID != nil && !seen[*cur.ParentID]; {
+			parent, ok := byID[*cur.ParentID]
+			if !ok {
+				break
+			}
+			pt = parent.Name + "/" + pt
+			seen[parent.ID] = true
+			cur = parent
+		}
+		res[c.ID] = pt
+	}
+	return res
+}
+
+// restoreCategories save given categories from backup into the vault of the
+// caller. Existing category with the same name under the same parent is
+// reused. Return the mapping of category id in backup to the id in repo.
+func (u *useCase) restoreCategories(ctx context.Context, tx pw.Repository, cats []bak.Category, conflict string, res *backup.ResponseRestore) (map[uint]uint, error) {
+	owner := identity.FromContext(ctx).ID
+	existing, err := tx.FindCategories(ctx, repo.Cols("id", "parent_id", "name", "revision"), repo.Where("owner_id = ?", owner))
+	if err != nil {
+		return nil, err
+	}
+	// key the categories by the parent id and the name
+	key := func(parentID *uint, name string) string {
+		if parentID == nil {
+			return "0/" + name
+		}
+		return strconv.Itoa(int(*parentID)) + "/" + name
+	}
+	byName := make(map[string]*entity.Category)
+	for _, c := range existing {
+		byName[key(c.ParentID, c.Name)] = c
+	}
+
+	ids := make(map[uint]uint)
+	for _, c := range parentsFirst(cats) {
+		// use the restored id of the parent
+		var parentID *uint
+		if c.ParentID != nil {
+			if id, ok := ids[*c.ParentID]; ok {
+				parentID = &id
+			}
+		}
+		if old, ok := byName[key(parentID, c.Name)]; ok {
+			ids[c.ID] = old.ID
+			if conflict != backup.ConflictOverwrite {
+				res.Categories.Skipped++
+				continue
+			}
+			// bump the revision, so clients know it's changed
+			obj := entity.Category{ImagePath: c.ImagePath, IconPath: c.IconPath, Revision: old.Revision + 1}
+			if _, err = tx.UpdateCategory(ctx, old.ID, obj, repo.Cols("image_path", "icon_path", "revision")); err != nil {
+				return nil, err
+			}
+			res.Categories.Updated++
+			continue
+		}
+
+		obj := entity.Category{
+			OwnerID:   owner,
+			ParentID:  parentID,
+			Name:      c.Name,
+			ImagePath: c.ImagePath,
+			IconPath:  c.IconPath,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+		}
+		newObj, err := tx.CreateCategory(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		ids[c.ID] = newObj.ID
+		byName[key(parentID, c.Name)] = newObj
+		res.Categories.Created++
+	}
+	return ids, nil
+}
+
+// parentsFirst return given categories ordered so that every parent comes
+// before its children. Category whose parent is missing is regarded as a root.
+func parentsFirst(cats []bak.Category) []bak.Category {
+	children := make(map[uint][]bak.Category)
+	known := make(map[uint]bool, len(cats))
+	for _, c := range cats {
+		known[c.ID] = true
+	}
+	var queue []bak.Category
+	for _, c := range cats {
+		if c.ParentID == nil || !known[*c.ParentID] || *c.ParentID == c.ID {
+			queue = append(queue, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	res := make([]bak.Category, 0, len(cats))
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = append(queue[1:], children[c.ID]...)
+		res = append(res, c)
+	}
+	return res
+}
+
+// restoreTags save given tags from backup. Existing tag with the same name is
+// reused. Return the mapping of tag id in backup to the id in repo.
+func (u *useCase) restoreTags(ctx context.Context, tx pw.Repository, tags []bak.Tag, conflict string) (map[uint]uint, error) {
+	ids := make(map[uint]uint)
+	if len(tags) == 0 {
+		return ids, nil
+	}
+	owner := identity.FromContext(ctx).ID
+	existing, err := tx.FindTags(ctx, repo.Cols("id", "name"), repo.Where("owner_id = ?", owner))
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]uint)
+	for _, t := range existing {
+		byName[t.Name] = t.ID
+	}
+
+	for _, t := range tags {
+		if id, ok := byName[t.Name]; ok {
+			ids[t.ID] = id
+			if conflict == backup.ConflictOverwrite {
+				if _, err = tx.UpdateTag(ctx, id, entity.Tag{Color: t.Color}, repo.Cols("color")); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		newObj, err := tx.CreateTag(ctx, entity.Tag{OwnerID: owner, Name: t.Name, Color: t.Color})
+		if err != nil {
+			return nil, err
+		}
+		ids[t.ID] = newObj.ID
+		byName[t.Name] = newObj.ID
+	}
+	return ids, nil
+}
+
+// restorePasswords save given passwords from backup using given mapping of
+// category and tag id. Password that has the same category and username with the
+// existing one is handled using given conflict strategy.
+func (u *useCase) restorePasswords(ctx context.Context, tx pw.Repository, pws []bak.Password, ids, tagIDs map[uint]uint, conflict string, res *backup.ResponseRestore) error {
+	owner := identity.FromContext(ctx).ID
+	for _, p := range pws {
+		catID, ok := ids[p.CategoryID]
+		if !ok {
+			u.log.Warn(help.Pad("skip password", strconv.Itoa(int(p.ID)), "from backup that has unknown category"))
+			res.Passwords.Skipped++
+			continue
+		}
+		obj := entity.Password{
+			Username:     p.Username,
+			Password:     p.Password,
+			URL:          p.URL,
+			Notes:        p.Notes,
+			CategoryID:   catID,
+			OwnerID:      owner,
+			Strength:     p.Strength,
+			Breached:     p.Breached,
+			Favorite:     p.Favorite,
+			ExpiresAt:    p.ExpiresAt,
+			RotationDays: p.RotationDays,
+			NotifiedAt:   p.NotifiedAt,
+			CreatedAt:    p.CreatedAt,
+			UpdatedAt:    p.UpdatedAt,
+		}
+
+		if conflict != backup.ConflictDuplicate {
+			old, err := tx.FindPassword(ctx,
+				repo.Cols("id", "revision"),
+				repo.Where("category_id = ? AND username = ?", catID, p.Username),
+				repo.Limit(1),
+			)
+			if err != nil {
+				return err
+			}
+			if len(old) > 0 {
+				if conflict == backup.ConflictSkip {
+					res.Passwords.Skipped++
+					continue
+				}
+				// bump the revision, so clients know it's changed
+				obj.Revision = old[0].Revision + 1
+				cols := repo.Cols("password", "url", "notes", "strength", "breached", "favorite", "expires_at", "rotation_days", "notified_at", "revision")
+				if _, err = tx.UpdatePassword(ctx, old[0].ID, obj, cols); err != nil {
+					return err
+				}
+				if err = u.restorePasswordTags(ctx, tx, old[0].ID, p.TagIDs, tagIDs); err != nil {
+					return err
+				}
+				res.Passwords.Updated++
+				continue
+			}
+		}
+
+		newObj, err := tx.CreatePassword(ctx, obj)
+		if err != nil {
+			return err
+		}
+		if err = u.restorePasswordTags(ctx, tx, newObj.ID, p.TagIDs, tagIDs); err != nil {
+			return err
+		}
+		res.Passwords.Created++
+	}
+	return nil
+}
+
+// restorePasswordTags attach the tags of a password from backup using given
+// mapping of tag id. Do nothing if the password has no tag.
+func (u *useCase) restorePasswordTags(ctx context.Context, tx pw.Repository, id uint, ids []uint, tagIDs map[uint]uint) error {
+	var newIDs []uint
+	for _, tid := range ids {
+		if newID, ok := tagIDs[tid]; ok {
+			newIDs = append(newIDs, newID)
+		}
+	}
+	if len(newIDs) == 0 {
+		return nil
+	}
+	return tx.ReplacePasswordTags(ctx, id, newIDs)
+}
+
+// exportFile write the media file with given name from storage to given
+// backup writer. Missing file is skipped.
+func (u *useCase) exportFile(bw *bak.Writer, fn string) error {
+	if fn == "" {
+		return nil
+	}
+	fl, err := os.Open(u.storagePath() + fn)
+	if err != nil {
+		u.log.Warn(help.Pad("skip missing media file", fn, "from backup:", err.Error()))
+		return nil
+	}
+	defer fl.Close()
+
+	st, err := fl.Stat()
+	if err != nil {
+		return err
+	}
+	return bw.WriteFile(fn, st.Size(), fl)
+}
+
+// restoreFile save the media file from backup to storage. The file names are
+// random, so existing file that has the same name is regarded as the same
+// file and kept as is.
+func (u *useCase) restoreFile(fn string, r io.Reader) error {
+	pt := u.storagePath() + fn
+	if _, err := os.Stat(pt); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(pt), 0770); err != nil {
+		return err
+	}
+
+	fl, err := os.Create(pt)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	_, err = io.Copy(fl, r)
+	return err
+}
+
+// storagePath return the storage path from config with trailing slash.
+func (u *useCase) storagePath() string {
+	return strings.TrimSuffix(u.conf.GetString("storage.path"), "/") + "/"
+}
@@ -0,0 +1,402 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	auditMock "github.com/mdanialr/pwman_backend/internal/domain/audit/repository/mocks"
+	"github.com/mdanialr/pwman_backend/internal/domain/backup"
+	backupUC "github.com/mdanialr/pwman_backend/internal/domain/backup/usecase"
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
+	pwMock "github.com/mdanialr/pwman_backend/internal/domain/password/repository/mocks"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const passphrase = "correct horse battery"
+
+// exportSample export one category with an image and two passwords from a
+// storage directory.
+func exportSample(t *testing.T) []byte {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "img.png"), []byte("image"), 0600))
+	conf := viper.New()
+	conf.Set("storage.path", dir)
+
+	created := time.Now().AddDate(-1, 0, 0).UTC().Truncate(time.Second)
+	repo := new(pwMock.MockpasswordRepository)
+	repo.EXPECT().
+		FindCategories(mock.Anything, mock.Anything, mock.Anything).
+		Return([]*entity.Category{{ID: 7, Name: "FAKE", ImagePath: "img.png"}}, nil).
+		Once()
+	repo.EXPECT().
+		FindTags(mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil).
+		Once()
+	repo.EXPECT().
+		FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]*entity.Password{
+			{ID: 1, Username: "alice", Password: "secret", CategoryID: 7, CreatedAt: created, UpdatedAt: created},
+			{ID: 2, Username: "bob", Password: "hunter2", CategoryID: 7, CreatedAt: created, UpdatedAt: created},
+		}, nil).
+		Once()
+
+	var buf bytes.Buffer
+	ctx := identity.NewContext(context.Background(), identity.User{ID: 1, Admin: true})
+	uc := backupUC.NewUseCase(conf, zaptest.NewLogger(t), repo, new(auditMock.MockauditRepository))
+	write, err := uc.Export(ctx, backup.RequestExport{Passphrase: passphrase})
+	require.NoError(t, err)
+	require.NoError(t, write(ctx, &buf))
+	return buf.Bytes()
+}
+
+func TestUseCase_Export(t *testing.T) {
+	ctx := identity.NewContext(context.Background(), identity.User{ID: 1, Admin: true})
+
+	t.Run("Given deps repository that failed to retrieve categories should return UC instance, "+
+		"DEPS_ERROR as code and something wasn't right as message", func(t *testing.T) {
+		repo := new(pwMock.MockpasswordRepository)
+		repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, errors.New("error")).
+			Once()
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo, new(auditMock.MockauditRepository))
+		write, err := uc.Export(ctx, backup.RequestExport{Passphrase: passphrase})
+
+		assert.Nil(t, write)
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "DEPS_ERROR", err.(*stderr.UC).Code)
+		assert.Equal(t, "something wasn't right", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given request for every vault and deps audit repository that failed to record it should "+
+		"return UC instance, DEPS_ERROR as code and export nothing", func(t *testing.T) {
+		au := new(auditMock.MockauditRepository)
+		au.EXPECT().
+			CreateAuditLog(mock.Anything, mock.Anything).
+			Return(nil, errors.New("error")).
+			Once()
+		repo := new(pwMock.MockpasswordRepository)
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo, au)
+		write, err := uc.Export(ctx, backup.RequestExport{Passphrase: passphrase, All: true})
+
+		assert.Nil(t, write)
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "DEPS_ERROR", err.(*stderr.UC).Code)
+		repo.AssertNotCalled(t, "FindCategories", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Given request for every vault should record it by the caller then retrieve the "+
+		"categories, tags and passwords of every vault", func(t *testing.T) {
+		au := new(auditMock.MockauditRepository)
+		au.EXPECT().
+			CreateAuditLog(mock.Anything, mock.MatchedBy(func(obj entity.AuditLog) bool {
+				return obj.Action == entity.AuditExportAll && obj.UserID == 1
+			})).
+			Return(&entity.AuditLog{}, nil).
+			Once()
+		repo := new(pwMock.MockpasswordRepository)
+		repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		repo.EXPECT().
+			FindTags(mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo, au)
+		_, err := uc.Export(ctx, backup.RequestExport{Passphrase: passphrase, All: true})
+		require.NoError(t, err)
+		au.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestUseCase_ExportPlain(t *testing.T) {
+	ctx := identity.NewContext(context.Background(), identity.User{ID: 1, Admin: true})
+
+	t.Run("Given unsupported format should return UC instance, INVALID_PAYLOAD as code and record "+
+		"nothing", func(t *testing.T) {
+		au := new(auditMock.MockauditRepository)
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), new(pwMock.MockpasswordRepository), au)
+		write, err := uc.ExportPlain(ctx, backup.RequestExportPlain{Format: "xml"})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "unsupported export format", err.(*stderr.UC).Msg)
+		assert.Nil(t, write)
+		au.AssertNotCalled(t, "CreateAuditLog", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Given deps audit repository that failed to record the export should return UC instance, "+
+		"DEPS_ERROR as code and nothing to write", func(t *testing.T) {
+		au := new(auditMock.MockauditRepository)
+		au.EXPECT().
+			CreateAuditLog(mock.Anything, mock.Anything).
+			Return(nil, errors.New("error")).
+			Once()
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), new(pwMock.MockpasswordRepository), au)
+		write, err := uc.ExportPlain(ctx, backup.RequestExportPlain{Format: "csv"})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "DEPS_ERROR", err.(*stderr.UC).Code)
+		assert.Nil(t, write)
+	})
+
+	t.Run("Given deps repository that failed to retrieve passwords should return UC instance, "+
+		"DEPS_ERROR as code before anything is written", func(t *testing.T) {
+		au := new(auditMock.MockauditRepository)
+		au.EXPECT().
+			CreateAuditLog(mock.Anything, mock.Anything).
+			Return(&entity.AuditLog{}, nil).
+			Once()
+		repo := new(pwMock.MockpasswordRepository)
+		repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, errors.New("error")).
+			Once()
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo, au)
+		write, err := uc.ExportPlain(ctx, backup.RequestExportPlain{Format: "csv"})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "DEPS_ERROR", err.(*stderr.UC).Code)
+		assert.Nil(t, write)
+	})
+
+	t.Run("Given csv as the format should record the export by the caller then write all passwords "+
+		"using the category as the folder", func(t *testing.T) {
+		au := new(auditMock.MockauditRepository)
+		au.EXPECT().
+			CreateAuditLog(mock.Anything, mock.MatchedBy(func(obj entity.AuditLog) bool {
+				return obj.Action == entity.AuditExportPlain && obj.IP == "127.0.0.1" && obj.UserID == 1
+			})).
+			Return(&entity.AuditLog{}, nil).
+			Once()
+		repo := new(pwMock.MockpasswordRepository)
+		repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Category{{ID: 7, Name: "FAKE"}}, nil).
+			Once()
+		repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Password{
+				{ID: 1, Username: "alice", Password: "secret", URL: "https://fake.com", CategoryID: 7},
+			}, nil).
+			Once()
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo, au)
+		write, err := uc.ExportPlain(ctx, backup.RequestExportPlain{Format: "csv", IP: "127.0.0.1"})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, write(context.Background(), &buf))
+		assert.Equal(t, "folder,name,username,password,url,notes\nFAKE,FAKE,alice,secret,https://fake.com,\n", buf.String())
+		au.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Given caller that's not the admin should export the passwords shared to them outside of the "+
+		"exported folders without any folder", func(t *testing.T) {
+		ctx := identity.NewContext(context.Background(), identity.User{ID: 2})
+		au := new(auditMock.MockauditRepository)
+		au.EXPECT().
+			CreateAuditLog(mock.Anything, mock.MatchedBy(func(obj entity.AuditLog) bool { return obj.UserID == 2 })).
+			Return(&entity.AuditLog{}, nil).
+			Once()
+		repo := new(pwMock.MockpasswordRepository)
+		repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Category{{ID: 7, Name: "FAKE"}}, nil).
+			Once()
+		repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Password{
+				{ID: 1, Username: "alice", Password: "secret", CategoryID: 7},
+				{ID: 2, Username: "bob", Password: "hunter2", CategoryID: 9},
+			}, nil).
+			Once()
+
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo, au)
+		write, err := uc.ExportPlain(ctx, backup.RequestExportPlain{Format: "csv"})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, write(context.Background(), &buf))
+		assert.Equal(t, "folder,name,username,password,url,notes\nFAKE,FAKE,alice,secret,,\n,,bob,hunter2,,\n", buf.String())
+		au.AssertExpectations(t)
+	})
+}
+
+func TestUseCase_Restore(t *testing.T) {
+	bak := exportSample(t)
+
+	// withTransaction make the mocked repo run the transaction fn using itself
+	withTransaction := func(repo *pwMock.MockpasswordRepository) {
+		repo.EXPECT().
+			Transaction(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, fn func(pw.Repository) error) error {
+				return fn(repo)
+			}).
+			Once()
+	}
+
+	t.Run("Given wrong passphrase should return UC instance, INVALID_PAYLOAD as code "+
+		"and wrong passphrase or corrupted backup as message", func(t *testing.T) {
+		uc := backupUC.NewUseCase(viper.New(), zaptest.NewLogger(t), new(pwMock.MockpasswordRepository), new(auditMock.MockauditRepository))
+		_, err := uc.Restore(context.Background(), bytes.NewReader(bak), backup.RequestRestore{Passphrase: "wrong", Conflict: backup.ConflictSkip})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "wrong passphrase or corrupted backup", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given empty repo should create all categories and passwords using the new category id "+
+		"and restore the media files", func(t *testing.T) {
+		dir := t.TempDir()
+		conf := viper.New()
+		conf.Set("storage.path", dir)
+
+		repo := new(pwMock.MockpasswordRepository)
+		withTransaction(repo)
+		repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		repo.EXPECT().
+			CreateCategory(mock.Anything, mock.MatchedBy(func(obj entity.Category) bool {
+				return obj.Name == "FAKE" && obj.ImagePath == "img.png" && obj.OwnerID == 7
+			})).
+			Return(&entity.Category{ID: 99}, nil).
+			Once()
+		repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Twice()
+		var saved []entity.Password
+		repo.EXPECT().
+			CreatePassword(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, obj entity.Password) (*entity.Password, error) {
+				saved = append(saved, obj)
+				return &obj, nil
+			}).
+			Twice()
+
+		uc := backupUC.NewUseCase(conf, zaptest.NewLogger(t), repo, new(auditMock.MockauditRepository))
+		// restore into the vault of the caller
+		ctx := identity.NewContext(context.Background(), identity.User{ID: 7})
+		res, err := uc.Restore(ctx, bytes.NewReader(bak), backup.RequestRestore{Passphrase: passphrase, Conflict: backup.ConflictSkip})
+		require.NoError(t, err)
+
+		assert.Equal(t, backup.ResponseRestoreCount{Created: 1}, res.Categories)
+		assert.Equal(t, backup.ResponseRestoreCount{Created: 2}, res.Passwords)
+		assert.Empty(t, res.MissingFiles)
+		require.Len(t, saved, 2)
+		assert.Equal(t, uint(99), saved[0].CategoryID)
+		assert.Equal(t, "secret", saved[0].Password)
+		assert.Equal(t, uint(7), saved[0].OwnerID)
+		// keep the original timestamps
+		assert.False(t, saved[0].CreatedAt.IsZero())
+
+		img, err := os.ReadFile(filepath.Join(dir, "img.png"))
+		require.NoError(t, err)
+		assert.Equal(t, "image", string(img))
+	})
+
+	testCases := []struct {
+		name          string
+		conflict      string
+		setup         func(repo *pwMock.MockpasswordRepository)
+		expectPasswds backup.ResponseRestoreCount
+	}{
+		{
+			name:     "Given skip as the conflict strategy and existing password with the same username should skip it",
+			conflict: backup.ConflictSkip,
+			setup: func(repo *pwMock.MockpasswordRepository) {
+				repo.EXPECT().
+					FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Password{{ID: 5}}, nil).
+					Twice()
+			},
+			expectPasswds: backup.ResponseRestoreCount{Skipped: 2},
+		},
+		{
+			name:     "Given overwrite as the conflict strategy and existing password with the same username should update it",
+			conflict: backup.ConflictOverwrite,
+			setup: func(repo *pwMock.MockpasswordRepository) {
+				repo.EXPECT().
+					UpdateCategory(mock.Anything, uint(3), mock.Anything, mock.Anything).
+					Return(&entity.Category{}, nil).
+					Once()
+				repo.EXPECT().
+					FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Password{{ID: 5}}, nil).
+					Twice()
+				repo.EXPECT().
+					UpdatePassword(mock.Anything, uint(5), mock.Anything, mock.Anything).
+					Return(&entity.Password{}, nil).
+					Twice()
+			},
+			expectPasswds: backup.ResponseRestoreCount{Updated: 2},
+		},
+		{
+			name:     "Given duplicate as the conflict strategy should create all passwords without looking for the existing one",
+			conflict: backup.ConflictDuplicate,
+			setup: func(repo *pwMock.MockpasswordRepository) {
+				repo.EXPECT().
+					CreatePassword(mock.Anything, mock.MatchedBy(func(obj entity.Password) bool { return obj.CategoryID == 3 })).
+					Return(&entity.Password{}, nil).
+					Twice()
+			},
+			expectPasswds: backup.ResponseRestoreCount{Created: 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := viper.New()
+			conf.Set("storage.path", t.TempDir())
+
+			repo := new(pwMock.MockpasswordRepository)
+			withTransaction(repo)
+			// the category is already exist with different id
+			repo.EXPECT().
+				FindCategories(mock.Anything, mock.Anything, mock.Anything).
+				Return([]*entity.Category{{ID: 3, Name: "FAKE"}}, nil).
+				Once()
+			tc.setup(repo)
+
+			uc := backupUC.NewUseCase(conf, zaptest.NewLogger(t), repo, new(auditMock.MockauditRepository))
+			res, err := uc.Restore(context.Background(), bytes.NewReader(bak), backup.RequestRestore{Passphrase: passphrase, Conflict: tc.conflict})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectPasswds, res.Passwords)
+			repo.AssertExpectations(t)
+		})
+	}
+}
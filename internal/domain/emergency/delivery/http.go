@@ -0,0 +1,105 @@
+package delivery
+
+import (
+	"github.com/mdanialr/pwman_backend/internal/domain/emergency"
+	emUC "github.com/mdanialr/pwman_backend/internal/domain/emergency/usecase"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// NewDelivery setup endpoints in domain emergency as delivery layer.
+func NewDelivery(app fiber.Router, conf *viper.Viper, uc emUC.UseCase) {
+	d := &delivery{uc: uc}
+
+	api := app.Group("/emergency", md.JWT(conf))
+	api.Get("/", d.Index)
+	api.Post("/create", d.Create)
+	api.Post("/delete", d.Delete)
+	api.Post("/request", d.Request)
+	api.Post("/reject", d.Reject)
+}
+
+type delivery struct {
+	uc emUC.UseCase
+}
+
+func (d *delivery) Index(c *fiber.Ctx) error {
+	var req emergency.Request
+	c.QueryParser(&req)
+
+	res, err := d.uc.IndexAccess(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) Create(c *fiber.Ctx) error {
+	var req emergency.Request
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveAccess(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) Delete(c *fiber.Ctx) error {
+	var req emergency.Request
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateID(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.DeleteAccess(c.Context(), req.ID); err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithMsg("revoked successfully"))
+}
+
+func (d *delivery) Request(c *fiber.Ctx) error {
+	var req emergency.Request
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateID(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.RequestAccess(c.Context(), req.ID)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) Reject(c *fiber.Ctx) error {
+	var req emergency.Request
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateID(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.RejectAccess(c.Context(), req.ID); err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithMsg("rejected successfully"))
+}
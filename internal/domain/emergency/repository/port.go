@@ -0,0 +1,40 @@
+package emergency
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	"github.com/mdanialr/pwman_backend/pkg/scheduler"
+)
+
+// Repository signature that's used in emergency domain for repository layer.
+// Also keep the scheduled jobs, so it may be used as scheduler.Store.
+type Repository interface {
+	scheduler.Store
+	// GetAccessByID retrieve an entity.EmergencyAccess by given id.
+	GetAccessByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.EmergencyAccess, error)
+	// GetAccess retrieve an entity.EmergencyAccess by given grantor and
+	// grantee id.
+	GetAccess(ctx context.Context, grantorID, granteeID uint) (*entity.EmergencyAccess, error)
+	// FindAccesses retrieve all entity.EmergencyAccess that match given
+	// condition in options.
+	FindAccesses(ctx context.Context, opts ...repo.Options) ([]*entity.EmergencyAccess, error)
+	// CreateAccess create new entity.EmergencyAccess and return the newly
+	// created object along with assigned id as primary key.
+	CreateAccess(ctx context.Context, obj entity.EmergencyAccess) (*entity.EmergencyAccess, error)
+	// UpdateAccess update existing entity.EmergencyAccess that match given
+	// id.
+	UpdateAccess(ctx context.Context, id uint, obj entity.EmergencyAccess, opts ...repo.Options) error
+	// DeleteAccess delete existing entity.EmergencyAccess that match given
+	// id.
+	DeleteAccess(ctx context.Context, id uint) error
+	// GetUserByUsername retrieve an entity.User by given username.
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	// CreateJob schedule new entity.Job.
+	CreateJob(ctx context.Context, obj entity.Job) error
+	// Transaction run given fn inside database transaction using Repository
+	// that's bound to that transaction. Commit if fn return no error,
+	// otherwise roll back.
+	Transaction(ctx context.Context, fn func(Repository) error) error
+}
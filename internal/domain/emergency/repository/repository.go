@@ -0,0 +1,113 @@
+package emergency
+
+import (
+	"context"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	"github.com/mdanialr/pwman_backend/pkg/scheduler"
+
+	"gorm.io/gorm"
+)
+
+// NewRepository return concrete implementation of Repository that use gorm.DB
+// as the data source.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func (r *repository) GetAccessByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.EmergencyAccess, error) {
+	q := r.db.WithContext(ctx)
+	var e entity.EmergencyAccess
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &e, q.First(&e, id).Error
+}
+
+func (r *repository) GetAccess(ctx context.Context, grantorID, granteeID uint) (*entity.EmergencyAccess, error) {
+	var e entity.EmergencyAccess
+	return &e, r.db.WithContext(ctx).Where("grantor_id = ? AND grantee_id = ?", grantorID, granteeID).First(&e).Error
+}
+
+func (r *repository) FindAccesses(ctx context.Context, opts ...repo.Options) ([]*entity.EmergencyAccess, error) {
+	q := r.db.WithContext(ctx).Model(&entity.EmergencyAccess{})
+	var e []*entity.EmergencyAccess
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return e, q.Find(&e).Error
+}
+
+func (r *repository) CreateAccess(ctx context.Context, obj entity.EmergencyAccess) (*entity.EmergencyAccess, error) {
+	q := r.db.WithContext(ctx)
+
+	return &obj, q.Create(&obj).Error
+}
+
+func (r *repository) UpdateAccess(ctx context.Context, id uint, obj entity.EmergencyAccess, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return q.Model(&entity.EmergencyAccess{ID: id}).Updates(obj).Error
+}
+
+func (r *repository) DeleteAccess(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&entity.EmergencyAccess{ID: id}).Error
+}
+
+func (r *repository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var u entity.User
+	return &u, r.db.WithContext(ctx).Where("username = ?", username).First(&u).Error
+}
+
+func (r *repository) CreateJob(ctx context.Context, obj entity.Job) error {
+	return r.db.WithContext(ctx).Create(&obj).Error
+}
+
+func (r *repository) DueJobs(ctx context.Context, now time.Time) ([]scheduler.Job, error) {
+	var jobs []entity.Job
+	err := r.db.WithContext(ctx).Where("run_at <= ?", now).Order("run_at ASC").Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]scheduler.Job, 0, len(jobs))
+	for _, j := range jobs {
+		res = append(res, scheduler.Job{ID: j.ID, Kind: j.Kind, RefID: j.RefID, RunAt: j.RunAt, Attempts: j.Attempts})
+	}
+	return res, nil
+}
+
+func (r *repository) DoneJob(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&entity.Job{ID: id}).Error
+}
+
+func (r *repository) RetryJob(ctx context.Context, id uint, at time.Time, reason string) error {
+	return r.db.WithContext(ctx).Model(&entity.Job{ID: id}).Updates(map[string]any{
+		"run_at":     at,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": reason,
+	}).Error
+}
+
+func (r *repository) Transaction(ctx context.Context, fn func(Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{db: tx})
+	})
+}
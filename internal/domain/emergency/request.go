@@ -0,0 +1,52 @@
+package emergency
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// Request standard request object that may be used in emergency domain.
+type Request struct {
+	// ID unique identifier of each emergency access. Should be required when
+	// requesting, rejecting or deleting.
+	ID uint `json:"id"`
+	// Username the username of the trusted user.
+	Username string `json:"username" validate:"required"`
+	// Access either view or takeover.
+	Access string `json:"access" validate:"required,oneof=view takeover"`
+	// WaitHours optional waiting period in hours. Default to the one in
+	// config.
+	WaitHours int `json:"wait_hours" validate:"omitempty,min=1,max=720"`
+	// Received whether to retrieve those that's granted to the caller
+	// instead of by the caller.
+	Received bool `json:"-" query:"received"`
+}
+
+// Validate apply validation rules for Request.
+func (r *Request) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateID apply validation rules for Request in the endpoints that only
+// need the id.
+func (r *Request) ValidateID() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.idRequiredValidation, Request{})
+	if err := v.StructExcept(r, "Username", "Access", "WaitHours"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// idRequiredValidation custom required fields validation in the endpoints
+// that only need the id.
+func (r *Request) idRequiredValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(Request)
+
+	// required for field ID
+	if req.ID < 1 {
+		sl.ReportError(req.ID, "id", "ID", "required", "ID")
+	}
+}
@@ -0,0 +1,49 @@
+package emergency
+
+import (
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+)
+
+// Response standard response object for an emergency access.
+type Response struct {
+	ID uint `json:"id"`
+	// Grantor the username of the user who own the vault. Omitted when it's
+	// the caller who just granted it.
+	Grantor string `json:"grantor,omitempty"`
+	// Grantee the username of the trusted user.
+	Grantee   string `json:"grantee"`
+	Access    string `json:"access"`
+	WaitHours int    `json:"wait_hours"`
+	Status    string `json:"status"`
+	// RequestedAt when the access is requested the last time if any.
+	RequestedAt *time.Time `json:"requested_at"`
+	// WaitUntil when the waiting period of the last request is over if any.
+	WaitUntil *time.Time `json:"wait_until"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewResponseFromEntity transform given entity.EmergencyAccess to Response.
+// The Grantor and Grantee should be loaded to fill the usernames.
+func NewResponseFromEntity(e entity.EmergencyAccess) *Response {
+	r := &Response{
+		ID:          e.ID,
+		Access:      e.Access,
+		WaitHours:   e.WaitHours,
+		Status:      e.Status,
+		RequestedAt: e.RequestedAt,
+		CreatedAt:   e.CreatedAt,
+	}
+	if e.RequestedAt != nil {
+		until := e.WaitUntil()
+		r.WaitUntil = &until
+	}
+	if e.Grantor != nil {
+		r.Grantor = e.Grantor.Username
+	}
+	if e.Grantee != nil {
+		r.Grantee = e.Grantee.Username
+	}
+	return r
+}
@@ -0,0 +1,47 @@
+package emergency_test
+
+import (
+	"context"
+	"testing"
+
+	emMock "github.com/mdanialr/pwman_backend/internal/domain/emergency/repository/mocks"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	ntMock "github.com/mdanialr/pwman_backend/pkg/notifier/mocks"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// caller is the id of the user that calls the use cases in the tests.
+const caller = uint(1)
+
+type (
+	deps struct {
+		config *viper.Viper
+		log    *zap.Logger
+		notify *ntMock.MocknotifierPort
+		repo   *emMock.MockemergencyRepository
+	}
+	helperSetup struct {
+		Dep deps
+	}
+)
+
+func setupTestHelper(t *testing.T) *helperSetup {
+	d := deps{
+		config: viper.New(),
+		log:    zaptest.NewLogger(t),
+		notify: new(ntMock.MocknotifierPort),
+		repo:   new(emMock.MockemergencyRepository),
+	}
+
+	return &helperSetup{
+		Dep: d,
+	}
+}
+
+// callerCtx returns a context that holds the identity of the caller.
+func callerCtx() context.Context {
+	return identity.NewContext(context.Background(), identity.User{ID: caller})
+}
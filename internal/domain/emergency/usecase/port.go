@@ -0,0 +1,35 @@
+package emergency
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/emergency"
+	"github.com/mdanialr/pwman_backend/pkg/scheduler"
+)
+
+// UseCase signature that's used in emergency domain for use case layer.
+type UseCase interface {
+	// IndexAccess retrieve all emergency accesses that's granted by the
+	// caller, or granted to the caller if Received is set in given request.
+	IndexAccess(ctx context.Context, req emergency.Request) ([]*emergency.Response, error)
+	// SaveAccess grant the user in given request the emergency access to the
+	// vault of the caller. The access level and waiting period are replaced
+	// if it's already granted to the same user.
+	SaveAccess(ctx context.Context, req emergency.Request) (*emergency.Response, error)
+	// DeleteAccess revoke existing emergency access that match given id.
+	// Either the grantor or the grantee may revoke it.
+	DeleteAccess(ctx context.Context, id uint) error
+	// RequestAccess start the waiting period of the emergency access that
+	// match given id and notify the grantor. Only the grantee may request it.
+	// The access is approved once the waiting period is over unless it's
+	// rejected.
+	RequestAccess(ctx context.Context, id uint) (*emergency.Response, error)
+	// RejectAccess reject the request of the emergency access that match
+	// given id, or take back the access if it's already approved. Only the
+	// grantor may reject it.
+	RejectAccess(ctx context.Context, id uint) error
+	// ApproveAccess approve the emergency access in given job if it's still
+	// waiting and the waiting period is over, then notify the grantee. Used
+	// as the scheduler.Handler of JobApprove.
+	ApproveAccess(ctx context.Context, job scheduler.Job) error
+}
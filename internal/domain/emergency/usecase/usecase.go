@@ -0,0 +1,228 @@
+package emergency
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/emergency"
+	emRepo "github.com/mdanialr/pwman_backend/internal/domain/emergency/repository"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/notifier"
+	"github.com/mdanialr/pwman_backend/pkg/scheduler"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// JobApprove the kind of scheduler.Job that approve an emergency access once
+// its waiting period is over.
+const JobApprove = "emergency.approve"
+
+// defaultWaitHours the waiting period in hours if it's not set in both the
+// request and config.
+const defaultWaitHours = 48
+
+// NewUseCase return concrete implementation of UseCase in emergency domain.
+func NewUseCase(conf *viper.Viper, log *zap.Logger, nt notifier.Port, repo emRepo.Repository) UseCase {
+	return &useCase{conf: conf, log: log, nt: nt, repo: repo}
+}
+
+type useCase struct {
+	conf *viper.Viper
+	log  *zap.Logger
+	nt   notifier.Port
+	repo emRepo.Repository
+}
+
+func (u *useCase) IndexAccess(ctx context.Context, req emergency.Request) ([]*emergency.Response, error) {
+	col := "grantor_id"
+	if req.Received {
+		col = "grantee_id"
+	}
+
+	accs, err := u.repo.FindAccesses(ctx,
+		repo.Where(col+" = ?", identity.FromContext(ctx).ID),
+		repo.Order("id ASC"),
+		repo.EagerLoad("Grantor"),
+		repo.EagerLoad("Grantee"),
+	)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve emergency accesses:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	res := make([]*emergency.Response, 0, len(accs))
+	for _, e := range accs {
+		res = append(res, emergency.NewResponseFromEntity(*e))
+	}
+	return res, nil
+}
+
+func (u *useCase) SaveAccess(ctx context.Context, req emergency.Request) (*emergency.Response, error) {
+	caller := identity.FromContext(ctx)
+
+	// make sure the user does really exist in repo
+	usr, err := u.repo.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if usr.ID == caller.ID {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrSelfShare)
+	}
+
+	wait := req.WaitHours
+	if wait < 1 {
+		wait = defaultWaitHours
+		if h := u.conf.GetInt("emergency.wait_hours"); h > 0 {
+			wait = h
+		}
+	}
+
+	// replace the access if it's already granted to the same user
+	e, _ := u.repo.GetAccess(ctx, caller.ID, usr.ID)
+	if e.ID == 0 {
+		e, err = u.repo.CreateAccess(ctx, entity.EmergencyAccess{
+			GrantorID: caller.ID,
+			GranteeID: usr.ID,
+			Access:    req.Access,
+			WaitHours: wait,
+			Status:    entity.EmergencyIdle,
+		})
+	} else {
+		e.Access, e.WaitHours = req.Access, wait
+		err = u.repo.UpdateAccess(ctx, e.ID, *e, repo.Cols("access", "wait_hours"))
+	}
+	if err != nil {
+		u.log.Error(help.Pad("failed to save emergency access:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	e.Grantee = usr
+
+	return emergency.NewResponseFromEntity(*e), nil
+}
+
+func (u *useCase) DeleteAccess(ctx context.Context, id uint) error {
+	uid := identity.FromContext(ctx).ID
+	e, err := u.repo.GetAccessByID(ctx, id)
+	if err != nil || (e.GrantorID != uid && e.GranteeID != uid) {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+
+	if err = u.repo.DeleteAccess(ctx, id); err != nil {
+		u.log.Error(help.Pad("failed to delete existing emergency access with id:", strconv.Itoa(int(id)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return nil
+}
+
+func (u *useCase) RequestAccess(ctx context.Context, id uint) (*emergency.Response, error) {
+	e, err := u.repo.GetAccessByID(ctx, id, repo.EagerLoad("Grantor"), repo.EagerLoad("Grantee"))
+	if err != nil || e.GranteeID != identity.FromContext(ctx).ID {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	switch e.Status {
+	case entity.EmergencyWaiting:
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrInProgress)
+	case entity.EmergencyApproved:
+		return emergency.NewResponseFromEntity(*e), nil
+	}
+
+	// start the waiting period along with the job that approve it once it's
+	// over, so it's still approved after the app is restarted
+	now := time.Now()
+	e.Status, e.RequestedAt = entity.EmergencyWaiting, &now
+	err = u.repo.Transaction(ctx, func(tx emRepo.Repository) error {
+		if err := tx.UpdateAccess(ctx, e.ID, *e, repo.Cols("status", "requested_at")); err != nil {
+			return err
+		}
+		return tx.CreateJob(ctx, entity.Job{Kind: JobApprove, RefID: e.ID, RunAt: e.WaitUntil()})
+	})
+	if err != nil {
+		u.log.Error(help.Pad("failed to request emergency access with id:", strconv.Itoa(int(id)), "and err:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	u.notify(ctx, e.Grantor, notifier.Message{
+		Subject: "Emergency access requested",
+		Body: fmt.Sprintf("%s requested %s access to your vault. It will be approved at %s unless you reject it.",
+			username(e.Grantee), e.Access, e.WaitUntil().Format(time.DateTime)),
+	})
+
+	return emergency.NewResponseFromEntity(*e), nil
+}
+
+func (u *useCase) RejectAccess(ctx context.Context, id uint) error {
+	e, err := u.repo.GetAccessByID(ctx, id, repo.EagerLoad("Grantor"), repo.EagerLoad("Grantee"))
+	if err != nil || e.GrantorID != identity.FromContext(ctx).ID {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if e.Status != entity.EmergencyWaiting && e.Status != entity.EmergencyApproved {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+
+	// the pending job skip it since it's no longer waiting
+	e.Status = entity.EmergencyRejected
+	if err = u.repo.UpdateAccess(ctx, e.ID, *e, repo.Cols("status")); err != nil {
+		u.log.Error(help.Pad("failed to reject emergency access with id:", strconv.Itoa(int(id)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	u.notify(ctx, e.Grantee, notifier.Message{
+		Subject: "Emergency access rejected",
+		Body:    fmt.Sprintf("%s rejected your emergency access to their vault.", username(e.Grantor)),
+	})
+
+	return nil
+}
+
+func (u *useCase) ApproveAccess(ctx context.Context, job scheduler.Job) error {
+	e, err := u.repo.GetAccessByID(ctx, job.RefID, repo.EagerLoad("Grantor"), repo.EagerLoad("Grantee"))
+	if err != nil {
+		// it's already revoked, nothing to do
+		return nil
+	}
+	// skip if it's rejected meanwhile, or requested again later which has
+	// its own job
+	if e.Status != entity.EmergencyWaiting || e.WaitUntil().After(time.Now()) {
+		return nil
+	}
+
+	e.Status = entity.EmergencyApproved
+	if err = u.repo.UpdateAccess(ctx, e.ID, *e, repo.Cols("status")); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Your %s access to the vault of %s is approved.", e.Access, username(e.Grantor))
+	u.notify(ctx, e.Grantee, notifier.Message{Subject: "Emergency access approved", Body: msg})
+	u.notify(ctx, e.Grantor, notifier.Message{
+		Subject: "Emergency access approved",
+		Body:    fmt.Sprintf("%s now has %s access to your vault.", username(e.Grantee), e.Access),
+	})
+
+	return nil
+}
+
+// notify send given message to given user and just log if there is any
+// error.
+func (u *useCase) notify(ctx context.Context, to *entity.User, msg notifier.Message) {
+	msg.Recipient = username(to)
+	if err := u.nt.Notify(ctx, msg); err != nil {
+		u.log.Error(help.Pad("failed to send emergency access notification:", err.Error()))
+	}
+}
+
+// username return the username of given user if it's loaded.
+func username(usr *entity.User) string {
+	if usr == nil {
+		return ""
+	}
+	return usr.Username
+}
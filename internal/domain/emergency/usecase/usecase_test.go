@@ -0,0 +1,270 @@
+package emergency_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/emergency"
+	emRepo "github.com/mdanialr/pwman_backend/internal/domain/emergency/repository"
+	emUC "github.com/mdanialr/pwman_backend/internal/domain/emergency/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/pkg/notifier"
+	"github.com/mdanialr/pwman_backend/pkg/scheduler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseCase_SaveAccess(t *testing.T) {
+	t.Run("Given the caller themselves should return UC instance, INVALID_PAYLOAD as code and "+
+		"can not share to yourself as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetUserByUsername(mock.Anything, "me").
+			Return(&entity.User{ID: caller}, nil).
+			Once()
+
+		uc := emUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.notify, h.Dep.repo)
+		_, err := uc.SaveAccess(callerCtx(), emergency.Request{Username: "me", Access: entity.EmergencyView})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "can not share to yourself", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given no waiting period should use the one in config", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.config.Set("emergency.wait_hours", 12)
+		h.Dep.repo.EXPECT().
+			GetUserByUsername(mock.Anything, "doe").
+			Return(&entity.User{ID: 2, Username: "doe"}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			GetAccess(mock.Anything, caller, uint(2)).
+			Return(&entity.EmergencyAccess{}, errors.New("record not found")).
+			Once()
+		h.Dep.repo.EXPECT().
+			CreateAccess(mock.Anything, entity.EmergencyAccess{
+				GrantorID: caller,
+				GranteeID: 2,
+				Access:    entity.EmergencyTakeover,
+				WaitHours: 12,
+				Status:    entity.EmergencyIdle,
+			}).
+			RunAndReturn(func(_ context.Context, obj entity.EmergencyAccess) (*entity.EmergencyAccess, error) {
+				obj.ID = 5
+				return &obj, nil
+			}).
+			Once()
+
+		uc := emUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.notify, h.Dep.repo)
+		res, err := uc.SaveAccess(callerCtx(), emergency.Request{Username: "doe", Access: entity.EmergencyTakeover})
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), res.ID)
+		assert.Equal(t, "doe", res.Grantee)
+		assert.Equal(t, 12, res.WaitHours)
+		assert.Equal(t, entity.EmergencyIdle, res.Status)
+	})
+}
+
+func TestUseCase_RequestAccess(t *testing.T) {
+	testCases := []struct {
+		name       string
+		access     *entity.EmergencyAccess
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given access that's granted to another user should return UC instance, INVALID_PAYLOAD as " +
+				"code and data not found as message",
+			access:     &entity.EmergencyAccess{ID: 5, GrantorID: 2, GranteeID: 3, Status: entity.EmergencyIdle},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given access that's still waiting should return UC instance, INVALID_PAYLOAD as code and " +
+				"process is still in progress as message",
+			access:     &entity.EmergencyAccess{ID: 5, GrantorID: 2, GranteeID: caller, Status: entity.EmergencyWaiting},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "process is still in progress",
+			wantErr:    true,
+		},
+		{
+			name: "Given access that's rejected before should start the waiting period again, schedule the " +
+				"approval and notify the grantor",
+			access: &entity.EmergencyAccess{
+				ID:        5,
+				GrantorID: 2,
+				Grantor:   &entity.User{Username: "boss"},
+				GranteeID: caller,
+				Grantee:   &entity.User{Username: "me"},
+				Access:    entity.EmergencyView,
+				WaitHours: 24,
+				Status:    entity.EmergencyRejected,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			h.Dep.repo.EXPECT().
+				GetAccessByID(mock.Anything, uint(5), mock.Anything, mock.Anything).
+				Return(tc.access, nil).
+				Once()
+			if !tc.wantErr {
+				h.Dep.repo.EXPECT().
+					Transaction(mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, fn func(emRepo.Repository) error) error {
+						return fn(h.Dep.repo)
+					}).
+					Once()
+				h.Dep.repo.EXPECT().
+					UpdateAccess(mock.Anything, uint(5), mock.MatchedBy(func(obj entity.EmergencyAccess) bool {
+						return obj.Status == entity.EmergencyWaiting && obj.RequestedAt != nil
+					}), mock.Anything).
+					Return(nil).
+					Once()
+				h.Dep.repo.EXPECT().
+					CreateJob(mock.Anything, mock.MatchedBy(func(obj entity.Job) bool {
+						return obj.Kind == emUC.JobApprove && obj.RefID == 5 &&
+							obj.RunAt.Sub(time.Now().Add(24*time.Hour)).Abs() < time.Minute
+					})).
+					Return(nil).
+					Once()
+				h.Dep.notify.EXPECT().
+					Notify(mock.Anything, mock.MatchedBy(func(msg notifier.Message) bool {
+						return msg.Recipient == "boss" && msg.Subject == "Emergency access requested"
+					})).
+					Return(nil).
+					Once()
+			}
+
+			uc := emUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.notify, h.Dep.repo)
+			res, err := uc.RequestAccess(callerCtx(), 5)
+			h.Dep.repo.AssertExpectations(t)
+			h.Dep.notify.AssertExpectations(t)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, entity.EmergencyWaiting, res.Status)
+			assert.NotNil(t, res.WaitUntil)
+		})
+	}
+}
+
+func TestUseCase_RejectAccess(t *testing.T) {
+	t.Run("Given access that's not requested should return UC instance, INVALID_PAYLOAD as code and "+
+		"data not found as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetAccessByID(mock.Anything, uint(5), mock.Anything, mock.Anything).
+			Return(&entity.EmergencyAccess{ID: 5, GrantorID: caller, Status: entity.EmergencyIdle}, nil).
+			Once()
+
+		uc := emUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.notify, h.Dep.repo)
+		err := uc.RejectAccess(callerCtx(), 5)
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "data not found", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given access that's waiting should reject it and notify the grantee", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetAccessByID(mock.Anything, uint(5), mock.Anything, mock.Anything).
+			Return(&entity.EmergencyAccess{ID: 5, GrantorID: caller, Grantee: &entity.User{Username: "doe"}, Status: entity.EmergencyWaiting}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			UpdateAccess(mock.Anything, uint(5), mock.MatchedBy(func(obj entity.EmergencyAccess) bool {
+				return obj.Status == entity.EmergencyRejected
+			}), mock.Anything).
+			Return(nil).
+			Once()
+		h.Dep.notify.EXPECT().
+			Notify(mock.Anything, mock.MatchedBy(func(msg notifier.Message) bool { return msg.Recipient == "doe" })).
+			Return(nil).
+			Once()
+
+		uc := emUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.notify, h.Dep.repo)
+		require.NoError(t, uc.RejectAccess(callerCtx(), 5))
+		h.Dep.repo.AssertExpectations(t)
+		h.Dep.notify.AssertExpectations(t)
+	})
+}
+
+func TestUseCase_ApproveAccess(t *testing.T) {
+	past := time.Now().Add(-25 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name    string
+		access  *entity.EmergencyAccess
+		approve bool
+	}{
+		{
+			name:   "Given access that's rejected meanwhile should do nothing",
+			access: &entity.EmergencyAccess{ID: 5, WaitHours: 24, Status: entity.EmergencyRejected, RequestedAt: &past},
+		},
+		{
+			name:   "Given access that's requested again later should wait for its own job",
+			access: &entity.EmergencyAccess{ID: 5, WaitHours: 24, Status: entity.EmergencyWaiting, RequestedAt: &recent},
+		},
+		{
+			name: "Given access whose waiting period is over should approve it and notify both users",
+			access: &entity.EmergencyAccess{
+				ID:          5,
+				Grantor:     &entity.User{Username: "boss"},
+				Grantee:     &entity.User{Username: "doe"},
+				WaitHours:   24,
+				Status:      entity.EmergencyWaiting,
+				RequestedAt: &past,
+			},
+			approve: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			h.Dep.repo.EXPECT().
+				GetAccessByID(mock.Anything, uint(5), mock.Anything, mock.Anything).
+				Return(tc.access, nil).
+				Once()
+			if tc.approve {
+				h.Dep.repo.EXPECT().
+					UpdateAccess(mock.Anything, uint(5), mock.MatchedBy(func(obj entity.EmergencyAccess) bool {
+						return obj.Status == entity.EmergencyApproved
+					}), mock.Anything).
+					Return(nil).
+					Once()
+				h.Dep.notify.EXPECT().
+					Notify(mock.Anything, mock.MatchedBy(func(msg notifier.Message) bool { return msg.Recipient == "doe" })).
+					Return(nil).
+					Once()
+				h.Dep.notify.EXPECT().
+					Notify(mock.Anything, mock.MatchedBy(func(msg notifier.Message) bool { return msg.Recipient == "boss" })).
+					Return(nil).
+					Once()
+			}
+
+			uc := emUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.notify, h.Dep.repo)
+			require.NoError(t, uc.ApproveAccess(context.Background(), scheduler.Job{Kind: emUC.JobApprove, RefID: 5}))
+			h.Dep.repo.AssertExpectations(t)
+			h.Dep.notify.AssertExpectations(t)
+		})
+	}
+}
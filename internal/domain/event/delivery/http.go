@@ -0,0 +1,106 @@
+package delivery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"time"
+
+	pwUC "github.com/mdanialr/pwman_backend/internal/domain/password/usecase"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	"github.com/mdanialr/pwman_backend/pkg/event"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+const (
+	// heartbeat how often a comment is sent to keep the connection open and
+	// to find out whether the client is gone.
+	heartbeat = 15 * time.Second
+	// retry how long in milliseconds the client should wait before
+	// reconnecting.
+	retry = "3000"
+)
+
+// NewDelivery setup endpoints in domain event as delivery layer. All streams
+// are closed once given ctx is done. Each caller only receive the events that
+// given pwUC.UseCase allow them to see.
+func NewDelivery(ctx context.Context, app fiber.Router, conf *viper.Viper, ev event.Port, uc pwUC.UseCase) {
+	d := &delivery{ctx: ctx, ev: ev, uc: uc}
+
+	api := app.Group("/events", md.JWTStream(conf))
+	api.Get("/", d.Stream)
+}
+
+type delivery struct {
+	ctx context.Context
+	ev  event.Port
+	uc  pwUC.UseCase
+}
+
+func (d *delivery) Stream(c *fiber.Ctx) error {
+	// resume after the last received event if any
+	lastID := c.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	// the request context is gone once streaming, so carry the caller over
+	ctx := identity.NewContext(d.ctx, identity.FromContext(c.Context()))
+	sub := d.ev.Subscribe(lastID, func(ev event.Event) bool {
+		return d.uc.VisibleEvent(ctx, ev)
+	})
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		w.WriteString("retry: " + retry + "\n\n")
+		// tell the client to retrieve the whole state again through the sync
+		if sub.Reset {
+			w.WriteString("event: reset\ndata: {}\n\n")
+		}
+		for _, ev := range sub.Replay {
+			writeEvent(w, ev)
+		}
+		if w.Flush() != nil {
+			return
+		}
+
+		tick := time.NewTicker(heartbeat)
+		defer tick.Stop()
+		for {
+			select {
+			case <-d.ctx.Done():
+				return
+			case ev, ok := <-sub.C:
+				// dropped for being too slow, the client will reconnect and
+				// resume from the last event
+				if !ok {
+					return
+				}
+				writeEvent(w, ev)
+			case <-tick.C:
+				w.WriteString(": ping\n\n")
+			}
+			// the client is gone
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeEvent write given event.Event in the server-sent events format.
+func writeEvent(w *bufio.Writer, ev event.Event) {
+	b, _ := json.Marshal(ev)
+	w.WriteString("id: " + ev.ID + "\nevent: " + ev.Type + "\ndata: ")
+	w.Write(b)
+	w.WriteString("\n\n")
+}
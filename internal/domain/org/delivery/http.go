@@ -0,0 +1,126 @@
+package delivery
+
+import (
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/org"
+	orgUC "github.com/mdanialr/pwman_backend/internal/domain/org/usecase"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// NewDelivery setup endpoints in domain org as delivery layer.
+func NewDelivery(app fiber.Router, conf *viper.Viper, uc orgUC.UseCase) {
+	d := &delivery{uc: uc}
+
+	api := app.Group("/org", md.JWT(conf))
+	api.Get("/", d.Index)
+	api.Post("/create", d.Create)
+	api.Post("/delete", d.Delete)
+	api.Get("/member", d.IndexMember)
+	api.Post("/member/create", d.CreateMember)
+	api.Post("/member/delete", d.DeleteMember)
+}
+
+type delivery struct {
+	uc orgUC.UseCase
+}
+
+// errResponse send given error from use case as the response. FORBIDDEN is
+// sent with its own status code.
+func errResponse(c *fiber.Ctx, err error) error {
+	if e, ok := err.(*stderr.UC); ok && e.Code == cons.Forbidden {
+		return resp.ErrorCode(c, fiber.StatusForbidden, resp.WithErr(err))
+	}
+	return resp.Error(c, resp.WithErr(err))
+}
+
+func (d *delivery) Index(c *fiber.Ctx) error {
+	res, err := d.uc.IndexOrg(c.Context())
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) Create(c *fiber.Ctx) error {
+	var req org.RequestOrg
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveOrg(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) Delete(c *fiber.Ctx) error {
+	var req org.RequestOrg
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateDelete(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.DeleteOrg(c.Context(), req.ID); err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithMsg("deleted successfully"))
+}
+
+func (d *delivery) IndexMember(c *fiber.Ctx) error {
+	var req org.RequestMember
+	c.QueryParser(&req)
+
+	res, err := d.uc.IndexMember(c.Context(), req.OrgID)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) CreateMember(c *fiber.Ctx) error {
+	var req org.RequestMember
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveMember(c.Context(), req)
+	if err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) DeleteMember(c *fiber.Ctx) error {
+	var req org.RequestMember
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateDelete(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.DeleteMember(c.Context(), req); err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithMsg("removed successfully"))
+}
@@ -0,0 +1,40 @@
+package org
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+)
+
+// Repository signature that's used in org domain for repository layer.
+type Repository interface {
+	// GetOrgByName retrieve an entity.Organization by given name.
+	GetOrgByName(ctx context.Context, name string) (*entity.Organization, error)
+	// CreateOrg create new entity.Organization along with the user of given
+	// id as its owner in a single transaction. Return the newly created
+	// object along with assigned id as primary key.
+	CreateOrg(ctx context.Context, obj entity.Organization, ownerID uint) (*entity.Organization, error)
+	// DeleteOrg delete existing entity.Organization that match given id
+	// along with all of its members.
+	DeleteOrg(ctx context.Context, id uint) error
+	// CountCategories count the categories that still belong to the
+	// entity.Organization of given id.
+	CountCategories(ctx context.Context, orgID uint) (int64, error)
+	// GetMember retrieve an entity.Member by given organization and user id.
+	GetMember(ctx context.Context, orgID, userID uint) (*entity.Member, error)
+	// FindMembers retrieve all entity.Member that match given condition in
+	// options.
+	FindMembers(ctx context.Context, opts ...repo.Options) ([]*entity.Member, error)
+	// CreateMember create new entity.Member and return the newly created
+	// object along with assigned id as primary key.
+	CreateMember(ctx context.Context, obj entity.Member) (*entity.Member, error)
+	// UpdateMember update existing entity.Member that match given id.
+	UpdateMember(ctx context.Context, id uint, obj entity.Member, opts ...repo.Options) (*entity.Member, error)
+	// DeleteMember delete given entity.Member along with recording that its
+	// user lost the access to the vault of the organization in a single
+	// transaction.
+	DeleteMember(ctx context.Context, obj entity.Member) error
+	// GetUserByUsername retrieve an entity.User by given username.
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+}
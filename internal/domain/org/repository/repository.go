@@ -0,0 +1,98 @@
+package org
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// NewRepository return concrete implementation of Repository that use gorm.DB
+// as the data source.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func (r *repository) GetOrgByName(ctx context.Context, name string) (*entity.Organization, error) {
+	var o entity.Organization
+	return &o, r.db.WithContext(ctx).Select("id").Where("name = ?", name).First(&o).Error
+}
+
+func (r *repository) CreateOrg(ctx context.Context, obj entity.Organization, ownerID uint) (*entity.Organization, error) {
+	return &obj, r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&obj).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.Member{OrgID: obj.ID, UserID: ownerID, Role: entity.RoleOwner}).Error
+	})
+}
+
+func (r *repository) DeleteOrg(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// remove all members first
+		if err := tx.Where("org_id = ?", id).Delete(&entity.Member{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Organization{ID: id}).Error
+	})
+}
+
+func (r *repository) CountCategories(ctx context.Context, orgID uint) (int64, error) {
+	var n int64
+	return n, r.db.WithContext(ctx).Model(&entity.Category{}).Where("org_id = ?", orgID).Count(&n).Error
+}
+
+func (r *repository) GetMember(ctx context.Context, orgID, userID uint) (*entity.Member, error) {
+	var m entity.Member
+	return &m, r.db.WithContext(ctx).Where("org_id = ? AND user_id = ?", orgID, userID).First(&m).Error
+}
+
+func (r *repository) FindMembers(ctx context.Context, opts ...repo.Options) ([]*entity.Member, error) {
+	q := r.db.WithContext(ctx).Model(&entity.Member{})
+	var m []*entity.Member
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return m, q.Find(&m).Error
+}
+
+func (r *repository) CreateMember(ctx context.Context, obj entity.Member) (*entity.Member, error) {
+	q := r.db.WithContext(ctx)
+
+	return &obj, q.Create(&obj).Error
+}
+
+func (r *repository) UpdateMember(ctx context.Context, id uint, obj entity.Member, opts ...repo.Options) (*entity.Member, error) {
+	q := r.db.WithContext(ctx)
+	m := entity.Member{ID: id}
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &m, q.Model(&m).Updates(obj).Error
+}
+
+func (r *repository) DeleteMember(ctx context.Context, obj entity.Member) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&entity.Member{ID: obj.ID}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.Revocation{UserID: obj.UserID, OrgID: &obj.OrgID}).Error
+	})
+}
+
+func (r *repository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var usr entity.User
+	return &usr, r.db.WithContext(ctx).Select("id", "username").Where("username = ?", username).First(&usr).Error
+}
@@ -0,0 +1,69 @@
+package org
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// RequestOrg standard request object that may be used in org domain.
+type RequestOrg struct {
+	// ID unique identifier of each Organization. Should be required when
+	// deleting.
+	ID uint `json:"id"`
+	// Name the name of organization. Should be unique.
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+// Validate apply validation rules for RequestOrg.
+func (r *RequestOrg) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateDelete apply validation rules for RequestOrg in delete endpoint.
+func (r *RequestOrg) ValidateDelete() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.deleteRequiredValidation, RequestOrg{})
+	if err := v.StructExcept(r, "Name"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// deleteRequiredValidation custom required fields validation in delete
+// endpoint.
+func (r *RequestOrg) deleteRequiredValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestOrg)
+
+	// required for field ID
+	if req.ID < 1 {
+		sl.ReportError(req.ID, "id", "ID", "required", "ID")
+	}
+}
+
+// RequestMember request object to manage the members of an organization.
+type RequestMember struct {
+	// OrgID the id of the organization.
+	OrgID uint `json:"org_id" query:"org_id" validate:"required"`
+	// Username the username of the member.
+	Username string `json:"username" query:"-" validate:"required"`
+	// Role either owner, admin, editor or viewer.
+	Role string `json:"role" query:"-" validate:"required,oneof=owner admin editor viewer"`
+}
+
+// Validate apply validation rules for RequestMember.
+func (r *RequestMember) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateDelete apply validation rules for RequestMember in delete endpoint.
+func (r *RequestMember) ValidateDelete() validator.ValidationErrors {
+	if err := validator.New().StructExcept(r, "Role"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
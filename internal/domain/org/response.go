@@ -0,0 +1,47 @@
+package org
+
+import (
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+)
+
+// ResponseOrg response object for an organization that the caller is a member
+// of.
+type ResponseOrg struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	// Role the role of the caller in this organization.
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewResponseOrgFromEntity transform given entity.Member to ResponseOrg. The
+// Org should be loaded.
+func NewResponseOrgFromEntity(m entity.Member) *ResponseOrg {
+	r := &ResponseOrg{ID: m.OrgID, Role: m.Role}
+	if m.Org != nil {
+		r.Name = m.Org.Name
+		r.CreatedAt = m.Org.CreatedAt
+	}
+	return r
+}
+
+// ResponseMember response object for a member of an organization.
+type ResponseMember struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	// JoinedAt when the user became the member.
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// NewResponseMemberFromEntity transform given entity.Member to
+// ResponseMember. The User should be loaded to fill the username.
+func NewResponseMemberFromEntity(m entity.Member) *ResponseMember {
+	r := &ResponseMember{ID: m.ID, Role: m.Role, JoinedAt: m.CreatedAt}
+	if m.User != nil {
+		r.Username = m.User.Username
+	}
+	return r
+}
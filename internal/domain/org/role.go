@@ -0,0 +1,24 @@
+package org
+
+import "github.com/mdanialr/pwman_backend/internal/entity"
+
+// roleRanks the rank of each member role, ordered from the least to the most
+// allowed.
+var roleRanks = map[string]int{
+	entity.RoleViewer: 1,
+	entity.RoleEditor: 2,
+	entity.RoleAdmin:  3,
+	entity.RoleOwner:  4,
+}
+
+// RoleRank return the rank of given member role. Higher rank is allowed to do
+// anything the lower ones do. Return zero if it's unknown.
+func RoleRank(role string) int {
+	return roleRanks[role]
+}
+
+// AtLeast whether given role is allowed to do anything that the least role
+// does.
+func AtLeast(role, least string) bool {
+	return RoleRank(role) > 0 && RoleRank(role) >= RoleRank(least)
+}
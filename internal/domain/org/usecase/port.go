@@ -0,0 +1,33 @@
+package org
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/org"
+)
+
+// UseCase signature that's used in org domain for use case layer.
+type UseCase interface {
+	// IndexOrg retrieve all organizations that the caller is a member of
+	// along with the role of the caller in each of them.
+	IndexOrg(ctx context.Context) ([]*org.ResponseOrg, error)
+	// SaveOrg create new organization from given request with the caller as
+	// its owner. The name should be unique.
+	SaveOrg(ctx context.Context, req org.RequestOrg) (*org.ResponseOrg, error)
+	// DeleteOrg delete existing organization that match given id along with
+	// all of its members. Only the owner may delete it and it should not
+	// have any category anymore.
+	DeleteOrg(ctx context.Context, id uint) error
+	// IndexMember retrieve all members of the organization that match given
+	// id. The caller should be one of them.
+	IndexMember(ctx context.Context, orgID uint) ([]*org.ResponseMember, error)
+	// SaveMember add the user in given request as a member of the
+	// organization, or replace the role if it's already a member. The
+	// caller should be at least an admin and can not grant a role that's
+	// higher than their own.
+	SaveMember(ctx context.Context, req org.RequestMember) (*org.ResponseMember, error)
+	// DeleteMember remove the user in given request from the organization.
+	// Any member may leave, otherwise the caller should be at least an admin
+	// whose role is not lower than the removed member.
+	DeleteMember(ctx context.Context, req org.RequestMember) error
+}
@@ -0,0 +1,210 @@
+package org
+
+import (
+	"context"
+	"strconv"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/org"
+	orgRepo "github.com/mdanialr/pwman_backend/internal/domain/org/repository"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// NewUseCase return concrete implementation of UseCase in org domain.
+func NewUseCase(conf *viper.Viper, log *zap.Logger, repo orgRepo.Repository) UseCase {
+	return &useCase{conf: conf, log: log, repo: repo}
+}
+
+type useCase struct {
+	conf *viper.Viper
+	log  *zap.Logger
+	repo orgRepo.Repository
+}
+
+func (u *useCase) IndexOrg(ctx context.Context) ([]*org.ResponseOrg, error) {
+	uid := identity.FromContext(ctx).ID
+	mems, err := u.repo.FindMembers(ctx, repo.Where("user_id = ?", uid), repo.Order("org_id ASC"), repo.EagerLoad("Org"))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve memberships:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	res := make([]*org.ResponseOrg, 0, len(mems))
+	for _, m := range mems {
+		res = append(res, org.NewResponseOrgFromEntity(*m))
+	}
+	return res, nil
+}
+
+func (u *useCase) SaveOrg(ctx context.Context, req org.RequestOrg) (*org.ResponseOrg, error) {
+	// make sure given name is not taken yet
+	o, _ := u.repo.GetOrgByName(ctx, req.Name)
+	if o.ID != 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
+	}
+
+	newObj, err := u.repo.CreateOrg(ctx, entity.Organization{Name: req.Name}, identity.FromContext(ctx).ID)
+	if err != nil {
+		u.log.Error(help.Pad("failed to create new organization:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return org.NewResponseOrgFromEntity(entity.Member{OrgID: newObj.ID, Org: newObj, Role: entity.RoleOwner}), nil
+}
+
+func (u *useCase) DeleteOrg(ctx context.Context, id uint) error {
+	// only the owner may delete it
+	caller, err := u.member(ctx, id, identity.FromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+	if caller.Role != entity.RoleOwner {
+		return stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+	}
+
+	// make sure no Category still belong to this organization
+	n, err := u.repo.CountCategories(ctx, id)
+	if err != nil {
+		u.log.Error(help.Pad("failed to count categories of organization with id:", strconv.Itoa(int(id)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if n > 0 {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrDataInUse)
+	}
+
+	if err = u.repo.DeleteOrg(ctx, id); err != nil {
+		u.log.Error(help.Pad("failed to delete existing organization with id:", strconv.Itoa(int(id)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return nil
+}
+
+func (u *useCase) IndexMember(ctx context.Context, orgID uint) ([]*org.ResponseMember, error) {
+	// only the members may see each other
+	if _, err := u.member(ctx, orgID, identity.FromContext(ctx).ID); err != nil {
+		return nil, err
+	}
+
+	mems, err := u.repo.FindMembers(ctx, repo.Where("org_id = ?", orgID), repo.Order("id ASC"), repo.EagerLoad("User"))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve members:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	res := make([]*org.ResponseMember, 0, len(mems))
+	for _, m := range mems {
+		res = append(res, org.NewResponseMemberFromEntity(*m))
+	}
+	return res, nil
+}
+
+func (u *useCase) SaveMember(ctx context.Context, req org.RequestMember) (*org.ResponseMember, error) {
+	// the caller should be at least an admin and can not grant higher role
+	// than their own
+	caller, err := u.member(ctx, req.OrgID, identity.FromContext(ctx).ID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.AtLeast(caller.Role, entity.RoleAdmin) || !org.AtLeast(caller.Role, req.Role) {
+		return nil, stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+	}
+
+	// make sure the user does really exist in repo
+	usr, err := u.repo.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+
+	// replace the role if it's already a member
+	m, _ := u.repo.GetMember(ctx, req.OrgID, usr.ID)
+	if m.ID == 0 {
+		m, err = u.repo.CreateMember(ctx, entity.Member{OrgID: req.OrgID, UserID: usr.ID, Role: req.Role})
+	} else {
+		// the caller can not change the role of those that's higher
+		if !org.AtLeast(caller.Role, m.Role) {
+			return nil, stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+		}
+		if m.Role == entity.RoleOwner && req.Role != entity.RoleOwner {
+			if err = u.keepOwner(ctx, req.OrgID); err != nil {
+				return nil, err
+			}
+		}
+		_, err = u.repo.UpdateMember(ctx, m.ID, entity.Member{Role: req.Role}, repo.Cols("role"))
+		m.Role = req.Role
+	}
+	if err != nil {
+		u.log.Error(help.Pad("failed to save member:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	m.User = usr
+
+	return org.NewResponseMemberFromEntity(*m), nil
+}
+
+func (u *useCase) DeleteMember(ctx context.Context, req org.RequestMember) error {
+	uid := identity.FromContext(ctx).ID
+	caller, err := u.member(ctx, req.OrgID, uid)
+	if err != nil {
+		return err
+	}
+
+	// make sure the user does really exist in repo and a member too
+	usr, err := u.repo.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	m := caller
+	if usr.ID != uid {
+		if m, err = u.member(ctx, req.OrgID, usr.ID); err != nil {
+			return err
+		}
+		// only admin may remove other members that's not higher than them
+		if !org.AtLeast(caller.Role, entity.RoleAdmin) || !org.AtLeast(caller.Role, m.Role) {
+			return stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+		}
+	}
+	if m.Role == entity.RoleOwner {
+		if err = u.keepOwner(ctx, req.OrgID); err != nil {
+			return err
+		}
+	}
+
+	if err = u.repo.DeleteMember(ctx, *m); err != nil {
+		u.log.Error(help.Pad("failed to delete existing member with id:", strconv.Itoa(int(m.ID)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return nil
+}
+
+// member retrieve the membership of given user id in given organization id.
+// Return not found if the user is not a member.
+func (u *useCase) member(ctx context.Context, orgID, userID uint) (*entity.Member, error) {
+	m, err := u.repo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	return m, nil
+}
+
+// keepOwner make sure there is another owner in given organization id, so one
+// of the owners may step down or leave.
+func (u *useCase) keepOwner(ctx context.Context, orgID uint) error {
+	owners, err := u.repo.FindMembers(ctx, repo.Cols("id"), repo.Where("org_id = ? AND role = ?", orgID, entity.RoleOwner), repo.Limit(2))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve owners:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(owners) < 2 {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrLastOwner)
+	}
+	return nil
+}
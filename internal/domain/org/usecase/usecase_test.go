@@ -0,0 +1,279 @@
+package org_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/org"
+	"github.com/mdanialr/pwman_backend/internal/domain/org/repository/mocks"
+	orgUC "github.com/mdanialr/pwman_backend/internal/domain/org/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseCase_SaveMember(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockorgRepository)
+		sample     org.RequestMember
+		expect     *org.ResponseMember
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given caller that's not a member should return UC instance, INVALID_PAYLOAD as code and " +
+				"data not found as message",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{}, errors.New("record not found")).
+					Once()
+			},
+			sample:     org.RequestMember{OrgID: 3, Username: "doe", Role: entity.RoleViewer},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given caller that's only an editor should return UC instance, FORBIDDEN as code and " +
+				"not allowed to do this as message",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleEditor}, nil).
+					Once()
+			},
+			sample:     org.RequestMember{OrgID: 3, Username: "doe", Role: entity.RoleViewer},
+			expectCode: "FORBIDDEN",
+			expectMsg:  "not allowed to do this",
+			wantErr:    true,
+		},
+		{
+			name: "Given admin that grant the owner role should return UC instance, FORBIDDEN as code and " +
+				"not allowed to do this as message",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleAdmin}, nil).
+					Once()
+			},
+			sample:     org.RequestMember{OrgID: 3, Username: "doe", Role: entity.RoleOwner},
+			expectCode: "FORBIDDEN",
+			expectMsg:  "not allowed to do this",
+			wantErr:    true,
+		},
+		{
+			name: "Given user that's not a member yet should add it as a new member",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleAdmin}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "doe").
+					Return(&entity.User{ID: 2, Username: "doe"}, nil).
+					Once()
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), uint(2)).
+					Return(&entity.Member{}, errors.New("record not found")).
+					Once()
+				repo.EXPECT().
+					CreateMember(mock.Anything, entity.Member{OrgID: 3, UserID: 2, Role: entity.RoleEditor}).
+					Return(&entity.Member{ID: 4, OrgID: 3, UserID: 2, Role: entity.RoleEditor}, nil).
+					Once()
+			},
+			sample: org.RequestMember{OrgID: 3, Username: "doe", Role: entity.RoleEditor},
+			expect: &org.ResponseMember{ID: 4, Username: "doe", Role: entity.RoleEditor},
+		},
+		{
+			name: "Given the only owner that's demoted should return UC instance, INVALID_PAYLOAD as code and " +
+				"organization should have at least one owner as message",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleOwner}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "john").
+					Return(&entity.User{ID: caller, Username: "john"}, nil).
+					Once()
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleOwner}, nil).
+					Once()
+				repo.EXPECT().
+					FindMembers(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Member{{ID: 1}}, nil).
+					Once()
+			},
+			sample:     org.RequestMember{OrgID: 3, Username: "john", Role: entity.RoleAdmin},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "organization should have at least one owner",
+			wantErr:    true,
+		},
+		{
+			name: "Given existing member should replace the role",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleOwner}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "doe").
+					Return(&entity.User{ID: 2, Username: "doe"}, nil).
+					Once()
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), uint(2)).
+					Return(&entity.Member{ID: 4, OrgID: 3, UserID: 2, Role: entity.RoleViewer}, nil).
+					Once()
+				repo.EXPECT().
+					UpdateMember(mock.Anything, uint(4), entity.Member{Role: entity.RoleAdmin}, mock.Anything).
+					Return(&entity.Member{}, nil).
+					Once()
+			},
+			sample: org.RequestMember{OrgID: 3, Username: "doe", Role: entity.RoleAdmin},
+			expect: &org.ResponseMember{ID: 4, Username: "doe", Role: entity.RoleAdmin},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := orgUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+			res, err := newUC.SaveMember(callerCtx(), tc.sample)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, res)
+		})
+	}
+}
+
+func TestUseCase_DeleteMember(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockorgRepository)
+		sample     org.RequestMember
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given admin that remove an owner should return UC instance, FORBIDDEN as code and " +
+				"not allowed to do this as message",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleAdmin}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "doe").
+					Return(&entity.User{ID: 2, Username: "doe"}, nil).
+					Once()
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), uint(2)).
+					Return(&entity.Member{ID: 4, Role: entity.RoleOwner}, nil).
+					Once()
+			},
+			sample:     org.RequestMember{OrgID: 3, Username: "doe"},
+			expectCode: "FORBIDDEN",
+			expectMsg:  "not allowed to do this",
+			wantErr:    true,
+		},
+		{
+			name: "Given the only owner that leave should return UC instance, INVALID_PAYLOAD as code and " +
+				"organization should have at least one owner as message",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, Role: entity.RoleOwner}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "john").
+					Return(&entity.User{ID: caller, Username: "john"}, nil).
+					Once()
+				repo.EXPECT().
+					FindMembers(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Member{{ID: 1}}, nil).
+					Once()
+			},
+			sample:     org.RequestMember{OrgID: 3, Username: "john"},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "organization should have at least one owner",
+			wantErr:    true,
+		},
+		{
+			name: "Given viewer that leave should remove the membership",
+			setup: func(repo *mocks.MockorgRepository) {
+				repo.EXPECT().
+					GetMember(mock.Anything, uint(3), caller).
+					Return(&entity.Member{ID: 1, OrgID: 3, UserID: caller, Role: entity.RoleViewer}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "john").
+					Return(&entity.User{ID: caller, Username: "john"}, nil).
+					Once()
+				repo.EXPECT().
+					DeleteMember(mock.Anything, entity.Member{ID: 1, OrgID: 3, UserID: caller, Role: entity.RoleViewer}).
+					Return(nil).
+					Once()
+			},
+			sample: org.RequestMember{OrgID: 3, Username: "john"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := orgUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+			err := newUC.DeleteMember(callerCtx(), tc.sample)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			h.Dep.repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUseCase_DeleteOrg(t *testing.T) {
+	t.Run("Given organization that still has categories should return UC instance, INVALID_PAYLOAD as "+
+		"code and data still in use as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetMember(mock.Anything, uint(3), caller).
+			Return(&entity.Member{ID: 1, Role: entity.RoleOwner}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			CountCategories(mock.Anything, uint(3)).
+			Return(2, nil).
+			Once()
+
+		newUC := orgUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.repo)
+		err := newUC.DeleteOrg(callerCtx(), 3)
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "data still in use", err.(*stderr.UC).Msg)
+	})
+}
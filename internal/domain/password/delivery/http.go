@@ -1,9 +1,12 @@
 package delivery
 
 import (
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
 	pw "github.com/mdanialr/pwman_backend/internal/domain/password"
 	pwUC "github.com/mdanialr/pwman_backend/internal/domain/password/usecase"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
 	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	paginate "github.com/mdanialr/pwman_backend/pkg/pagination"
 	resp "github.com/mdanialr/pwman_backend/pkg/response"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,23 +15,94 @@ import (
 
 // NewDelivery setup endpoints in domain password as delivery layer.
 func NewDelivery(app fiber.Router, conf *viper.Viper, uc pwUC.UseCase) {
-	d := &delivery{uc: uc}
+	d := &delivery{conf: conf, uc: uc}
 
 	apiCat := app.Group("/category", md.JWT(conf))
 	apiCat.Get("/", d.IndexCategory)
+	apiCat.Get("/tree", d.TreeCategory)
+	apiCat.Post("/move", d.MoveCategory)
 	apiCat.Post("/create", d.CreateCategory)
 	apiCat.Post("/update", d.UpdateCategory)
 	apiCat.Post("/delete", d.DeleteCategory)
 
+	apiTag := app.Group("/tag", md.JWT(conf))
+	apiTag.Get("/", d.IndexTag)
+	apiTag.Post("/create", d.CreateTag)
+	apiTag.Post("/update", d.UpdateTag)
+	apiTag.Post("/delete", d.DeleteTag)
+
 	api := app.Group("/password", md.JWT(conf))
 	api.Get("/", d.Index)
 	api.Post("/create", d.Create)
 	api.Post("/update", d.Update)
 	api.Post("/delete", d.Delete)
+	api.Post("/reveal", d.Reveal)
+	api.Post("/import", d.Import)
+	api.Post("/:id/share-link", d.CreateShareLink)
+	api.Get("/breach/scan", d.ScanBreachStatus)
+	api.Post("/breach/scan", d.ScanBreach)
+
+	apiZK := app.Group("/zk", md.JWT(conf))
+	apiZK.Get("/key", d.GetVaultKey)
+	apiZK.Post("/key", d.SaveVaultKey)
+	apiZK.Get("/blob", d.IndexBlob)
+	apiZK.Post("/blob/create", d.CreateBlob)
+	apiZK.Post("/blob/update", d.UpdateBlob)
+	apiZK.Post("/blob/delete", d.DeleteBlob)
+
+	apiShare := app.Group("/share", md.JWT(conf))
+	apiShare.Get("/", d.IndexShare)
+	apiShare.Post("/create", d.CreateShare)
+	apiShare.Post("/delete", d.DeleteShare)
 }
 
+// NewPublicDelivery setup endpoints in domain password that's open to
+// anyone without an account.
+func NewPublicDelivery(app fiber.Router, uc pwUC.UseCase) {
+	d := &delivery{uc: uc}
+
+	app.Get("/s/:id", d.OpenShareLink)
+}
+
+// invalidIfMatch error message when the If-Match header is malformed.
+const invalidIfMatch = "If-Match header should be the ETag of the data"
+
 type delivery struct {
-	uc pwUC.UseCase
+	conf *viper.Viper
+	uc   pwUC.UseCase
+}
+
+// ifMatch replace given revision with the one in If-Match header if any.
+// Return false if the header is malformed.
+func ifMatch(c *fiber.Ctx, rev *uint) bool {
+	r, ok := pw.ParseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if ok && r != 0 {
+		*rev = r
+	}
+	return ok
+}
+
+// errResponse return the error response of given err. Stale revision is
+// returned as 409 Conflict instead of 400 Bad Request, along with the current
+// copy in the detail and its ETag. Lack of permission is returned as 403
+// Forbidden.
+func errResponse(c *fiber.Ctx, err error) error {
+	e, ok := err.(*stderr.UC)
+	if ok && e.Code == cons.Forbidden {
+		return resp.ErrorCode(c, fiber.StatusForbidden, resp.WithErr(err))
+	}
+	if ok && e.Code == cons.Conflict {
+		switch cur := e.Detail.(type) {
+		case *pw.Response:
+			c.Set(fiber.HeaderETag, pw.ETag(cur.Revision))
+		case *pw.ResponseCategory:
+			c.Set(fiber.HeaderETag, pw.ETag(cur.Revision))
+		case *pw.ResponseBlob:
+			c.Set(fiber.HeaderETag, pw.ETag(cur.Revision))
+		}
+		return resp.ErrorCode(c, fiber.StatusConflict, resp.WithErr(err))
+	}
+	return resp.Error(c, resp.WithErr(err))
 }
 
 func (d *delivery) Index(c *fiber.Ctx) error {
@@ -37,6 +111,14 @@ func (d *delivery) Index(c *fiber.Ctx) error {
 	// set up the query order and sort
 	req.SetQuery()
 
+	// validate the filters, the page size and the cursor
+	if err := req.ValidateFilter(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+	if err := req.ValidatePagination(paginate.NewLimits(d.conf, "password")); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
 	res, err := d.uc.IndexPassword(c.Context(), req)
 	if err != nil {
 		return resp.Error(c, resp.WithErr(err))
@@ -53,12 +135,17 @@ func (d *delivery) Create(c *fiber.Ctx) error {
 	if err := req.Validate(); err != nil {
 		return resp.Error(c, resp.WithErrValidation(err))
 	}
+	// enforce the password strength policy if any
+	if err := req.ValidateStrength(d.conf.GetInt("policy.min_score")); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
 
 	res, err := d.uc.SavePassword(c.Context(), req)
 	if err != nil {
-		return resp.Error(c, resp.WithErr(err))
+		return errResponse(c, err)
 	}
 
+	c.Set(fiber.HeaderETag, pw.ETag(res.Revision))
 	return resp.Success(c, resp.WithData(res))
 }
 
@@ -70,11 +157,20 @@ func (d *delivery) Update(c *fiber.Ctx) error {
 	if err := req.ValidateUpdate(); err != nil {
 		return resp.Error(c, resp.WithErrValidation(err))
 	}
+	// enforce the password strength policy if any
+	if err := req.ValidateStrength(d.conf.GetInt("policy.min_score")); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+	if !ifMatch(c, &req.Revision) {
+		return resp.Error(c, resp.WithErrCode(cons.InvalidPayload), resp.WithErrMsg(invalidIfMatch))
+	}
 
-	if err := d.uc.UpdatePassword(c.Context(), req.ID, req); err != nil {
-		return resp.Error(c, resp.WithErr(err))
+	rev, err := d.uc.UpdatePassword(c.Context(), req.ID, req)
+	if err != nil {
+		return errResponse(c, err)
 	}
 
+	c.Set(fiber.HeaderETag, pw.ETag(rev))
 	return resp.Success(c, resp.WithMsg("updated successfully"))
 }
 
@@ -86,8 +182,131 @@ func (d *delivery) Delete(c *fiber.Ctx) error {
 	if err := req.ValidateDelete(); err != nil {
 		return resp.Error(c, resp.WithErrValidation(err))
 	}
+	if !ifMatch(c, &req.Revision) {
+		return resp.Error(c, resp.WithErrCode(cons.InvalidPayload), resp.WithErrMsg(invalidIfMatch))
+	}
+
+	if err := d.uc.DeletePassword(c.Context(), req.ID, req.Revision); err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithMsg("deleted successfully"))
+}
 
-	if err := d.uc.DeletePassword(c.Context(), req.ID); err != nil {
+func (d *delivery) Reveal(c *fiber.Ctx) error {
+	var req pw.Request
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateDelete(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.RevealPassword(c.Context(), req.ID)
+	if err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) Import(c *fiber.Ctx) error {
+	var req pw.RequestImport
+	c.BodyParser(&req)
+	// manually retrieve binary file
+	req.File, _ = c.FormFile("file")
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.ImportPassword(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) ScanBreach(c *fiber.Ctx) error {
+	if err := d.uc.ScanBreach(c.Context()); err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithMsg("scan started"))
+}
+
+func (d *delivery) ScanBreachStatus(c *fiber.Ctx) error {
+	return resp.Success(c, resp.WithData(d.uc.ScanBreachStatus(c.Context())))
+}
+
+func (d *delivery) IndexTag(c *fiber.Ctx) error {
+	var req pw.RequestTag
+	c.QueryParser(&req)
+	// set up the query order and sort
+	req.SetQuery()
+
+	// validate the page size and the cursor
+	if err := req.ValidatePagination(paginate.NewLimits(d.conf, "tag")); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.IndexTag(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res.Data), resp.WithMeta(res.Pagination))
+}
+
+func (d *delivery) CreateTag(c *fiber.Ctx) error {
+	var req pw.RequestTag
+	c.BodyParser(&req)
+	// normalize name field
+	req.NormalizeName()
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveTag(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) UpdateTag(c *fiber.Ctx) error {
+	var req pw.RequestTag
+	c.BodyParser(&req)
+	// normalize name field
+	req.NormalizeName()
+
+	// validate the request
+	if err := req.ValidateUpdate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.UpdateTag(c.Context(), req.ID, req); err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithMsg("updated successfully"))
+}
+
+func (d *delivery) DeleteTag(c *fiber.Ctx) error {
+	var req pw.RequestTag
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateDelete(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.DeleteTag(c.Context(), req.ID); err != nil {
 		return resp.Error(c, resp.WithErr(err))
 	}
 
@@ -100,6 +319,11 @@ func (d *delivery) IndexCategory(c *fiber.Ctx) error {
 	// set up the query order and sort
 	req.SetQuery()
 
+	// validate the page size and the cursor
+	if err := req.ValidatePagination(paginate.NewLimits(d.conf, "category")); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
 	res, err := d.uc.IndexCategory(c.Context(), req)
 	if err != nil {
 		return resp.Error(c, resp.WithErr(err))
@@ -108,6 +332,31 @@ func (d *delivery) IndexCategory(c *fiber.Ctx) error {
 	return resp.Success(c, resp.WithData(res.Data), resp.WithMeta(res.Pagination))
 }
 
+func (d *delivery) TreeCategory(c *fiber.Ctx) error {
+	res, err := d.uc.TreeCategory(c.Context(), uint(c.QueryInt("org_id")))
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) MoveCategory(c *fiber.Ctx) error {
+	var req pw.RequestCategory
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateMove(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.MoveCategory(c.Context(), req.ID, req.ParentID); err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithMsg("moved successfully"))
+}
+
 func (d *delivery) CreateCategory(c *fiber.Ctx) error {
 	var req pw.RequestCategory
 	c.BodyParser(&req)
@@ -124,9 +373,10 @@ func (d *delivery) CreateCategory(c *fiber.Ctx) error {
 
 	res, err := d.uc.SaveCategory(c.Context(), req)
 	if err != nil {
-		return resp.Error(c, resp.WithErr(err))
+		return errResponse(c, err)
 	}
 
+	c.Set(fiber.HeaderETag, pw.ETag(res.Revision))
 	return resp.Success(c, resp.WithData(res))
 }
 
@@ -143,11 +393,16 @@ func (d *delivery) UpdateCategory(c *fiber.Ctx) error {
 	}
 	// normalize name field
 	req.NormalizeName()
+	if !ifMatch(c, &req.Revision) {
+		return resp.Error(c, resp.WithErrCode(cons.InvalidPayload), resp.WithErrMsg(invalidIfMatch))
+	}
 
-	if err := d.uc.UpdateCategory(c.Context(), req.ID, req); err != nil {
-		return resp.Error(c, resp.WithErr(err))
+	rev, err := d.uc.UpdateCategory(c.Context(), req.ID, req)
+	if err != nil {
+		return errResponse(c, err)
 	}
 
+	c.Set(fiber.HeaderETag, pw.ETag(rev))
 	return resp.Success(c, resp.WithMsg("updated successfully"))
 }
 
@@ -160,9 +415,196 @@ func (d *delivery) DeleteCategory(c *fiber.Ctx) error {
 		return resp.Error(c, resp.WithErrValidation(err))
 	}
 
-	if err := d.uc.DeleteCategory(c.Context(), req.ID); err != nil {
+	if !ifMatch(c, &req.Revision) {
+		return resp.Error(c, resp.WithErrCode(cons.InvalidPayload), resp.WithErrMsg(invalidIfMatch))
+	}
+
+	if err := d.uc.DeleteCategory(c.Context(), req.ID, req.Revision); err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithMsg("deleted successfully"))
+}
+
+func (d *delivery) IndexShare(c *fiber.Ctx) error {
+	var req pw.RequestShare
+	c.QueryParser(&req)
+
+	res, err := d.uc.IndexShare(c.Context(), req)
+	if err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) CreateShare(c *fiber.Ctx) error {
+	var req pw.RequestShare
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveShare(c.Context(), req)
+	if err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) DeleteShare(c *fiber.Ctx) error {
+	var req pw.RequestShare
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateDelete(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	if err := d.uc.DeleteShare(c.Context(), req.ID); err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithMsg("revoked successfully"))
+}
+
+func (d *delivery) CreateShareLink(c *fiber.Ctx) error {
+	var req pw.RequestShareLink
+	c.BodyParser(&req)
+	id, _ := c.ParamsInt("id")
+	req.ID = uint(id)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveShareLink(c.Context(), req)
+	if err != nil {
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) OpenShareLink(c *fiber.Ctx) error {
+	// the secret should never be kept by any cache along the way
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	res, err := d.uc.OpenShareLink(c.Context(), c.Params("id"))
+	if err != nil {
+		if e, ok := err.(*stderr.UC); ok && e.Code == cons.InvalidPayload {
+			return resp.ErrorCode(c, fiber.StatusNotFound, resp.WithErr(err))
+		}
+		return errResponse(c, err)
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) GetVaultKey(c *fiber.Ctx) error {
+	res, err := d.uc.GetVaultKey(c.Context())
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) SaveVaultKey(c *fiber.Ctx) error {
+	var req pw.RequestVaultKey
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveVaultKey(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) IndexBlob(c *fiber.Ctx) error {
+	var req pw.RequestBlob
+	c.QueryParser(&req)
+	// set up the query order and sort
+	req.SetQuery()
+	req.SanitizeOrder()
+
+	// validate the page size and the cursor
+	if err := req.ValidatePagination(paginate.NewLimits(d.conf, "blob")); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.IndexBlob(c.Context(), req)
+	if err != nil {
 		return resp.Error(c, resp.WithErr(err))
 	}
 
+	return resp.Success(c, resp.WithData(res.Data), resp.WithMeta(res.Pagination))
+}
+
+func (d *delivery) CreateBlob(c *fiber.Ctx) error {
+	var req pw.RequestBlob
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.SaveBlob(c.Context(), req)
+	if err != nil {
+		return errResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, pw.ETag(res.Revision))
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) UpdateBlob(c *fiber.Ctx) error {
+	var req pw.RequestBlob
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateUpdate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+	if !ifMatch(c, &req.Revision) {
+		return resp.Error(c, resp.WithErrCode(cons.InvalidPayload), resp.WithErrMsg(invalidIfMatch))
+	}
+
+	rev, err := d.uc.UpdateBlob(c.Context(), req.ID, req)
+	if err != nil {
+		return errResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, pw.ETag(rev))
+	return resp.Success(c, resp.WithMsg("updated successfully"))
+}
+
+func (d *delivery) DeleteBlob(c *fiber.Ctx) error {
+	var req pw.RequestBlob
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.ValidateDelete(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+	if !ifMatch(c, &req.Revision) {
+		return resp.Error(c, resp.WithErrCode(cons.InvalidPayload), resp.WithErrMsg(invalidIfMatch))
+	}
+
+	if err := d.uc.DeleteBlob(c.Context(), req.ID, req.Revision); err != nil {
+		return errResponse(c, err)
+	}
+
 	return resp.Success(c, resp.WithMsg("deleted successfully"))
 }
@@ -0,0 +1,29 @@
+package password
+
+// Type of the events that's published whenever passwords, categories or tags
+// are changed.
+const (
+	EventPasswordCreated  = "password.created"
+	EventPasswordUpdated  = "password.updated"
+	EventPasswordDeleted  = "password.deleted"
+	EventPasswordImported = "password.imported"
+	EventCategoryCreated  = "category.created"
+	EventCategoryUpdated  = "category.updated"
+	EventCategoryDeleted  = "category.deleted"
+	EventTagCreated       = "tag.created"
+	EventTagUpdated       = "tag.updated"
+	EventTagDeleted       = "tag.deleted"
+)
+
+// EventData the payload of the events. Only hold the id and the revision, so
+// no secret is pushed. Clients should retrieve the changes through the sync
+// instead.
+type EventData struct {
+	ID       uint `json:"id,omitempty"`
+	Revision uint `json:"revision,omitempty"`
+	// Owner the id of the User who own the changed data, which is used to
+	// find out who may receive the event. Zero if it's in an organization.
+	Owner uint `json:"-"`
+	// Org the id of the Organization that own the changed data if any.
+	Org uint `json:"-"`
+}
@@ -0,0 +1,36 @@
+package password
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+)
+
+// EachPassword iterate all passwords in given r that match given opts in
+// batches of given size ordered by the id, then call given fn for each batch.
+// Stop the iteration as soon as fn return an error.
+func EachPassword(ctx context.Context, r Repository, size int, fn func([]*entity.Password) error, opts ...repo.Options) error {
+	var lastID uint
+	for {
+		pws, err := r.FindPassword(ctx, append([]repo.Options{
+			repo.Cons("id > " + strconv.Itoa(int(lastID))),
+			repo.Order("id ASC"),
+			repo.Limit(size),
+		}, opts...)...)
+		if err != nil {
+			return err
+		}
+		if len(pws) == 0 {
+			return nil
+		}
+		if err = fn(pws); err != nil {
+			return err
+		}
+		if len(pws) < size {
+			return nil
+		}
+		lastID = pws[len(pws)-1].ID
+	}
+}
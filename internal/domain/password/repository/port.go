@@ -18,10 +18,13 @@ type Repository interface {
 	// object along with assigned id as primary key.
 	CreatePassword(ctx context.Context, obj entity.Password) (*entity.Password, error)
 	// UpdatePassword update existing entity.Password that match given id and
-	// return the updated object.
+	// return the updated object. Return repo.ErrNotAffected if there is no
+	// row that match given id and the condition in opts.
 	UpdatePassword(ctx context.Context, id uint, obj entity.Password, opts ...repo.Options) (*entity.Password, error)
-	// DeletePassword soft delete entity.Password that match given id.
-	DeletePassword(ctx context.Context, id uint) error
+	// DeletePassword soft delete entity.Password that match given id. Return
+	// repo.ErrNotAffected if there is no row that match given id and the
+	// condition in opts.
+	DeletePassword(ctx context.Context, id uint, opts ...repo.Options) error
 	// GetCategoryByID retrieve an entity.Category by given id.
 	GetCategoryByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Category, error)
 	// FindCategories retrieve all entity.Category that match given condition
@@ -31,8 +34,90 @@ type Repository interface {
 	// object along with assigned id as primary key.
 	CreateCategory(ctx context.Context, obj entity.Category) (*entity.Category, error)
 	// UpdateCategory update existing entity.Category that match given id and
-	// return the updated object.
+	// return the updated object. Return repo.ErrNotAffected if there is no
+	// row that match given id and the condition in opts.
 	UpdateCategory(ctx context.Context, id uint, obj entity.Category, opts ...repo.Options) (*entity.Category, error)
-	// DeleteCategory soft delete entity.Category that match given id.
-	DeleteCategory(ctx context.Context, id uint) error
+	// DeleteCategory soft delete entity.Category that match given id. Return
+	// repo.ErrNotAffected if there is no row that match given id and the
+	// condition in opts.
+	DeleteCategory(ctx context.Context, id uint, opts ...repo.Options) error
+	// GetTagByID retrieve an entity.Tag by given id.
+	GetTagByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Tag, error)
+	// FindTags retrieve all entity.Tag that match given condition in opts.
+	FindTags(ctx context.Context, opts ...repo.Options) ([]*entity.Tag, error)
+	// CreateTag create new entity.Tag and return the newly created object
+	// along with assigned id as primary key.
+	CreateTag(ctx context.Context, obj entity.Tag) (*entity.Tag, error)
+	// UpdateTag update existing entity.Tag that match given id and return the
+	// updated object.
+	UpdateTag(ctx context.Context, id uint, obj entity.Tag, opts ...repo.Options) (*entity.Tag, error)
+	// DeleteTag soft delete entity.Tag that match given id and detach it from
+	// all passwords.
+	DeleteTag(ctx context.Context, id uint) error
+	// CountTagUsage count the number of passwords that use each of given tag
+	// ids. Count all tags if no id is given.
+	CountTagUsage(ctx context.Context, ids ...uint) (map[uint]int, error)
+	// ReplacePasswordTags replace all tags of entity.Password that match given
+	// id with given tag ids.
+	ReplacePasswordTags(ctx context.Context, id uint, tagIDs []uint) error
+	// GetUserByUsername retrieve an entity.User by given username.
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	// FindUsers retrieve all entity.User that match given condition in opts.
+	FindUsers(ctx context.Context, opts ...repo.Options) ([]*entity.User, error)
+	// GetShareByID retrieve an entity.Share by given id.
+	GetShareByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Share, error)
+	// FindShares retrieve all entity.Share that match given condition in
+	// opts.
+	FindShares(ctx context.Context, opts ...repo.Options) ([]*entity.Share, error)
+	// CreateShare create new entity.Share and return the newly created object
+	// along with assigned id as primary key.
+	CreateShare(ctx context.Context, obj entity.Share) (*entity.Share, error)
+	// UpdateShare update existing entity.Share that match given id and return
+	// the updated object.
+	UpdateShare(ctx context.Context, id uint, obj entity.Share, opts ...repo.Options) (*entity.Share, error)
+	// DeleteShare permanently delete given entity.Share, so the access is
+	// revoked right away, along with recording the lost access of the grantee
+	// in a single transaction.
+	DeleteShare(ctx context.Context, obj entity.Share) error
+	// GetShareLinkByID retrieve an entity.ShareLink by given id.
+	GetShareLinkByID(ctx context.Context, id string, opts ...repo.Options) (*entity.ShareLink, error)
+	// CreateShareLink create new entity.ShareLink and return the newly created
+	// object.
+	CreateShareLink(ctx context.Context, obj entity.ShareLink) (*entity.ShareLink, error)
+	// UpdateShareLink update existing entity.ShareLink that match given id.
+	UpdateShareLink(ctx context.Context, id string, obj entity.ShareLink, opts ...repo.Options) error
+	// DeleteShareLinks permanently delete all entity.ShareLink that match
+	// given condition in opts.
+	DeleteShareLinks(ctx context.Context, opts ...repo.Options) error
+	// GetVaultKey retrieve the entity.VaultKey of the user of given id.
+	GetVaultKey(ctx context.Context, userID uint) (*entity.VaultKey, error)
+	// SaveVaultKey create new entity.VaultKey or replace the one that belong
+	// to the same user.
+	SaveVaultKey(ctx context.Context, obj entity.VaultKey) (*entity.VaultKey, error)
+	// GetBlobByID retrieve an entity.Blob by given id.
+	GetBlobByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Blob, error)
+	// FindBlobs retrieve all entity.Blob that match given condition in opts.
+	FindBlobs(ctx context.Context, opts ...repo.Options) ([]*entity.Blob, error)
+	// CreateBlob create new entity.Blob and return the newly created object
+	// along with assigned id as primary key.
+	CreateBlob(ctx context.Context, obj entity.Blob) (*entity.Blob, error)
+	// UpdateBlob update existing entity.Blob that match given id. Return
+	// repo.ErrNotAffected if nothing match the condition in opts.
+	UpdateBlob(ctx context.Context, id uint, obj entity.Blob, opts ...repo.Options) error
+	// DeleteBlob delete existing entity.Blob that match given id. Return
+	// repo.ErrNotAffected if nothing match the condition in opts.
+	DeleteBlob(ctx context.Context, id uint, opts ...repo.Options) error
+	// FindRevocations retrieve all entity.Revocation that match given
+	// condition in opts.
+	FindRevocations(ctx context.Context, opts ...repo.Options) ([]*entity.Revocation, error)
+	// CreateRevocations create all given entity.Revocation at once. Do
+	// nothing if there is none.
+	CreateRevocations(ctx context.Context, objs []*entity.Revocation) error
+	// FindEmergencyAccesses retrieve all entity.EmergencyAccess that match
+	// given condition in opts.
+	FindEmergencyAccesses(ctx context.Context, opts ...repo.Options) ([]*entity.EmergencyAccess, error)
+	// Transaction run given fn inside database transaction using Repository
+	// that's bound to that transaction. Commit if fn return no error,
+	// otherwise roll back.
+	Transaction(ctx context.Context, fn func(Repository) error) error
 }
@@ -5,18 +5,22 @@ import (
 
 	"github.com/mdanialr/pwman_backend/internal/entity"
 	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	"github.com/mdanialr/pwman_backend/pkg/vault"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // NewRepository return concrete implementation of Repository that use gorm.DB
-// as the data source.
-func NewRepository(db *gorm.DB) Repository {
-	return &repository{db: db}
+// as the data source. The secret of each password is encrypted and decrypted
+// using given vault.Cipher.
+func NewRepository(db *gorm.DB, c vault.Cipher) Repository {
+	return &repository{db: db, cipher: c}
 }
 
 type repository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	cipher vault.Cipher
 }
 
 func (r *repository) GetPasswordByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Password, error) {
@@ -28,7 +32,10 @@ func (r *repository) GetPasswordByID(ctx context.Context, id uint, opts ...repo.
 		q = opt(q)
 	}
 
-	return &p, q.First(&p).Error
+	if err := q.First(&p).Error; err != nil {
+		return &p, err
+	}
+	return &p, r.open(&p)
 }
 
 func (r *repository) FindPassword(ctx context.Context, opts ...repo.Options) ([]*entity.Password, error) {
@@ -40,13 +47,24 @@ func (r *repository) FindPassword(ctx context.Context, opts ...repo.Options) ([]
 		q = opt(q)
 	}
 
-	return p, q.Find(&p).Error
+	if err := q.Find(&p).Error; err != nil {
+		return p, err
+	}
+	return p, r.open(p...)
 }
 
 func (r *repository) CreatePassword(ctx context.Context, obj entity.Password) (*entity.Password, error) {
 	q := r.db.WithContext(ctx)
 
-	return &obj, q.Create(&obj).Error
+	// keep the plain secret in the returned object
+	plain := obj.Password
+	var err error
+	if obj.Password, err = r.cipher.Encrypt(plain); err != nil {
+		return &obj, err
+	}
+	err = q.Create(&obj).Error
+	obj.Password = plain
+	return &obj, err
 }
 
 func (r *repository) UpdatePassword(ctx context.Context, id uint, obj entity.Password, opts ...repo.Options) (*entity.Password, error) {
@@ -58,11 +76,30 @@ func (r *repository) UpdatePassword(ctx context.Context, id uint, obj entity.Pas
 		q = opt(q)
 	}
 
-	return &p, q.Model(&p).Updates(obj).Error
+	var err error
+	if obj.Password, err = r.cipher.Encrypt(obj.Password); err != nil {
+		return &p, err
+	}
+	res := q.Model(&p).Updates(obj)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return &p, repo.ErrNotAffected
+	}
+	return &p, res.Error
 }
 
-func (r *repository) DeletePassword(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.Password{ID: id}).Error
+func (r *repository) DeletePassword(ctx context.Context, id uint, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	res := q.Delete(&entity.Password{ID: id})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return repo.ErrNotAffected
+	}
+	return res.Error
 }
 
 func (r *repository) GetCategoryByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Category, error) {
@@ -104,9 +141,350 @@ func (r *repository) UpdateCategory(ctx context.Context, id uint, obj entity.Cat
 		q = opt(q)
 	}
 
-	return &c, q.Model(&c).Updates(obj).Error
+	res := q.Model(&c).Updates(obj)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return &c, repo.ErrNotAffected
+	}
+	return &c, res.Error
+}
+
+func (r *repository) DeleteCategory(ctx context.Context, id uint, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	res := q.Delete(&entity.Category{ID: id})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return repo.ErrNotAffected
+	}
+	return res.Error
+}
+
+func (r *repository) GetTagByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Tag, error) {
+	q := r.db.WithContext(ctx)
+	t := entity.Tag{ID: id}
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &t, q.First(&t).Error
+}
+
+func (r *repository) FindTags(ctx context.Context, opts ...repo.Options) ([]*entity.Tag, error) {
+	q := r.db.WithContext(ctx).Model(&entity.Tag{})
+	var t []*entity.Tag
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return t, q.Find(&t).Error
+}
+
+func (r *repository) CreateTag(ctx context.Context, obj entity.Tag) (*entity.Tag, error) {
+	q := r.db.WithContext(ctx)
+
+	return &obj, q.Create(&obj).Error
+}
+
+func (r *repository) UpdateTag(ctx context.Context, id uint, obj entity.Tag, opts ...repo.Options) (*entity.Tag, error) {
+	q := r.db.WithContext(ctx)
+	t := entity.Tag{ID: id}
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &t, q.Model(&t).Updates(obj).Error
+}
+
+func (r *repository) DeleteTag(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// detach from all passwords first
+		if err := tx.Exec("DELETE FROM password_tag WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Tag{ID: id}).Error
+	})
+}
+
+func (r *repository) CountTagUsage(ctx context.Context, ids ...uint) (map[uint]int, error) {
+	q := r.db.WithContext(ctx).
+		Table("password_tag").
+		Select("password_tag.tag_id, COUNT(*) AS count").
+		// only count the passwords that's not deleted yet
+		Joins("JOIN password ON password.id = password_tag.password_id AND password.deleted_at IS NULL").
+		Group("password_tag.tag_id")
+	if len(ids) > 0 {
+		q = q.Where("password_tag.tag_id IN ?", ids)
+	}
+
+	var rows []struct {
+		TagID uint
+		Count int
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	m := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		m[row.TagID] = row.Count
+	}
+	return m, nil
+}
+
+func (r *repository) ReplacePasswordTags(ctx context.Context, id uint, tagIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM password_tag WHERE password_id = ?", id).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+
+		rows := make([]map[string]any, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			rows = append(rows, map[string]any{"password_id": id, "tag_id": tagID})
+		}
+		return tx.Table("password_tag").Create(rows).Error
+	})
+}
+
+func (r *repository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var usr entity.User
+	return &usr, r.db.WithContext(ctx).Select("id", "username").Where("username = ?", username).First(&usr).Error
+}
+
+func (r *repository) FindUsers(ctx context.Context, opts ...repo.Options) ([]*entity.User, error) {
+	q := r.db.WithContext(ctx).Model(&entity.User{})
+	var usr []*entity.User
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return usr, q.Find(&usr).Error
+}
+
+func (r *repository) GetShareByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Share, error) {
+	q := r.db.WithContext(ctx)
+	s := entity.Share{ID: id}
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &s, q.First(&s).Error
 }
 
-func (r *repository) DeleteCategory(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.Category{ID: id}).Error
+func (r *repository) FindShares(ctx context.Context, opts ...repo.Options) ([]*entity.Share, error) {
+	q := r.db.WithContext(ctx).Model(&entity.Share{})
+	var s []*entity.Share
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return s, q.Find(&s).Error
+}
+
+func (r *repository) CreateShare(ctx context.Context, obj entity.Share) (*entity.Share, error) {
+	q := r.db.WithContext(ctx)
+
+	return &obj, q.Create(&obj).Error
+}
+
+func (r *repository) UpdateShare(ctx context.Context, id uint, obj entity.Share, opts ...repo.Options) (*entity.Share, error) {
+	q := r.db.WithContext(ctx)
+	s := entity.Share{ID: id}
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &s, q.Model(&s).Updates(obj).Error
+}
+
+func (r *repository) DeleteShare(ctx context.Context, obj entity.Share) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&entity.Share{ID: obj.ID}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.Revocation{UserID: obj.GranteeID, PasswordID: obj.PasswordID, CategoryID: obj.CategoryID}).Error
+	})
+}
+
+func (r *repository) FindRevocations(ctx context.Context, opts ...repo.Options) ([]*entity.Revocation, error) {
+	q := r.db.WithContext(ctx)
+	var revs []*entity.Revocation
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return revs, q.Find(&revs).Error
+}
+
+func (r *repository) CreateRevocations(ctx context.Context, objs []*entity.Revocation) error {
+	if len(objs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&objs).Error
+}
+
+func (r *repository) GetShareLinkByID(ctx context.Context, id string, opts ...repo.Options) (*entity.ShareLink, error) {
+	q := r.db.WithContext(ctx)
+	var l entity.ShareLink
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &l, q.Where("id = ?", id).First(&l).Error
+}
+
+func (r *repository) CreateShareLink(ctx context.Context, obj entity.ShareLink) (*entity.ShareLink, error) {
+	q := r.db.WithContext(ctx)
+
+	return &obj, q.Create(&obj).Error
+}
+
+func (r *repository) UpdateShareLink(ctx context.Context, id string, obj entity.ShareLink, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return q.Model(&entity.ShareLink{ID: id}).Updates(obj).Error
+}
+
+func (r *repository) DeleteShareLinks(ctx context.Context, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return q.Delete(&entity.ShareLink{}).Error
+}
+
+func (r *repository) GetVaultKey(ctx context.Context, userID uint) (*entity.VaultKey, error) {
+	var k entity.VaultKey
+	return &k, r.db.WithContext(ctx).Where("user_id = ?", userID).First(&k).Error
+}
+
+func (r *repository) SaveVaultKey(ctx context.Context, obj entity.VaultKey) (*entity.VaultKey, error) {
+	q := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kdf_algorithm", "kdf_iterations", "kdf_memory", "kdf_parallelism", "salt", "protected_key", "updated_at"}),
+	})
+
+	return &obj, q.Create(&obj).Error
+}
+
+func (r *repository) GetBlobByID(ctx context.Context, id uint, opts ...repo.Options) (*entity.Blob, error) {
+	q := r.db.WithContext(ctx)
+	b := entity.Blob{ID: id}
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return &b, q.First(&b).Error
+}
+
+func (r *repository) FindBlobs(ctx context.Context, opts ...repo.Options) ([]*entity.Blob, error) {
+	q := r.db.WithContext(ctx).Model(&entity.Blob{})
+	var b []*entity.Blob
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return b, q.Find(&b).Error
+}
+
+func (r *repository) CreateBlob(ctx context.Context, obj entity.Blob) (*entity.Blob, error) {
+	q := r.db.WithContext(ctx)
+
+	return &obj, q.Create(&obj).Error
+}
+
+func (r *repository) UpdateBlob(ctx context.Context, id uint, obj entity.Blob, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	res := q.Model(&entity.Blob{ID: id}).Updates(obj)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return repo.ErrNotAffected
+	}
+	return res.Error
+}
+
+func (r *repository) DeleteBlob(ctx context.Context, id uint, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	res := q.Delete(&entity.Blob{ID: id})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return repo.ErrNotAffected
+	}
+	return res.Error
+}
+
+func (r *repository) FindEmergencyAccesses(ctx context.Context, opts ...repo.Options) ([]*entity.EmergencyAccess, error) {
+	q := r.db.WithContext(ctx).Model(&entity.EmergencyAccess{})
+	var e []*entity.EmergencyAccess
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return e, q.Find(&e).Error
+}
+
+func (r *repository) Transaction(ctx context.Context, fn func(Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{db: tx, cipher: r.cipher})
+	})
+}
+
+// open decrypt the secret of given passwords in place.
+func (r *repository) open(pws ...*entity.Password) error {
+	for _, p := range pws {
+		pt, err := r.cipher.Decrypt(p.Password)
+		if err != nil {
+			return err
+		}
+		p.Password = pt
+	}
+	return nil
 }
@@ -2,7 +2,12 @@ package password
 
 import (
 	"mime/multipart"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/pkg/importer"
+	"github.com/mdanialr/pwman_backend/pkg/strength"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -12,8 +17,61 @@ type Request struct {
 	pagination
 	ID       uint   `json:"id"`
 	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Password string `json:"password" validate:"required,max=4096"`
 	Category uint   `json:"category" validate:"required"`
+	// Revision optional revision of the password that's being updated or
+	// deleted. Rejected if it's stale. Overridden by the If-Match header.
+	Revision uint `json:"revision" query:"-"`
+	// URL optional address where this password is used.
+	URL string `json:"url"`
+	// Notes optional free text notes.
+	Notes string `json:"notes"`
+	// ExpiresAt optional date when the password should be rotated. Take
+	// precedence over RotationDays.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// RotationDays optional rotation interval in days. The expiry date will
+	// be reset using this interval whenever the password is rotated.
+	RotationDays int `json:"rotation_days" validate:"omitempty,min=1"`
+	// Favorite whether the password is pinned.
+	Favorite bool `json:"favorite" query:"-"`
+	// Tags ids of the tags for the password. Leave it out to keep the current
+	// tags when updating, or send empty list to remove all of them.
+	Tags []uint `json:"tags" query:"-"`
+	// FilterTags filter only passwords that has the given tag ids.
+	FilterTags []uint `json:"-" query:"tags"`
+	// TagMode how FilterTags are matched. Either any (default) to match
+	// passwords that has at least one of the tags or all to match passwords
+	// that has all the tags.
+	TagMode string `json:"-" query:"tag_mode"`
+	// FavoriteFirst sort the favorite passwords first before the other
+	// orders.
+	FavoriteFirst bool `json:"-" query:"favorite_first"`
+	// OrgID list the passwords in the vault of this organization instead of
+	// the personal vault.
+	OrgID uint `json:"-" query:"org_id"`
+	// FilterCategory filter only passwords that belong to one of the given
+	// category ids.
+	FilterCategory []uint `json:"-" query:"category_id"`
+	// Recursive whether FilterCategory also include the passwords that belong
+	// to all descendants of the categories.
+	Recursive bool `json:"-" query:"recursive"`
+	// CreatedFrom filter only passwords that's created at or after this date.
+	// Either a date such as 2006-01-02 or RFC3339 timestamp.
+	CreatedFrom string `json:"-" query:"created_from"`
+	// CreatedTo filter only passwords that's created at or before this date.
+	// Date without time include the whole day.
+	CreatedTo string `json:"-" query:"created_to"`
+	// UpdatedFrom same as CreatedFrom but for the last updated date.
+	UpdatedFrom string `json:"-" query:"updated_from"`
+	// UpdatedTo same as CreatedTo but for the last updated date.
+	UpdatedTo string `json:"-" query:"updated_to"`
+	// HasURL filter only passwords that either has or has no URL.
+	HasURL *bool `json:"-" query:"has_url"`
+	// Expired filter only passwords that already expired.
+	Expired bool `json:"-" query:"expired"`
+	// ExpiringWithin filter only passwords that will expire within the given
+	// number of days.
+	ExpiringWithin int `json:"-" query:"expiring_within"`
 }
 
 // Validate apply validation rules for Request.
@@ -24,6 +82,105 @@ func (r *Request) Validate() validator.ValidationErrors {
 	return nil
 }
 
+// ValidateFilter apply validation rules for the filters of Request in index
+// endpoint. The search is rejected in the cursor mode.
+func (r *Request) ValidateFilter() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		r.dateRangeValidation(sl)
+		r.searchValidation(sl)
+	}, Request{})
+	if err := v.StructPartial(r, "FilterCategory"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// CreatedRange return the range of CreatedFrom and CreatedTo. Should be
+// called after ValidateFilter.
+func (r *Request) CreatedRange() DateRange {
+	return newDateRange(r.CreatedFrom, r.CreatedTo)
+}
+
+// UpdatedRange return the range of UpdatedFrom and UpdatedTo. Should be
+// called after ValidateFilter.
+func (r *Request) UpdatedRange() DateRange {
+	return newDateRange(r.UpdatedFrom, r.UpdatedTo)
+}
+
+// dateRangeValidation custom validation to make sure the date filters have
+// valid format and the start is not after the end of the range.
+func (r *Request) dateRangeValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(Request)
+
+	fields := []struct {
+		name, from, to string
+		fromVal, toVal string
+	}{
+		{"created", "created_from", "created_to", req.CreatedFrom, req.CreatedTo},
+		{"updated", "updated_from", "updated_to", req.UpdatedFrom, req.UpdatedTo},
+	}
+	for _, f := range fields {
+		from, okFrom := parseFilterDate(f.fromVal, false)
+		if !okFrom {
+			sl.ReportError(f.fromVal, f.from, f.from, "datetime", "")
+		}
+		to, okTo := parseFilterDate(f.toVal, true)
+		if !okTo {
+			sl.ReportError(f.toVal, f.to, f.to, "datetime", "")
+		}
+		if from != nil && to != nil && from.After(*to) {
+			sl.ReportError(f.toVal, f.to, f.to, "gtefield", f.from)
+		}
+	}
+}
+
+// searchValidation custom validation to reject the search in the cursor mode,
+// since the results are sorted by their relevance first which is not part of
+// the cursor, so the pages would be sorted by the id instead.
+func (r *Request) searchValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(Request)
+	if req.Search != "" && req.IsCursor() {
+		sl.ReportError(req.Search, "search", "Search", "excluded_with", "cursor")
+	}
+}
+
+// DateRange inclusive range of a date filter. Nil means the range is open on
+// that side.
+type DateRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// newDateRange return DateRange from given raw filters. Invalid filter is
+// ignored.
+func newDateRange(from, to string) DateRange {
+	f, _ := parseFilterDate(from, false)
+	t, _ := parseFilterDate(to, true)
+	return DateRange{From: f, To: t}
+}
+
+// parseFilterDate parse given date filter that's either a date or RFC3339
+// timestamp. Date without time as the end of the range is moved to the end
+// of that day, so the whole day is included. Return false if the format is
+// invalid, or nil time if it's empty.
+func parseFilterDate(s string, end bool) (*time.Time, bool) {
+	if s == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, true
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return nil, false
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	return &t, true
+}
+
 // ValidateUpdate apply validation rules for RequestCategory in update
 // endpoint.
 func (r *Request) ValidateUpdate() validator.ValidationErrors {
@@ -48,6 +205,33 @@ func (r *Request) ValidateDelete() validator.ValidationErrors {
 	return nil
 }
 
+// ValidateStrength apply password strength policy for Request using given
+// minimum score. Zero minimum score means no policy is enforced.
+func (r *Request) ValidateStrength(minScore int) validator.ValidationErrors {
+	if minScore <= strength.MinScore {
+		return nil
+	}
+
+	v := validator.New()
+	v.RegisterStructValidation(r.strengthValidation(minScore), Request{})
+	if err := v.StructPartial(r, "Password"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// strengthValidation custom validation to make sure the estimated strength
+// of the password is not below given minimum score.
+func (r *Request) strengthValidation(minScore int) validator.StructLevelFunc {
+	return func(sl validator.StructLevel) {
+		req := sl.Current().Interface().(Request)
+
+		if strength.Estimate(req.Password, req.Username).Score < minScore {
+			sl.ReportError(req.Password, "password", "Password", "strength", strconv.Itoa(minScore))
+		}
+	}
+}
+
 // updateRequiredValidation custom required fields validation in update and
 // delete endpoint.
 func (r *Request) updateRequiredValidation(sl validator.StructLevel) {
@@ -59,12 +243,362 @@ func (r *Request) updateRequiredValidation(sl validator.StructLevel) {
 	}
 }
 
+// RequestImport request object that's used to import passwords from other
+// password managers.
+type RequestImport struct {
+	// Format the export format of the uploaded File.
+	Format string `form:"format" validate:"required,oneof=bitwarden keepass-xml keepass-csv 1password-1pux 1password-csv chrome firefox"`
+	// DryRun only preview the import result without saving anything.
+	DryRun bool `form:"dry_run"`
+	// File binary file of the export that should be parsed manually from
+	// delivery.
+	File *multipart.FileHeader `form:"file"`
+}
+
+// Validate apply validation rules for RequestImport.
+func (r *RequestImport) Validate() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.fileRequiredValidation, RequestImport{})
+	if err := v.Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ImportFormat return Format as importer.Format.
+func (r *RequestImport) ImportFormat() importer.Format {
+	return importer.Format(r.Format)
+}
+
+// fileRequiredValidation custom required validation for field File.
+func (r *RequestImport) fileRequiredValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestImport)
+
+	if req.File == nil {
+		sl.ReportError(req.File, "file", "File", "required", "File")
+	}
+}
+
+// RequestTag request object that's used to manage tags.
+type RequestTag struct {
+	pagination
+	// ID unique identifier of each Tag. Should be required when updating.
+	ID uint `json:"id"`
+	// Name the name of tag.
+	Name string `json:"name" validate:"required,max=50"`
+	// Color optional hex color code such as #ff0000.
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+// Validate apply validation rules for RequestTag.
+func (r *RequestTag) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateUpdate apply validation rules for RequestTag in update endpoint.
+func (r *RequestTag) ValidateUpdate() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.updateRequiredValidation, RequestTag{})
+	if err := v.Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateDelete apply validation rules for RequestTag in delete endpoint.
+func (r *RequestTag) ValidateDelete() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.updateRequiredValidation, RequestTag{})
+	if err := v.StructExcept(r, "Name"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// NormalizeName transform value of Name field to trimmed lower-cased.
+func (r *RequestTag) NormalizeName() {
+	r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+}
+
+// updateRequiredValidation custom required fields validation in update and
+// delete endpoints.
+func (r *RequestTag) updateRequiredValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestTag)
+
+	// required for field ID
+	if req.ID < 1 {
+		sl.ReportError(req.ID, "id", "ID", "required", "ID")
+	}
+}
+
+// RequestShare request object that's used to manage the shares of a password
+// or a category.
+type RequestShare struct {
+	// ID unique identifier of each Share. Should be required when revoking.
+	ID uint `json:"id" query:"-"`
+	// PasswordID the id of the shared password. Either this or CategoryID
+	// should be given when sharing.
+	PasswordID uint `json:"password_id" query:"password_id"`
+	// CategoryID the id of the shared category, which include all of its
+	// passwords and descendants.
+	CategoryID uint `json:"category_id" query:"category_id"`
+	// Username the username of the user who is granted the access.
+	Username string `json:"username" query:"-" validate:"required"`
+	// Permission either view, reveal or edit.
+	Permission string `json:"permission" query:"-" validate:"required,oneof=view reveal edit"`
+	// Received list the shares that's granted to the caller instead of the
+	// ones that's granted by the caller.
+	Received bool `json:"-" query:"received"`
+}
+
+// Validate apply validation rules for RequestShare.
+func (r *RequestShare) Validate() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.targetValidation, RequestShare{})
+	if err := v.Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateDelete apply validation rules for RequestShare in delete endpoint.
+func (r *RequestShare) ValidateDelete() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.updateRequiredValidation, RequestShare{})
+	if err := v.StructExcept(r, "Username", "Permission"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// targetValidation custom validation to make sure exactly one of password or
+// category is shared.
+func (r *RequestShare) targetValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestShare)
+
+	if (req.PasswordID == 0) == (req.CategoryID == 0) {
+		sl.ReportError(req.PasswordID, "password_id", "PasswordID", "required_without", "CategoryID")
+	}
+}
+
+// updateRequiredValidation custom required fields validation in delete
+// endpoint.
+func (r *RequestShare) updateRequiredValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestShare)
+
+	// required for field ID
+	if req.ID < 1 {
+		sl.ReportError(req.ID, "id", "ID", "required", "ID")
+	}
+}
+
+// RequestShareLink request object to create a link that hand the secret of a
+// password to someone without an account.
+type RequestShareLink struct {
+	// ID the id of the password which is taken from the path.
+	ID uint `json:"-"`
+	// Views optional number of times the link can be opened. Default to once.
+	Views int `json:"views" validate:"omitempty,min=1,max=100"`
+	// ExpiresIn optional number of minutes before the link is expired.
+	// Default to a day.
+	ExpiresIn int `json:"expires_in" validate:"omitempty,min=1,max=43200"`
+}
+
+// Validate apply validation rules for RequestShareLink.
+func (r *RequestShareLink) Validate() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.requiredValidation, RequestShareLink{})
+	if err := v.Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ViewCount return Views or the default if it's not set.
+func (r *RequestShareLink) ViewCount() int {
+	if r.Views < 1 {
+		return 1
+	}
+	return r.Views
+}
+
+// Expiry return ExpiresIn as duration or the default if it's not set.
+func (r *RequestShareLink) Expiry() time.Duration {
+	if r.ExpiresIn < 1 {
+		return 24 * time.Hour
+	}
+	return time.Duration(r.ExpiresIn) * time.Minute
+}
+
+// requiredValidation custom required fields validation for RequestShareLink.
+func (r *RequestShareLink) requiredValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestShareLink)
+
+	// required for field ID
+	if req.ID < 1 {
+		sl.ReportError(req.ID, "id", "ID", "required", "ID")
+	}
+}
+
+// Minimum KDF parameters that's accepted, following the OWASP
+// recommendations.
+const (
+	minPBKDF2Iterations = 600000
+	minArgon2Iterations = 2
+	minArgon2Memory     = 19456
+)
+
+// RequestVaultKey request object to set up the zero-knowledge vault of the
+// caller, or to replace the protected key once the master password is changed.
+type RequestVaultKey struct {
+	// KdfAlgorithm either pbkdf2-sha256 or argon2id.
+	KdfAlgorithm string `json:"kdf_algorithm" validate:"required,oneof=pbkdf2-sha256 argon2id"`
+	// KdfIterations the number of iterations, or the time cost of argon2id.
+	KdfIterations int `json:"kdf_iterations" validate:"required,max=10000000"`
+	// KdfMemory the memory cost of argon2id in KiB. Required for argon2id.
+	KdfMemory int `json:"kdf_memory" validate:"omitempty,max=1048576"`
+	// KdfParallelism the parallelism of argon2id. Required for argon2id.
+	KdfParallelism int `json:"kdf_parallelism" validate:"omitempty,max=16"`
+	// Salt base64 encoded random salt of the KDF.
+	Salt []byte `json:"salt" validate:"required,min=16,max=64"`
+	// ProtectedKey base64 encoded symmetric key that's encrypted by the key
+	// that's derived from the master password.
+	ProtectedKey []byte `json:"protected_key" validate:"required,min=32,max=1024"`
+}
+
+// Validate apply validation rules for RequestVaultKey.
+func (r *RequestVaultKey) Validate() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.kdfValidation, RequestVaultKey{})
+	if err := v.Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// kdfValidation make sure the KDF parameters are not weaker than the minimum
+// of the chosen algorithm.
+func (r *RequestVaultKey) kdfValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestVaultKey)
+
+	switch req.KdfAlgorithm {
+	case "pbkdf2-sha256":
+		if req.KdfIterations < minPBKDF2Iterations {
+			sl.ReportError(req.KdfIterations, "kdf_iterations", "KdfIterations", "min", strconv.Itoa(minPBKDF2Iterations))
+		}
+	case "argon2id":
+		if req.KdfIterations < minArgon2Iterations {
+			sl.ReportError(req.KdfIterations, "kdf_iterations", "KdfIterations", "min", strconv.Itoa(minArgon2Iterations))
+		}
+		if req.KdfMemory < minArgon2Memory {
+			sl.ReportError(req.KdfMemory, "kdf_memory", "KdfMemory", "min", strconv.Itoa(minArgon2Memory))
+		}
+		if req.KdfParallelism < 1 {
+			sl.ReportError(req.KdfParallelism, "kdf_parallelism", "KdfParallelism", "min", "1")
+		}
+	}
+}
+
+// RequestBlob request object for an item of the zero-knowledge vault that's
+// encrypted by the client.
+type RequestBlob struct {
+	pagination
+	// ID unique identifier of each Blob. Should be required when updating.
+	ID uint `json:"id"`
+	// Ciphertext base64 encoded item that's encrypted by the client.
+	Ciphertext []byte `json:"ciphertext" query:"-" validate:"required,max=65536"`
+	// Meta optional unencrypted fields that the client choose to expose for
+	// search. Anything in here is readable by the server.
+	Meta map[string]string `json:"meta" query:"-" validate:"max=20,dive,keys,required,max=50,endkeys,max=255"`
+	// Revision optional revision of the blob that's being updated or
+	// deleted. Rejected if it's stale. Overridden by the If-Match header.
+	Revision uint `json:"revision" query:"-"`
+	// FilterMeta optional exact match of the meta given as key:value. Every
+	// one of them should match.
+	FilterMeta []string `json:"-" query:"meta"`
+}
+
+// Validate apply validation rules for RequestBlob.
+func (r *RequestBlob) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateUpdate apply validation rules for RequestBlob in update endpoint.
+func (r *RequestBlob) ValidateUpdate() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.updateRequiredValidation, RequestBlob{})
+	if err := v.Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// ValidateDelete apply validation rules for RequestBlob in delete endpoint.
+func (r *RequestBlob) ValidateDelete() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.updateRequiredValidation, RequestBlob{})
+	if err := v.StructExcept(r, "Ciphertext", "Meta"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// SanitizeOrder fallback Order to id if it's not one of the columns that the
+// blobs may be sorted by, since anything else is opaque to the server.
+func (r *RequestBlob) SanitizeOrder() {
+	switch r.Order {
+	case "id", "created_at", "updated_at":
+		return
+	}
+	r.Order = "id"
+	r.SetKey(r.Order, r.Sort == "DESC")
+}
+
+// MetaFilter return FilterMeta as a map of key to value. Those without
+// colon are ignored.
+func (r *RequestBlob) MetaFilter() map[string]string {
+	m := make(map[string]string)
+	for _, f := range r.FilterMeta {
+		if k, v, ok := strings.Cut(f, ":"); ok && k != "" {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// updateRequiredValidation custom required fields validation in update and
+// delete endpoint.
+func (r *RequestBlob) updateRequiredValidation(sl validator.StructLevel) {
+	req := sl.Current().Interface().(RequestBlob)
+
+	// required for field ID
+	if req.ID < 1 {
+		sl.ReportError(req.ID, "id", "ID", "required", "ID")
+	}
+}
+
 // RequestCategory standard request object that may be used in password domain.
 type RequestCategory struct {
 	pagination
 	// ID unique identifier of each Category. Should be required when updating.
-	ID uint `form:"id"`
-	// Name the name of category.
+	ID uint `form:"id" json:"id"`
+	// ParentID optional id of the parent category. Zero means it's a root
+	// category.
+	ParentID uint `form:"parent_id" json:"parent_id" query:"-"`
+	// OrgID optional id of the organization whose vault the category belongs
+	// to. Zero means the personal vault. When creating, it's only used for
+	// the root categories since the others follow their parent.
+	OrgID uint `form:"org_id" json:"org_id" query:"org_id"`
+	// Revision optional revision of the category that's being updated or
+	// deleted. Rejected if it's stale. Overridden by the If-Match header.
+	Revision uint `form:"revision" json:"revision" query:"-"`
+	// Name the name of category. Should be unique among the siblings.
 	Name string `form:"name" validate:"required"`
 	// Image binary file for image field that should be parsed manually from
 	// delivery.
@@ -122,6 +656,16 @@ func (r *RequestCategory) ValidateDelete() validator.ValidationErrors {
 	return nil
 }
 
+// ValidateMove apply validation rules for RequestCategory in move endpoint.
+func (r *RequestCategory) ValidateMove() validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(r.updateRequiredValidation, RequestCategory{})
+	if err := v.StructExcept(r, "Name"); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
 // NormalizeName transform value of Name field to upper-cased.
 func (r *RequestCategory) NormalizeName() {
 	r.Name = strings.ToUpper(r.Name)
@@ -178,3 +722,22 @@ func (r *RequestCategory) updateRequiredValidation(sl validator.StructLevel) {
 		sl.ReportError(req.ID, "id", "ID", "required", "ID")
 	}
 }
+
+// ParseIfMatch parse given value of If-Match header into the revision. Both
+// strong and weak ETag are accepted. Return zero if it's empty or the
+// wildcard, and false if it's malformed.
+func ParseIfMatch(h string) (uint, bool) {
+	h = strings.TrimSpace(h)
+	if h == "" || h == "*" {
+		return 0, true
+	}
+	h = strings.TrimPrefix(h, "W/")
+	if len(h) < 2 || h[0] != '"' || h[len(h)-1] != '"' {
+		return 0, false
+	}
+	rev, err := strconv.ParseUint(h[1:len(h)-1], 10, 0)
+	if err != nil || rev == 0 {
+		return 0, false
+	}
+	return uint(rev), true
+}
@@ -1,8 +1,10 @@
 package password
 
 import (
+	"strconv"
 	"strings"
 
+	"github.com/go-playground/validator/v10"
 	paginate "github.com/mdanialr/pwman_backend/pkg/pagination"
 )
 
@@ -31,6 +33,35 @@ func (p *pagination) SetQuery() {
 	}
 	// make sure the Sort is upper-cased
 	p.Sort = strings.ToUpper(p.Sort)
+	// use the same order as the key in cursor mode
+	p.SetKey(p.Order, p.Sort == "DESC")
+}
+
+// ValidatePagination make sure the page and the page size are within given
+// limits and the cursor is valid if any. Then set the page size to the
+// default if it's not given.
+func (p *pagination) ValidatePagination(l paginate.Limits) validator.ValidationErrors {
+	v := validator.New()
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		if p.Limit < 0 {
+			sl.ReportError(p.Limit, "limit", "Limit", "min", "1")
+		}
+		if p.Limit > l.Max {
+			sl.ReportError(p.Limit, "limit", "Limit", "max", strconv.Itoa(l.Max))
+		}
+		if p.Page < 0 {
+			sl.ReportError(p.Page, "page", "Page", "min", "1")
+		}
+		if err := p.Parse(); err != nil {
+			sl.ReportError(p.Cursor, "cursor", "Cursor", "cursor", "")
+		}
+	}, pagination{})
+	if err := v.Struct(p); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+
+	p.SetDefaultLimit(l)
+	return nil
 }
 
 // sanitizeQuerySort make sure Sort has the expected value.
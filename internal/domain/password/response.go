@@ -1,7 +1,9 @@
 package password
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mdanialr/pwman_backend/internal/entity"
 	paginate "github.com/mdanialr/pwman_backend/pkg/pagination"
@@ -10,32 +12,174 @@ import (
 // responseAble generic type that holds all standard Response that can be
 // transformed from entity to IndexResponse.
 type responseAble interface {
-	ResponseCategory | Response
+	ResponseCategory | Response | ResponseTag | ResponseBlob
 }
 
 // Response standard response object that may be used in password domain.
 type Response struct {
 	ID         uint   `json:"id"`
 	Username   string `json:"username"`
+	URL        string `json:"url,omitempty"`
+	Notes      string `json:"notes,omitempty"`
 	CategoryID uint   `json:"category_id"`
+	// Strength estimated strength score of the password from 0 (too
+	// guessable) to 4 (very unguessable).
+	Strength int `json:"strength"`
+	// Breached whether the password has been exposed in known data breaches.
+	Breached bool `json:"breached"`
+	// ExpiresAt when the password should be rotated.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// RotationDays rotation interval in days.
+	RotationDays int `json:"rotation_days,omitempty"`
+	// Favorite whether the password is pinned.
+	Favorite bool `json:"favorite"`
+	// Tags labels of the password.
+	Tags []*ResponseTag `json:"tags,omitempty"`
+	// Revision the current revision that should be sent back when updating
+	// or deleting.
+	Revision uint `json:"revision"`
+	// Relevance how similar the password is to the search query from 0 to 1.
+	// Only set when searching.
+	Relevance *float64 `json:"relevance,omitempty"`
 }
 
 // NewResponseFromEntity transform given entity.Password to Response.
 func NewResponseFromEntity(pw entity.Password) *Response {
 	r := &Response{
-		ID:         pw.ID,
-		Username:   pw.Username,
-		CategoryID: pw.CategoryID,
+		ID:           pw.ID,
+		Username:     pw.Username,
+		URL:          pw.URL,
+		Notes:        pw.Notes,
+		CategoryID:   pw.CategoryID,
+		Strength:     pw.Strength,
+		Breached:     pw.Breached,
+		ExpiresAt:    pw.ExpiresAt,
+		RotationDays: pw.RotationDays,
+		Favorite:     pw.Favorite,
+		Revision:     pw.Revision,
+	}
+	for _, t := range pw.Tags {
+		r.Tags = append(r.Tags, NewResponseTagFromEntity(*t))
+	}
+	if pw.Relevance > 0 {
+		rel := pw.Relevance
+		r.Relevance = &rel
 	}
 	return r
 }
 
-// ResponseCategory standard response object that may be used in password domain.
-type ResponseCategory struct {
+// ETag format given revision as the value of ETag header.
+func ETag(rev uint) string {
+	return `"` + strconv.FormatUint(uint64(rev), 10) + `"`
+}
+
+// ResponseReveal response object that contain the secret of a password.
+type ResponseReveal struct {
+	ID       uint   `json:"id"`
+	Password string `json:"password"`
+}
+
+// ResponseShare response object for the share of a password or a category.
+type ResponseShare struct {
+	ID         uint  `json:"id"`
+	PasswordID *uint `json:"password_id,omitempty"`
+	CategoryID *uint `json:"category_id,omitempty"`
+	// Owner the username of the user who share it.
+	Owner string `json:"owner,omitempty"`
+	// Grantee the username of the user who is granted the access.
+	Grantee    string    `json:"grantee,omitempty"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewResponseShareFromEntity transform given entity.Share to ResponseShare.
+// Both Owner and Grantee should be loaded to fill the usernames.
+func NewResponseShareFromEntity(s entity.Share) *ResponseShare {
+	r := &ResponseShare{
+		ID:         s.ID,
+		PasswordID: s.PasswordID,
+		CategoryID: s.CategoryID,
+		Permission: s.Permission,
+		CreatedAt:  s.CreatedAt,
+	}
+	if s.Owner != nil {
+		r.Owner = s.Owner.Username
+	}
+	if s.Grantee != nil {
+		r.Grantee = s.Grantee.Username
+	}
+	return r
+}
+
+// ResponseTag standard response object for tag.
+type ResponseTag struct {
 	ID    uint   `json:"id"`
 	Name  string `json:"name"`
-	Image string `json:"image"`
-	Icon  string `json:"icon"`
+	Color string `json:"color"`
+	// Usage the number of passwords that use this tag. Only set when listing
+	// the tags.
+	Usage *int `json:"usage,omitempty"`
+}
+
+// NewResponseTagFromEntity transform given entity.Tag to ResponseTag.
+func NewResponseTagFromEntity(t entity.Tag) *ResponseTag {
+	return &ResponseTag{ID: t.ID, Name: t.Name, Color: t.Color}
+}
+
+// ResponseScan response that's used to report the progress of the breach scan
+// for all passwords that the caller may see.
+type ResponseScan struct {
+	// Running whether the scan is still running.
+	Running bool `json:"running"`
+	// Scanned the number of passwords that has been scanned.
+	Scanned int `json:"scanned"`
+	// Breached the number of scanned passwords that's found in breaches.
+	Breached int `json:"breached"`
+	// StartedAt when the scan is started.
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// FinishedAt when the scan is finished.
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ResponseImport response that's used to report the result of importing
+// passwords from other password managers.
+type ResponseImport struct {
+	// DryRun whether this is only a preview and nothing is saved.
+	DryRun bool `json:"dry_run"`
+	// Total the number of records found in the export.
+	Total int `json:"total"`
+	// Imported the number of records that's imported, or would be imported
+	// in dry run.
+	Imported int `json:"imported"`
+	// Failed the number of records that can not be imported.
+	Failed int `json:"failed"`
+	// NewCategories name of categories that's created, or would be created
+	// in dry run.
+	NewCategories []string `json:"new_categories"`
+	// Errors the reason of each record that can not be imported.
+	Errors []*ResponseImportError `json:"errors"`
+}
+
+// ResponseImportError the reason why a record can not be imported.
+type ResponseImportError struct {
+	// Row the position of the record in the export starting from 1.
+	Row     int    `json:"row"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// ResponseCategory standard response object that may be used in password domain.
+type ResponseCategory struct {
+	ID       uint   `json:"id"`
+	ParentID *uint  `json:"parent_id"`
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Icon     string `json:"icon"`
+	// Revision the current revision that should be sent back when updating
+	// or deleting.
+	Revision uint `json:"revision"`
+	// Children the sub categories. Only filled in the tree.
+	Children []*ResponseCategory `json:"children,omitempty"`
 }
 
 // NewResponseCategoryFromEntity transform given entity.Category to
@@ -45,10 +189,12 @@ func NewResponseCategoryFromEntity(cat entity.Category, prefix string) *Response
 	pr := strings.TrimSuffix(prefix, "/") + "/"
 
 	r := &ResponseCategory{
-		ID:    cat.ID,
-		Name:  cat.Name,
-		Image: pr + cat.ImagePath,
-		Icon:  pr + cat.IconPath,
+		ID:       cat.ID,
+		ParentID: cat.ParentID,
+		Name:     cat.Name,
+		Image:    pr + cat.ImagePath,
+		Icon:     pr + cat.IconPath,
+		Revision: cat.Revision,
 	}
 	return r
 }
@@ -71,6 +217,22 @@ func NewIndexResponseFromEntity(pw []*entity.Password) *IndexResponse[Response]
 	return &IndexResponse[Response]{Data: res}
 }
 
+// NewIndexResponseTagFromEntity create new pointer IndexResponse from given
+// slices of entity.Tag along with their usage from given map of tag id to the
+// number of passwords.
+func NewIndexResponseTagFromEntity(tags []*entity.Tag, usage map[uint]int) *IndexResponse[ResponseTag] {
+	var res []*ResponseTag
+
+	for _, t := range tags {
+		r := NewResponseTagFromEntity(*t)
+		cnt := usage[t.ID]
+		r.Usage = &cnt
+		res = append(res, r)
+	}
+
+	return &IndexResponse[ResponseTag]{Data: res}
+}
+
 // NewIndexResponseCategoryFromEntity create new pointer IndexResponse from given slices
 // of entity.Category. Also prepend given prefix to both Image & Icon fields
 // after cleaning the trailing slash.
@@ -83,3 +245,107 @@ func NewIndexResponseCategoryFromEntity(cats []*entity.Category, prefix string)
 
 	return &IndexResponse[ResponseCategory]{Data: res}
 }
+
+// NewTreeResponseCategoryFromEntity arrange given slices of entity.Category
+// as a tree then return the root categories. Category whose parent is not in
+// given slices is regarded as a root. Also prepend given prefix to both Image
+// & Icon fields after cleaning the trailing slash.
+func NewTreeResponseCategoryFromEntity(cats []*entity.Category, prefix string) []*ResponseCategory {
+	nodes := make(map[uint]*ResponseCategory, len(cats))
+	for _, cat := range cats {
+		nodes[cat.ID] = NewResponseCategoryFromEntity(*cat, prefix)
+	}
+
+	res := make([]*ResponseCategory, 0)
+	for _, cat := range cats {
+		node := nodes[cat.ID]
+		if cat.ParentID != nil {
+			if parent, ok := nodes[*cat.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		res = append(res, node)
+	}
+	return res
+}
+
+// ResponseShareLink response object for newly created share link.
+type ResponseShareLink struct {
+	ID string `json:"id"`
+	// URL the link that should be handed over. The key is only in the
+	// fragment, which is never sent to the server.
+	URL       string    `json:"url"`
+	Views     int       `json:"views"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ResponseShareLinkSecret response object for opened share link.
+type ResponseShareLinkSecret struct {
+	// Ciphertext the AES-256-GCM encrypted secret whose first 12 bytes are
+	// the nonce. Decrypt it using the key in the fragment of the link.
+	Ciphertext []byte `json:"ciphertext"`
+	// ViewsLeft how many times the link can still be opened.
+	ViewsLeft int       `json:"views_left"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ResponseVaultKey response object for what a client need to unlock the
+// zero-knowledge vault.
+type ResponseVaultKey struct {
+	KdfAlgorithm   string    `json:"kdf_algorithm"`
+	KdfIterations  int       `json:"kdf_iterations"`
+	KdfMemory      int       `json:"kdf_memory,omitempty"`
+	KdfParallelism int       `json:"kdf_parallelism,omitempty"`
+	Salt           []byte    `json:"salt"`
+	ProtectedKey   []byte    `json:"protected_key"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// NewResponseVaultKeyFromEntity transform given entity.VaultKey to
+// ResponseVaultKey.
+func NewResponseVaultKeyFromEntity(k entity.VaultKey) *ResponseVaultKey {
+	return &ResponseVaultKey{
+		KdfAlgorithm:   k.KdfAlgorithm,
+		KdfIterations:  k.KdfIterations,
+		KdfMemory:      k.KdfMemory,
+		KdfParallelism: k.KdfParallelism,
+		Salt:           k.Salt,
+		ProtectedKey:   k.ProtectedKey,
+		UpdatedAt:      k.UpdatedAt,
+	}
+}
+
+// ResponseBlob response object for an item of the zero-knowledge vault.
+type ResponseBlob struct {
+	ID         uint              `json:"id"`
+	Ciphertext []byte            `json:"ciphertext"`
+	Meta       map[string]string `json:"meta,omitempty"`
+	Revision   uint              `json:"revision"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// NewResponseBlobFromEntity transform given entity.Blob to ResponseBlob.
+func NewResponseBlobFromEntity(b entity.Blob) *ResponseBlob {
+	return &ResponseBlob{
+		ID:         b.ID,
+		Ciphertext: b.Ciphertext,
+		Meta:       b.Meta,
+		Revision:   b.Revision,
+		CreatedAt:  b.CreatedAt,
+		UpdatedAt:  b.UpdatedAt,
+	}
+}
+
+// NewIndexResponseBlobFromEntity create new pointer IndexResponse from given
+// slices of entity.Blob.
+func NewIndexResponseBlobFromEntity(blobs []*entity.Blob) *IndexResponse[ResponseBlob] {
+	var res []*ResponseBlob
+
+	for _, b := range blobs {
+		res = append(res, NewResponseBlobFromEntity(*b))
+	}
+
+	return &IndexResponse[ResponseBlob]{Data: res}
+}
@@ -0,0 +1,152 @@
+package password
+
+import (
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+)
+
+// Rank of the access to a password or category, ordered from the least to the
+// most allowed. The owner is allowed to do anything including managing the
+// shares.
+const (
+	RankNone = iota
+	RankView
+	RankReveal
+	RankEdit
+	RankOwner
+)
+
+// permissionRanks the Rank of each share permission.
+var permissionRanks = map[string]int{
+	entity.ShareView:   RankView,
+	entity.ShareReveal: RankReveal,
+	entity.ShareEdit:   RankEdit,
+}
+
+// emergencyRanks the Rank of each emergency access level over the whole
+// vault of the grantor.
+var emergencyRanks = map[string]int{
+	entity.EmergencyView:     RankReveal,
+	entity.EmergencyTakeover: RankOwner,
+}
+
+// PermissionRank return the Rank of given share permission. Return RankNone
+// if it's unknown.
+func PermissionRank(perm string) int {
+	return permissionRanks[perm]
+}
+
+// EmergencyRank return the Rank of given emergency access level. Return
+// RankNone if it's unknown.
+func EmergencyRank(access string) int {
+	return emergencyRanks[access]
+}
+
+// sharedCategoriesQuery select the id of categories that's shared to the given
+// user along with all of their descendants.
+const sharedCategoriesQuery = "WITH RECURSIVE sub AS (" +
+	"SELECT category_id AS id FROM share WHERE grantee_id = ? AND category_id IS NOT NULL " +
+	"UNION SELECT c.id FROM category c JOIN sub ON c.parent_id = sub.id WHERE c.deleted_at IS NULL" +
+	") SELECT id FROM sub"
+
+// emergencyGrantorsQuery select the id of users whose emergency access to
+// their vault is approved for the given user.
+const emergencyGrantorsQuery = "SELECT grantor_id FROM emergency_access WHERE grantee_id = ? AND status = '" + entity.EmergencyApproved + "'"
+
+// memberOrgsQuery select the id of organizations that the given user is a
+// member of.
+const memberOrgsQuery = "SELECT org_id FROM member WHERE user_id = ?"
+
+const (
+	// passwordAccessQuery match passwords that's owned by or shared to the
+	// given user, either directly or through their category, or through an
+	// approved emergency access.
+	passwordAccessQuery = "owner_id = ? OR id IN (SELECT password_id FROM share WHERE grantee_id = ? AND password_id IS NOT NULL) " +
+		"OR category_id IN (" + sharedCategoriesQuery + ") OR (org_id IS NULL AND owner_id IN (" + emergencyGrantorsQuery + "))"
+	// categoryAccessQuery match categories that's owned by or shared to the
+	// given user, including the descendants of the shared ones, or through
+	// an approved emergency access.
+	categoryAccessQuery = "owner_id = ? OR id IN (" + sharedCategoriesQuery + ") " +
+		"OR (org_id IS NULL AND owner_id IN (" + emergencyGrantorsQuery + "))"
+)
+
+// PasswordAccessCond return repo option that only match passwords that's owned
+// by or shared to given user id, either directly or through their category,
+// or through an approved emergency access.
+func PasswordAccessCond(userID uint) repo.Options {
+	return repo.Where("("+passwordAccessQuery+")", userID, userID, userID, userID)
+}
+
+// CategoryAccessCond return repo option that only match categories that's
+// owned by or shared to given user id, including the descendants of the
+// shared ones.
+func CategoryAccessCond(userID uint) repo.Options {
+	return repo.Where("("+categoryAccessQuery+")", userID, userID, userID)
+}
+
+// revealPermissions the share permissions that allow to reveal the password.
+const revealPermissions = "('" + entity.ShareReveal + "', '" + entity.ShareEdit + "')"
+
+// revealedCategoriesQuery same as sharedCategoriesQuery but only through the
+// shares that allow to reveal the passwords.
+const revealedCategoriesQuery = "WITH RECURSIVE sub AS (" +
+	"SELECT category_id AS id FROM share WHERE grantee_id = ? AND category_id IS NOT NULL AND permission IN " + revealPermissions + " " +
+	"UNION SELECT c.id FROM category c JOIN sub ON c.parent_id = sub.id WHERE c.deleted_at IS NULL" +
+	") SELECT id FROM sub"
+
+// PasswordRevealCond return repo option that only match passwords outside of
+// the organizations that given user id may reveal, which are those owned by
+// them, shared to them with at least reveal permission, or through an
+// approved emergency access.
+func PasswordRevealCond(userID uint) repo.Options {
+	return repo.Where("org_id IS NULL AND (owner_id = ? "+
+		"OR id IN (SELECT password_id FROM share WHERE grantee_id = ? AND password_id IS NOT NULL AND permission IN "+revealPermissions+") "+
+		"OR category_id IN ("+revealedCategoriesQuery+") OR owner_id IN ("+emergencyGrantorsQuery+"))",
+		userID, userID, userID, userID)
+}
+
+// CategoryRevealCond same as PasswordRevealCond but for the categories.
+func CategoryRevealCond(userID uint) repo.Options {
+	return repo.Where("org_id IS NULL AND (owner_id = ? OR id IN ("+revealedCategoriesQuery+") "+
+		"OR owner_id IN ("+emergencyGrantorsQuery+"))", userID, userID, userID)
+}
+
+// PasswordVaultsCond same as PasswordAccessCond but also match the passwords
+// in the vaults of the organizations that given user id is a member of.
+func PasswordVaultsCond(userID uint) repo.Options {
+	return repo.Where("("+passwordAccessQuery+" OR org_id IN ("+memberOrgsQuery+"))", userID, userID, userID, userID, userID)
+}
+
+// CategoryVaultsCond same as CategoryAccessCond but also match the categories
+// in the vaults of the organizations that given user id is a member of.
+func CategoryVaultsCond(userID uint) repo.Options {
+	return repo.Where("("+categoryAccessQuery+" OR org_id IN ("+memberOrgsQuery+"))", userID, userID, userID, userID)
+}
+
+// revokedCategoriesQuery select the id of given categories along with all of
+// their descendants, including the deleted ones.
+const revokedCategoriesQuery = "WITH RECURSIVE sub AS (" +
+	"SELECT id FROM category WHERE id IN ? " +
+	"UNION SELECT c.id FROM category c JOIN sub ON c.parent_id = sub.id" +
+	") SELECT id FROM sub"
+
+// LostPasswordsCond return repo option that only match passwords that given
+// user id may no longer access among given revoked passwords, those in given
+// revoked categories including their descendants and those in the vaults of
+// given organizations.
+func LostPasswordsCond(userID uint, pwIDs, catIDs, orgIDs []uint) repo.Options {
+	// the access conditions may be null for those outside any organization
+	return repo.Where("(id IN ? OR category_id IN ("+revokedCategoriesQuery+") OR org_id IN ?) "+
+		"AND NOT COALESCE(("+passwordAccessQuery+" OR org_id IN ("+memberOrgsQuery+")), FALSE)",
+		pwIDs, catIDs, orgIDs, userID, userID, userID, userID, userID)
+}
+
+// LostCategoriesCond return repo option that only match categories that given
+// user id may no longer access among given revoked categories including their
+// descendants and those in the vaults of given organizations.
+func LostCategoriesCond(userID uint, catIDs, orgIDs []uint) repo.Options {
+	// the access conditions may be null for those outside any organization
+	return repo.Where("(id IN ("+revokedCategoriesQuery+") OR org_id IN ?) "+
+		"AND NOT COALESCE(("+categoryAccessQuery+" OR org_id IN ("+memberOrgsQuery+")), FALSE)",
+		catIDs, orgIDs, userID, userID, userID, userID)
+}
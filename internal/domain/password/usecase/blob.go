@@ -0,0 +1,163 @@
+package password
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/password"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	paginate "github.com/mdanialr/pwman_backend/pkg/pagination"
+)
+
+func (u *useCase) GetVaultKey(ctx context.Context) (*password.ResponseVaultKey, error) {
+	k, err := u.repo.GetVaultKey(ctx, identity.FromContext(ctx).ID)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNoVaultKey)
+	}
+	return password.NewResponseVaultKeyFromEntity(*k), nil
+}
+
+func (u *useCase) SaveVaultKey(ctx context.Context, req password.RequestVaultKey) (*password.ResponseVaultKey, error) {
+	obj := entity.VaultKey{
+		UserID:         identity.FromContext(ctx).ID,
+		KdfAlgorithm:   req.KdfAlgorithm,
+		KdfIterations:  req.KdfIterations,
+		KdfMemory:      req.KdfMemory,
+		KdfParallelism: req.KdfParallelism,
+		Salt:           req.Salt,
+		ProtectedKey:   req.ProtectedKey,
+	}
+	// the parameters of argon2id are meaningless for pbkdf2
+	if obj.KdfAlgorithm == entity.KdfPBKDF2 {
+		obj.KdfMemory, obj.KdfParallelism = 0, 0
+	}
+
+	k, err := u.repo.SaveVaultKey(ctx, obj)
+	if err != nil {
+		u.log.Error(help.Pad("failed to save vault key:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return password.NewResponseVaultKeyFromEntity(*k), nil
+}
+
+func (u *useCase) IndexBlob(ctx context.Context, req password.RequestBlob) (*password.IndexResponse[password.ResponseBlob], error) {
+	// set up repo options to only include those that the caller own
+	opts := []repo.Options{repo.Where("owner_id = ?", identity.FromContext(ctx).ID), repo.Order(req.Order + " " + req.Sort)}
+	// additionally match the exposed meta
+	if m := req.MetaFilter(); len(m) > 0 {
+		b, _ := json.Marshal(m)
+		opts = append(opts, repo.Where("meta @> ?", string(b)))
+	}
+	if req.Search != "" {
+		opts = append(opts, repo.Where("EXISTS (SELECT 1 FROM jsonb_each_text(meta) WHERE value ILIKE ?)", "%"+req.Search+"%"))
+	}
+	// set up pagination in last order
+	opts = append(opts, repo.Paginate(&req.M))
+
+	blobs, err := u.repo.FindBlobs(ctx, opts...)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve blobs:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	// remove the extra row of the next page if any
+	blobs = paginate.Trim(&req.M, blobs)
+
+	// prepare the response to contain the actual data and the pagination info
+	resp := password.NewIndexResponseBlobFromEntity(blobs)
+	resp.Pagination = &req.M
+	resp.Pagination.Paginate()
+
+	return resp, nil
+}
+
+func (u *useCase) SaveBlob(ctx context.Context, req password.RequestBlob) (*password.ResponseBlob, error) {
+	uid := identity.FromContext(ctx).ID
+	// the client should be able to decrypt it later
+	if _, err := u.repo.GetVaultKey(ctx, uid); err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNoVaultKey)
+	}
+
+	newObj, err := u.repo.CreateBlob(ctx, entity.Blob{OwnerID: uid, Ciphertext: req.Ciphertext, Meta: req.Meta})
+	if err != nil {
+		u.log.Error(help.Pad("failed to create new blob:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return password.NewResponseBlobFromEntity(*newObj), nil
+}
+
+func (u *useCase) UpdateBlob(ctx context.Context, id uint, req password.RequestBlob) (uint, error) {
+	// make sure given id does really exist in repo and belong to the caller
+	b, err := u.blob(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	// make sure the client edited the current revision
+	if req.Revision != 0 && req.Revision != b.Revision {
+		return 0, u.blobConflict(ctx, b.ID)
+	}
+
+	newB := entity.Blob{Ciphertext: req.Ciphertext, Meta: req.Meta, Revision: b.Revision + 1}
+	// only update if nobody else changed it since it's retrieved
+	err = u.repo.UpdateBlob(ctx, b.ID, newB, repo.Cols("ciphertext", "meta", "revision"), revisionCond(b.Revision))
+	if errors.Is(err, repo.ErrNotAffected) {
+		return 0, u.blobConflict(ctx, b.ID)
+	}
+	if err != nil {
+		u.log.Error(help.Pad("failed to update existing blob with id:", strconv.Itoa(int(b.ID)), "and err:", err.Error()))
+		return 0, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return newB.Revision, nil
+}
+
+func (u *useCase) DeleteBlob(ctx context.Context, id, revision uint) error {
+	// make sure given id does really exist in repo and belong to the caller
+	b, err := u.blob(ctx, id)
+	if err != nil {
+		return err
+	}
+	// make sure the client deleted the current revision
+	if revision != 0 && revision != b.Revision {
+		return u.blobConflict(ctx, b.ID)
+	}
+
+	err = u.repo.DeleteBlob(ctx, b.ID, revisionCond(b.Revision))
+	if errors.Is(err, repo.ErrNotAffected) {
+		return u.blobConflict(ctx, b.ID)
+	}
+	if err != nil {
+		u.log.Error(help.Pad("failed to delete existing blob with id:", strconv.Itoa(int(b.ID)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return nil
+}
+
+// blob retrieve the blob that match given id. Return not found if it does not
+// belong to the caller, since nobody else could decrypt it anyway.
+func (u *useCase) blob(ctx context.Context, id uint) (*entity.Blob, error) {
+	b, err := u.repo.GetBlobByID(ctx, id)
+	if err != nil || b.OwnerID != identity.FromContext(ctx).ID {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	return b, nil
+}
+
+// blobConflict return CONFLICT error along with the current copy of the blob
+// that match given id. Return not found instead if it's deleted meanwhile.
+func (u *useCase) blobConflict(ctx context.Context, id uint) error {
+	b, err := u.repo.GetBlobByID(ctx, id)
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	return stderr.NewUCErrDetail(cons.Conflict, cons.ErrStaleRevision, password.NewResponseBlobFromEntity(*b))
+}
@@ -0,0 +1,199 @@
+package password_test
+
+import (
+	"errors"
+	"testing"
+
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password"
+	"github.com/mdanialr/pwman_backend/internal/domain/password/repository/mocks"
+	password "github.com/mdanialr/pwman_backend/internal/domain/password/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseCase_SaveBlob(t *testing.T) {
+	t.Run("Given caller without vault key should return UC instance, INVALID_PAYLOAD as code and "+
+		"zero-knowledge vault is not set up yet as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetVaultKey(mock.Anything, owner).
+			Return(&entity.VaultKey{}, errors.New("record not found")).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		_, err := newUC.SaveBlob(ownerCtx(), pw.RequestBlob{Ciphertext: []byte("ct")})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "zero-knowledge vault is not set up yet", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given caller with vault key should store the ciphertext as is along with the meta", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetVaultKey(mock.Anything, owner).
+			Return(&entity.VaultKey{ID: 1, UserID: owner}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			CreateBlob(mock.Anything, entity.Blob{OwnerID: owner, Ciphertext: []byte("ct"), Meta: map[string]string{"site": "github"}}).
+			Return(&entity.Blob{ID: 3, OwnerID: owner, Ciphertext: []byte("ct"), Meta: map[string]string{"site": "github"}, Revision: 1}, nil).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		res, err := newUC.SaveBlob(ownerCtx(), pw.RequestBlob{Ciphertext: []byte("ct"), Meta: map[string]string{"site": "github"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, &pw.ResponseBlob{ID: 3, Ciphertext: []byte("ct"), Meta: map[string]string{"site": "github"}, Revision: 1}, res)
+	})
+}
+
+func TestUseCase_UpdateBlob(t *testing.T) {
+	current := &entity.Blob{ID: 3, OwnerID: owner, Ciphertext: []byte("old"), Revision: 4}
+
+	testCases := []struct {
+		name           string
+		setup          func(repo *mocks.MockpasswordRepository)
+		revision       uint
+		expect         uint
+		expectCode     string
+		expectRevision uint
+		wantErr        bool
+	}{
+		{
+			name: "Given blob of another user should return UC instance and INVALID_PAYLOAD as code",
+			setup: func(r *mocks.MockpasswordRepository) {
+				r.EXPECT().
+					GetBlobByID(mock.Anything, uint(3)).
+					Return(&entity.Blob{ID: 3, OwnerID: 2, Revision: 4}, nil).
+					Once()
+			},
+			expectCode: "INVALID_PAYLOAD",
+			wantErr:    true,
+		},
+		{
+			name: "Given stale revision should return UC instance, CONFLICT as code and the current copy",
+			setup: func(r *mocks.MockpasswordRepository) {
+				r.EXPECT().
+					GetBlobByID(mock.Anything, uint(3)).
+					Return(current, nil).
+					Twice()
+			},
+			revision:       3,
+			expectCode:     "CONFLICT",
+			expectRevision: 4,
+			wantErr:        true,
+		},
+		{
+			name: "Given blob that's changed meanwhile should return UC instance, CONFLICT as code and the " +
+				"current copy",
+			setup: func(r *mocks.MockpasswordRepository) {
+				r.EXPECT().
+					GetBlobByID(mock.Anything, uint(3)).
+					Return(current, nil).
+					Once()
+				r.EXPECT().
+					UpdateBlob(mock.Anything, uint(3), mock.Anything, mock.Anything, mock.Anything).
+					Return(repo.ErrNotAffected).
+					Once()
+				r.EXPECT().
+					GetBlobByID(mock.Anything, uint(3)).
+					Return(&entity.Blob{ID: 3, OwnerID: owner, Revision: 5}, nil).
+					Once()
+			},
+			revision:       4,
+			expectCode:     "CONFLICT",
+			expectRevision: 5,
+			wantErr:        true,
+		},
+		{
+			name: "Given current revision should replace the ciphertext and return the next revision",
+			setup: func(r *mocks.MockpasswordRepository) {
+				r.EXPECT().
+					GetBlobByID(mock.Anything, uint(3)).
+					Return(current, nil).
+					Once()
+				r.EXPECT().
+					UpdateBlob(mock.Anything, uint(3), entity.Blob{Ciphertext: []byte("new"), Revision: 5}, mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			revision: 4,
+			expect:   5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			rev, err := newUC.UpdateBlob(ownerCtx(), 3, pw.RequestBlob{ID: 3, Ciphertext: []byte("new"), Revision: tc.revision})
+			h.Dep.repo.AssertExpectations(t)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				if tc.expectRevision != 0 {
+					require.IsType(t, &pw.ResponseBlob{}, err.(*stderr.UC).Detail)
+					assert.Equal(t, tc.expectRevision, err.(*stderr.UC).Detail.(*pw.ResponseBlob).Revision)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, rev)
+		})
+	}
+}
+
+func TestRequestVaultKey_Validate(t *testing.T) {
+	salt, key := make([]byte, 16), make([]byte, 32)
+
+	testCases := []struct {
+		name    string
+		sample  pw.RequestVaultKey
+		wantErr bool
+	}{
+		{
+			name:    "Given pbkdf2 with too few iterations should return error",
+			sample:  pw.RequestVaultKey{KdfAlgorithm: entity.KdfPBKDF2, KdfIterations: 1000, Salt: salt, ProtectedKey: key},
+			wantErr: true,
+		},
+		{
+			name:   "Given pbkdf2 with enough iterations should pass",
+			sample: pw.RequestVaultKey{KdfAlgorithm: entity.KdfPBKDF2, KdfIterations: 600000, Salt: salt, ProtectedKey: key},
+		},
+		{
+			name:    "Given argon2id without memory cost should return error",
+			sample:  pw.RequestVaultKey{KdfAlgorithm: entity.KdfArgon2id, KdfIterations: 3, KdfParallelism: 4, Salt: salt, ProtectedKey: key},
+			wantErr: true,
+		},
+		{
+			name: "Given argon2id with enough cost should pass",
+			sample: pw.RequestVaultKey{
+				KdfAlgorithm: entity.KdfArgon2id, KdfIterations: 3, KdfMemory: 65536, KdfParallelism: 4, Salt: salt, ProtectedKey: key,
+			},
+		},
+		{
+			name:    "Given too short salt should return error",
+			sample:  pw.RequestVaultKey{KdfAlgorithm: entity.KdfPBKDF2, KdfIterations: 600000, Salt: salt[:8], ProtectedKey: key},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.sample.Validate()
+			if tc.wantErr {
+				assert.NotEmpty(t, err)
+				return
+			}
+			assert.Empty(t, err)
+		})
+	}
+}
@@ -1,21 +1,38 @@
 package password_test
 
 import (
+	"context"
 	"testing"
 
 	pwMock "github.com/mdanialr/pwman_backend/internal/domain/password/repository/mocks"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	brMock "github.com/mdanialr/pwman_backend/pkg/breach/mocks"
+	"github.com/mdanialr/pwman_backend/pkg/event"
+	ntMock "github.com/mdanialr/pwman_backend/pkg/notifier/mocks"
 	strMock "github.com/mdanialr/pwman_backend/pkg/storage/mocks"
 
 	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
+// owner is the id of the user that calls the use cases in the tests.
+const owner = uint(1)
+
 type (
 	deps struct {
 		config  *viper.Viper
 		log     *zap.Logger
 		storage *strMock.MockstoragePort
+		breach  *brMock.MockbreachPort
+		notify  *ntMock.MocknotifierPort
+		event   event.Port
 		repo    *pwMock.MockpasswordRepository
 	}
 	helperSetup struct {
@@ -28,6 +45,9 @@ func setupTestHelper(t *testing.T) *helperSetup {
 		config:  viper.New(),
 		log:     zaptest.NewLogger(t),
 		storage: new(strMock.MockstoragePort),
+		breach:  new(brMock.MockbreachPort),
+		notify:  new(ntMock.MocknotifierPort),
+		event:   event.NewBus(10),
 		repo:    new(pwMock.MockpasswordRepository),
 	}
 
@@ -35,3 +55,24 @@ func setupTestHelper(t *testing.T) *helperSetup {
 		Dep: d,
 	}
 }
+
+// ownerCtx returns a context that holds the identity of the owner.
+func ownerCtx() context.Context {
+	return identity.NewContext(context.Background(), identity.User{ID: owner})
+}
+
+// dryRun render given repo options to SQL without touching any database.
+func dryRun(t *testing.T, opts ...repo.Options) *gorm.Statement {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		NamingStrategy:         schema.NamingStrategy{SingularTable: true},
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	var pws []*entity.Password
+	return db.Find(&pws).Statement
+}
@@ -0,0 +1,242 @@
+package password
+
+import (
+	"context"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/org"
+	orgRepo "github.com/mdanialr/pwman_backend/internal/domain/org/repository"
+	"github.com/mdanialr/pwman_backend/internal/domain/password"
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+)
+
+// NewPolicy return UseCase that check the role of the caller in the
+// organization that own the password or category before calling given
+// UseCase. Those in the personal vault are passed as is, since they are
+// checked by given UseCase itself.
+func NewPolicy(repo pw.Repository, orgs orgRepo.Repository, uc UseCase) UseCase {
+	return &policy{UseCase: uc, repo: repo, orgs: orgs}
+}
+
+type policy struct {
+	UseCase
+	repo pw.Repository
+	orgs orgRepo.Repository
+}
+
+func (p *policy) IndexPassword(ctx context.Context, req password.Request) (*password.IndexResponse[password.Response], error) {
+	ctx, err := p.allow(ctx, req.OrgID, entity.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return p.UseCase.IndexPassword(ctx, req)
+}
+
+func (p *policy) SavePassword(ctx context.Context, req password.Request) (*password.Response, error) {
+	ctx, err := p.allow(ctx, p.categoryOrg(ctx, req.Category), entity.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	return p.UseCase.SavePassword(ctx, req)
+}
+
+func (p *policy) UpdatePassword(ctx context.Context, id uint, req password.Request) (uint, error) {
+	ctx, err := p.allow(ctx, p.passwordOrg(ctx, id), entity.RoleEditor)
+	if err != nil {
+		return 0, err
+	}
+	// the new category may belong to other organization
+	if ctx, err = p.allow(ctx, p.categoryOrg(ctx, req.Category), entity.RoleEditor); err != nil {
+		return 0, err
+	}
+	return p.UseCase.UpdatePassword(ctx, id, req)
+}
+
+func (p *policy) DeletePassword(ctx context.Context, id, revision uint) error {
+	ctx, err := p.allow(ctx, p.passwordOrg(ctx, id), entity.RoleEditor)
+	if err != nil {
+		return err
+	}
+	return p.UseCase.DeletePassword(ctx, id, revision)
+}
+
+func (p *policy) RevealPassword(ctx context.Context, id uint) (*password.ResponseReveal, error) {
+	ctx, err := p.allow(ctx, p.passwordOrg(ctx, id), entity.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return p.UseCase.RevealPassword(ctx, id)
+}
+
+func (p *policy) IndexCategory(ctx context.Context, req password.RequestCategory) (*password.IndexResponse[password.ResponseCategory], error) {
+	ctx, err := p.allow(ctx, req.OrgID, entity.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return p.UseCase.IndexCategory(ctx, req)
+}
+
+func (p *policy) TreeCategory(ctx context.Context, orgID uint) ([]*password.ResponseCategory, error) {
+	ctx, err := p.allow(ctx, orgID, entity.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return p.UseCase.TreeCategory(ctx, orgID)
+}
+
+func (p *policy) SaveCategory(ctx context.Context, req password.RequestCategory) (*password.ResponseCategory, error) {
+	// the child always belong to the organization of its parent
+	orgID := req.OrgID
+	if req.ParentID != 0 {
+		orgID = p.categoryOrg(ctx, req.ParentID)
+	}
+	ctx, err := p.allow(ctx, orgID, entity.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return p.UseCase.SaveCategory(ctx, req)
+}
+
+func (p *policy) UpdateCategory(ctx context.Context, id uint, req password.RequestCategory) (uint, error) {
+	ctx, err := p.allow(ctx, p.categoryOrg(ctx, id), entity.RoleAdmin)
+	if err != nil {
+		return 0, err
+	}
+	return p.UseCase.UpdateCategory(ctx, id, req)
+}
+
+func (p *policy) MoveCategory(ctx context.Context, id, parentID uint) error {
+	ctx, err := p.allow(ctx, p.categoryOrg(ctx, id), entity.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if ctx, err = p.allow(ctx, p.categoryOrg(ctx, parentID), entity.RoleAdmin); err != nil {
+		return err
+	}
+	return p.UseCase.MoveCategory(ctx, id, parentID)
+}
+
+func (p *policy) DeleteCategory(ctx context.Context, id, revision uint) error {
+	ctx, err := p.allow(ctx, p.categoryOrg(ctx, id), entity.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	return p.UseCase.DeleteCategory(ctx, id, revision)
+}
+
+func (p *policy) SaveShare(ctx context.Context, req password.RequestShare) (*password.ResponseShare, error) {
+	orgID := p.categoryOrg(ctx, req.CategoryID)
+	if req.PasswordID != 0 {
+		orgID = p.passwordOrg(ctx, req.PasswordID)
+	}
+	// those in the vault of an organization are shared through the
+	// membership instead
+	if orgID != 0 {
+		if _, err := p.allow(ctx, orgID, entity.RoleViewer); err != nil {
+			return nil, err
+		}
+		return nil, stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+	}
+	return p.UseCase.SaveShare(ctx, req)
+}
+
+func (p *policy) SaveShareLink(ctx context.Context, req password.RequestShareLink) (*password.ResponseShareLink, error) {
+	ctx, err := p.allow(ctx, p.passwordOrg(ctx, req.ID), entity.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return p.UseCase.SaveShareLink(ctx, req)
+}
+
+// allow make sure the caller has at least given role in the organization of
+// given id, then return the context that mark it as granted. Zero id means
+// the personal vault which is always passed.
+func (p *policy) allow(ctx context.Context, orgID uint, role string) (context.Context, error) {
+	if orgID == 0 {
+		return ctx, nil
+	}
+	m, err := p.orgs.GetMember(ctx, orgID, identity.FromContext(ctx).ID)
+	if err != nil {
+		return ctx, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if !org.AtLeast(m.Role, role) {
+		return ctx, stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+	}
+	return grant(ctx, orgID), nil
+}
+
+// passwordOrg return the id of the organization that own the password of given
+// id. Zero if it's in the personal vault or does not exist, which is left to
+// the use case.
+func (p *policy) passwordOrg(ctx context.Context, id uint) uint {
+	if id == 0 {
+		return 0
+	}
+	pass, err := p.repo.GetPasswordByID(ctx, id, repo.Cols("id", "org_id"))
+	if err != nil || pass.OrgID == nil {
+		return 0
+	}
+	return *pass.OrgID
+}
+
+// categoryOrg same as passwordOrg but for the category of given id.
+func (p *policy) categoryOrg(ctx context.Context, id uint) uint {
+	if id == 0 {
+		return 0
+	}
+	c, err := p.repo.GetCategoryByID(ctx, id, repo.Cols("id", "org_id"))
+	if err != nil || c.OrgID == nil {
+		return 0
+	}
+	return *c.OrgID
+}
+
+// grantKey context key of the organization ids that the policy has granted the
+// caller to.
+type grantKey struct{}
+
+// grant return copy of given context that also mark given organization id as
+// granted.
+func grant(ctx context.Context, orgID uint) context.Context {
+	ids, _ := ctx.Value(grantKey{}).([]uint)
+	return context.WithValue(ctx, grantKey{}, append(ids[:len(ids):len(ids)], orgID))
+}
+
+// isGranted whether the policy has granted the caller to given organization id
+// in given context.
+func isGranted(ctx context.Context, orgID uint) bool {
+	ids, _ := ctx.Value(grantKey{}).([]uint)
+	for _, id := range ids {
+		if id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrg return not found unless the policy has granted the caller to given
+// organization id.
+func allowOrg(ctx context.Context, orgID uint) error {
+	if !isGranted(ctx, orgID) {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	return nil
+}
+
+// vaultCond return repo option that match the vault of given organization id,
+// which should be granted by the policy. Zero id means the personal vault of
+// the caller along with those that's shared to them using given personal
+// cond.
+func vaultCond(ctx context.Context, orgID uint, personal func(uint) repo.Options) (repo.Options, error) {
+	if orgID == 0 {
+		return personal(identity.FromContext(ctx).ID), nil
+	}
+	if err := allowOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return repo.Where("org_id = ?", orgID), nil
+}
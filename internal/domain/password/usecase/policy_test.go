@@ -0,0 +1,170 @@
+package password_test
+
+import (
+	"errors"
+	"testing"
+
+	orgMock "github.com/mdanialr/pwman_backend/internal/domain/org/repository/mocks"
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password"
+	"github.com/mdanialr/pwman_backend/internal/domain/password/repository/mocks"
+	password "github.com/mdanialr/pwman_backend/internal/domain/password/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	orgID := uint(3)
+	orgPassword := &entity.Password{ID: 7, Password: "secret", OrgID: &orgID, CategoryID: 2}
+
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository, orgs *orgMock.MockorgRepository)
+		call       func(uc password.UseCase) error
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given password of organization that the caller is not a member of should return UC " +
+				"instance, INVALID_PAYLOAD as code and data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository, orgs *orgMock.MockorgRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(orgPassword, nil).
+					Once()
+				orgs.EXPECT().
+					GetMember(mock.Anything, orgID, owner).
+					Return(&entity.Member{}, errors.New("record not found")).
+					Once()
+			},
+			call: func(uc password.UseCase) error {
+				_, err := uc.RevealPassword(ownerCtx(), 7)
+				return err
+			},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given viewer that delete password of the organization should return UC instance, " +
+				"FORBIDDEN as code and not allowed to do this as message",
+			setup: func(repo *mocks.MockpasswordRepository, orgs *orgMock.MockorgRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(orgPassword, nil).
+					Once()
+				orgs.EXPECT().
+					GetMember(mock.Anything, orgID, owner).
+					Return(&entity.Member{Role: entity.RoleViewer}, nil).
+					Once()
+			},
+			call: func(uc password.UseCase) error {
+				return uc.DeletePassword(ownerCtx(), 7, 0)
+			},
+			expectCode: "FORBIDDEN",
+			expectMsg:  "not allowed to do this",
+			wantErr:    true,
+		},
+		{
+			name: "Given viewer that reveal password of the organization should return the password",
+			setup: func(repo *mocks.MockpasswordRepository, orgs *orgMock.MockorgRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(orgPassword, nil).
+					Twice()
+				orgs.EXPECT().
+					GetMember(mock.Anything, orgID, owner).
+					Return(&entity.Member{Role: entity.RoleViewer}, nil).
+					Once()
+			},
+			call: func(uc password.UseCase) error {
+				_, err := uc.RevealPassword(ownerCtx(), 7)
+				return err
+			},
+		},
+		{
+			name: "Given editor that delete password of the organization should delete it",
+			setup: func(repo *mocks.MockpasswordRepository, orgs *orgMock.MockorgRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(orgPassword, nil).
+					Twice()
+				orgs.EXPECT().
+					GetMember(mock.Anything, orgID, owner).
+					Return(&entity.Member{Role: entity.RoleEditor}, nil).
+					Once()
+				repo.EXPECT().
+					DeletePassword(mock.Anything, uint(7)).
+					Return(nil).
+					Once()
+			},
+			call: func(uc password.UseCase) error {
+				return uc.DeletePassword(ownerCtx(), 7, 0)
+			},
+		},
+		{
+			name: "Given admin that share password of the organization should return UC instance, FORBIDDEN " +
+				"as code and not allowed to do this as message",
+			setup: func(repo *mocks.MockpasswordRepository, orgs *orgMock.MockorgRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(orgPassword, nil).
+					Once()
+				orgs.EXPECT().
+					GetMember(mock.Anything, orgID, owner).
+					Return(&entity.Member{Role: entity.RoleAdmin}, nil).
+					Once()
+			},
+			call: func(uc password.UseCase) error {
+				_, err := uc.SaveShare(ownerCtx(), pw.RequestShare{PasswordID: 7, Username: "doe", Permission: entity.ShareView})
+				return err
+			},
+			expectCode: "FORBIDDEN",
+			expectMsg:  "not allowed to do this",
+			wantErr:    true,
+		},
+		{
+			name: "Given organization that the caller is not a member of should return UC instance, " +
+				"INVALID_PAYLOAD as code and data not found as message instead of its categories",
+			setup: func(_ *mocks.MockpasswordRepository, orgs *orgMock.MockorgRepository) {
+				orgs.EXPECT().
+					GetMember(mock.Anything, orgID, owner).
+					Return(&entity.Member{}, errors.New("record not found")).
+					Once()
+			},
+			call: func(uc password.UseCase) error {
+				_, err := uc.TreeCategory(ownerCtx(), orgID)
+				return err
+			},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			orgs := new(orgMock.MockorgRepository)
+			tc.setup(h.Dep.repo, orgs)
+
+			uc := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			err := tc.call(password.NewPolicy(h.Dep.repo, orgs, uc))
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			h.Dep.repo.AssertExpectations(t)
+			orgs.AssertExpectations(t)
+		})
+	}
+}
@@ -5,34 +5,127 @@ import (
 	"mime/multipart"
 
 	pw "github.com/mdanialr/pwman_backend/internal/domain/password"
+	"github.com/mdanialr/pwman_backend/pkg/event"
 )
 
 // UseCase signature that's used in password domain for use case layer.
 type UseCase interface {
-	// IndexPassword retrieve all passwords information but omit password from
-	// response.
+	// IndexPassword retrieve all passwords information in the vault of the
+	// organization in given request, or those that the caller own or are
+	// shared to them, but omit password from response.
 	IndexPassword(ctx context.Context, req pw.Request) (*pw.IndexResponse[pw.Response], error)
 	// SavePassword create new password from given request including to make
 	// sure given category id in request does really exist.
 	SavePassword(ctx context.Context, req pw.Request) (*pw.Response, error)
-	// UpdatePassword update existing Password that match given id.
-	UpdatePassword(ctx context.Context, id uint, req pw.Request) error
+	// UpdatePassword update existing Password that match given id then
+	// return its new revision. Return CONFLICT along with the current copy if
+	// the revision in given request is stale or it's changed meanwhile.
+	UpdatePassword(ctx context.Context, id uint, req pw.Request) (uint, error)
 	// DeletePassword delete existing Password that match given id. Make sure
-	// that the given id does really exist in data source first.
-	DeletePassword(ctx context.Context, id uint) error
-	// IndexCategory retrieve all category information including the url to
-	// both image and icon.
+	// that the given id does really exist in data source first. Return
+	// CONFLICT along with the current copy if given non-zero revision is
+	// stale.
+	DeletePassword(ctx context.Context, id, revision uint) error
+	// RevealPassword retrieve the secret of existing Password that match given
+	// id. The caller should be the owner or has at least reveal permission.
+	RevealPassword(ctx context.Context, id uint) (*pw.ResponseReveal, error)
+	// ImportPassword import passwords from export of other password managers
+	// in a single transaction. Folders are mapped to categories which will be
+	// created if not exist yet. Invalid records are skipped and reported. In
+	// dry run, nothing is saved but the result is still reported.
+	ImportPassword(ctx context.Context, req pw.RequestImport) (*pw.ResponseImport, error)
+	// ScanBreach start scanning all passwords that the caller may see
+	// against known data breaches in background then flag those that has
+	// been exposed. Return error if the caller still has a running scan.
+	ScanBreach(ctx context.Context) error
+	// ScanBreachStatus return the progress of the running or the last breach
+	// scan of the caller.
+	ScanBreachStatus(ctx context.Context) *pw.ResponseScan
+	// NotifyRotation send a single reminder to each owner for all of their
+	// passwords that already expired or will expire soon. Each password is
+	// only notified once until its expiry date is changed.
+	NotifyRotation(ctx context.Context) error
+	// VisibleEvent whether the caller may receive given event, which is only
+	// true if they may access the changed password, category or tag.
+	VisibleEvent(ctx context.Context, ev event.Event) bool
+	// IndexTag retrieve all tags along with the number of passwords that use
+	// each of them.
+	IndexTag(ctx context.Context, req pw.RequestTag) (*pw.IndexResponse[pw.ResponseTag], error)
+	// SaveTag create new tag from given request. The name should be unique.
+	SaveTag(ctx context.Context, req pw.RequestTag) (*pw.ResponseTag, error)
+	// UpdateTag update existing Tag that match given id.
+	UpdateTag(ctx context.Context, id uint, req pw.RequestTag) error
+	// DeleteTag delete existing Tag that match given id and detach it from
+	// all passwords.
+	DeleteTag(ctx context.Context, id uint) error
+	// IndexCategory retrieve all category information in the vault of the
+	// organization in given request including the url to both image and
+	// icon.
 	IndexCategory(ctx context.Context, req pw.RequestCategory) (*pw.IndexResponse[pw.ResponseCategory], error)
+	// TreeCategory retrieve all categories in the vault of the organization
+	// of given id arranged as a tree. Zero id means the personal vault.
+	TreeCategory(ctx context.Context, orgID uint) ([]*pw.ResponseCategory, error)
 	// SaveCategory create new category from given request including the binary
-	// files for both image and icon fields.
+	// files for both image and icon fields. The name should be unique among
+	// the siblings.
 	SaveCategory(ctx context.Context, req pw.RequestCategory) (*pw.ResponseCategory, error)
-	// UpdateCategory update existing Category that match given id. Optionally
-	// replace either or both Image & Icon fields if provided.
-	UpdateCategory(ctx context.Context, id uint, req pw.RequestCategory) error
+	// UpdateCategory update existing Category that match given id then
+	// return its new revision. Optionally replace either or both Image & Icon
+	// fields if provided. Return CONFLICT along with the current copy if the
+	// revision in given request is stale or it's changed meanwhile.
+	UpdateCategory(ctx context.Context, id uint, req pw.RequestCategory) (uint, error)
+	// MoveCategory move existing Category that match given id along with all
+	// of its descendants under the given parent id. Zero parent id move it to
+	// the root. Make sure the new parent is not the category itself or any of
+	// its descendants.
+	MoveCategory(ctx context.Context, id, parentID uint) error
 	// DeleteCategory delete existing Category that match given id. Make sure
-	// that no Password that's still has relation to given Category. Finally
-	// remove all attached Image & Icon.
-	DeleteCategory(ctx context.Context, id uint) error
+	// that no Password nor child Category that's still has relation to given
+	// Category. Finally remove all attached Image & Icon. Return CONFLICT
+	// along with the current copy if given non-zero revision is stale.
+	DeleteCategory(ctx context.Context, id, revision uint) error
+	// IndexShare retrieve all shares that's granted by the caller, or granted
+	// to the caller if Received is set in given request.
+	IndexShare(ctx context.Context, req pw.RequestShare) ([]*pw.ResponseShare, error)
+	// SaveShare grant the user in given request the access to either a
+	// password or a whole category including its descendants. Only the owner
+	// may share it. The permission is replaced if it's already shared to the
+	// same user.
+	SaveShare(ctx context.Context, req pw.RequestShare) (*pw.ResponseShare, error)
+	// DeleteShare revoke existing Share that match given id. Either the owner
+	// or the grantee may revoke it.
+	DeleteShare(ctx context.Context, id uint) error
+	// SaveShareLink create a link that hand the secret of the password in
+	// given request to someone without an account. The secret is encrypted
+	// with a key that's only put in the fragment of the link. The caller
+	// should be allowed to reveal the password.
+	SaveShareLink(ctx context.Context, req pw.RequestShareLink) (*pw.ResponseShareLink, error)
+	// OpenShareLink return the encrypted secret of the share link that match
+	// given id. The link is destroyed once it's opened as many as allowed or
+	// expired.
+	OpenShareLink(ctx context.Context, id string) (*pw.ResponseShareLinkSecret, error)
+	// GetVaultKey retrieve the KDF parameters and the protected key of the
+	// zero-knowledge vault of the caller. Return error if it's not set up
+	// yet.
+	GetVaultKey(ctx context.Context) (*pw.ResponseVaultKey, error)
+	// SaveVaultKey set up the zero-knowledge vault of the caller, or replace
+	// the KDF parameters and the protected key once the master password is
+	// changed. The blobs are left untouched since the symmetric key is the
+	// same.
+	SaveVaultKey(ctx context.Context, req pw.RequestVaultKey) (*pw.ResponseVaultKey, error)
+	// IndexBlob retrieve all blobs of the caller that match the exposed meta
+	// in given request.
+	IndexBlob(ctx context.Context, req pw.RequestBlob) (*pw.IndexResponse[pw.ResponseBlob], error)
+	// SaveBlob store new blob that's encrypted by the client. The
+	// zero-knowledge vault of the caller should be set up first.
+	SaveBlob(ctx context.Context, req pw.RequestBlob) (*pw.ResponseBlob, error)
+	// UpdateBlob replace existing Blob that match given id then return its
+	// new revision. Return CONFLICT along with the current copy if the
+	// revision in given request is stale or it's changed meanwhile.
+	UpdateBlob(ctx context.Context, id uint, req pw.RequestBlob) (uint, error)
+	// DeleteBlob delete existing Blob that match given id. Return CONFLICT
+	// along with the current copy if given non-zero revision is stale.
+	DeleteBlob(ctx context.Context, id, revision uint) error
 	// SaveFile store given multipart to storage.Port then return filename of
 	// the stored file that's ready to be saved. Optionally append given
 	// prefix path too.
@@ -0,0 +1,221 @@
+package password_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password"
+	pwRepo "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
+	password "github.com/mdanialr/pwman_backend/internal/domain/password/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	"github.com/mdanialr/pwman_backend/pkg/migration"
+	"github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// testDSNEnv env that hold the DSN of a disposable Postgres database to run
+// the search tests against. All tables in it are dropped.
+//
+// Example:
+//
+//	PWMAN_TEST_DSN="host=localhost user=postgres password=postgres dbname=pwman_test sslmode=disable"
+const testDSNEnv = "PWMAN_TEST_DSN"
+
+// setupTestDB connect to the Postgres from testDSNEnv then migrate the tables
+// from scratch, which are emptied again once the test is done. Skip the test
+// if it's not set.
+func setupTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skip(testDSNEnv, "is not set, skip the tests against real Postgres")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:         logger.Discard,
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	require.NoError(t, err)
+	migration.Migrate(db, true)
+	t.Cleanup(func() {
+		tables, err := db.Migrator().GetTables()
+		require.NoError(t, err)
+		if len(tables) > 0 {
+			require.NoError(t, db.Exec("TRUNCATE TABLE "+strings.Join(quoteAll(tables), ", ")+" RESTART IDENTITY CASCADE").Error)
+		}
+	})
+
+	return db
+}
+
+// quoteAll quote each of given identifiers for Postgres.
+func quoteAll(names []string) []string {
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		res = append(res, `"`+strings.ReplaceAll(n, `"`, `""`)+`"`)
+	}
+	return res
+}
+
+func TestUseCase_IndexPassword_Search(t *testing.T) {
+	db := setupTestDB(t)
+
+	social := entity.Category{Name: "SOCIAL"}
+	work := entity.Category{Name: "WORK"}
+	require.NoError(t, db.Create(&social).Error)
+	require.NoError(t, db.Create(&work).Error)
+	pws := []*entity.Password{
+		{Username: "john@github.com", URL: "https://github.com", CategoryID: work.ID},
+		{Username: "jane", URL: "https://gitlab.com", CategoryID: work.ID},
+		{Username: "bob", Notes: "shared account for the staging database", CategoryID: work.ID},
+		{Username: "alice", URL: "https://facebook.com", CategoryID: social.ID},
+	}
+	require.NoError(t, db.Create(pws).Error)
+
+	testCases := []struct {
+		name        string
+		search      string
+		expectFirst string
+		notExpect   []string
+	}{
+		{
+			name:        "Given search with a typo should still find the closest username and url",
+			search:      "githb",
+			expectFirst: "john@github.com",
+			notExpect:   []string{"alice", "bob"},
+		},
+		{
+			name:        "Given partial word should match using the substring",
+			search:      "hub",
+			expectFirst: "john@github.com",
+			notExpect:   []string{"jane", "alice"},
+		},
+		{
+			name:        "Given the category name should find the passwords in that category",
+			search:      "social",
+			expectFirst: "alice",
+			notExpect:   []string{"john@github.com", "jane", "bob"},
+		},
+		{
+			name:        "Given a word in the notes should find the password",
+			search:      "stagin",
+			expectFirst: "bob",
+			notExpect:   []string{"alice"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+
+			req := pw.Request{}
+			req.Search = tc.search
+			req.Limit = 10
+			req.SetQuery()
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, pwRepo.NewRepository(db, vault.NewKeeper(0)))
+			res, err := newUC.IndexPassword(context.Background(), req)
+			require.NoError(t, err)
+			require.NotEmpty(t, res.Data)
+
+			assert.Equal(t, tc.expectFirst, res.Data[0].Username)
+			var names []string
+			for i, r := range res.Data {
+				names = append(names, r.Username)
+				// sorted by the relevance
+				require.NotNil(t, r.Relevance)
+				if i > 0 {
+					assert.LessOrEqual(t, *r.Relevance, *res.Data[i-1].Relevance)
+				}
+			}
+			for _, n := range tc.notExpect {
+				assert.NotContains(t, names, n)
+			}
+		})
+	}
+}
+
+func TestUseCase_IndexPassword_SearchOptions(t *testing.T) {
+	testCases := []struct {
+		name          string
+		favorite      bool
+		search        string
+		expectSQL     []string
+		expectOrder   string
+		expectBinding []any
+		// expectOpts the number of repo options including the default order,
+		// pagination and eager load
+		expectOpts int
+	}{
+		{
+			name:   "Given search should bind the query to every similarity and the substring to the ILIKE",
+			search: "git'hub",
+			expectSQL: []string{
+				"GREATEST(word_similarity($1, username), word_similarity($2, url), word_similarity($3, notes), " +
+					"(SELECT word_similarity($4, name) FROM category WHERE id = password.category_id)) AS relevance",
+				"($9 <% username OR $10 <% url OR $11 <% notes OR username ILIKE $12 OR url ILIKE $13 OR " +
+					"category_id IN (SELECT id FROM category WHERE $14 <% name AND deleted_at IS NULL))",
+			},
+			expectOrder: "ORDER BY relevance DESC,id ASC",
+			expectOpts:  7,
+			expectBinding: []any{
+				"git'hub", "git'hub", "git'hub", "git'hub", owner, owner, owner, owner,
+				"git'hub", "git'hub", "git'hub", "%git'hub%", "%git'hub%", "git'hub",
+			},
+		},
+		{
+			name:        "Given search along with favorite first should still sort by the favorite first",
+			favorite:    true,
+			search:      "github",
+			expectOrder: "ORDER BY favorite DESC,relevance DESC,id ASC",
+			expectOpts:  8,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			args := make([]any, tc.expectOpts+1)
+			for i := range args {
+				args[i] = mock.Anything
+			}
+			var opts []repo.Options
+			h.Dep.repo.On("FindPassword", args...).
+				Run(func(args mock.Arguments) {
+					for _, arg := range args[1:] {
+						opts = append(opts, arg.(repo.Options))
+					}
+				}).
+				Return(nil, nil).
+				Once()
+
+			req := pw.Request{FavoriteFirst: tc.favorite}
+			req.Search = tc.search
+			req.SetQuery()
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			_, err := newUC.IndexPassword(ownerCtx(), req)
+			require.NoError(t, err)
+			require.NotEmpty(t, opts)
+
+			// leave out pagination and eager load that need a real connection
+			stmt := dryRun(t, opts[:len(opts)-2]...)
+			sql := stmt.SQL.String()
+			for _, exp := range tc.expectSQL {
+				assert.Contains(t, sql, exp)
+			}
+			assert.Contains(t, sql, tc.expectOrder)
+			if tc.expectBinding != nil {
+				assert.Equal(t, tc.expectBinding, stmt.Vars)
+			}
+		})
+	}
+}
@@ -2,60 +2,166 @@ package password
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"io"
 	"mime/multipart"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	cons "github.com/mdanialr/pwman_backend/internal/constant"
 	"github.com/mdanialr/pwman_backend/internal/domain/password"
 	pw "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
 	"github.com/mdanialr/pwman_backend/internal/entity"
 	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
 	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	"github.com/mdanialr/pwman_backend/pkg/breach"
+	"github.com/mdanialr/pwman_backend/pkg/event"
 	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/importer"
+	"github.com/mdanialr/pwman_backend/pkg/notifier"
+	paginate "github.com/mdanialr/pwman_backend/pkg/pagination"
+	"github.com/mdanialr/pwman_backend/pkg/seal"
 	"github.com/mdanialr/pwman_backend/pkg/storage"
+	"github.com/mdanialr/pwman_backend/pkg/strength"
 
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+const (
+	// scanBatchSize the number of passwords that's retrieved at once while
+	// scanning all passwords.
+	scanBatchSize = 500
+	// defaultRemindBefore default number of days before the expiry date when
+	// the rotation reminder should be sent.
+	defaultRemindBefore = 7
+	// defaultImportCategory the category name for imported records that do
+	// not belong to any folder.
+	defaultImportCategory = "IMPORTED"
+)
+
+// subCategoriesQuery select the id of given categories along with all of
+// their descendants.
+const subCategoriesQuery = "WITH RECURSIVE sub AS (" +
+	"SELECT id FROM category WHERE id IN ? AND deleted_at IS NULL " +
+	"UNION SELECT c.id FROM category c JOIN sub ON c.parent_id = sub.id WHERE c.deleted_at IS NULL" +
+	") SELECT id FROM sub"
+
+// ancestorsQuery select the id of given category along with all of its
+// ancestors.
+const ancestorsQuery = "WITH RECURSIVE up AS (" +
+	"SELECT id, parent_id FROM category WHERE id = ? " +
+	"UNION SELECT c.id, c.parent_id FROM category c JOIN up ON c.id = up.parent_id" +
+	") SELECT id FROM up"
+
+// liveShareCond match shares whose password or category is not deleted yet.
+const liveShareCond = "(password_id IS NULL OR password_id IN (SELECT id FROM password WHERE deleted_at IS NULL)) AND " +
+	"(category_id IS NULL OR category_id IN (SELECT id FROM category WHERE deleted_at IS NULL))"
+
+const (
+	// searchCond match passwords whose username, url, notes or category name
+	// is similar to the search query using trigram word similarity. The
+	// ILIKE is kept, so partial words still match.
+	searchCond = "(? <% username OR ? <% url OR ? <% notes OR username ILIKE ? OR url ILIKE ? OR " +
+		"category_id IN (SELECT id FROM category WHERE ? <% name AND deleted_at IS NULL))"
+	// searchRelevance select the highest similarity score among the searched
+	// fields as the relevance.
+	searchRelevance = "*, GREATEST(word_similarity(?, username), word_similarity(?, url), word_similarity(?, notes), " +
+		"(SELECT word_similarity(?, name) FROM category WHERE id = password.category_id)) AS relevance"
+)
+
+// errDryRun returned inside import transaction to roll back the dry run.
+var errDryRun = errors.New("dry run")
+
 // NewUseCase return concrete implementation of UseCase in password domain.
-func NewUseCase(conf *viper.Viper, log *zap.Logger, st storage.Port, repo pw.Repository) UseCase {
-	return &useCase{conf: conf, log: log, st: st, repo: repo}
+func NewUseCase(conf *viper.Viper, log *zap.Logger, st storage.Port, br breach.Port, nt notifier.Port, ev event.Port, repo pw.Repository) UseCase {
+	return &useCase{conf: conf, log: log, st: st, br: br, nt: nt, ev: ev, repo: repo}
 }
 
 type useCase struct {
 	conf *viper.Viper
 	log  *zap.Logger
 	st   storage.Port
+	br   breach.Port
+	nt   notifier.Port
+	ev   event.Port
 	repo pw.Repository
+	// scan hold the state of the running or the last breach scan of each
+	// user.
+	scan struct {
+		sync.Mutex
+		byUser map[uint]*password.ResponseScan
+	}
 }
 
 func (u *useCase) IndexPassword(ctx context.Context, req password.Request) (*password.IndexResponse[password.Response], error) {
-	// set up repo options
-	opts := []repo.Options{repo.Order(req.Order + " " + req.Sort)}
-	// additionally add search option
+	// set up repo options to only include those that the caller may see
+	vault, err := vaultCond(ctx, req.OrgID, password.PasswordAccessCond)
+	if err != nil {
+		return nil, err
+	}
+	opts := []repo.Options{vault}
+	// optionally sort the favorite passwords first
+	if req.FavoriteFirst {
+		opts = append(opts, repo.Order("favorite DESC"))
+	}
+	// additionally add fuzzy search option and sort by the relevance first
 	if req.Search != "" {
-		q := "username ILIKE '%" + req.Search + "%'" // search in password
-		opts = append(opts, repo.Cons(q))
-
-		// query for all available category names
-		catQ := "name ILIKE '%" + req.Search + "%'"
-		cats, err := u.repo.FindCategories(ctx, repo.Cons(catQ))
-		if err != nil {
-			// it's optional so just log without blocking for any error
-			u.log.Error(help.Pad("failed to find categories with name:", req.Search, "and err:", err.Error()))
+		q, like := req.Search, "%"+req.Search+"%"
+		opts = append(opts,
+			repo.Select(searchRelevance, q, q, q, q),
+			repo.Where(searchCond, q, q, q, like, like, q),
+			repo.Order("relevance DESC"),
+		)
+	}
+	opts = append(opts, repo.Order(req.Order+" "+req.Sort))
+	// additionally add expiry filters
+	now := time.Now()
+	if req.Expired {
+		opts = append(opts, repo.Where("expires_at <= ?", now))
+	}
+	if req.ExpiringWithin > 0 {
+		opts = append(opts, repo.Where("expires_at > ? AND expires_at <= ?", now, now.AddDate(0, 0, req.ExpiringWithin)))
+	}
+	// additionally add category filter
+	if len(req.FilterCategory) > 0 {
+		if req.Recursive {
+			opts = append(opts, repo.Where("category_id IN ("+subCategoriesQuery+")", req.FilterCategory))
+		} else {
+			opts = append(opts, repo.Where("category_id IN ?", req.FilterCategory))
 		}
-		// optionally search by category id(s)
-		if ids := u.pluckCategoriesID(cats); ids != "" {
-			q2 := "category_id IN (" + ids + ")" // search by category id(s)
-			opts = append(opts, repo.Ors(q2))
+	}
+	// additionally add date range filters
+	opts = append(opts, dateRangeOpts("created_at", req.CreatedRange())...)
+	opts = append(opts, dateRangeOpts("updated_at", req.UpdatedRange())...)
+	// additionally add url filter
+	if req.HasURL != nil {
+		if *req.HasURL {
+			opts = append(opts, repo.Cons("COALESCE(url, '') <> ''"))
+		} else {
+			opts = append(opts, repo.Cons("COALESCE(url, '') = ''"))
 		}
 	}
-	// set up pagination in last order
-	opts = append(opts, repo.Paginate(&req.M))
+	// additionally add tag filters
+	if len(req.FilterTags) > 0 {
+		q := "id IN (SELECT password_id FROM password_tag WHERE tag_id IN ?)"
+		if req.TagMode == "all" {
+			// the password should have as many distinct matched tags as given
+			q = "id IN (SELECT password_id FROM password_tag WHERE tag_id IN ? GROUP BY password_id HAVING COUNT(DISTINCT tag_id) = ?)"
+			opts = append(opts, repo.Where(q, req.FilterTags, len(uniqueIDs(req.FilterTags))))
+		} else {
+			opts = append(opts, repo.Where(q, req.FilterTags))
+		}
+	}
+	// set up pagination in last order, then load the tags after the
+	// pagination is counted
+	opts = append(opts, repo.Paginate(&req.M), repo.EagerLoad("Tags"))
 
 	// search for all passwords that matched given conditions
 	pws, err := u.repo.FindPassword(ctx, opts...)
@@ -63,6 +169,8 @@ func (u *useCase) IndexPassword(ctx context.Context, req password.Request) (*pas
 		u.log.Error(help.Pad("failed to retrieve passwords:", err.Error()))
 		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
+	// remove the extra row of the next page if any
+	pws = paginate.Trim(&req.M, pws)
 
 	// prepare the response to contain the actual data and the pagination info
 	resp := password.NewIndexResponseFromEntity(pws)
@@ -73,76 +181,511 @@ func (u *useCase) IndexPassword(ctx context.Context, req password.Request) (*pas
 }
 
 func (u *useCase) SavePassword(ctx context.Context, req password.Request) (*password.Response, error) {
-	// make sure given category id does really exist in repo
-	_, err := u.repo.GetCategoryByID(ctx, req.Category)
+	// make sure given category id does really exist in repo and the caller
+	// may add passwords into it
+	c, err := u.repo.GetCategoryByID(ctx, req.Category)
 	if err != nil {
 		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
 	}
+	if err = u.allowCategory(ctx, c, password.RankEdit); err != nil {
+		return nil, err
+	}
+
+	// make sure all given tags does really exist in repo
+	tags, err := u.findTags(ctx, req.Tags)
+	if err != nil {
+		return nil, err
+	}
 
 	obj := entity.Password{
 		Username:   req.Username,
 		Password:   req.Password,
+		URL:        req.URL,
+		Notes:      req.Notes,
 		CategoryID: req.Category,
+		// the password always belong to the vault of its category
+		OwnerID:  c.OwnerID,
+		OrgID:    c.OrgID,
+		Strength: strength.Estimate(req.Password, req.Username).Score,
+		Breached: u.isBreached(req.Password),
+		Favorite: req.Favorite,
+		// set the expiry date based on the request
+		ExpiresAt:    expiry(req, nil, time.Now()),
+		RotationDays: req.RotationDays,
+	}
+	var newObj *entity.Password
+	if len(tags) == 0 {
+		newObj, err = u.repo.CreatePassword(ctx, obj)
+	} else {
+		// save the password along with its tags in a single transaction
+		err = u.repo.Transaction(ctx, func(tx pw.Repository) error {
+			if newObj, err = tx.CreatePassword(ctx, obj); err != nil {
+				return err
+			}
+			return tx.ReplacePasswordTags(ctx, newObj.ID, req.Tags)
+		})
 	}
-	newObj, err := u.repo.CreatePassword(ctx, obj)
 	if err != nil {
 		u.log.Error(help.Pad("failed to create new password:", err.Error()))
 		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
+	newObj.Tags = tags
+	u.publish(password.EventPasswordCreated, newObj.ID, newObj.Revision, newObj.OwnerID, newObj.OrgID)
 
 	// adapt to appropriate response
 	return password.NewResponseFromEntity(*newObj), nil
 }
 
-func (u *useCase) UpdatePassword(ctx context.Context, id uint, req password.Request) error {
+func (u *useCase) UpdatePassword(ctx context.Context, id uint, req password.Request) (uint, error) {
 	// make sure given id does really exist in repo
 	p, err := u.repo.GetPasswordByID(ctx, id)
 	if err != nil {
-		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		return 0, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if err = u.allowPassword(ctx, p, password.RankEdit); err != nil {
+		return 0, err
+	}
+	// make sure the caller edited the current revision
+	if req.Revision != 0 && req.Revision != p.Revision {
+		return 0, u.passwordConflict(ctx, p.ID)
 	}
 
-	// if new category is different then make sure that's exist in repo
+	// if new category is different then make sure that's exist in repo and
+	// the caller may add passwords into it
 	if req.Category != p.CategoryID {
-		if _, err = u.repo.GetCategoryByID(ctx, req.Category); err != nil {
-			return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		c, err := u.repo.GetCategoryByID(ctx, req.Category)
+		if err != nil {
+			return 0, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		}
+		// it should stay in the same vault
+		if c.OwnerID != p.OwnerID || !sameOrg(c.OrgID, p.OrgID) {
+			return 0, stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
 		}
+		if err = u.allowCategory(ctx, c, password.RankEdit); err != nil {
+			return 0, err
+		}
+	}
+
+	// make sure all given tags does really exist in repo
+	if _, err = u.findTags(ctx, req.Tags); err != nil {
+		return 0, err
 	}
 
 	newP := entity.Password{
 		Username:   req.Username,
 		Password:   req.Password,
+		URL:        req.URL,
+		Notes:      req.Notes,
 		CategoryID: req.Category,
+		Strength:   strength.Estimate(req.Password, req.Username).Score,
+		Breached:   u.isBreached(req.Password),
+		Favorite:   req.Favorite,
+		// reset the expiry date if the password is rotated
+		ExpiresAt:    expiry(req, p, time.Now()),
+		RotationDays: req.RotationDays,
+		Revision:     p.Revision + 1,
+	}
+	// explicitly select the fields, so zero and nil values are also updated
+	cols := []string{"username", "password", "url", "notes", "category_id", "strength", "breached", "favorite", "expires_at", "rotation_days", "revision"}
+	// also reset the reminder if the expiry date is changed
+	if !sameTime(newP.ExpiresAt, p.ExpiresAt) {
+		cols = append(cols, "notified_at")
+	}
+	// only update if nobody else changed it since it's retrieved
+	opts := []repo.Options{repo.Cols(cols...), revisionCond(p.Revision)}
+	if req.Tags == nil && req.Category == p.CategoryID {
+		_, err = u.repo.UpdatePassword(ctx, p.ID, newP, opts...)
+	} else {
+		// replace the tags and record the lost access if it's moved out of
+		// the shared categories too in a single transaction
+		err = u.repo.Transaction(ctx, func(tx pw.Repository) error {
+			if _, err := tx.UpdatePassword(ctx, p.ID, newP, opts...); err != nil {
+				return err
+			}
+			if req.Category != p.CategoryID {
+				if err := revokeMoved(ctx, tx, p.CategoryID, entity.Revocation{PasswordID: &p.ID}); err != nil {
+					return err
+				}
+			}
+			if req.Tags == nil {
+				return nil
+			}
+			return tx.ReplacePasswordTags(ctx, p.ID, req.Tags)
+		})
+	}
+	if errors.Is(err, repo.ErrNotAffected) {
+		return 0, u.passwordConflict(ctx, p.ID)
 	}
-	if _, err = u.repo.UpdatePassword(ctx, p.ID, newP); err != nil {
+	if err != nil {
 		u.log.Error(help.Pad("failed to update existing password with id:", strconv.Itoa(int(p.ID)), "and err:", err.Error()))
+		return 0, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	u.publish(password.EventPasswordUpdated, p.ID, newP.Revision, p.OwnerID, p.OrgID)
+
+	return newP.Revision, nil
+}
+
+func (u *useCase) DeletePassword(ctx context.Context, id, revision uint) error {
+	// make sure given id does really exist in repo
+	p, err := u.repo.GetPasswordByID(ctx, id, repo.Cols("id", "owner_id", "org_id", "category_id", "revision"))
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if err = u.allowPassword(ctx, p, password.RankEdit); err != nil {
+		return err
+	}
+
+	var opts []repo.Options
+	if revision != 0 {
+		// make sure the caller saw the current revision
+		if revision != p.Revision {
+			return u.passwordConflict(ctx, p.ID)
+		}
+		opts = append(opts, revisionCond(revision))
+	}
+	err = u.repo.DeletePassword(ctx, p.ID, opts...)
+	if errors.Is(err, repo.ErrNotAffected) {
+		return u.passwordConflict(ctx, p.ID)
+	}
+	if err != nil {
+		u.log.Error(help.Pad("failed to delete existing password with id:", strconv.Itoa(int(p.ID)), "and err:", err.Error()))
 		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
+	u.publish(password.EventPasswordDeleted, p.ID, 0, p.OwnerID, p.OrgID)
 
 	return nil
 }
 
-func (u *useCase) DeletePassword(ctx context.Context, id uint) error {
+func (u *useCase) RevealPassword(ctx context.Context, id uint) (*password.ResponseReveal, error) {
 	// make sure given id does really exist in repo
-	p, err := u.repo.GetPasswordByID(ctx, id, repo.Cols("id"))
+	p, err := u.repo.GetPasswordByID(ctx, id, repo.Cols("id", "password", "owner_id", "org_id", "category_id"))
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if err = u.allowPassword(ctx, p, password.RankReveal); err != nil {
+		return nil, err
+	}
+
+	return &password.ResponseReveal{ID: p.ID, Password: p.Password}, nil
+}
+
+func (u *useCase) ImportPassword(ctx context.Context, req password.RequestImport) (*password.ResponseImport, error) {
+	fl, err := req.File.Open()
+	if err != nil {
+		u.log.Error(help.Pad("failed to open import file:", req.File.Filename, "with err:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	defer fl.Close()
+	data, err := io.ReadAll(fl)
+	if err != nil {
+		u.log.Error(help.Pad("failed to read import file:", req.File.Filename, "with err:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	// parse the export based on the format
+	recs, err := importer.Parse(req.ImportFormat(), data)
+	if err != nil {
+		return nil, stderr.NewUC(cons.InvalidPayload, help.Pad("failed to parse the import file:", err.Error()))
+	}
+
+	res := &password.ResponseImport{
+		DryRun:        req.DryRun,
+		Total:         len(recs),
+		NewCategories: []string{},
+		Errors:        []*password.ResponseImportError{},
+	}
+	// everything is imported into the vault of the caller
+	owner := identity.FromContext(ctx).ID
+	// the same strength policy as saving the password one by one
+	minScore := u.conf.GetInt("policy.min_score")
+	// save all records in a single transaction, so either all or nothing is
+	// saved. Invalid records are skipped and reported instead.
+	err = u.repo.Transaction(ctx, func(tx pw.Repository) error {
+		cats := make(map[string]uint)
+		for _, rec := range recs {
+			if rec.Err != nil {
+				res.Errors = append(res.Errors, &password.ResponseImportError{Row: rec.Row, Name: rec.Name, Message: rec.Err.Error()})
+				continue
+			}
+			score := strength.Estimate(rec.Password, rec.Username).Score
+			if minScore > strength.MinScore && score < minScore {
+				msg := help.Pad("password strength is below the minimum score of", strconv.Itoa(minScore))
+				res.Errors = append(res.Errors, &password.ResponseImportError{Row: rec.Row, Name: rec.Name, Message: msg})
+				continue
+			}
+
+			catID, err := u.importCategory(ctx, tx, cats, owner, rec.Folder, res)
+			if err != nil {
+				return err
+			}
+			obj := entity.Password{
+				Username:   rec.Username,
+				Password:   rec.Password,
+				URL:        rec.URL,
+				Notes:      rec.Notes,
+				CategoryID: catID,
+				OwnerID:    owner,
+				Strength:   score,
+				Breached:   u.isBreached(rec.Password),
+			}
+			if _, err = tx.CreatePassword(ctx, obj); err != nil {
+				return err
+			}
+			res.Imported++
+		}
+
+		// roll back everything in dry run
+		if req.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		u.log.Error(help.Pad("failed to import passwords:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	res.Failed = len(res.Errors)
+	// a single event for the whole import, clients should sync afterwards
+	if !req.DryRun && res.Imported > 0 {
+		u.publish(password.EventPasswordImported, 0, 0, owner, nil)
+	}
+
+	return res, nil
+}
+
+func (u *useCase) ScanBreach(ctx context.Context) error {
+	uid := identity.FromContext(ctx).ID
+	u.scan.Lock()
+	defer u.scan.Unlock()
+
+	// make sure only one scan is running at a time for each user
+	if s := u.scan.byUser[uid]; s != nil && s.Running {
+		return stderr.NewUCErr(cons.InProgress, cons.ErrInProgress)
+	}
+	if u.scan.byUser == nil {
+		u.scan.byUser = make(map[uint]*password.ResponseScan)
+	}
+	now := time.Now()
+	s := &password.ResponseScan{Running: true, StartedAt: &now}
+	u.scan.byUser[uid] = s
+
+	// use new context, since the scan will outlive the request
+	go u.scanBreach(context.Background(), uid, s)
+
+	return nil
+}
+
+func (u *useCase) ScanBreachStatus(ctx context.Context) *password.ResponseScan {
+	u.scan.Lock()
+	defer u.scan.Unlock()
+
+	var res password.ResponseScan
+	if s := u.scan.byUser[identity.FromContext(ctx).ID]; s != nil {
+		res = *s
+	}
+	return &res
+}
+
+func (u *useCase) NotifyRotation(ctx context.Context) error {
+	before := defaultRemindBefore
+	if u.conf.IsSet("rotation.remind_before") {
+		before = u.conf.GetInt("rotation.remind_before")
+	}
+	now := time.Now()
+
+	// search for passwords that will expire soon and not notified yet
+	pws, err := u.repo.FindPassword(ctx,
+		repo.Cols("id", "username", "category_id", "owner_id", "expires_at"),
+		repo.Where("expires_at <= ? AND notified_at IS NULL", now.AddDate(0, 0, before)),
+		repo.Order("expires_at ASC"),
+	)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve passwords that need rotation:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(pws) == 0 {
+		return nil
+	}
+
+	// group them per owner, so each owner only receive their own passwords
+	var owners []uint
+	perOwner := make(map[uint][]*entity.Password)
+	for _, p := range pws {
+		if _, ok := perOwner[p.OwnerID]; !ok {
+			owners = append(owners, p.OwnerID)
+		}
+		perOwner[p.OwnerID] = append(perOwner[p.OwnerID], p)
+	}
+	usrs, err := u.repo.FindUsers(ctx, repo.Cols("id", "username"), repo.Where("id IN ?", owners))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve the owners of passwords that need rotation:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	usernames := make(map[uint]string, len(usrs))
+	for _, usr := range usrs {
+		usernames[usr.ID] = usr.Username
+	}
+
+	var failed bool
+	for _, owner := range owners {
+		msg := rotationMessage(perOwner[owner], now)
+		msg.Recipient = usernames[owner]
+		if err = u.nt.Notify(ctx, msg); err != nil {
+			u.log.Error(help.Pad("failed to send rotation reminder to user with id:", strconv.Itoa(int(owner)), "and err:", err.Error()))
+			failed = true
+			continue
+		}
+
+		// mark them as notified, so they will not be notified again until the
+		// expiry date is changed
+		for _, p := range perOwner[owner] {
+			obj := entity.Password{NotifiedAt: &now}
+			if _, err = u.repo.UpdatePassword(ctx, p.ID, obj, repo.Cols("notified_at"), repo.Omit("updated_at")); err != nil {
+				u.log.Error(help.Pad("failed to mark password with id:", strconv.Itoa(int(p.ID)), "as notified and err:", err.Error()))
+			}
+		}
+	}
+	if failed {
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return nil
+}
+
+func (u *useCase) VisibleEvent(ctx context.Context, ev event.Event) bool {
+	data, ok := ev.Data.(password.EventData)
+	uid := identity.FromContext(ctx).ID
+	if !ok || uid == 0 {
+		return false
+	}
+	if data.Org == 0 && data.Owner == uid {
+		return true
+	}
+
+	// otherwise it should be shared to the caller or in the vault of their
+	// organizations, including those that's already deleted
+	var err error
+	switch {
+	case data.ID == 0:
+		return false
+	case strings.HasPrefix(ev.Type, "password."):
+		_, err = u.repo.GetPasswordByID(ctx, data.ID, repo.Cols("id"), repo.Unscoped(), password.PasswordVaultsCond(uid))
+	case strings.HasPrefix(ev.Type, "category."):
+		_, err = u.repo.GetCategoryByID(ctx, data.ID, repo.Cols("id"), repo.Unscoped(), password.CategoryVaultsCond(uid))
+	default:
+		// tags are never shared
+		return false
+	}
+	return err == nil
+}
+
+func (u *useCase) IndexTag(ctx context.Context, req password.RequestTag) (*password.IndexResponse[password.ResponseTag], error) {
+	// set up repo options to only include the tags of the caller
+	opts := []repo.Options{tagOwnerCond(ctx), repo.Order(req.Order + " " + req.Sort)}
+	// additionally add search option
+	if req.Search != "" {
+		opts = append(opts, repo.Where("name ILIKE ?", "%"+req.Search+"%"))
+	}
+	// set up pagination in last order
+	opts = append(opts, repo.Paginate(&req.M))
+
+	// search for all tags that matched given conditions
+	tags, err := u.repo.FindTags(ctx, opts...)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve tags:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	// remove the extra row of the next page if any
+	tags = paginate.Trim(&req.M, tags)
+	// count the usage of the retrieved tags
+	var usage map[uint]int
+	if len(tags) > 0 {
+		ids := make([]uint, 0, len(tags))
+		for _, t := range tags {
+			ids = append(ids, t.ID)
+		}
+		if usage, err = u.repo.CountTagUsage(ctx, ids...); err != nil {
+			u.log.Error(help.Pad("failed to count tag usage:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+	}
+
+	// prepare the response to contain the actual data and the pagination info
+	resp := password.NewIndexResponseTagFromEntity(tags, usage)
+	resp.Pagination = &req.M
+	resp.Pagination.Paginate()
+
+	return resp, nil
+}
+
+func (u *useCase) SaveTag(ctx context.Context, req password.RequestTag) (*password.ResponseTag, error) {
+	// make sure given tag name not used yet by the caller
+	t, _ := u.repo.GetTagByID(ctx, 0, repo.Cols("id"), tagOwnerCond(ctx), repo.Where("name = ?", req.Name))
+	if t.ID != 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
+	}
+
+	newObj, err := u.repo.CreateTag(ctx, entity.Tag{OwnerID: identity.FromContext(ctx).ID, Name: req.Name, Color: req.Color})
+	if err != nil {
+		u.log.Error(help.Pad("failed to create new tag:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	u.publish(password.EventTagCreated, newObj.ID, 0, newObj.OwnerID, nil)
+
+	return password.NewResponseTagFromEntity(*newObj), nil
+}
+
+func (u *useCase) UpdateTag(ctx context.Context, id uint, req password.RequestTag) error {
+	// retrieve tag of the caller from repo using given id
+	t, err := u.repo.GetTagByID(ctx, id, tagOwnerCond(ctx))
 	if err != nil {
 		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
 	}
+	// make sure the new name is not taken yet by other tag
+	if t.Name != req.Name {
+		oldT, _ := u.repo.GetTagByID(ctx, 0, repo.Cols("id"), tagOwnerCond(ctx), repo.Where("name = ?", req.Name))
+		if oldT.ID != 0 {
+			return stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
+		}
+	}
 
-	if err = u.repo.DeletePassword(ctx, p.ID); err != nil {
-		u.log.Error(help.Pad("failed to delete existing password with id:", strconv.Itoa(int(p.ID)), "and err:", err.Error()))
+	obj := entity.Tag{Name: req.Name, Color: req.Color}
+	if _, err = u.repo.UpdateTag(ctx, t.ID, obj, repo.Cols("name", "color")); err != nil {
+		u.log.Error(help.Pad("failed to update existing tag with id:", strconv.Itoa(int(t.ID)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	u.publish(password.EventTagUpdated, t.ID, 0, identity.FromContext(ctx).ID, nil)
+
+	return nil
+}
+
+func (u *useCase) DeleteTag(ctx context.Context, id uint) error {
+	// make sure given id does really exist in repo and belong to the caller
+	t, err := u.repo.GetTagByID(ctx, id, repo.Cols("id"), tagOwnerCond(ctx))
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+
+	if err = u.repo.DeleteTag(ctx, t.ID); err != nil {
+		u.log.Error(help.Pad("failed to delete existing tag with id:", strconv.Itoa(int(t.ID)), "and err:", err.Error()))
 		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
+	u.publish(password.EventTagDeleted, t.ID, 0, identity.FromContext(ctx).ID, nil)
 
 	return nil
 }
 
 func (u *useCase) IndexCategory(ctx context.Context, req password.RequestCategory) (*password.IndexResponse[password.ResponseCategory], error) {
-	// set up repo options
-	opts := []repo.Options{repo.Order(req.Order + " " + req.Sort)}
+	// set up repo options to only include those that the caller may see
+	vault, err := vaultCond(ctx, req.OrgID, password.CategoryAccessCond)
+	if err != nil {
+		return nil, err
+	}
+	opts := []repo.Options{vault, repo.Order(req.Order + " " + req.Sort)}
 	// additionally add search option
 	if req.Search != "" {
-		q := "name ILIKE '%" + req.Search + "%'"
-		opts = append(opts, repo.Cons(q))
+		opts = append(opts, repo.Where("name ILIKE ?", "%"+req.Search+"%"))
 	}
 	// set up pagination in last order
 	opts = append(opts, repo.Paginate(&req.M))
@@ -153,6 +696,8 @@ func (u *useCase) IndexCategory(ctx context.Context, req password.RequestCategor
 		u.log.Error(help.Pad("failed to retrieve categories:", err.Error()))
 		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
+	// remove the extra row of the next page if any
+	cats = paginate.Trim(&req.M, cats)
 
 	// prepare the response to contain the actual data and the pagination info
 	resp := password.NewIndexResponseCategoryFromEntity(cats, u.conf.GetString("storage.url"))
@@ -163,9 +708,29 @@ func (u *useCase) IndexCategory(ctx context.Context, req password.RequestCategor
 }
 
 func (u *useCase) SaveCategory(ctx context.Context, req password.RequestCategory) (*password.ResponseCategory, error) {
-	// make sure given category name not used yet in data store
-	cond := "name = '" + req.Name + "'"
-	c, _ := u.repo.GetCategoryByID(ctx, 0, repo.Cols("id"), repo.Cons(cond))
+	// by default it's saved into the personal vault of the caller
+	owner, org := identity.FromContext(ctx).ID, (*uint)(nil)
+	if req.ParentID != 0 {
+		// make sure given parent id does really exist in repo and owned by
+		// the caller
+		parent, err := u.repo.GetCategoryByID(ctx, req.ParentID, repo.Cols("id", "owner_id", "org_id"))
+		if err != nil {
+			return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		}
+		if err = u.allowCategory(ctx, parent, password.RankOwner); err != nil {
+			return nil, err
+		}
+		// the child always belong to the vault of its parent
+		owner, org = parent.OwnerID, parent.OrgID
+	} else if req.OrgID != 0 {
+		// make sure the caller is allowed into the vault of the organization
+		if !isGranted(ctx, req.OrgID) {
+			return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		}
+		owner, org = 0, &req.OrgID
+	}
+	// make sure given category name not used yet by the siblings
+	c, _ := u.repo.GetCategoryByID(ctx, 0, repo.Cols("id"), siblingCond(owner, org, req.Name, req.ParentID))
 	// return error if already exist
 	if c.ID != 0 {
 		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
@@ -186,6 +751,9 @@ func (u *useCase) SaveCategory(ctx context.Context, req password.RequestCategory
 
 	// save the category to data store
 	obj := entity.Category{
+		OwnerID:   owner,
+		OrgID:     org,
+		ParentID:  parentPtr(req.ParentID),
 		Name:      req.Name,
 		IconPath:  ico,
 		ImagePath: img,
@@ -195,6 +763,7 @@ func (u *useCase) SaveCategory(ctx context.Context, req password.RequestCategory
 		u.log.Error(help.Pad("failed to create new category:", err.Error()))
 		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
+	u.publish(password.EventCategoryCreated, newObj.ID, newObj.Revision, newObj.OwnerID, newObj.OrgID)
 
 	// adapt to appropriate response
 	resp := password.NewResponseCategoryFromEntity(*newObj, u.conf.GetString("storage.url"))
@@ -202,27 +771,33 @@ func (u *useCase) SaveCategory(ctx context.Context, req password.RequestCategory
 	return resp, nil
 }
 
-func (u *useCase) UpdateCategory(ctx context.Context, id uint, req password.RequestCategory) error {
+func (u *useCase) UpdateCategory(ctx context.Context, id uint, req password.RequestCategory) (uint, error) {
 	// retrieve category from repo using given id
 	c, err := u.repo.GetCategoryByID(ctx, id)
 	if err != nil {
 		// throw error if category not found
-		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		return 0, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if err = u.allowCategory(ctx, c, password.RankEdit); err != nil {
+		return 0, err
+	}
+	// make sure the caller edited the current revision
+	if req.Revision != 0 && req.Revision != c.Revision {
+		return 0, u.categoryConflict(ctx, c.ID)
 	}
 	// do additional validation if the name from request and from repo is different
 	if c.Name != req.Name {
-		// make sure it's unique and not taken yet
-		cond := "name = '" + req.Name + "'"
-		oldC, _ := u.repo.GetCategoryByID(ctx, 0, repo.Cols("id"), repo.Cons(cond))
+		// make sure it's unique among the siblings and not taken yet
+		oldC, _ := u.repo.GetCategoryByID(ctx, 0, repo.Cols("id"), siblingCond(c.OwnerID, c.OrgID, req.Name, parentOf(c)))
 		// return error if already exist
 		if oldC.ID != 0 {
-			return stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
+			return 0, stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
 		}
 	}
 
 	// record the updated fields
-	updatedFields := []string{"name"}
-	newCategory := entity.Category{Name: req.Name}
+	updatedFields := []string{"name", "revision"}
+	newCategory := entity.Category{Name: req.Name, Revision: c.Revision + 1}
 
 	// update Image if provided
 	if req.Image != nil {
@@ -231,7 +806,7 @@ func (u *useCase) UpdateCategory(ctx context.Context, id uint, req password.Requ
 		img, err := u.SaveFile(req.Image)
 		if err != nil {
 			u.log.Error(help.Pad("failed to save image:", req.Image.Filename, "with err:", err.Error()))
-			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+			return 0, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 		}
 		newCategory.ImagePath = img
 	}
@@ -242,31 +817,45 @@ func (u *useCase) UpdateCategory(ctx context.Context, id uint, req password.Requ
 		icon, err := u.SaveFile(req.Icon)
 		if err != nil {
 			u.log.Error(help.Pad("failed to save image:", req.Icon.Filename, "with err:", err.Error()))
-			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+			return 0, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 		}
 		newCategory.IconPath = icon
 	}
 
-	if _, err := u.repo.UpdateCategory(ctx, c.ID, newCategory, repo.Cols(updatedFields...)); err != nil {
+	// only update if nobody else changed it since it's retrieved
+	_, err = u.repo.UpdateCategory(ctx, c.ID, newCategory, repo.Cols(updatedFields...), revisionCond(c.Revision))
+	if errors.Is(err, repo.ErrNotAffected) {
+		return 0, u.categoryConflict(ctx, c.ID)
+	}
+	if err != nil {
 		u.log.Error(help.Pad("failed to update existing category with id:", strconv.Itoa(int(c.ID)), "and err:", err.Error()))
-		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		return 0, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
 
+	u.publish(password.EventCategoryUpdated, c.ID, newCategory.Revision, c.OwnerID, c.OrgID)
+
 	// lastly remove the old image & icon
 	go u.removeOldMedia(*c, updatedFields...)
 
-	return nil
+	return newCategory.Revision, nil
 }
 
-func (u *useCase) DeleteCategory(ctx context.Context, id uint) error {
+func (u *useCase) DeleteCategory(ctx context.Context, id, revision uint) error {
 	// make sure given id does really exist in repo
 	c, err := u.repo.GetCategoryByID(ctx, id)
 	if err != nil {
 		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
 	}
+	if err = u.allowCategory(ctx, c, password.RankOwner); err != nil {
+		return err
+	}
+	// make sure the caller saw the current revision
+	if revision != 0 && revision != c.Revision {
+		return u.categoryConflict(ctx, c.ID)
+	}
 
 	// make sure no Password still attached to this category
-	cats, err := u.repo.FindPassword(ctx, repo.Cons("category_id = "+strconv.Itoa(int(c.ID))))
+	cats, err := u.repo.FindPassword(ctx, repo.Cols("id"), repo.Cons("category_id = "+strconv.Itoa(int(c.ID))))
 	if err != nil {
 		u.log.Error(help.Pad("failed to retrieve categories:", err.Error()))
 		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
@@ -274,11 +863,29 @@ func (u *useCase) DeleteCategory(ctx context.Context, id uint) error {
 	if len(cats) > 0 {
 		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrDataInUse)
 	}
+	// make sure no child Category still attached to this category
+	children, err := u.repo.FindCategories(ctx, repo.Cols("id"), repo.Where("parent_id = ?", c.ID), repo.Limit(1))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve child categories:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(children) > 0 {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrDataInUse)
+	}
 
-	if err = u.repo.DeleteCategory(ctx, c.ID); err != nil {
+	var opts []repo.Options
+	if revision != 0 {
+		opts = append(opts, revisionCond(revision))
+	}
+	err = u.repo.DeleteCategory(ctx, c.ID, opts...)
+	if errors.Is(err, repo.ErrNotAffected) {
+		return u.categoryConflict(ctx, c.ID)
+	}
+	if err != nil {
 		u.log.Error(help.Pad("failed to delete existing category with id:", strconv.Itoa(int(c.ID)), "and err:", err.Error()))
 		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
 	}
+	u.publish(password.EventCategoryDeleted, c.ID, 0, c.OwnerID, c.OrgID)
 
 	// lastly remove the old image & icon
 	go u.removeOldMedia(*c, "image_path", "icon_path")
@@ -286,6 +893,275 @@ func (u *useCase) DeleteCategory(ctx context.Context, id uint) error {
 	return nil
 }
 
+func (u *useCase) TreeCategory(ctx context.Context, orgID uint) ([]*password.ResponseCategory, error) {
+	vault, err := vaultCond(ctx, orgID, password.CategoryAccessCond)
+	if err != nil {
+		return nil, err
+	}
+	cats, err := u.repo.FindCategories(ctx, vault, repo.Order("name ASC"))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve categories:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return password.NewTreeResponseCategoryFromEntity(cats, u.conf.GetString("storage.url")), nil
+}
+
+func (u *useCase) MoveCategory(ctx context.Context, id, parentID uint) error {
+	// make sure given id does really exist in repo
+	c, err := u.repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if err = u.allowCategory(ctx, c, password.RankOwner); err != nil {
+		return err
+	}
+
+	if parentID != 0 {
+		// make sure the new parent does really exist in repo and owned by the
+		// caller too
+		parent, err := u.repo.GetCategoryByID(ctx, parentID, repo.Cols("id", "owner_id", "org_id"))
+		if err != nil {
+			return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		}
+		if err = u.allowCategory(ctx, parent, password.RankOwner); err != nil {
+			return err
+		}
+		// it should stay in the same vault
+		if parent.OwnerID != c.OwnerID || !sameOrg(parent.OrgID, c.OrgID) {
+			return stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+		}
+	}
+
+	// lock the category and the ancestors of the new parent until it's moved,
+	// so concurrent moves can never end up in a cycle
+	obj := entity.Category{ParentID: parentPtr(parentID), Revision: c.Revision + 1}
+	err = u.repo.Transaction(ctx, func(tx pw.Repository) error {
+		if _, err := tx.GetCategoryByID(ctx, c.ID, repo.Cols("id"), repo.ForUpdate()); err != nil {
+			return err
+		}
+		// make sure the new parent is not the category itself or any of its
+		// descendants, which means the category is one of its ancestors
+		if parentID != 0 {
+			ups, err := tx.FindCategories(ctx, repo.Cols("id"), repo.Where("id IN ("+ancestorsQuery+")", parentID), repo.ForUpdate())
+			if err != nil {
+				return err
+			}
+			for _, up := range ups {
+				if up.ID == c.ID {
+					return stderr.NewUCErr(cons.InvalidPayload, cons.ErrCyclicParent)
+				}
+			}
+		}
+
+		// make sure the name is not taken yet by the new siblings
+		oldC, _ := tx.GetCategoryByID(ctx, 0, repo.Cols("id"), siblingCond(c.OwnerID, c.OrgID, c.Name, parentID))
+		if oldC.ID != 0 && oldC.ID != c.ID {
+			return stderr.NewUCErr(cons.InvalidPayload, cons.ErrAlreadyExist)
+		}
+
+		// the descendants follow along since they refer to this category
+		if _, err := tx.UpdateCategory(ctx, c.ID, obj, repo.Cols("parent_id", "revision"), revisionCond(c.Revision)); err != nil {
+			return err
+		}
+		if parentOf(c) == 0 || parentOf(c) == parentID {
+			return nil
+		}
+		return revokeMoved(ctx, tx, parentOf(c), entity.Revocation{CategoryID: &c.ID})
+	})
+	if e, ok := err.(*stderr.UC); ok {
+		return e
+	}
+	if errors.Is(err, repo.ErrNotAffected) {
+		return u.categoryConflict(ctx, c.ID)
+	}
+	if err != nil {
+		u.log.Error(help.Pad("failed to move category with id:", strconv.Itoa(int(c.ID)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	u.publish(password.EventCategoryUpdated, c.ID, obj.Revision, c.OwnerID, c.OrgID)
+
+	return nil
+}
+
+func (u *useCase) IndexShare(ctx context.Context, req password.RequestShare) ([]*password.ResponseShare, error) {
+	uid := identity.FromContext(ctx).ID
+	// either the ones that's granted by or to the caller
+	opts := []repo.Options{repo.Where("owner_id = ?", uid)}
+	if req.Received {
+		opts = []repo.Options{repo.Where("grantee_id = ?", uid)}
+	}
+	// additionally filter by the shared password or category
+	if req.PasswordID != 0 {
+		opts = append(opts, repo.Where("password_id = ?", req.PasswordID))
+	}
+	if req.CategoryID != 0 {
+		opts = append(opts, repo.Where("category_id = ?", req.CategoryID))
+	}
+	opts = append(opts, repo.Cons(liveShareCond), repo.Order("id ASC"), repo.EagerLoad("Owner"), repo.EagerLoad("Grantee"))
+
+	shares, err := u.repo.FindShares(ctx, opts...)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve shares:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	res := make([]*password.ResponseShare, 0, len(shares))
+	for _, sh := range shares {
+		res = append(res, password.NewResponseShareFromEntity(*sh))
+	}
+	return res, nil
+}
+
+func (u *useCase) SaveShare(ctx context.Context, req password.RequestShare) (*password.ResponseShare, error) {
+	uid := identity.FromContext(ctx).ID
+	obj := entity.Share{OwnerID: uid, Permission: req.Permission}
+
+	// make sure the shared password or category does really exist in repo
+	// and only the owner may share it
+	var target repo.Options
+	if req.PasswordID != 0 {
+		p, err := u.repo.GetPasswordByID(ctx, req.PasswordID, repo.Cols("id", "owner_id", "org_id", "category_id"))
+		if err != nil {
+			return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		}
+		if err = u.allowPassword(ctx, p, password.RankOwner); err != nil {
+			return nil, err
+		}
+		obj.PasswordID, target = &p.ID, repo.Where("password_id = ?", p.ID)
+	} else {
+		c, err := u.repo.GetCategoryByID(ctx, req.CategoryID, repo.Cols("id", "owner_id", "org_id"))
+		if err != nil {
+			return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+		}
+		if err = u.allowCategory(ctx, c, password.RankOwner); err != nil {
+			return nil, err
+		}
+		obj.CategoryID, target = &c.ID, repo.Where("category_id = ?", c.ID)
+	}
+
+	// make sure the grantee does really exist in repo
+	grantee, err := u.repo.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if grantee.ID == uid {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrSelfShare)
+	}
+	obj.GranteeID = grantee.ID
+
+	// replace the permission if it's already shared to the same user
+	old, err := u.repo.FindShares(ctx, repo.Cols("id", "created_at"), repo.Where("grantee_id = ?", grantee.ID), target, repo.Limit(1))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve shares:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	newObj := &obj
+	if len(old) > 0 {
+		_, err = u.repo.UpdateShare(ctx, old[0].ID, entity.Share{Permission: req.Permission}, repo.Cols("permission"))
+		obj.ID, obj.CreatedAt = old[0].ID, old[0].CreatedAt
+	} else {
+		newObj, err = u.repo.CreateShare(ctx, obj)
+	}
+	if err != nil {
+		u.log.Error(help.Pad("failed to save share:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	newObj.Grantee = grantee
+
+	return password.NewResponseShareFromEntity(*newObj), nil
+}
+
+func (u *useCase) DeleteShare(ctx context.Context, id uint) error {
+	uid := identity.FromContext(ctx).ID
+	// make sure given id does really exist in repo and either granted by or
+	// to the caller
+	sh, err := u.repo.GetShareByID(ctx, id, repo.Cols("id", "owner_id", "grantee_id", "password_id", "category_id"))
+	if err != nil || (sh.OwnerID != uid && sh.GranteeID != uid) {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+
+	if err = u.repo.DeleteShare(ctx, *sh); err != nil {
+		u.log.Error(help.Pad("failed to delete existing share with id:", strconv.Itoa(int(sh.ID)), "and err:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return nil
+}
+
+func (u *useCase) SaveShareLink(ctx context.Context, req password.RequestShareLink) (*password.ResponseShareLink, error) {
+	// only those who may reveal the password may hand it over
+	sec, err := u.RevealPassword(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	// the key is only put in the link and never stored
+	ct, key, err := seal.Seal([]byte(sec.Password))
+	if err != nil {
+		u.log.Error(help.Pad("failed to seal password with id:", strconv.Itoa(int(sec.ID)), "and err:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	now := time.Now()
+	// destroy the expired links that's never opened along the way
+	if err = u.repo.DeleteShareLinks(ctx, repo.Where("expires_at <= ?", now)); err != nil {
+		u.log.Error(help.Pad("failed to delete expired share links:", err.Error()))
+	}
+
+	obj := entity.ShareLink{
+		ID:         uuid.NewString(),
+		PasswordID: sec.ID,
+		CreatorID:  identity.FromContext(ctx).ID,
+		Ciphertext: ct,
+		ViewsLeft:  req.ViewCount(),
+		ExpiresAt:  now.Add(req.Expiry()),
+	}
+	newObj, err := u.repo.CreateShareLink(ctx, obj)
+	if err != nil {
+		u.log.Error(help.Pad("failed to create new share link:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return &password.ResponseShareLink{
+		ID:        newObj.ID,
+		URL:       strings.TrimSuffix(u.conf.GetString("share_link.url"), "/") + "/s/" + newObj.ID + "#" + base64.RawURLEncoding.EncodeToString(key),
+		Views:     newObj.ViewsLeft,
+		ExpiresAt: newObj.ExpiresAt,
+	}, nil
+}
+
+func (u *useCase) OpenShareLink(ctx context.Context, id string) (*password.ResponseShareLinkSecret, error) {
+	var l *entity.ShareLink
+	now := time.Now()
+	// lock the link, so it's not opened more than allowed at the same time
+	err := u.repo.Transaction(ctx, func(tx pw.Repository) error {
+		found, err := tx.GetShareLinkByID(ctx, id, repo.ForUpdate())
+		if err != nil {
+			// it's never created or already destroyed
+			return nil
+		}
+		// destroy it once it's used up or expired
+		if found.ViewsLeft <= 1 || !found.ExpiresAt.After(now) {
+			err = tx.DeleteShareLinks(ctx, repo.Where("id = ?", found.ID))
+		} else {
+			err = tx.UpdateShareLink(ctx, found.ID, entity.ShareLink{ViewsLeft: found.ViewsLeft - 1}, repo.Cols("views_left"))
+		}
+		if found.ExpiresAt.After(now) {
+			l = found
+		}
+		return err
+	})
+	if err != nil {
+		u.log.Error(help.Pad("failed to open share link:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if l == nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+
+	return &password.ResponseShareLinkSecret{Ciphertext: l.Ciphertext, ViewsLeft: l.ViewsLeft - 1, ExpiresAt: l.ExpiresAt}, nil
+}
+
 func (u *useCase) SaveFile(f *multipart.FileHeader, prefix ...string) (string, error) {
 	fl, err := f.Open()
 	if err != nil {
@@ -327,13 +1203,346 @@ func (u *useCase) removeOldMedia(c entity.Category, fields ...string) {
 	}
 }
 
-// pluckCategoriesID pluck ids from given a bunch of entity.Category then
-// join them using , as the separator. e.g '1,2,3'
-func (u *useCase) pluckCategoriesID(cats []*entity.Category) string {
-	var ids []string
+// scanBreach check all passwords that given user may see in batches against
+// breach.Port then update the breached flag for those that has changed. Also
+// record the progress in given s.
+func (u *useCase) scanBreach(ctx context.Context, uid uint, s *password.ResponseScan) {
+	err := pw.EachPassword(ctx, u.repo, scanBatchSize, func(pws []*entity.Password) error {
+		for _, p := range pws {
+			breached := u.isBreached(p.Password)
+			if breached != p.Breached {
+				obj := entity.Password{Breached: breached}
+				// omit updated_at, so it's still reflect the last rotation
+				if _, err := u.repo.UpdatePassword(ctx, p.ID, obj, repo.Cols("breached"), repo.Omit("updated_at")); err != nil {
+					return err
+				}
+			}
+
+			u.scan.Lock()
+			s.Scanned++
+			if breached {
+				s.Breached++
+			}
+			u.scan.Unlock()
+		}
+		return nil
+	}, repo.Cols("id", "password", "breached"), password.PasswordVaultsCond(uid))
+	if err != nil {
+		u.log.Error(help.Pad("failed to scan passwords for breaches:", err.Error()))
+	}
+
+	u.scan.Lock()
+	now := time.Now()
+	s.Running = false
+	s.FinishedAt = &now
+	u.scan.Unlock()
+}
+
+// importCategory return the id of category of given owner for given folder
+// name which is normalized the same way as RequestCategory. Create new
+// category if it does not exist yet and record it in given response. Given
+// cache is used to prevent looking up the same category repeatedly.
+func (u *useCase) importCategory(ctx context.Context, tx pw.Repository, cache map[string]uint, owner uint, folder string, res *password.ResponseImport) (uint, error) {
+	reqCat := password.RequestCategory{Name: folder}
+	if reqCat.Name == "" {
+		reqCat.Name = defaultImportCategory
+	}
+	reqCat.NormalizeName()
+
+	if id, ok := cache[reqCat.Name]; ok {
+		return id, nil
+	}
+
+	// use the existing category if any
+	c, _ := tx.GetCategoryByID(ctx, 0, repo.Cols("id"), siblingCond(owner, nil, reqCat.Name, 0))
+	if c == nil || c.ID == 0 {
+		newC, err := tx.CreateCategory(ctx, entity.Category{OwnerID: owner, Name: reqCat.Name})
+		if err != nil {
+			return 0, err
+		}
+		c = newC
+		res.NewCategories = append(res.NewCategories, reqCat.Name)
+	}
+
+	cache[reqCat.Name] = c.ID
+	return c.ID, nil
+}
 
-	for _, cat := range cats {
-		ids = append(ids, strconv.Itoa(int(cat.ID)))
+// isBreached check given plaintext password against breach.Port. Just log if
+// there is any error and regard it as not breached.
+func (u *useCase) isBreached(pass string) bool {
+	cnt, err := u.br.Count(pass)
+	if err != nil {
+		u.log.Error(help.Pad("failed to check password for breaches:", err.Error()))
+		return false
+	}
+	return cnt > 0
+}
+
+// expiry decide when the password should be rotated. Explicit expiry date from
+// request take precedence over the rotation interval. The old expiry date from
+// given existing password, if any, is kept unless the password is rotated or
+// the rotation interval is changed.
+func expiry(req password.Request, old *entity.Password, now time.Time) *time.Time {
+	if req.ExpiresAt != nil {
+		return req.ExpiresAt
+	}
+	if req.RotationDays < 1 {
+		return nil
+	}
+	if old != nil && old.ExpiresAt != nil && old.Password == req.Password && old.RotationDays == req.RotationDays {
+		return old.ExpiresAt
+	}
+	exp := now.AddDate(0, 0, req.RotationDays)
+	return &exp
+}
+
+// sameTime whether both given time are nil or equal.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// sameOrg whether both given organization ids are nil or equal.
+func sameOrg(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// rotationMessage build the rotation reminder for given passwords.
+func rotationMessage(pws []*entity.Password, now time.Time) notifier.Message {
+	var b strings.Builder
+	b.WriteString("The following passwords should be rotated:\n")
+	for _, p := range pws {
+		b.WriteString(help.Pad("-", "#"+strconv.Itoa(int(p.ID)), p.Username, "in category", strconv.Itoa(int(p.CategoryID))))
+		if p.ExpiresAt.After(now) {
+			b.WriteString(" will expire at " + p.ExpiresAt.Format(time.DateTime) + "\n")
+			continue
+		}
+		b.WriteString(" has expired at " + p.ExpiresAt.Format(time.DateTime) + "\n")
+	}
+
+	return notifier.Message{
+		Subject: strconv.Itoa(len(pws)) + " password(s) need rotation",
+		Body:    b.String(),
+	}
+}
+
+// dateRangeOpts return repo options that filter given column using given
+// range. Return nothing if the range is open on both sides.
+func dateRangeOpts(col string, r password.DateRange) []repo.Options {
+	var opts []repo.Options
+	if r.From != nil {
+		opts = append(opts, repo.Where(col+" >= ?", *r.From))
+	}
+	if r.To != nil {
+		opts = append(opts, repo.Where(col+" <= ?", *r.To))
+	}
+	return opts
+}
+
+// publish notify the subscribers that the data of given id, which is owned by
+// given user id or organization id, is changed.
+func (u *useCase) publish(typ string, id, rev, owner uint, org *uint) {
+	data := password.EventData{ID: id, Revision: rev, Owner: owner}
+	if org != nil {
+		data.Owner, data.Org = 0, *org
+	}
+	u.ev.Publish(typ, data)
+}
+
+// revisionCond return repo option that only match the row whose revision is
+// still the given one.
+func revisionCond(rev uint) repo.Options {
+	return repo.Where("revision = ?", rev)
+}
+
+// passwordConflict return CONFLICT error along with the current copy of the
+// password that match given id. Return not found instead if it's deleted
+// meanwhile.
+func (u *useCase) passwordConflict(ctx context.Context, id uint) error {
+	p, err := u.repo.GetPasswordByID(ctx, id, repo.EagerLoad("Tags"))
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	return stderr.NewUCErrDetail(cons.Conflict, cons.ErrStaleRevision, password.NewResponseFromEntity(*p))
+}
+
+// categoryConflict return CONFLICT error along with the current copy of the
+// category that match given id. Return not found instead if it's deleted
+// meanwhile.
+func (u *useCase) categoryConflict(ctx context.Context, id uint) error {
+	c, err := u.repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	return stderr.NewUCErrDetail(cons.Conflict, cons.ErrStaleRevision, password.NewResponseCategoryFromEntity(*c, u.conf.GetString("storage.url")))
+}
+
+// allowPassword make sure the caller has at least given rank to given
+// password, either as the owner or through the shares of the password itself,
+// its category or any of the ancestors. Return not found if the caller has no
+// access at all, so its existence is not revealed. Those in the vault of an
+// organization are only allowed if the policy has granted the caller to it.
+func (u *useCase) allowPassword(ctx context.Context, p *entity.Password, need int) error {
+	if p.OrgID != nil {
+		return allowOrg(ctx, *p.OrgID)
+	}
+	uid := identity.FromContext(ctx).ID
+	if uid != 0 && p.OwnerID == uid {
+		return nil
+	}
+	cond := repo.Where("grantee_id = ? AND (password_id = ? OR category_id IN ("+ancestorsQuery+"))", uid, p.ID, p.CategoryID)
+	return u.allowShared(ctx, uid, p.OwnerID, need, cond)
+}
+
+// allowCategory same as allowPassword but for given category, which is shared
+// either directly or through any of the ancestors.
+func (u *useCase) allowCategory(ctx context.Context, c *entity.Category, need int) error {
+	if c.OrgID != nil {
+		return allowOrg(ctx, *c.OrgID)
+	}
+	uid := identity.FromContext(ctx).ID
+	if uid != 0 && c.OwnerID == uid {
+		return nil
+	}
+	cond := repo.Where("grantee_id = ? AND category_id IN ("+ancestorsQuery+")", uid, c.ID)
+	return u.allowShared(ctx, uid, c.OwnerID, need, cond)
+}
+
+// allowShared make sure the highest permission among the shares that match
+// given cond, or the approved emergency access to the vault of given owner, is
+// at least given rank.
+func (u *useCase) allowShared(ctx context.Context, uid, owner uint, need int, cond repo.Options) error {
+	rank := password.RankNone
+	if uid != 0 {
+		shares, err := u.repo.FindShares(ctx, repo.Cols("permission"), cond)
+		if err != nil {
+			u.log.Error(help.Pad("failed to retrieve shares:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		for _, sh := range shares {
+			if r := password.PermissionRank(sh.Permission); r > rank {
+				rank = r
+			}
+		}
+	}
+	// only look for the emergency access if the shares are not enough
+	if uid != 0 && rank < need {
+		accs, err := u.repo.FindEmergencyAccesses(ctx,
+			repo.Cols("access"),
+			repo.Where("grantor_id = ? AND grantee_id = ? AND status = ?", owner, uid, entity.EmergencyApproved),
+		)
+		if err != nil {
+			u.log.Error(help.Pad("failed to retrieve emergency accesses:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		for _, e := range accs {
+			if r := password.EmergencyRank(e.Access); r > rank {
+				rank = r
+			}
+		}
+	}
+
+	if rank == password.RankNone {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	if rank < need {
+		return stderr.NewUCErr(cons.Forbidden, cons.ErrForbidden)
+	}
+	return nil
+}
+
+// siblingCond return repo option that match category in the vault of given
+// owner or organization with given name under given parent id. Zero parent id
+// means the root categories.
+func siblingCond(owner uint, org *uint, name string, parentID uint) repo.Options {
+	q, args := "owner_id = ? AND name = ?", []any{owner, name}
+	if org == nil {
+		q += " AND org_id IS NULL"
+	} else {
+		q, args = q+" AND org_id = ?", append(args, *org)
+	}
+	if parentID == 0 {
+		return repo.Where(q+" AND parent_id IS NULL", args...)
+	}
+	return repo.Where(q+" AND parent_id = ?", append(args, parentID)...)
+}
+
+// parentOf return the parent id of given category or zero if it's a root
+// category.
+func parentOf(c *entity.Category) uint {
+	if c.ParentID == nil {
+		return 0
+	}
+	return *c.ParentID
+}
+
+// parentPtr return pointer to given parent id or nil if it's zero.
+func parentPtr(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// revokeMoved record that the grantees of the shares of given category or any
+// of its ancestors lost the access to given password or category that's just
+// moved out of it. Those who still have the access through other shares are
+// left out once they sync.
+func revokeMoved(ctx context.Context, tx pw.Repository, from uint, obj entity.Revocation) error {
+	shares, err := tx.FindShares(ctx, repo.Cols("grantee_id"), repo.Where("category_id IN ("+ancestorsQuery+")", from))
+	if err != nil {
+		return err
+	}
+
+	revs := make([]*entity.Revocation, 0, len(shares))
+	for _, sh := range shares {
+		rev := obj
+		rev.UserID = sh.GranteeID
+		revs = append(revs, &rev)
+	}
+	return tx.CreateRevocations(ctx, revs)
+}
+
+// findTags retrieve the tags of the caller that match given ids. Return error
+// if any of them does not exist.
+func (u *useCase) findTags(ctx context.Context, ids []uint) ([]*entity.Tag, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	tags, err := u.repo.FindTags(ctx, repo.Where("id IN ?", ids), tagOwnerCond(ctx))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve tags:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(tags) != len(ids) {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotFound)
+	}
+	return tags, nil
+}
+
+// tagOwnerCond return repo.Options that only include the tags of the caller.
+func tagOwnerCond(ctx context.Context) repo.Options {
+	return repo.Where("owner_id = ?", identity.FromContext(ctx).ID)
+}
+
+// uniqueIDs return given ids without the duplicated ones while keeping the
+// order.
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	res := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
 	}
-	return strings.Join(ids, ",")
+	return res
 }
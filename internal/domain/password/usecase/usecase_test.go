@@ -1,24 +1,44 @@
 package password_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"mime/multipart"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password"
+	pwRepo "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
 	"github.com/mdanialr/pwman_backend/internal/domain/password/repository/mocks"
 	password "github.com/mdanialr/pwman_backend/internal/domain/password/usecase"
 	"github.com/mdanialr/pwman_backend/internal/entity"
 	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	brMock "github.com/mdanialr/pwman_backend/pkg/breach/mocks"
+	"github.com/mdanialr/pwman_backend/pkg/event"
+	"github.com/mdanialr/pwman_backend/pkg/notifier"
+	ntMock "github.com/mdanialr/pwman_backend/pkg/notifier/mocks"
+	"github.com/mdanialr/pwman_backend/pkg/seal"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestUseCase_DeletePassword(t *testing.T) {
+	errNotAffected := repo.ErrNotAffected
+
 	testCases := []struct {
 		name       string
 		setup      func(repo *mocks.MockpasswordRepository)
 		sample     uint
+		revision   uint
 		expectCode string
 		expectMsg  string
 		wantErr    bool
@@ -42,7 +62,7 @@ func TestUseCase_DeletePassword(t *testing.T) {
 				" the record should return UC instance, DEPS_ERROR as code and something wasn't " +
 				"right as message",
 			setup: func(repo *mocks.MockpasswordRepository) {
-				obj := entity.Password{ID: 12}
+				obj := entity.Password{ID: 12, OwnerID: owner}
 				repo.EXPECT().
 					GetPasswordByID(mock.Anything, obj.ID, mock.Anything).
 					Return(&obj, nil).
@@ -62,7 +82,7 @@ func TestUseCase_DeletePassword(t *testing.T) {
 				" the record should return UC instance, DEPS_ERROR as code and something wasn't " +
 				"right as message",
 			setup: func(repo *mocks.MockpasswordRepository) {
-				obj := entity.Password{ID: 5}
+				obj := entity.Password{ID: 5, OwnerID: owner}
 				repo.EXPECT().
 					GetPasswordByID(mock.Anything, obj.ID, mock.Anything).
 					Return(&obj, nil).
@@ -74,6 +94,40 @@ func TestUseCase_DeletePassword(t *testing.T) {
 			},
 			sample: 5,
 		},
+		{
+			name: "Given stale revision should return UC instance, CONFLICT as code and not delete the record",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				obj := entity.Password{ID: 7, Revision: 3, OwnerID: owner}
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, obj.ID, mock.Anything).
+					Return(&obj, nil).
+					Twice()
+			},
+			sample:     7,
+			revision:   2,
+			expectCode: "CONFLICT",
+			expectMsg:  "data has been changed since it was retrieved",
+			wantErr:    true,
+		},
+		{
+			name: "Given current revision but changed meanwhile should return UC instance and CONFLICT as code",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				obj := entity.Password{ID: 7, Revision: 3, OwnerID: owner}
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, obj.ID, mock.Anything).
+					Return(&obj, nil).
+					Twice()
+				repo.EXPECT().
+					DeletePassword(mock.Anything, obj.ID, mock.Anything).
+					Return(errNotAffected).
+					Once()
+			},
+			sample:     7,
+			revision:   3,
+			expectCode: "CONFLICT",
+			expectMsg:  "data has been changed since it was retrieved",
+			wantErr:    true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -81,8 +135,11 @@ func TestUseCase_DeletePassword(t *testing.T) {
 			h := setupTestHelper(t)
 			tc.setup(h.Dep.repo)
 
-			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.repo)
-			err := newUC.DeletePassword(context.Background(), tc.sample)
+			sub := h.Dep.event.Subscribe("", nil)
+			defer sub.Close()
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			err := newUC.DeletePassword(ownerCtx(), tc.sample, tc.revision)
 
 			if tc.wantErr {
 				assert.Error(t, err)
@@ -101,6 +158,1575 @@ func TestUseCase_DeletePassword(t *testing.T) {
 			assert.Panics(t, func() {
 				_ = err.(*stderr.UC)
 			})
+			// notify the subscribers
+			require.Len(t, sub.C, 1)
+			ev := <-sub.C
+			assert.Equal(t, pw.EventPasswordDeleted, ev.Type)
+			assert.Equal(t, pw.EventData{ID: tc.sample, Owner: owner}, ev.Data)
+		})
+	}
+}
+
+func TestUseCase_UpdatePassword(t *testing.T) {
+	errNotAffected := repo.ErrNotAffected
+	current := entity.Password{ID: 7, Username: "john", CategoryID: 1, Revision: 3, OwnerID: owner}
+	sample := pw.Request{Username: "john", Password: "x7#Lq!9vR2@m", Category: 1}
+
+	testCases := []struct {
+		name           string
+		setup          func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort)
+		revision       uint
+		category       uint
+		expect         uint
+		expectCode     string
+		expectRevision uint
+		wantErr        bool
+	}{
+		{
+			name: "Given stale revision should return UC instance, CONFLICT as code and the current copy " +
+				"without updating it",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, current.ID).
+					Return(&current, nil).
+					Once()
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, current.ID, mock.Anything).
+					Return(&current, nil).
+					Once()
+			},
+			revision:       2,
+			expectCode:     "CONFLICT",
+			expectRevision: 3,
+			wantErr:        true,
+		},
+		{
+			name: "Given current revision but changed meanwhile should return UC instance, CONFLICT as " +
+				"code and the latest copy",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, current.ID).
+					Return(&current, nil).
+					Once()
+				br.EXPECT().
+					Count(mock.Anything).
+					Return(0, nil).
+					Once()
+				repo.EXPECT().
+					UpdatePassword(mock.Anything, current.ID, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, errNotAffected).
+					Once()
+				latest := current
+				latest.Revision = 4
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, current.ID, mock.Anything).
+					Return(&latest, nil).
+					Once()
+			},
+			revision:       3,
+			expectCode:     "CONFLICT",
+			expectRevision: 4,
+			wantErr:        true,
+		},
+		{
+			name: "Given no revision should update it using the retrieved revision as the condition and " +
+				"return the next revision",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, current.ID).
+					Return(&current, nil).
+					Once()
+				br.EXPECT().
+					Count(mock.Anything).
+					Return(0, nil).
+					Once()
+				repo.EXPECT().
+					UpdatePassword(mock.Anything, current.ID, mock.MatchedBy(func(obj entity.Password) bool {
+						return obj.Revision == 4
+					}), mock.Anything, mock.Anything).
+					Return(&entity.Password{}, nil).
+					Once()
+			},
+			expect: 4,
+		},
+		{
+			name: "Given another category should move it then record the lost access of the grantees of " +
+				"the old category in transaction",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, current.ID).
+					Return(&current, nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(2)).
+					Return(&entity.Category{ID: 2, OwnerID: owner}, nil).
+					Once()
+				br.EXPECT().
+					Count(mock.Anything).
+					Return(0, nil).
+					Once()
+				repo.EXPECT().
+					Transaction(mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, fn func(pwRepo.Repository) error) error {
+						return fn(repo)
+					}).
+					Once()
+				repo.EXPECT().
+					UpdatePassword(mock.Anything, current.ID, mock.MatchedBy(func(obj entity.Password) bool {
+						return obj.CategoryID == 2
+					}), mock.Anything, mock.Anything).
+					Return(&entity.Password{}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Share{{GranteeID: 5}, {GranteeID: 6}}, nil).
+					Once()
+				id := current.ID
+				repo.EXPECT().
+					CreateRevocations(mock.Anything, []*entity.Revocation{{UserID: 5, PasswordID: &id}, {UserID: 6, PasswordID: &id}}).
+					Return(nil).
+					Once()
+			},
+			category: 2,
+			expect:   4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo, h.Dep.breach)
+
+			sub := h.Dep.event.Subscribe("", nil)
+			defer sub.Close()
+
+			req := sample
+			req.Revision = tc.revision
+			if tc.category != 0 {
+				req.Category = tc.category
+			}
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			rev, err := newUC.UpdatePassword(ownerCtx(), current.ID, req)
+			h.Dep.repo.AssertExpectations(t)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				require.IsType(t, &pw.Response{}, err.(*stderr.UC).Detail)
+				assert.Equal(t, tc.expectRevision, err.(*stderr.UC).Detail.(*pw.Response).Revision)
+				// nothing is changed, so nothing is published
+				assert.Empty(t, sub.C)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, rev)
+			// notify the subscribers along with the new revision
+			require.Len(t, sub.C, 1)
+			ev := <-sub.C
+			assert.Equal(t, pw.EventPasswordUpdated, ev.Type)
+			assert.Equal(t, pw.EventData{ID: current.ID, Revision: tc.expect, Owner: owner}, ev.Data)
+		})
+	}
+}
+
+func TestUseCase_IndexPassword(t *testing.T) {
+	hasURL := true
+
+	testCases := []struct {
+		name         string
+		sample       pw.Request
+		search       string
+		expectSQL    []string
+		notExpectSQL []string
+		expectVars   int
+		// expectOpts the number of repo options including the default order,
+		// pagination and eager load
+		expectOpts int
+	}{
+		{
+			name:         "Given no filter should only include the passwords that's owned by or shared to the caller",
+			sample:       pw.Request{},
+			expectSQL:    []string{"owner_id = $1", "grantee_id = $2", "category_id IN (WITH RECURSIVE sub AS", "emergency_access WHERE grantee_id = $4"},
+			notExpectSQL: []string{"category_id IN ($", "created_at >=", "url"},
+			expectVars:   4,
+			expectOpts:   4,
+		},
+		{
+			name:   "Given search should match by similarity, select the relevance and sort by it first",
+			search: "githb",
+			expectSQL: []string{
+				"word_similarity($1, username)", "AS relevance", "$9 <% username", "username ILIKE $12",
+				"ORDER BY relevance DESC,id ASC",
+			},
+			expectVars: 14,
+			expectOpts: 7,
+		},
+		{
+			name:       "Given multiple category ids should filter by all of them",
+			sample:     pw.Request{FilterCategory: []uint{3, 4}},
+			expectSQL:  []string{"category_id IN ($5,$6)"},
+			expectVars: 6,
+			expectOpts: 5,
+		},
+		{
+			name:       "Given category id recursively should include the descendants",
+			sample:     pw.Request{FilterCategory: []uint{3}, Recursive: true},
+			expectSQL:  []string{"category_id IN (WITH RECURSIVE sub AS (SELECT id FROM category WHERE id IN ($5)"},
+			expectOpts: 5,
+		},
+		{
+			name:   "Given created and updated ranges along with has url should combine all of them",
+			sample: pw.Request{CreatedFrom: "2024-01-01", CreatedTo: "2024-01-31", UpdatedFrom: "2024-02-01T00:00:00Z", HasURL: &hasURL},
+			expectSQL: []string{
+				"created_at >= $5", "created_at <= $6", "updated_at >= $7", "COALESCE(url, '') <> ''",
+			},
+			expectVars: 7,
+			expectOpts: 8,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			args := make([]any, tc.expectOpts+1)
+			for i := range args {
+				args[i] = mock.Anything
+			}
+			var stmt *gorm.Statement
+			h.Dep.repo.On("FindPassword", args...).
+				Run(func(args mock.Arguments) {
+					var opts []repo.Options
+					for _, arg := range args[1:] {
+						opts = append(opts, arg.(repo.Options))
+					}
+					// leave out pagination and eager load that need a real connection
+					stmt = dryRun(t, opts[:len(opts)-2]...)
+				}).
+				Return(nil, nil).
+				Once()
+
+			tc.sample.Search = tc.search
+			tc.sample.SetQuery()
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			_, err := newUC.IndexPassword(ownerCtx(), tc.sample)
+			require.NoError(t, err)
+
+			sql := stmt.SQL.String()
+			for _, exp := range tc.expectSQL {
+				assert.Contains(t, sql, exp)
+			}
+			for _, exp := range tc.notExpectSQL {
+				assert.NotContains(t, sql, exp)
+			}
+			if tc.expectVars > 0 {
+				assert.Len(t, stmt.Vars, tc.expectVars)
+			}
+		})
+	}
+}
+
+func TestUseCase_SavePassword(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort)
+		sample     pw.Request
+		expect     *pw.Response
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given category id 9 that does not exist in deps repository should return UC instance, " +
+				"INVALID_PAYLOAD as code and data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository, _ *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(9)).
+					Return(nil, errors.New("error")).
+					Once()
+			},
+			sample:     pw.Request{Username: "john", Password: "password", Category: 9},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given valid request but deps somehow failed to create the record should return UC " +
+				"instance, DEPS_ERROR as code and something wasn't right as message",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(1)).
+					Return(&entity.Category{ID: 1, OwnerID: owner}, nil).
+					Once()
+				br.EXPECT().
+					Count("password").
+					Return(0, nil).
+					Once()
+				repo.EXPECT().
+					CreatePassword(mock.Anything, mock.Anything).
+					Return(nil, errors.New("error")).
+					Once()
+			},
+			sample:     pw.Request{Username: "john", Password: "password", Category: 1},
+			expectCode: "DEPS_ERROR",
+			expectMsg:  "something wasn't right",
+			wantErr:    true,
+		},
+		{
+			name: "Given password that has been seen in breaches should save and return the response with " +
+				"breached flag and the strength score",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(1)).
+					Return(&entity.Category{ID: 1, OwnerID: owner}, nil).
+					Once()
+				br.EXPECT().
+					Count("password").
+					Return(120, nil).
+					Once()
+				repo.EXPECT().
+					CreatePassword(mock.Anything, entity.Password{
+						Username:   "john",
+						Password:   "password",
+						CategoryID: 1,
+						OwnerID:    owner,
+						Strength:   0,
+						Breached:   true,
+					}).
+					RunAndReturn(func(_ context.Context, obj entity.Password) (*entity.Password, error) {
+						obj.ID = 3
+						return &obj, nil
+					}).
+					Once()
+			},
+			sample: pw.Request{Username: "john", Password: "password", Category: 1},
+			expect: &pw.Response{ID: 3, Username: "john", CategoryID: 1, Strength: 0, Breached: true},
+		},
+		{
+			name: "Given password that failed to be checked for breaches should still save and return the " +
+				"response without breached flag",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(1)).
+					Return(&entity.Category{ID: 1, OwnerID: owner}, nil).
+					Once()
+				br.EXPECT().
+					Count("x7#Lq!9vR2@m").
+					Return(0, errors.New("error")).
+					Once()
+				repo.EXPECT().
+					CreatePassword(mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, obj entity.Password) (*entity.Password, error) {
+						obj.ID = 4
+						return &obj, nil
+					}).
+					Once()
+			},
+			sample: pw.Request{Username: "john", Password: "x7#Lq!9vR2@m", Category: 1},
+			expect: &pw.Response{ID: 4, Username: "john", CategoryID: 1, Strength: 4},
+		},
+		{
+			name: "Given tag id that does not exist in deps repository should return UC instance, " +
+				"INVALID_PAYLOAD as code and data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository, _ *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(1)).
+					Return(&entity.Category{ID: 1, OwnerID: owner}, nil).
+					Once()
+				repo.EXPECT().
+					FindTags(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Tag{{ID: 1, Name: "prod"}}, nil).
+					Once()
+			},
+			sample:     pw.Request{Username: "john", Password: "password", Category: 1, Tags: []uint{1, 9}},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given existing tags and favorite flag should save the password along with the tags in " +
+				"transaction and return them in the response",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(1)).
+					Return(&entity.Category{ID: 1, OwnerID: owner}, nil).
+					Once()
+				repo.EXPECT().
+					FindTags(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Tag{{ID: 1, Name: "prod", Color: "#ff0000"}, {ID: 2, Name: "pinned"}}, nil).
+					Once()
+				br.EXPECT().
+					Count("x7#Lq!9vR2@m").
+					Return(0, nil).
+					Once()
+				repo.EXPECT().
+					Transaction(mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, fn func(pwRepo.Repository) error) error {
+						return fn(repo)
+					}).
+					Once()
+				repo.EXPECT().
+					CreatePassword(mock.Anything, mock.MatchedBy(func(obj entity.Password) bool { return obj.Favorite })).
+					RunAndReturn(func(_ context.Context, obj entity.Password) (*entity.Password, error) {
+						obj.ID = 5
+						return &obj, nil
+					}).
+					Once()
+				repo.EXPECT().
+					ReplacePasswordTags(mock.Anything, uint(5), []uint{1, 2, 1}).
+					Return(nil).
+					Once()
+			},
+			sample: pw.Request{Username: "john", Password: "x7#Lq!9vR2@m", Category: 1, Favorite: true, Tags: []uint{1, 2, 1}},
+			expect: &pw.Response{ID: 5, Username: "john", CategoryID: 1, Strength: 4, Favorite: true, Tags: []*pw.ResponseTag{
+				{ID: 1, Name: "prod", Color: "#ff0000"},
+				{ID: 2, Name: "pinned"},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo, h.Dep.breach)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			res, err := newUC.SavePassword(ownerCtx(), tc.sample)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				// assert error instance
+				assert.IsType(t, &stderr.UC{}, err)
+				// assert Code and Message
+				assert.NotPanics(t, func() {
+					stdErrUC := err.(*stderr.UC)
+					assert.Equal(t, tc.expectCode, stdErrUC.Code)
+					assert.Equal(t, tc.expectMsg, stdErrUC.Msg)
+				})
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, res)
+		})
+	}
+}
+
+func TestUseCase_IndexCategory(t *testing.T) {
+	t.Run("Given search with a quote should bind it as a parameter instead of a part of the query", func(t *testing.T) {
+		h := setupTestHelper(t)
+		var opts []repo.Options
+		h.Dep.repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(_ context.Context, o ...repo.Options) { opts = o }).
+			Return(nil, nil).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		search := "x' OR '1'='1"
+		var req pw.RequestCategory
+		req.Search, req.Order, req.Sort = search, "id", "asc"
+		_, err := newUC.IndexCategory(ownerCtx(), req)
+		require.NoError(t, err)
+
+		// leave the pagination out, since it's not needed to render the query
+		stmt := dryRun(t, opts[:len(opts)-1]...)
+		assert.Contains(t, stmt.SQL.String(), "name ILIKE $")
+		assert.NotContains(t, stmt.SQL.String(), "'1'='1")
+		assert.Contains(t, stmt.Vars, "%"+search+"%")
+	})
+}
+
+func TestUseCase_IndexTag(t *testing.T) {
+	t.Run("Given tags in deps repository should return them along with their usage count", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindTags(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Tag{{ID: 1, Name: "prod"}, {ID: 2, Name: "unused"}}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			CountTagUsage(mock.Anything, uint(1), uint(2)).
+			Return(map[uint]int{1: 3}, nil).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		res, err := newUC.IndexTag(ownerCtx(), pw.RequestTag{})
+		require.NoError(t, err)
+
+		require.Len(t, res.Data, 2)
+		assert.Equal(t, 3, *res.Data[0].Usage)
+		assert.Equal(t, 0, *res.Data[1].Usage)
+	})
+}
+
+func TestUseCase_SaveTag(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository)
+		sample     pw.RequestTag
+		expect     *pw.ResponseTag
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given tag name that already exist in deps repository should return UC instance, " +
+				"INVALID_PAYLOAD as code and data is already exist as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetTagByID(mock.Anything, uint(0), mock.Anything, mock.Anything, mock.Anything).
+					Return(&entity.Tag{ID: 1}, nil).
+					Once()
+			},
+			sample:     pw.RequestTag{Name: "prod"},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data is already exist",
+			wantErr:    true,
+		},
+		{
+			name: "Given new tag name should save and return the response",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetTagByID(mock.Anything, uint(0), mock.Anything, mock.Anything, mock.Anything).
+					Return(&entity.Tag{}, errors.New("record not found")).
+					Once()
+				repo.EXPECT().
+					CreateTag(mock.Anything, entity.Tag{OwnerID: owner, Name: "prod", Color: "#ff0000"}).
+					Return(&entity.Tag{ID: 2, Name: "prod", Color: "#ff0000"}, nil).
+					Once()
+			},
+			sample: pw.RequestTag{Name: "prod", Color: "#ff0000"},
+			expect: &pw.ResponseTag{ID: 2, Name: "prod", Color: "#ff0000"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			res, err := newUC.SaveTag(ownerCtx(), tc.sample)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, res)
+		})
+	}
+}
+
+func TestUseCase_DeleteTag(t *testing.T) {
+	t.Run("Given tag of other user should return UC instance, INVALID_PAYLOAD as code and data not found "+
+		"as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		var opts []repo.Options
+		h.Dep.repo.EXPECT().
+			GetTagByID(mock.Anything, uint(7), mock.Anything, mock.Anything).
+			Run(func(_ context.Context, _ uint, o ...repo.Options) { opts = o }).
+			Return(&entity.Tag{}, gorm.ErrRecordNotFound).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		err := newUC.DeleteTag(ownerCtx(), 7)
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "data not found", err.(*stderr.UC).Msg)
+		// only the tag of the caller is looked up
+		stmt := dryRun(t, opts...)
+		assert.Contains(t, stmt.SQL.String(), "owner_id = $1")
+		assert.Equal(t, []any{owner}, stmt.Vars)
+		h.Dep.repo.AssertNotCalled(t, "DeleteTag", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUseCase_MoveCategory(t *testing.T) {
+	parent := func(id uint) *uint { return &id }
+	// the tree is 1 -> 2 -> 3 and 4 as another root
+	tree := []*entity.Category{
+		{ID: 1, OwnerID: owner},
+		{ID: 2, ParentID: parent(1), OwnerID: owner},
+		{ID: 3, ParentID: parent(2), OwnerID: owner},
+		{ID: 4, OwnerID: owner},
+	}
+
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository)
+		id         uint
+		parentID   uint
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given parent id that does not exist in deps repository should return UC instance, " +
+				"INVALID_PAYLOAD as code and data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(2)).
+					Return(tree[1], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(99), mock.Anything).
+					Return(&entity.Category{}, errors.New("record not found")).
+					Once()
+			},
+			id:         2,
+			parentID:   99,
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given one of its descendants as the new parent should return UC instance, " +
+				"INVALID_PAYLOAD as code and can not be moved into itself or its descendant as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(1)).
+					Return(tree[0], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(3), mock.Anything).
+					Return(tree[2], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(1), mock.Anything, mock.Anything).
+					Return(tree[0], nil).
+					Once()
+				repo.EXPECT().
+					FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(tree[:3], nil).
+					Once()
+			},
+			id:         1,
+			parentID:   3,
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "can not be moved into itself or its descendant",
+			wantErr:    true,
+		},
+		{
+			name: "Given itself as the new parent should return UC instance, INVALID_PAYLOAD as code " +
+				"and can not be moved into itself or its descendant as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(2)).
+					Return(tree[1], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(2), mock.Anything).
+					Return(tree[1], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(2), mock.Anything, mock.Anything).
+					Return(tree[1], nil).
+					Once()
+				repo.EXPECT().
+					FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(tree[:2], nil).
+					Once()
+			},
+			id:         2,
+			parentID:   2,
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "can not be moved into itself or its descendant",
+			wantErr:    true,
+		},
+		{
+			name: "Given another root as the new parent should update the parent id and record the lost access " +
+				"of the grantees of the old parent",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(2)).
+					Return(tree[1], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(4), mock.Anything).
+					Return(tree[3], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(2), mock.Anything, mock.Anything).
+					Return(tree[1], nil).
+					Once()
+				repo.EXPECT().
+					FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(tree[3:], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(0), mock.Anything, mock.Anything).
+					Return(&entity.Category{}, errors.New("record not found")).
+					Once()
+				repo.EXPECT().
+					UpdateCategory(mock.Anything, uint(2), entity.Category{ParentID: parent(4), Revision: 1}, mock.Anything, mock.Anything).
+					Return(&entity.Category{}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Share{{GranteeID: 5}}, nil).
+					Once()
+				repo.EXPECT().
+					CreateRevocations(mock.Anything, []*entity.Revocation{{UserID: 5, CategoryID: parent(2)}}).
+					Return(nil).
+					Once()
+			},
+			id:       2,
+			parentID: 4,
+		},
+		{
+			name: "Given zero as the new parent should move it to the root",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(3)).
+					Return(tree[2], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(3), mock.Anything, mock.Anything).
+					Return(tree[2], nil).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(0), mock.Anything, mock.Anything).
+					Return(&entity.Category{}, errors.New("record not found")).
+					Once()
+				repo.EXPECT().
+					UpdateCategory(mock.Anything, uint(3), entity.Category{Revision: 1}, mock.Anything, mock.Anything).
+					Return(&entity.Category{}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+				repo.EXPECT().
+					CreateRevocations(mock.Anything, []*entity.Revocation{}).
+					Return(nil).
+					Once()
+			},
+			id: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			h.Dep.repo.EXPECT().
+				Transaction(mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, fn func(pwRepo.Repository) error) error {
+					return fn(h.Dep.repo)
+				}).
+				Maybe()
+			tc.setup(h.Dep.repo)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			err := newUC.MoveCategory(ownerCtx(), tc.id, tc.parentID)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			h.Dep.repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUseCase_DeleteCategory(t *testing.T) {
+	t.Run("Given category that still has child category should return UC instance, "+
+		"INVALID_PAYLOAD as code and data still in use as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetCategoryByID(mock.Anything, uint(1)).
+			Return(&entity.Category{ID: 1, OwnerID: owner}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Category{{ID: 2}}, nil).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		err := newUC.DeleteCategory(ownerCtx(), 1, 0)
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "data still in use", err.(*stderr.UC).Msg)
+	})
+}
+
+func TestUseCase_RevealPassword(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository)
+		expect     *pw.ResponseReveal
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given password that belong to the caller should return the password",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(&entity.Password{ID: 7, Password: "secret", OwnerID: owner}, nil).
+					Once()
+			},
+			expect: &pw.ResponseReveal{ID: 7, Password: "secret"},
+		},
+		{
+			name: "Given password of another user that is not shared to the caller should return UC instance, " +
+				"INVALID_PAYLOAD as code and data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(&entity.Password{ID: 7, Password: "secret", OwnerID: 2}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+				repo.EXPECT().
+					FindEmergencyAccesses(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+			},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given password of another user that is shared to the caller with view permission should " +
+				"return UC instance, FORBIDDEN as code and not allowed to do this as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(&entity.Password{ID: 7, Password: "secret", OwnerID: 2}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Share{{Permission: entity.ShareView}}, nil).
+					Once()
+				repo.EXPECT().
+					FindEmergencyAccesses(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+			},
+			expectCode: "FORBIDDEN",
+			expectMsg:  "not allowed to do this",
+			wantErr:    true,
+		},
+		{
+			name: "Given password of another user that is shared to the caller with reveal permission through " +
+				"one of the shares should return the password",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(&entity.Password{ID: 7, Password: "secret", OwnerID: 2}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Share{{Permission: entity.ShareView}, {Permission: entity.ShareReveal}}, nil).
+					Once()
+			},
+			expect: &pw.ResponseReveal{ID: 7, Password: "secret"},
+		},
+		{
+			name: "Given password of another user whose emergency access is approved for the caller should " +
+				"return the password",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(&entity.Password{ID: 7, Password: "secret", OwnerID: 2}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+				repo.EXPECT().
+					FindEmergencyAccesses(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.EmergencyAccess{{Access: entity.EmergencyView}}, nil).
+					Once()
+			},
+			expect: &pw.ResponseReveal{ID: 7, Password: "secret"},
+		},
+		{
+			name: "Given password of organization that's not granted by the policy should return UC instance, " +
+				"INVALID_PAYLOAD as code and data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				org := uint(3)
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+					Return(&entity.Password{ID: 7, Password: "secret", OrgID: &org}, nil).
+					Once()
+			},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			res, err := newUC.RevealPassword(ownerCtx(), 7)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, res)
+		})
+	}
+}
+
+func TestUseCase_SaveShare(t *testing.T) {
+	pid := uint(7)
+
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository)
+		sample     pw.RequestShare
+		expect     *pw.ResponseShare
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given password that is shared to the caller with edit permission should return UC instance, " +
+				"FORBIDDEN as code and not allowed to do this as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, pid, mock.Anything).
+					Return(&entity.Password{ID: pid, OwnerID: 2}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Share{{Permission: entity.ShareEdit}}, nil).
+					Once()
+				repo.EXPECT().
+					FindEmergencyAccesses(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+			},
+			sample:     pw.RequestShare{PasswordID: pid, Username: "doe", Permission: entity.ShareView},
+			expectCode: "FORBIDDEN",
+			expectMsg:  "not allowed to do this",
+			wantErr:    true,
+		},
+		{
+			name: "Given the caller itself as the grantee should return UC instance, INVALID_PAYLOAD as code " +
+				"and can not share to yourself as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, pid, mock.Anything).
+					Return(&entity.Password{ID: pid, OwnerID: owner}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "john").
+					Return(&entity.User{ID: owner, Username: "john"}, nil).
+					Once()
+			},
+			sample:     pw.RequestShare{PasswordID: pid, Username: "john", Permission: entity.ShareView},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "can not share to yourself",
+			wantErr:    true,
+		},
+		{
+			name: "Given password that has not been shared to the grantee yet should create new share",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, pid, mock.Anything).
+					Return(&entity.Password{ID: pid, OwnerID: owner}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "doe").
+					Return(&entity.User{ID: 2, Username: "doe"}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+				repo.EXPECT().
+					CreateShare(mock.Anything, entity.Share{OwnerID: owner, GranteeID: 2, PasswordID: &pid, Permission: entity.ShareReveal}).
+					RunAndReturn(func(_ context.Context, obj entity.Share) (*entity.Share, error) {
+						obj.ID = 3
+						return &obj, nil
+					}).
+					Once()
+			},
+			sample: pw.RequestShare{PasswordID: pid, Username: "doe", Permission: entity.ShareReveal},
+			expect: &pw.ResponseShare{ID: 3, PasswordID: &pid, Grantee: "doe", Permission: entity.ShareReveal},
+		},
+		{
+			name: "Given password that has been shared to the grantee should replace the permission",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetPasswordByID(mock.Anything, pid, mock.Anything).
+					Return(&entity.Password{ID: pid, OwnerID: owner}, nil).
+					Once()
+				repo.EXPECT().
+					GetUserByUsername(mock.Anything, "doe").
+					Return(&entity.User{ID: 2, Username: "doe"}, nil).
+					Once()
+				repo.EXPECT().
+					FindShares(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Share{{ID: 5}}, nil).
+					Once()
+				repo.EXPECT().
+					UpdateShare(mock.Anything, uint(5), entity.Share{Permission: entity.ShareEdit}, mock.Anything).
+					Return(&entity.Share{}, nil).
+					Once()
+			},
+			sample: pw.RequestShare{PasswordID: pid, Username: "doe", Permission: entity.ShareEdit},
+			expect: &pw.ResponseShare{ID: 5, PasswordID: &pid, Grantee: "doe", Permission: entity.ShareEdit},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			res, err := newUC.SaveShare(ownerCtx(), tc.sample)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, res)
+		})
+	}
+}
+
+func TestUseCase_ScanBreach(t *testing.T) {
+	h := setupTestHelper(t)
+	other := identity.NewContext(context.Background(), identity.User{ID: 2})
+	release := make(chan struct{})
+	var scanned []uint
+	var mu sync.Mutex
+	h.Dep.repo.EXPECT().
+		FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, opts ...repo.Options) ([]*entity.Password, error) {
+			<-release
+			// only the passwords that the caller may see are scanned
+			stmt := dryRun(t, opts...)
+			mu.Lock()
+			scanned = append(scanned, stmt.Vars[len(stmt.Vars)-1].(uint))
+			mu.Unlock()
+			return nil, nil
+		}).
+		Twice()
+
+	newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+	require.NoError(t, newUC.ScanBreach(ownerCtx()))
+
+	// the running scan of the caller block their next scan
+	err := newUC.ScanBreach(ownerCtx())
+	require.IsType(t, &stderr.UC{}, err)
+	assert.Equal(t, "IN_PROGRESS", err.(*stderr.UC).Code)
+
+	// but not the scan of other users, who only see their own progress
+	assert.False(t, newUC.ScanBreachStatus(other).Running)
+	require.NoError(t, newUC.ScanBreach(other))
+	assert.True(t, newUC.ScanBreachStatus(other).Running)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return !newUC.ScanBreachStatus(ownerCtx()).Running && !newUC.ScanBreachStatus(other).Running
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []uint{owner, 2}, scanned)
+}
+
+func TestUseCase_NotifyRotation(t *testing.T) {
+	exp := time.Now().AddDate(0, 0, 2)
+
+	testCases := []struct {
+		name    string
+		setup   func(repo *mocks.MockpasswordRepository, nt *ntMock.MocknotifierPort)
+		wantErr bool
+	}{
+		{
+			name: "Given no password that need rotation should not send any notification",
+			setup: func(repo *mocks.MockpasswordRepository, _ *ntMock.MocknotifierPort) {
+				repo.EXPECT().
+					FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+			},
+		},
+		{
+			name: "Given passwords that need rotation but deps repository failed to retrieve their owners " +
+				"should return error and not send any notification",
+			setup: func(repo *mocks.MockpasswordRepository, _ *ntMock.MocknotifierPort) {
+				repo.EXPECT().
+					FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Password{{ID: 7, Username: "john", CategoryID: 1, OwnerID: owner, ExpiresAt: &exp}}, nil).
+					Once()
+				repo.EXPECT().
+					FindUsers(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, errors.New("error")).
+					Once()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Given passwords that need rotation but deps notifier failed to send should return error " +
+				"and not mark them as notified",
+			setup: func(repo *mocks.MockpasswordRepository, nt *ntMock.MocknotifierPort) {
+				repo.EXPECT().
+					FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Password{{ID: 7, Username: "john", CategoryID: 1, OwnerID: owner, ExpiresAt: &exp}}, nil).
+					Once()
+				repo.EXPECT().
+					FindUsers(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.User{{ID: owner, Username: "admin"}}, nil).
+					Once()
+				nt.EXPECT().
+					Notify(mock.Anything, mock.Anything).
+					Return(errors.New("error")).
+					Once()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Given passwords of different owners that need rotation should send a single notification " +
+				"to each owner then mark each of them as notified",
+			setup: func(repo *mocks.MockpasswordRepository, nt *ntMock.MocknotifierPort) {
+				repo.EXPECT().
+					FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.Password{
+						{ID: 7, Username: "john", CategoryID: 1, OwnerID: owner, ExpiresAt: &exp},
+						{ID: 8, Username: "doe", CategoryID: 2, OwnerID: 2, ExpiresAt: &exp},
+						{ID: 9, Username: "jane", CategoryID: 1, OwnerID: owner, ExpiresAt: &exp},
+					}, nil).
+					Once()
+				repo.EXPECT().
+					FindUsers(mock.Anything, mock.Anything, mock.Anything).
+					Return([]*entity.User{{ID: owner, Username: "admin"}, {ID: 2, Username: "other"}}, nil).
+					Once()
+				nt.EXPECT().
+					Notify(mock.Anything, mock.MatchedBy(func(msg notifier.Message) bool {
+						return msg.Recipient == "admin" && msg.Subject == "2 password(s) need rotation"
+					})).
+					Return(nil).
+					Once()
+				nt.EXPECT().
+					Notify(mock.Anything, mock.MatchedBy(func(msg notifier.Message) bool {
+						return msg.Recipient == "other" && msg.Subject == "1 password(s) need rotation"
+					})).
+					Return(nil).
+					Once()
+				for _, id := range []uint{7, 8, 9} {
+					repo.EXPECT().
+						UpdatePassword(mock.Anything, id, mock.Anything, mock.Anything, mock.Anything).
+						Return(nil, nil).
+						Once()
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo, h.Dep.notify)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			err := newUC.NotifyRotation(context.Background())
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUseCase_VisibleEvent(t *testing.T) {
+	org := uint(3)
+	testCases := []struct {
+		name   string
+		ev     event.Event
+		setup  func(*mocks.MockpasswordRepository)
+		expect bool
+	}{
+		{
+			name:   "Given event of the password that's owned by the caller should be visible",
+			ev:     event.Event{Type: pw.EventPasswordUpdated, Data: pw.EventData{ID: 7, Owner: owner}},
+			setup:  func(*mocks.MockpasswordRepository) {},
+			expect: true,
+		},
+		{
+			name:   "Given event of the tag that's owned by other user should not be visible",
+			ev:     event.Event{Type: pw.EventTagCreated, Data: pw.EventData{ID: 7, Owner: 2}},
+			setup:  func(*mocks.MockpasswordRepository) {},
+			expect: false,
+		},
+		{
+			name:   "Given import of other user should not be visible",
+			ev:     event.Event{Type: pw.EventPasswordImported, Data: pw.EventData{Owner: 2}},
+			setup:  func(*mocks.MockpasswordRepository) {},
+			expect: false,
+		},
+		{
+			name: "Given event of the password that's shared to the caller should be visible",
+			ev:   event.Event{Type: pw.EventPasswordDeleted, Data: pw.EventData{ID: 7, Owner: 2}},
+			setup: func(r *mocks.MockpasswordRepository) {
+				r.EXPECT().
+					GetPasswordByID(mock.Anything, uint(7), mock.Anything, mock.Anything, mock.Anything).
+					Return(&entity.Password{ID: 7}, nil).
+					Once()
+			},
+			expect: true,
+		},
+		{
+			name: "Given event of the category in the organization that the caller is not a member of should " +
+				"not be visible",
+			ev: event.Event{Type: pw.EventCategoryUpdated, Data: pw.EventData{ID: 7, Org: org}},
+			setup: func(r *mocks.MockpasswordRepository) {
+				r.EXPECT().
+					GetCategoryByID(mock.Anything, uint(7), mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, gorm.ErrRecordNotFound).
+					Once()
+			},
+			expect: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			assert.Equal(t, tc.expect, newUC.VisibleEvent(ownerCtx(), tc.ev))
+			h.Dep.repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Given no caller should not be visible", func(t *testing.T) {
+		h := setupTestHelper(t)
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		ev := event.Event{Type: pw.EventPasswordCreated, Data: pw.EventData{ID: 7}}
+		assert.False(t, newUC.VisibleEvent(context.Background(), ev))
+	})
+}
+
+func TestUseCase_SaveShareLink(t *testing.T) {
+	t.Run("Given password that is shared to the caller with view permission should return UC instance, "+
+		"FORBIDDEN as code and not allowed to do this as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+			Return(&entity.Password{ID: 7, Password: "secret", OwnerID: 2}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindShares(mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Share{{Permission: entity.ShareView}}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindEmergencyAccesses(mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		_, err := newUC.SaveShareLink(ownerCtx(), pw.RequestShareLink{ID: 7})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "FORBIDDEN", err.(*stderr.UC).Code)
+		assert.Equal(t, "not allowed to do this", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given password that belong to the caller should return the link whose fragment is the key "+
+		"to decrypt the stored secret", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.config.Set("share_link.url", "https://my.domain.com/")
+		h.Dep.repo.EXPECT().
+			GetPasswordByID(mock.Anything, uint(7), mock.Anything).
+			Return(&entity.Password{ID: 7, Password: "secret", OwnerID: owner}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			DeleteShareLinks(mock.Anything, mock.Anything).
+			Return(nil).
+			Once()
+		var stored entity.ShareLink
+		h.Dep.repo.EXPECT().
+			CreateShareLink(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, obj entity.ShareLink) (*entity.ShareLink, error) {
+				stored = obj
+				return &obj, nil
+			}).
+			Once()
+
+		newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+		res, err := newUC.SaveShareLink(ownerCtx(), pw.RequestShareLink{ID: 7, Views: 3})
+		require.NoError(t, err)
+
+		assert.Equal(t, uint(7), stored.PasswordID)
+		assert.Equal(t, owner, stored.CreatorID)
+		assert.Equal(t, 3, res.Views)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), res.ExpiresAt, time.Minute)
+		assert.NotContains(t, string(stored.Ciphertext), "secret")
+
+		prefix := "https://my.domain.com/s/" + stored.ID + "#"
+		require.True(t, strings.HasPrefix(res.URL, prefix))
+		key, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(res.URL, prefix))
+		require.NoError(t, err)
+		plain, err := seal.Open(stored.Ciphertext, key)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(plain))
+	})
+}
+
+func TestUseCase_OpenShareLink(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository)
+		expect     *pw.ResponseShareLinkSecret
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given link that's already destroyed should return UC instance, INVALID_PAYLOAD as code and " +
+				"data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetShareLinkByID(mock.Anything, "abc", mock.Anything).
+					Return(nil, gorm.ErrRecordNotFound).
+					Once()
+			},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given link that's already expired should destroy it and return UC instance, INVALID_PAYLOAD " +
+				"as code and data not found as message",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetShareLinkByID(mock.Anything, "abc", mock.Anything).
+					Return(&entity.ShareLink{ID: "abc", ViewsLeft: 3, ExpiresAt: time.Now().Add(-time.Minute)}, nil).
+					Once()
+				repo.EXPECT().
+					DeleteShareLinks(mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "data not found",
+			wantErr:    true,
+		},
+		{
+			name: "Given link that's opened for the last time should destroy it and return the ciphertext",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetShareLinkByID(mock.Anything, "abc", mock.Anything).
+					Return(&entity.ShareLink{ID: "abc", Ciphertext: []byte("ct"), ViewsLeft: 1, ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)}, nil).
+					Once()
+				repo.EXPECT().
+					DeleteShareLinks(mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			expect: &pw.ResponseShareLinkSecret{Ciphertext: []byte("ct"), ViewsLeft: 0, ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "Given link that still can be opened more should decrease the views left and return the ciphertext",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.EXPECT().
+					GetShareLinkByID(mock.Anything, "abc", mock.Anything).
+					Return(&entity.ShareLink{ID: "abc", Ciphertext: []byte("ct"), ViewsLeft: 3, ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)}, nil).
+					Once()
+				repo.EXPECT().
+					UpdateShareLink(mock.Anything, "abc", entity.ShareLink{ViewsLeft: 2}, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			expect: &pw.ResponseShareLinkSecret{Ciphertext: []byte("ct"), ViewsLeft: 2, ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			h.Dep.repo.EXPECT().
+				Transaction(mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, fn func(pwRepo.Repository) error) error {
+					return fn(h.Dep.repo)
+				}).
+				Once()
+			tc.setup(h.Dep.repo)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			res, err := newUC.OpenShareLink(context.Background(), "abc")
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, res)
+		})
+	}
+}
+
+// importFile create the uploaded file that hold given data as it's parsed
+// from multipart form by the delivery.
+func importFile(t *testing.T, data string) *multipart.FileHeader {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	w, err := mw.CreateFormFile("file", "export.csv")
+	require.NoError(t, err)
+	w.Write([]byte(data))
+	require.NoError(t, mw.Close())
+
+	form, err := multipart.NewReader(&buf, mw.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	return form.File["file"][0]
+}
+
+func TestUseCase_ImportPassword(t *testing.T) {
+	// the header is in row 1, then a strong password, a missing password and
+	// a weak password
+	const sample = "name,url,username,password\n" +
+		"mail,https://mail,amy,x7#Lq!9vR2@m\n" +
+		"shop,https://shop,bob,\n" +
+		"bank,https://bank,jim,password\n"
+
+	testCases := []struct {
+		name       string
+		setup      func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort)
+		sample     pw.RequestImport
+		expect     *pw.ResponseImport
+		expectCode string
+		wantErr    bool
+	}{
+		{
+			name:       "Given export that can not be parsed should return UC instance and INVALID_PAYLOAD as code",
+			setup:      func(_ *mocks.MockpasswordRepository, _ *brMock.MockbreachPort) {},
+			sample:     pw.RequestImport{Format: "chrome", File: importFile(t, "name,url,username\nmail,https://mail,amy\n")},
+			expectCode: "INVALID_PAYLOAD",
+			wantErr:    true,
+		},
+		{
+			name: "Given deps repository that failed to save a password should roll back the transaction and " +
+				"return UC instance and DEPS_ERROR as code",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					Transaction(mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, fn func(pwRepo.Repository) error) error {
+						return fn(repo)
+					}).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(0), mock.Anything, mock.Anything).
+					Return(&entity.Category{ID: 3}, nil).
+					Once()
+				br.EXPECT().
+					Count("x7#Lq!9vR2@m").
+					Return(0, nil).
+					Once()
+				repo.EXPECT().
+					CreatePassword(mock.Anything, mock.Anything).
+					Return(nil, errors.New("error")).
+					Once()
+			},
+			sample:     pw.RequestImport{Format: "chrome", File: importFile(t, sample)},
+			expectCode: "DEPS_ERROR",
+			wantErr:    true,
+		},
+		{
+			name: "Given dry run should save the valid records in transaction then roll it back and report " +
+				"the invalid and the weak records by their row in the export",
+			setup: func(repo *mocks.MockpasswordRepository, br *brMock.MockbreachPort) {
+				repo.EXPECT().
+					Transaction(mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, fn func(pwRepo.Repository) error) error {
+						// the returned error is what roll back the transaction
+						err := fn(repo)
+						assert.Error(t, err)
+						return err
+					}).
+					Once()
+				repo.EXPECT().
+					GetCategoryByID(mock.Anything, uint(0), mock.Anything, mock.Anything).
+					Return(nil, gorm.ErrRecordNotFound).
+					Once()
+				repo.EXPECT().
+					CreateCategory(mock.Anything, entity.Category{OwnerID: owner, Name: "IMPORTED"}).
+					Return(&entity.Category{ID: 3}, nil).
+					Once()
+				br.EXPECT().
+					Count("x7#Lq!9vR2@m").
+					Return(0, nil).
+					Once()
+				repo.EXPECT().
+					CreatePassword(mock.Anything, mock.MatchedBy(func(obj entity.Password) bool {
+						return obj.Username == "amy" && obj.CategoryID == 3 && obj.OwnerID == owner && obj.Strength == 4
+					})).
+					Return(&entity.Password{ID: 9}, nil).
+					Once()
+			},
+			sample: pw.RequestImport{Format: "chrome", DryRun: true, File: importFile(t, sample)},
+			expect: &pw.ResponseImport{
+				DryRun:        true,
+				Total:         3,
+				Imported:      1,
+				Failed:        2,
+				NewCategories: []string{"IMPORTED"},
+				Errors: []*pw.ResponseImportError{
+					{Row: 3, Name: "shop", Message: "password is required"},
+					{Row: 4, Name: "bank", Message: "password strength is below the minimum score of 3"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			h.Dep.config.Set("policy.min_score", 3)
+			tc.setup(h.Dep.repo, h.Dep.breach)
+
+			newUC := password.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.storage, h.Dep.breach, h.Dep.notify, h.Dep.event, h.Dep.repo)
+			res, err := newUC.ImportPassword(ownerCtx(), tc.sample)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, res)
+			h.Dep.repo.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,40 @@
+package delivery
+
+import (
+	"github.com/mdanialr/pwman_backend/internal/domain/report"
+	reportUC "github.com/mdanialr/pwman_backend/internal/domain/report/usecase"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// NewDelivery setup endpoints in domain report as delivery layer.
+func NewDelivery(app fiber.Router, conf *viper.Viper, uc reportUC.UseCase) {
+	d := &delivery{uc: uc}
+
+	api := app.Group("/report", md.JWT(conf))
+	api.Get("/health", d.Health)
+}
+
+type delivery struct {
+	uc reportUC.UseCase
+}
+
+func (d *delivery) Health(c *fiber.Ctx) error {
+	var req report.Request
+	c.QueryParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.Health(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
@@ -0,0 +1,18 @@
+package report
+
+import "github.com/go-playground/validator/v10"
+
+// Request standard request object that may be used in report domain.
+type Request struct {
+	// Days the number of days since the last update before a password is
+	// regarded as old. Default to the one in config.
+	Days int `query:"days" validate:"omitempty,min=1"`
+}
+
+// Validate apply validation rules for Request.
+func (r *Request) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
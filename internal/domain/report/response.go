@@ -0,0 +1,65 @@
+package report
+
+import (
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+)
+
+// ResponseHealth response that's used in use case Health.
+type ResponseHealth struct {
+	// Summary the number of problematic passwords in the whole vault.
+	Summary ResponseHealthSummary `json:"summary"`
+	// Categories the problematic passwords grouped by their category. Only
+	// category that has at least one problem will be listed.
+	Categories []*ResponseHealthCategory `json:"categories"`
+}
+
+// ResponseHealthSummary the number of passwords for each health problem.
+type ResponseHealthSummary struct {
+	Total          int `json:"total"`
+	Reused         int `json:"reused"`
+	Weak           int `json:"weak"`
+	Old            int `json:"old"`
+	Breached       int `json:"breached"`
+	SharedUsername int `json:"shared_username"`
+}
+
+// ResponseHealthCategory problematic passwords that belong to a category.
+type ResponseHealthCategory struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	// Reused passwords that has the same secret with other passwords.
+	Reused []*ResponseHealthItem `json:"reused,omitempty"`
+	// Weak passwords that has strength score below the threshold.
+	Weak []*ResponseHealthItem `json:"weak,omitempty"`
+	// Old passwords that has not been rotated in the given days.
+	Old []*ResponseHealthItem `json:"old,omitempty"`
+	// Breached passwords that has been exposed in known data breaches.
+	Breached []*ResponseHealthItem `json:"breached,omitempty"`
+	// SharedUsername passwords that has the same username with other
+	// passwords in different categories.
+	SharedUsername []*ResponseHealthItem `json:"shared_username,omitempty"`
+}
+
+// ResponseHealthItem a problematic password.
+type ResponseHealthItem struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Strength the estimated strength score. Only filled for weak password.
+	Strength *int `json:"strength,omitempty"`
+	// Related ids of other passwords that share the same secret or the same
+	// username.
+	Related []uint `json:"related,omitempty"`
+}
+
+// NewResponseHealthItemFromEntity transform given entity.Password to
+// ResponseHealthItem.
+func NewResponseHealthItemFromEntity(pw entity.Password) *ResponseHealthItem {
+	return &ResponseHealthItem{
+		ID:        pw.ID,
+		Username:  pw.Username,
+		UpdatedAt: pw.UpdatedAt,
+	}
+}
@@ -0,0 +1,15 @@
+package report
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/report"
+)
+
+// UseCase signature that's used in report domain for use case layer.
+type UseCase interface {
+	// Health go through all passwords then report those that are reused,
+	// weak, old, breached or share the same username across categories.
+	// The result is grouped per category.
+	Health(ctx context.Context, req report.Request) (*report.ResponseHealth, error)
+}
@@ -0,0 +1,236 @@
+package report
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
+	"github.com/mdanialr/pwman_backend/internal/domain/report"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// batchSize the number of passwords that's retrieved at once.
+	batchSize = 500
+	// defaultWeakScore default strength score that's regarded as weak if the
+	// password has lower score than this.
+	defaultWeakScore = 3
+	// defaultMaxAge default number of days before a password is regarded as
+	// old.
+	defaultMaxAge = 90
+)
+
+// NewUseCase return concrete implementation of UseCase in report domain.
+func NewUseCase(conf *viper.Viper, log *zap.Logger, repo pw.Repository) UseCase {
+	return &useCase{conf: conf, log: log, repo: repo}
+}
+
+type useCase struct {
+	conf *viper.Viper
+	log  *zap.Logger
+	repo pw.Repository
+}
+
+func (u *useCase) Health(ctx context.Context, req report.Request) (*report.ResponseHealth, error) {
+	// random key for the keyed hash that only live as long as this report, so
+	// the hashes are useless outside this report
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		u.log.Error(help.Pad("failed to generate key for health report:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	// prepare the thresholds
+	weakScore := defaultWeakScore
+	if u.conf.IsSet("report.weak_score") {
+		weakScore = u.conf.GetInt("report.weak_score")
+	}
+	days := req.Days
+	if days == 0 {
+		days = u.conf.GetInt("report.max_age")
+	}
+	if days == 0 {
+		days = defaultMaxAge
+	}
+	oldBefore := time.Now().AddDate(0, 0, -days)
+
+	h := newHealth()
+	err := pw.EachPassword(ctx, u.repo, batchSize, func(pws []*entity.Password) error {
+		for _, p := range pws {
+			h.Summary.Total++
+
+			// compare the secret using keyed hash then forget the plaintext
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(p.Password))
+			var sum [sha256.Size]byte
+			copy(sum[:], mac.Sum(nil))
+			p.Password = ""
+
+			h.secrets[sum] = append(h.secrets[sum], p)
+			uname := strings.ToLower(p.Username)
+			h.usernames[uname] = append(h.usernames[uname], p)
+
+			// the strength is estimated whenever the password is saved
+			if p.Strength < weakScore {
+				score := p.Strength
+				item := report.NewResponseHealthItemFromEntity(*p)
+				item.Strength = &score
+				h.category(p.CategoryID).Weak = append(h.category(p.CategoryID).Weak, item)
+				h.Summary.Weak++
+			}
+			if p.UpdatedAt.Before(oldBefore) {
+				h.category(p.CategoryID).Old = append(h.category(p.CategoryID).Old, report.NewResponseHealthItemFromEntity(*p))
+				h.Summary.Old++
+			}
+			if p.Breached {
+				h.category(p.CategoryID).Breached = append(h.category(p.CategoryID).Breached, report.NewResponseHealthItemFromEntity(*p))
+				h.Summary.Breached++
+			}
+		}
+		return nil
+	}, repo.Cols("id", "username", "password", "category_id", "strength", "breached", "updated_at"), repo.Where("owner_id = ?", identity.FromContext(ctx).ID))
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve passwords for health report:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	h.collectReused()
+	h.collectSharedUsername()
+
+	// fill in the category names
+	if err = u.fillCategories(ctx, h); err != nil {
+		u.log.Error(help.Pad("failed to retrieve categories for health report:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return h.response(), nil
+}
+
+// fillCategories retrieve the categories that has at least one problem then
+// set their name.
+func (u *useCase) fillCategories(ctx context.Context, h *health) error {
+	if len(h.categories) == 0 {
+		return nil
+	}
+
+	var ids []string
+	for id := range h.categories {
+		ids = append(ids, strconv.Itoa(int(id)))
+	}
+	cats, err := u.repo.FindCategories(ctx, repo.Cols("id", "name"), repo.Cons("id IN ("+strings.Join(ids, ",")+")"))
+	if err != nil {
+		return err
+	}
+	for _, cat := range cats {
+		if c, ok := h.categories[cat.ID]; ok {
+			c.Name = cat.Name
+		}
+	}
+	return nil
+}
+
+// health accumulator while building the health report.
+type health struct {
+	Summary report.ResponseHealthSummary
+	// categories problematic passwords grouped by category id.
+	categories map[uint]*report.ResponseHealthCategory
+	// secrets passwords grouped by the keyed hash of their secret.
+	secrets map[[sha256.Size]byte][]*entity.Password
+	// usernames passwords grouped by their lower-cased username.
+	usernames map[string][]*entity.Password
+}
+
+// newHealth return new initialized health.
+func newHealth() *health {
+	return &health{
+		categories: make(map[uint]*report.ResponseHealthCategory),
+		secrets:    make(map[[sha256.Size]byte][]*entity.Password),
+		usernames:  make(map[string][]*entity.Password),
+	}
+}
+
+// category return the report of given category id, create new one if not
+// exist yet.
+func (h *health) category(id uint) *report.ResponseHealthCategory {
+	c, ok := h.categories[id]
+	if !ok {
+		c = &report.ResponseHealthCategory{ID: id}
+		h.categories[id] = c
+	}
+	return c
+}
+
+// collectReused report every password that share the same secret with other
+// passwords.
+func (h *health) collectReused() {
+	for _, pws := range h.secrets {
+		if len(pws) < 2 {
+			continue
+		}
+		for _, p := range pws {
+			item := report.NewResponseHealthItemFromEntity(*p)
+			item.Related = pluckOtherIDs(pws, p.ID)
+			h.category(p.CategoryID).Reused = append(h.category(p.CategoryID).Reused, item)
+			h.Summary.Reused++
+		}
+	}
+}
+
+// collectSharedUsername report every password that share the same username
+// with other passwords in different categories.
+func (h *health) collectSharedUsername() {
+	for _, pws := range h.usernames {
+		cats := make(map[uint]bool)
+		for _, p := range pws {
+			cats[p.CategoryID] = true
+		}
+		if len(cats) < 2 {
+			continue
+		}
+		for _, p := range pws {
+			item := report.NewResponseHealthItemFromEntity(*p)
+			item.Related = pluckOtherIDs(pws, p.ID)
+			h.category(p.CategoryID).SharedUsername = append(h.category(p.CategoryID).SharedUsername, item)
+			h.Summary.SharedUsername++
+		}
+	}
+}
+
+// response transform the accumulated health to report.ResponseHealth with
+// the categories sorted by their id.
+func (h *health) response() *report.ResponseHealth {
+	res := &report.ResponseHealth{Summary: h.Summary, Categories: []*report.ResponseHealthCategory{}}
+	for _, c := range h.categories {
+		for _, items := range [][]*report.ResponseHealthItem{c.Reused, c.Weak, c.Old, c.Breached, c.SharedUsername} {
+			sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+		}
+		res.Categories = append(res.Categories, c)
+	}
+	sort.Slice(res.Categories, func(i, j int) bool { return res.Categories[i].ID < res.Categories[j].ID })
+	return res
+}
+
+// pluckOtherIDs pluck ids from given passwords except given id.
+func pluckOtherIDs(pws []*entity.Password, except uint) []uint {
+	var ids []uint
+	for _, p := range pws {
+		if p.ID != except {
+			ids = append(ids, p.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
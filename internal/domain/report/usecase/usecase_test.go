@@ -0,0 +1,95 @@
+package report_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/password/repository/mocks"
+	"github.com/mdanialr/pwman_backend/internal/domain/report"
+	reportUC "github.com/mdanialr/pwman_backend/internal/domain/report/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestUseCase_Health(t *testing.T) {
+	t.Run("Given deps repository that failed to retrieve passwords should return UC instance, "+
+		"DEPS_ERROR as code and something wasn't right as message", func(t *testing.T) {
+		repo := new(mocks.MockpasswordRepository)
+		repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, errors.New("error")).
+			Once()
+
+		uc := reportUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo)
+		_, err := uc.Health(context.Background(), report.Request{})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "DEPS_ERROR", err.(*stderr.UC).Code)
+		assert.Equal(t, "something wasn't right", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given passwords with various problems should report them grouped per category", func(t *testing.T) {
+		now := time.Now()
+		old := now.AddDate(0, 0, -100)
+		strong := "x7#Lq!9vR2@m"
+
+		repo := new(mocks.MockpasswordRepository)
+		repo.EXPECT().
+			FindPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Password{
+				// reused across categories
+				{ID: 1, Username: "alice", Password: strong, CategoryID: 1, Strength: 4, UpdatedAt: now},
+				{ID: 2, Username: "bob", Password: strong, CategoryID: 2, Strength: 4, UpdatedAt: now},
+				// weak
+				{ID: 3, Username: "carol", Password: "password", CategoryID: 1, Strength: 0, UpdatedAt: now},
+				// old and breached
+				{ID: 4, Username: "dave", Password: "Kq9!vz#2Lm@x", CategoryID: 2, Strength: 4, UpdatedAt: old, Breached: true},
+				// same username in different category than the first one
+				{ID: 5, Username: "ALICE", Password: "2@mR!x7#9vLq", CategoryID: 2, Strength: 4, UpdatedAt: now},
+			}, nil).
+			Once()
+		repo.EXPECT().
+			FindCategories(mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Category{{ID: 1, Name: "FAKE"}, {ID: 2, Name: "DUMMIES"}}, nil).
+			Once()
+
+		uc := reportUC.NewUseCase(viper.New(), zaptest.NewLogger(t), repo)
+		res, err := uc.Health(context.Background(), report.Request{})
+		require.NoError(t, err)
+
+		assert.Equal(t, report.ResponseHealthSummary{
+			Total:          5,
+			Reused:         2,
+			Weak:           1,
+			Old:            1,
+			Breached:       1,
+			SharedUsername: 2,
+		}, res.Summary)
+
+		require.Len(t, res.Categories, 2)
+		first, second := res.Categories[0], res.Categories[1]
+		assert.Equal(t, "FAKE", first.Name)
+		assert.Equal(t, "DUMMIES", second.Name)
+
+		require.Len(t, first.Reused, 1)
+		assert.Equal(t, uint(1), first.Reused[0].ID)
+		assert.Equal(t, []uint{2}, first.Reused[0].Related)
+		require.Len(t, first.Weak, 1)
+		assert.Equal(t, uint(3), first.Weak[0].ID)
+		require.Len(t, first.SharedUsername, 1)
+		assert.Equal(t, []uint{5}, first.SharedUsername[0].Related)
+
+		require.Len(t, second.Old, 1)
+		assert.Equal(t, uint(4), second.Old[0].ID)
+		require.Len(t, second.Breached, 1)
+		assert.Equal(t, uint(4), second.Breached[0].ID)
+	})
+}
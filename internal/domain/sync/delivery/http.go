@@ -0,0 +1,35 @@
+package delivery
+
+import (
+	"github.com/mdanialr/pwman_backend/internal/domain/sync"
+	syncUC "github.com/mdanialr/pwman_backend/internal/domain/sync/usecase"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// NewDelivery setup endpoints in domain sync as delivery layer.
+func NewDelivery(app fiber.Router, conf *viper.Viper, uc syncUC.UseCase) {
+	d := &delivery{uc: uc}
+
+	api := app.Group("/sync", md.JWT(conf))
+	api.Get("/", d.Delta)
+}
+
+type delivery struct {
+	uc syncUC.UseCase
+}
+
+func (d *delivery) Delta(c *fiber.Ctx) error {
+	var req sync.Request
+	c.QueryParser(&req)
+
+	res, err := d.uc.Delta(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
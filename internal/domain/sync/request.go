@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+)
+
+// tokenPrefix the version of the sync token, so the format can be changed
+// later without breaking the old tokens.
+const tokenPrefix = "v1."
+
+// Request standard request object that may be used in sync domain.
+type Request struct {
+	// Since the sync token from the previous sync. Empty means this is the
+	// first sync, so everything is retrieved.
+	Since string `query:"since"`
+	// Cursor the cursor from the previous page of the same sync. Empty means
+	// this is the first page.
+	Cursor string `query:"cursor"`
+}
+
+// SinceTime decode Since into the time of the previous sync. Return zero time
+// if Since is empty or cons.ErrInvalidToken if it's malformed.
+func (r *Request) SinceTime() (time.Time, error) {
+	if r.Since == "" {
+		return time.Time{}, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(r.Since)
+	if err != nil || !strings.HasPrefix(string(b), tokenPrefix) {
+		return time.Time{}, cons.ErrInvalidToken
+	}
+	us, err := strconv.ParseInt(strings.TrimPrefix(string(b), tokenPrefix), 10, 64)
+	if err != nil || us <= 0 {
+		return time.Time{}, cons.ErrInvalidToken
+	}
+	return time.UnixMicro(us), nil
+}
+
+// CursorOf decode Cursor into the time of the sync that it belong to and the
+// id of the last password in the previous page. Return zero values if Cursor
+// is empty or cons.ErrInvalidToken if it's malformed.
+func (r *Request) CursorOf() (time.Time, uint, error) {
+	if r.Cursor == "" {
+		return time.Time{}, 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(r.Cursor)
+	if err != nil || !strings.HasPrefix(string(b), tokenPrefix) {
+		return time.Time{}, 0, cons.ErrInvalidToken
+	}
+	us, id, ok := strings.Cut(strings.TrimPrefix(string(b), tokenPrefix), ".")
+	if !ok {
+		return time.Time{}, 0, cons.ErrInvalidToken
+	}
+	t, err := strconv.ParseInt(us, 10, 64)
+	if err != nil || t <= 0 {
+		return time.Time{}, 0, cons.ErrInvalidToken
+	}
+	last, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || last == 0 {
+		return time.Time{}, 0, cons.ErrInvalidToken
+	}
+	return time.UnixMicro(t), uint(last), nil
+}
+
+// NewCursor create the opaque cursor of the next page of the sync at given
+// time, which continue after given id of the last password.
+func NewCursor(t time.Time, lastID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + strconv.FormatInt(t.UnixMicro(), 10) + "." + strconv.FormatUint(uint64(lastID), 10)))
+}
+
+// NewToken create the opaque sync token that point to given time. The time is
+// kept in microseconds as that's the precision of the database.
+func NewToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + strconv.FormatInt(t.UnixMicro(), 10)))
+}
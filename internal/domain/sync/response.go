@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"time"
+
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+)
+
+// Response response that's used in use case Delta.
+type Response struct {
+	// Token the sync token that should be sent as since in the next sync,
+	// once every page of this sync is retrieved. It's the same in every page.
+	Token string `json:"token"`
+	// Next the cursor of the next page of this sync. Empty if this is the
+	// last page.
+	Next string `json:"next,omitempty"`
+	// Full whether this is a full sync, so the client should replace its
+	// local data instead of merging it.
+	Full bool `json:"full"`
+	// Passwords the passwords that are created or updated since the previous
+	// sync. The same password may come again in the next sync.
+	Passwords []*pw.Response `json:"passwords"`
+	// Categories the categories that are created or updated since the
+	// previous sync. The same category may come again in the next sync.
+	Categories []*pw.ResponseCategory `json:"categories"`
+	// Deleted the tombstones of the data that are deleted or no longer
+	// accessible since the previous sync.
+	Deleted ResponseDeleted `json:"deleted"`
+}
+
+// NewResponse return pointer Response with given token and empty lists, so
+// they are encoded as empty arrays instead of null.
+func NewResponse(token string) *Response {
+	return &Response{
+		Token:      token,
+		Passwords:  make([]*pw.Response, 0),
+		Categories: make([]*pw.ResponseCategory, 0),
+		Deleted: ResponseDeleted{
+			Passwords:  make([]*ResponseTombstone, 0),
+			Categories: make([]*ResponseTombstone, 0),
+		},
+	}
+}
+
+// ResponseDeleted tombstones grouped by their type.
+type ResponseDeleted struct {
+	Passwords  []*ResponseTombstone `json:"passwords"`
+	Categories []*ResponseTombstone `json:"categories"`
+}
+
+// ResponseTombstone the id of deleted data along with when it's deleted.
+type ResponseTombstone struct {
+	ID        uint      `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// AddPassword append given entity.Password either to Passwords or to the
+// tombstones if it's deleted.
+func (r *Response) AddPassword(p entity.Password) {
+	if p.DeletedAt.Valid {
+		r.Deleted.Passwords = append(r.Deleted.Passwords, &ResponseTombstone{ID: p.ID, DeletedAt: p.DeletedAt.Time})
+		return
+	}
+	r.Passwords = append(r.Passwords, pw.NewResponseFromEntity(p))
+}
+
+// AddRevoked append the tombstones of given passwords and categories that are
+// no longer accessible since given time.
+func (r *Response) AddRevoked(pws []*entity.Password, cats []*entity.Category, at time.Time) {
+	for _, p := range pws {
+		r.Deleted.Passwords = append(r.Deleted.Passwords, &ResponseTombstone{ID: p.ID, DeletedAt: at})
+	}
+	for _, c := range cats {
+		r.Deleted.Categories = append(r.Deleted.Categories, &ResponseTombstone{ID: c.ID, DeletedAt: at})
+	}
+}
+
+// AddCategory append given entity.Category either to Categories or to the
+// tombstones if it's deleted. Also prepend given prefix to both Image & Icon
+// fields after cleaning the trailing slash.
+func (r *Response) AddCategory(c entity.Category, prefix string) {
+	if c.DeletedAt.Valid {
+		r.Deleted.Categories = append(r.Deleted.Categories, &ResponseTombstone{ID: c.ID, DeletedAt: c.DeletedAt.Time})
+		return
+	}
+	r.Categories = append(r.Categories, pw.NewResponseCategoryFromEntity(c, prefix))
+}
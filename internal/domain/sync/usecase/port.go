@@ -0,0 +1,17 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/sync"
+)
+
+// UseCase signature that's used in sync domain for use case layer.
+type UseCase interface {
+	// Delta retrieve passwords and categories that are created, updated,
+	// deleted or no longer accessible since the sync token in given request
+	// along with the new sync token. Retrieve everything that's not deleted
+	// if there is no token. The passwords are retrieved in pages, so the
+	// cursor of the next page is returned too if there is more.
+	Delta(ctx context.Context, req sync.Request) (*sync.Response, error)
+}
@@ -0,0 +1,158 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/password"
+	pw "github.com/mdanialr/pwman_backend/internal/domain/password/repository"
+	"github.com/mdanialr/pwman_backend/internal/domain/sync"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/internal/identity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// overlap how far before the previous sync the changes are looked up.
+	// Covers changes whose timestamp is taken before the previous sync but
+	// committed after it, at the cost of sending some of them twice.
+	overlap = 5 * time.Second
+	// defaultPageSize the default number of passwords in each page.
+	defaultPageSize = 500
+)
+
+// passwordCols the columns of the passwords that are synced. The secret is
+// left out, so nothing is decrypted and it's only retrieved when revealed.
+var passwordCols = []string{"id", "username", "url", "notes", "category_id", "strength", "breached", "favorite",
+	"expires_at", "rotation_days", "revision", "deleted_at"}
+
+// NewUseCase return concrete implementation of UseCase in sync domain.
+func NewUseCase(conf *viper.Viper, log *zap.Logger, repo pw.Repository) UseCase {
+	return &useCase{conf: conf, log: log, repo: repo}
+}
+
+type useCase struct {
+	conf *viper.Viper
+	log  *zap.Logger
+	repo pw.Repository
+}
+
+func (u *useCase) Delta(ctx context.Context, req sync.Request) (*sync.Response, error) {
+	since, err := req.SinceTime()
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, err)
+	}
+	at, after, err := req.CursorOf()
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, err)
+	}
+
+	// take the time before querying, so changes made while querying are
+	// retrieved in the next sync. The next pages keep the time of the first
+	// one for the same reason.
+	now := time.Now()
+	if after != 0 {
+		now = at
+	}
+	res := sync.NewResponse(sync.NewToken(now))
+
+	var (
+		opts []repo.Options
+		from time.Time
+	)
+	if since.IsZero() {
+		res.Full = true
+	} else {
+		from = since.Add(-overlap)
+		opts = append(opts,
+			repo.Unscoped(),
+			repo.Where("updated_at > ? OR deleted_at > ?", from, from),
+		)
+	}
+
+	// only those that the caller may see
+	uid := identity.FromContext(ctx).ID
+	// the categories and the lost access only come in the first page
+	if after == 0 {
+		cats, err := u.repo.FindCategories(ctx, append(opts, password.CategoryVaultsCond(uid), repo.Order("id ASC"))...)
+		if err != nil {
+			u.log.Error(help.Pad("failed to retrieve categories for sync:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		for _, c := range cats {
+			res.AddCategory(*c, u.conf.GetString("storage.url"))
+		}
+		if !res.Full {
+			if err = u.revoked(ctx, res, uid, from, now); err != nil {
+				u.log.Error(help.Pad("failed to retrieve the lost access for sync:", err.Error()))
+				return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+			}
+		}
+	}
+
+	size := defaultPageSize
+	if n := u.conf.GetInt("sync.page_size"); n > 0 {
+		size = n
+	}
+	opts = append(opts,
+		password.PasswordVaultsCond(uid),
+		repo.Cols(passwordCols...),
+		repo.Where("id > ?", after),
+		repo.Order("id ASC"),
+		repo.Limit(size),
+		repo.EagerLoad("Tags"),
+	)
+	pws, err := u.repo.FindPassword(ctx, opts...)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve passwords for sync:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	for _, p := range pws {
+		res.AddPassword(*p)
+	}
+	// a full page means there may be more
+	if len(pws) == size {
+		res.Next = sync.NewCursor(now, pws[len(pws)-1].ID)
+	}
+
+	return res, nil
+}
+
+// revoked add the tombstones of the passwords and categories that the caller
+// of given user id may no longer access since given time to given res, which
+// are recorded whenever a share is revoked, the caller is removed from an
+// organization or they are moved out of the shared categories.
+func (u *useCase) revoked(ctx context.Context, res *sync.Response, uid uint, from, now time.Time) error {
+	revs, err := u.repo.FindRevocations(ctx, repo.Where("user_id = ? AND created_at > ?", uid, from))
+	if err != nil || len(revs) == 0 {
+		return err
+	}
+	var pwIDs, catIDs, orgIDs []uint
+	for _, r := range revs {
+		switch {
+		case r.PasswordID != nil:
+			pwIDs = append(pwIDs, *r.PasswordID)
+		case r.CategoryID != nil:
+			catIDs = append(catIDs, *r.CategoryID)
+		case r.OrgID != nil:
+			orgIDs = append(orgIDs, *r.OrgID)
+		}
+	}
+
+	// leave out those that's still accessible some other way
+	cats, err := u.repo.FindCategories(ctx, repo.Unscoped(), repo.Cols("id"), password.LostCategoriesCond(uid, catIDs, orgIDs), repo.Order("id ASC"))
+	if err != nil {
+		return err
+	}
+	pws, err := u.repo.FindPassword(ctx, repo.Unscoped(), repo.Cols("id"), password.LostPasswordsCond(uid, pwIDs, catIDs, orgIDs), repo.Order("id ASC"))
+	if err != nil {
+		return err
+	}
+	res.AddRevoked(pws, cats, now)
+	return nil
+}
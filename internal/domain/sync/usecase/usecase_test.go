@@ -0,0 +1,225 @@
+package sync_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/password/repository/mocks"
+	"github.com/mdanialr/pwman_backend/internal/domain/sync"
+	syncUC "github.com/mdanialr/pwman_backend/internal/domain/sync/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"gorm.io/gorm"
+)
+
+// anything return n of mock.Anything to match the variadic opts.
+func anything(n int) []any {
+	args := make([]any, n)
+	for i := range args {
+		args[i] = mock.Anything
+	}
+	return args
+}
+
+func TestUseCase_Delta(t *testing.T) {
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	since := sync.NewToken(time.Now().Add(-time.Hour))
+
+	testCases := []struct {
+		name          string
+		sample        sync.Request
+		setup         func(repo *mocks.MockpasswordRepository)
+		wantErr       bool
+		expectCode    string
+		expectFull    bool
+		expectNext    bool
+		expectPws     []uint
+		expectCats    []uint
+		expectDelPws  []uint
+		expectDelCats []uint
+	}{
+		{
+			name:       "Given malformed token should return UC instance and INVALID_PAYLOAD as code",
+			sample:     sync.Request{Since: "not a token"},
+			setup:      func(repo *mocks.MockpasswordRepository) {},
+			wantErr:    true,
+			expectCode: "INVALID_PAYLOAD",
+		},
+		{
+			name:       "Given malformed cursor should return UC instance and INVALID_PAYLOAD as code",
+			sample:     sync.Request{Cursor: sync.NewToken(time.Now())},
+			setup:      func(repo *mocks.MockpasswordRepository) {},
+			wantErr:    true,
+			expectCode: "INVALID_PAYLOAD",
+		},
+		{
+			name:   "Given deps repository that failed to retrieve categories should return UC instance and DEPS_ERROR as code",
+			sample: sync.Request{Since: since},
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.On("FindCategories", anything(5)...).Return(nil, errors.New("error")).Once()
+			},
+			wantErr:    true,
+			expectCode: "DEPS_ERROR",
+		},
+		{
+			name:   "Given deps repository that failed to retrieve passwords should return UC instance and DEPS_ERROR as code",
+			sample: sync.Request{Since: since},
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.On("FindCategories", anything(5)...).Return(nil, nil).Once()
+				repo.On("FindRevocations", anything(2)...).Return(nil, nil).Once()
+				repo.On("FindPassword", anything(9)...).Return(nil, errors.New("error")).Once()
+			},
+			wantErr:    true,
+			expectCode: "DEPS_ERROR",
+		},
+		{
+			name: "Given no token should do a full sync without tombstones",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.On("FindCategories", anything(3)...).
+					Return([]*entity.Category{{ID: 1}, {ID: 2}}, nil).
+					Once()
+				repo.On("FindPassword", anything(7)...).
+					Return([]*entity.Password{{ID: 3}}, nil).
+					Once()
+			},
+			expectFull: true,
+			expectPws:  []uint{3},
+			expectCats: []uint{1, 2},
+		},
+		{
+			name: "Given no token and a full page of passwords should return the cursor of the next page",
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.On("FindCategories", anything(3)...).
+					Return([]*entity.Category{{ID: 1}}, nil).
+					Once()
+				repo.On("FindPassword", anything(7)...).
+					Return([]*entity.Password{{ID: 3}, {ID: 4}}, nil).
+					Once()
+			},
+			expectFull: true,
+			expectNext: true,
+			expectPws:  []uint{3, 4},
+			expectCats: []uint{1},
+		},
+		{
+			name:   "Given cursor should only retrieve the next page of passwords",
+			sample: sync.Request{Cursor: sync.NewCursor(time.Now(), 4)},
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.On("FindPassword", anything(7)...).
+					Return([]*entity.Password{{ID: 5}}, nil).
+					Once()
+			},
+			expectFull: true,
+			expectPws:  []uint{5},
+		},
+		{
+			name:   "Given token should include deleted data as tombstones",
+			sample: sync.Request{Since: since},
+			setup: func(repo *mocks.MockpasswordRepository) {
+				repo.On("FindCategories", anything(5)...).
+					Return([]*entity.Category{
+						{ID: 1},
+						{ID: 2, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+					}, nil).
+					Once()
+				repo.On("FindRevocations", anything(2)...).Return(nil, nil).Once()
+				repo.On("FindPassword", anything(9)...).
+					Return([]*entity.Password{
+						{ID: 3, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+						{ID: 4},
+						{ID: 5},
+					}, nil).
+					Once()
+			},
+			expectPws:     []uint{4, 5},
+			expectCats:    []uint{1},
+			expectDelPws:  []uint{3},
+			expectDelCats: []uint{2},
+		},
+		{
+			name:   "Given token and lost access since then should include those no longer accessible as tombstones",
+			sample: sync.Request{Since: since},
+			setup: func(repo *mocks.MockpasswordRepository) {
+				pwID, orgID := uint(6), uint(9)
+				repo.On("FindCategories", anything(5)...).Return(nil, nil).Once()
+				repo.On("FindRevocations", anything(2)...).
+					Return([]*entity.Revocation{{PasswordID: &pwID}, {OrgID: &orgID}}, nil).
+					Once()
+				repo.On("FindCategories", anything(5)...).
+					Return([]*entity.Category{{ID: 7}}, nil).
+					Once()
+				repo.On("FindPassword", anything(5)...).
+					Return([]*entity.Password{{ID: 6}, {ID: 8}}, nil).
+					Once()
+				repo.On("FindPassword", anything(9)...).Return(nil, nil).Once()
+			},
+			expectDelPws:  []uint{6, 8},
+			expectDelCats: []uint{7},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockpasswordRepository)
+			tc.setup(repo)
+
+			before := time.Now().Truncate(time.Microsecond)
+			conf := viper.New()
+			conf.Set("sync.page_size", 2)
+			uc := syncUC.NewUseCase(conf, zaptest.NewLogger(t), repo)
+			res, err := uc.Delta(context.Background(), tc.sample)
+			repo.AssertExpectations(t)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectFull, res.Full)
+			assert.Equal(t, tc.expectNext, res.Next != "")
+
+			// the new token point to the time of this sync, which is kept by
+			// the next pages
+			next, err := (&sync.Request{Since: res.Token}).SinceTime()
+			require.NoError(t, err)
+			if at, _, _ := tc.sample.CursorOf(); !at.IsZero() {
+				assert.Equal(t, at, next)
+			} else {
+				assert.False(t, next.Before(before))
+			}
+			if res.Next != "" {
+				at, last, err := (&sync.Request{Cursor: res.Next}).CursorOf()
+				require.NoError(t, err)
+				assert.Equal(t, next, at)
+				assert.Equal(t, res.Passwords[len(res.Passwords)-1].ID, last)
+			}
+
+			var pws, cats, delPws, delCats []uint
+			for _, p := range res.Passwords {
+				pws = append(pws, p.ID)
+			}
+			for _, c := range res.Categories {
+				cats = append(cats, c.ID)
+			}
+			for _, d := range res.Deleted.Passwords {
+				delPws = append(delPws, d.ID)
+			}
+			for _, d := range res.Deleted.Categories {
+				delCats = append(delCats, d.ID)
+			}
+			assert.Equal(t, tc.expectPws, pws)
+			assert.Equal(t, tc.expectCats, cats)
+			assert.Equal(t, tc.expectDelPws, delPws)
+			assert.Equal(t, tc.expectDelCats, delCats)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package delivery
+
+import (
+	"time"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	vaultUC "github.com/mdanialr/pwman_backend/internal/domain/vault/usecase"
+	md "github.com/mdanialr/pwman_backend/internal/middleware"
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// defaultUnsealRate the number of shares that may be submitted every minute
+// from the same ip if it's not set in config.
+const defaultUnsealRate = 10
+
+// NewDelivery setup endpoints in domain vault as delivery layer.
+func NewDelivery(app fiber.Router, conf *viper.Viper, uc vaultUC.UseCase) {
+	d := &delivery{uc: uc}
+	rate := defaultUnsealRate
+	if n := conf.GetInt("vault.unseal_rate_limit"); n > 0 {
+		rate = n
+	}
+
+	api := app.Group("/vault")
+	api.Get("/", md.JWT(conf), d.Status)
+	// the whole vault is unlocked at once, so only the admin is allowed
+	api.Post("/unlock", md.JWT(conf), md.Admin(), d.Unlock)
+	api.Post("/lock", md.JWT(conf), md.Admin(), d.Lock)
+	// the holders of the shares may not have an account, and a share alone
+	// reveals nothing, but guessing should still be slowed down
+	api.Post("/unseal", md.RateLimit(rate, time.Minute), d.Unseal)
+	api.Post("/unseal/reset", md.JWT(conf), md.Admin(), d.ResetUnseal)
+}
+
+type delivery struct {
+	uc vaultUC.UseCase
+}
+
+func (d *delivery) Status(c *fiber.Ctx) error {
+	return resp.Success(c, resp.WithData(d.uc.Status(c.Context())))
+}
+
+func (d *delivery) Unlock(c *fiber.Ctx) error {
+	var req vault.RequestUnlock
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.Unlock(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) Lock(c *fiber.Ctx) error {
+	return resp.Success(c, resp.WithData(d.uc.Lock(c.Context())))
+}
+
+func (d *delivery) Unseal(c *fiber.Ctx) error {
+	var req vault.RequestUnseal
+	c.BodyParser(&req)
+
+	// validate the request
+	if err := req.Validate(); err != nil {
+		return resp.Error(c, resp.WithErrValidation(err))
+	}
+
+	res, err := d.uc.Unseal(c.Context(), req)
+	if err != nil {
+		return resp.Error(c, resp.WithErr(err))
+	}
+
+	return resp.Success(c, resp.WithData(res))
+}
+
+func (d *delivery) ResetUnseal(c *fiber.Ctx) error {
+	return resp.Success(c, resp.WithData(d.uc.ResetUnseal(c.Context())))
+}
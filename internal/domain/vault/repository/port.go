@@ -0,0 +1,43 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+)
+
+// Repository signature that's used in vault domain for repository layer.
+type Repository interface {
+	// FindDataKeys retrieve all entity.DataKey that match given condition in
+	// opts.
+	FindDataKeys(ctx context.Context, opts ...repo.Options) ([]*entity.DataKey, error)
+	// SaveDataKey create new entity.DataKey if the id of given object is
+	// zero, otherwise replace the existing one.
+	SaveDataKey(ctx context.Context, obj entity.DataKey) (*entity.DataKey, error)
+	// DeleteDataKeys permanently delete all entity.DataKey that match given
+	// condition in opts.
+	DeleteDataKeys(ctx context.Context, opts ...repo.Options) error
+	// CountSecrets count all entity.Password, including the deleted ones,
+	// that match given condition in opts.
+	CountSecrets(ctx context.Context, opts ...repo.Options) (int64, error)
+	// FindSecrets retrieve the id and the stored secret of all
+	// entity.Password, including the deleted ones, that match given condition
+	// in opts. The secrets are not decrypted.
+	FindSecrets(ctx context.Context, opts ...repo.Options) ([]*entity.Password, error)
+	// RekeySecrets replace the stored secret of each of given Secret, then
+	// save given cursor as the RotatedID of entity.DataKey that match given
+	// id, all in one transaction. The secret that's changed since it's
+	// retrieved is left as is.
+	RekeySecrets(ctx context.Context, keyID, cursor uint, secrets []Secret) error
+}
+
+// Secret the stored secret of an entity.Password that's re-encrypted using
+// the new data key.
+type Secret struct {
+	ID uint
+	// Old the stored secret before it's re-encrypted.
+	Old string
+	// New the stored secret after it's re-encrypted.
+	New string
+}
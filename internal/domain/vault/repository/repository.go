@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// NewRepository return concrete implementation of Repository that use gorm.DB
+// as the data source.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func (r *repository) FindDataKeys(ctx context.Context, opts ...repo.Options) ([]*entity.DataKey, error) {
+	q := r.db.WithContext(ctx).Model(&entity.DataKey{})
+	var k []*entity.DataKey
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return k, q.Find(&k).Error
+}
+
+func (r *repository) SaveDataKey(ctx context.Context, obj entity.DataKey) (*entity.DataKey, error) {
+	return &obj, r.db.WithContext(ctx).Save(&obj).Error
+}
+
+func (r *repository) DeleteDataKeys(ctx context.Context, opts ...repo.Options) error {
+	q := r.db.WithContext(ctx)
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return q.Delete(&entity.DataKey{}).Error
+}
+
+func (r *repository) CountSecrets(ctx context.Context, opts ...repo.Options) (int64, error) {
+	q := r.db.WithContext(ctx).Model(&entity.Password{}).Unscoped()
+	var n int64
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return n, q.Count(&n).Error
+}
+
+func (r *repository) FindSecrets(ctx context.Context, opts ...repo.Options) ([]*entity.Password, error) {
+	q := r.db.WithContext(ctx).Model(&entity.Password{}).Unscoped().Select("id", "password")
+	var p []*entity.Password
+
+	// apply options
+	for _, opt := range opts {
+		q = opt(q)
+	}
+
+	return p, q.Find(&p).Error
+}
+
+func (r *repository) RekeySecrets(ctx context.Context, keyID, cursor uint, secrets []Secret) error {
+	var err error
+	trx := repo.Trx(func(tx *gorm.DB) error {
+		for _, s := range secrets {
+			// the secret that's updated meanwhile is already encrypted using
+			// whatever key is current, so leave it to the verification
+			err = tx.Model(&entity.Password{}).Unscoped().
+				Where("id = ? AND password = ?", s.ID, s.Old).
+				Update("password", s.New).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = tx.Model(&entity.DataKey{ID: keyID}).Update("rotated_id", cursor).Error
+		return err
+	})
+	if q := trx(r.db.WithContext(ctx)); q.Error != nil {
+		return q.Error
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// RequestUnlock request object that's used to unlock the vault.
+type RequestUnlock struct {
+	// MasterPassword the password that unwrap the data key.
+	MasterPassword string `json:"master_password" validate:"required"`
+}
+
+// Validate apply validation rules for RequestUnlock.
+func (r *RequestUnlock) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestChangeMaster request object that's used to change the master
+// password.
+type RequestChangeMaster struct {
+	// Current the current master password. Ignored if the master password is
+	// not set yet.
+	Current string
+	// New the new master password.
+	New string `validate:"required,min=12"`
+}
+
+// Validate apply validation rules for RequestChangeMaster.
+func (r *RequestChangeMaster) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestSplit request object that's used to split the data key into shares.
+type RequestSplit struct {
+	// Master the master password that unwrap the data key.
+	Master string `validate:"required"`
+	// Shares the number of shares.
+	Shares int `validate:"min=2,max=255"`
+	// Threshold the number of shares that's needed to recover the data key.
+	Threshold int `validate:"min=2,ltefield=Shares"`
+}
+
+// Validate apply validation rules for RequestSplit.
+func (r *RequestSplit) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestRecover request object that's used to recover the data key from the
+// shares, then set new master password.
+type RequestRecover struct {
+	// Shares the base64 shares from RequestSplit.
+	Shares []string `validate:"min=2,dive,base64"`
+	// New the new master password.
+	New string `validate:"required,min=12"`
+}
+
+// Validate apply validation rules for RequestRecover.
+func (r *RequestRecover) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestUnseal request object that's used to submit a share to unlock the
+// vault.
+type RequestUnseal struct {
+	// Share one of the base64 shares from RequestSplit.
+	Share string `json:"share" validate:"required,base64"`
+}
+
+// Validate apply validation rules for RequestUnseal.
+func (r *RequestUnseal) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
+
+// RequestRotate request object that's used to rotate the data key.
+type RequestRotate struct {
+	// Master the master password that unwrap the data keys, which also wrap
+	// the new one.
+	Master string `validate:"required"`
+	// Batch the number of passwords that's re-encrypted in each transaction.
+	Batch int `validate:"min=1,max=10000"`
+	// DiscardShares whether to rotate the data key that's split into shares,
+	// which stop working once the old data key is retired, so the new one
+	// should be split again.
+	DiscardShares bool
+}
+
+// Validate apply validation rules for RequestRotate.
+func (r *RequestRotate) Validate() validator.ValidationErrors {
+	if err := validator.New().Struct(r); err != nil {
+		return err.(validator.ValidationErrors)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package vault
+
+// Response response object of the state of the vault.
+type Response struct {
+	// Enabled whether the master password is set.
+	Enabled bool `json:"enabled"`
+	// Locked whether the vault should be unlocked before the passwords can be
+	// used.
+	Locked bool `json:"locked"`
+	// Threshold the number of shares that's needed to unseal the vault. Zero
+	// if the data key is never split.
+	Threshold int `json:"threshold,omitempty"`
+	// Progress the number of shares that's submitted so far.
+	Progress int `json:"progress,omitempty"`
+}
+
+// ResponseRotate response object of the progress of the key rotation.
+type ResponseRotate struct {
+	// Version the version of the data key that the passwords are
+	// re-encrypted to.
+	Version uint `json:"version"`
+	// Created whether the data key of Version is just created, otherwise the
+	// interrupted rotation is resumed.
+	Created bool `json:"created"`
+	// Total the number of passwords, including the deleted ones.
+	Total int64 `json:"total"`
+	// Done the number of passwords that's checked so far.
+	Done int64 `json:"done"`
+	// Rekeyed the number of passwords that's re-encrypted so far.
+	Rekeyed int64 `json:"rekeyed"`
+	// Retired the versions of the old data keys that's removed after every
+	// password is verified.
+	Retired []uint `json:"retired,omitempty"`
+	// Discarded whether the retired data key is split into shares, which no
+	// longer work, so the new one should be split again.
+	Discarded bool `json:"discarded,omitempty"`
+}
@@ -0,0 +1,37 @@
+package vault_test
+
+import (
+	"testing"
+
+	vaultMock "github.com/mdanialr/pwman_backend/internal/domain/vault/repository/mocks"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+type (
+	deps struct {
+		config *viper.Viper
+		log    *zap.Logger
+		keeper *vk.Keeper
+		repo   *vaultMock.MockvaultRepository
+	}
+	helperSetup struct {
+		Dep deps
+	}
+)
+
+func setupTestHelper(t *testing.T) *helperSetup {
+	d := deps{
+		config: viper.New(),
+		log:    zaptest.NewLogger(t),
+		keeper: vk.NewKeeper(0),
+		repo:   new(vaultMock.MockvaultRepository),
+	}
+
+	return &helperSetup{
+		Dep: d,
+	}
+}
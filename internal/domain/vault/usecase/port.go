@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+)
+
+// UseCase signature that's used in vault domain for use case layer.
+type UseCase interface {
+	// Setup mark the vault as locked if the master password is set. Should
+	// be called once before the passwords are used.
+	Setup(ctx context.Context) error
+	// Status return the current state of the vault.
+	Status(ctx context.Context) *vault.Response
+	// Unlock unwrap the data keys using the master password in given request
+	// then keep them in memory until the vault is locked.
+	Unlock(ctx context.Context, req vault.RequestUnlock) (*vault.Response, error)
+	// Lock wipe the data key from memory, so the passwords can not be used
+	// until the vault is unlocked again.
+	Lock(ctx context.Context) *vault.Response
+	// ChangeMaster wrap the data keys again using the new master password in
+	// given request. Create new data key if the master password is not set
+	// yet, which is then kept unlocked so the existing secrets can be
+	// encrypted using Reencrypt.
+	ChangeMaster(ctx context.Context, req vault.RequestChangeMaster) error
+	// SplitKey split the data key that's unwrapped using the master password
+	// in given request into shares using Shamir's scheme. Return the base64
+	// shares, any threshold of which may recover the data key.
+	SplitKey(ctx context.Context, req vault.RequestSplit) ([]string, error)
+	// RecoverKey combine the shares in given request to recover the data key,
+	// then wrap it using the new master password. Used when the master
+	// password is lost.
+	RecoverKey(ctx context.Context, req vault.RequestRecover) error
+	// Unseal collect the share in given request until the threshold is
+	// reached, then unlock the vault using the data key that's recovered from
+	// them. All collected shares are discarded if they're wrong. A share is
+	// rejected if a different one with the same index is already collected.
+	Unseal(ctx context.Context, req vault.RequestUnseal) (*vault.Response, error)
+	// ResetUnseal discard the shares that's collected so far by Unseal.
+	ResetUnseal(ctx context.Context) *vault.Response
+	// RotateKey unlock the vault using all data keys that's unwrapped using
+	// the master password in given request, then add new data key that
+	// encrypt the new secrets. Resume the previous rotation instead if it's
+	// interrupted.
+	RotateKey(ctx context.Context, req vault.RequestRotate) (*vault.ResponseRotate, error)
+	// Reencrypt re-encrypt the secret of every password using the newest data
+	// key, given number of them in each transaction, and call given progress
+	// after each of them. Retire the old data keys once every password is
+	// verified. Should be called after RotateKey.
+	Reencrypt(ctx context.Context, batch int, progress func(*vault.ResponseRotate)) (*vault.ResponseRotate, error)
+	// Sync pick up the data keys that's added or retired since the vault is
+	// unlocked, such as by the rotation in another process.
+	Sync(ctx context.Context)
+}
@@ -0,0 +1,246 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	vaultRepo "github.com/mdanialr/pwman_backend/internal/domain/vault/repository"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/seal"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+)
+
+func (u *useCase) RotateKey(ctx context.Context, req vault.RequestRotate) (*vault.ResponseRotate, error) {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(dks) == 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNoMaster)
+	}
+	keys, err := unwrapAll(dks, req.Master)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrWrongMaster)
+	}
+
+	// the old data key is only retired once the rotation is done, so more
+	// than one means the previous rotation is interrupted
+	latest := dks[len(dks)-1]
+	res := &vault.ResponseRotate{Version: latest.Version}
+	if len(dks) == 1 {
+		// the shares only recover the data key they're split from
+		if latest.Threshold > 0 && !req.DiscardShares {
+			return nil, stderr.NewUCErr(cons.Conflict, cons.ErrSplitRotate)
+		}
+		key, err := vk.NewDataKey()
+		if err != nil {
+			u.log.Error(help.Pad("failed to generate data key:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		// whoever hold the current data key, such as the running server, may
+		// open the new one without the master password
+		sealed, err := seal.Encrypt(key, keys[latest.Version])
+		if err != nil {
+			u.log.Error(help.Pad("failed to seal data key:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		w, err := vk.Wrap(key, req.Master, vk.DefaultParams)
+		if err != nil {
+			u.log.Error(help.Pad("failed to wrap data key:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		dk := &entity.DataKey{Version: latest.Version + 1, SealedKey: sealed}
+		if err = u.saveWrapped(ctx, dk, key, w); err != nil {
+			return nil, err
+		}
+		keys[dk.Version] = key
+		res.Version, res.Created = dk.Version, true
+	}
+	u.keeper.Unlock(keys)
+
+	return res, nil
+}
+
+func (u *useCase) Reencrypt(ctx context.Context, batch int, progress func(*vault.ResponseRotate)) (*vault.ResponseRotate, error) {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(dks) == 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNoMaster)
+	}
+	latest := dks[len(dks)-1]
+	if u.keeper.Current() != latest.Version {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotRotating)
+	}
+
+	// resume after the last password that's re-encrypted
+	res := &vault.ResponseRotate{Version: latest.Version}
+	if res.Total, err = u.repo.CountSecrets(ctx); err != nil {
+		u.log.Error(help.Pad("failed to count passwords:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if res.Done, err = u.repo.CountSecrets(ctx, repo.Where("id <= ?", latest.RotatedID)); err != nil {
+		u.log.Error(help.Pad("failed to count passwords:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	cursor := latest.RotatedID
+	for {
+		pws, err := u.repo.FindSecrets(ctx, repo.Where("id > ?", cursor), repo.Order("id ASC"), repo.Limit(batch))
+		if err != nil {
+			u.log.Error(help.Pad("failed to retrieve passwords:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		if len(pws) == 0 {
+			break
+		}
+
+		secrets, err := u.rekey(pws, latest.Version)
+		if err != nil {
+			u.log.Error(help.Pad("failed to re-encrypt passwords:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		cursor = pws[len(pws)-1].ID
+		if err = u.repo.RekeySecrets(ctx, latest.ID, cursor, secrets); err != nil {
+			u.log.Error(help.Pad("failed to save re-encrypted passwords:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		res.Done += int64(len(pws))
+		res.Rekeyed += int64(len(secrets))
+		progress(res)
+	}
+
+	// the passwords that's changed while they're re-encrypted may still use
+	// the old data key, so check all of them before it's retired
+	stale, err := u.verify(ctx, batch, latest.Version)
+	if err != nil {
+		u.log.Error(help.Pad("failed to verify passwords:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if stale > 0 {
+		// start over from the first password the next time
+		if err = u.repo.RekeySecrets(ctx, latest.ID, 0, nil); err != nil {
+			u.log.Error(help.Pad("failed to reset rotation progress:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		return nil, stderr.NewUC(cons.Conflict, fmt.Sprintf("%d %s, run the rotation again", stale, cons.ErrRotationStale))
+	}
+
+	if len(dks) > 1 {
+		if err = u.repo.DeleteDataKeys(ctx, repo.Where("version < ?", latest.Version)); err != nil {
+			u.log.Error(help.Pad("failed to retire old data keys:", err.Error()))
+			return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		for _, dk := range dks[:len(dks)-1] {
+			res.Retired = append(res.Retired, dk.Version)
+			res.Discarded = res.Discarded || dk.Threshold > 0
+		}
+	}
+	u.keeper.Retain(latest.Version)
+	// the shares of the retired data key can no longer unseal the vault
+	u.mu.Lock()
+	u.threshold = latest.Threshold
+	u.shares = make(map[byte][]byte)
+	u.mu.Unlock()
+
+	return res, nil
+}
+
+func (u *useCase) Sync(ctx context.Context) {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return
+	}
+	if len(dks) == 0 {
+		return
+	}
+	u.keeper.Enable()
+	u.mu.Lock()
+	u.threshold = threshold(dks)
+	u.mu.Unlock()
+	if u.keeper.Locked() {
+		return
+	}
+
+	versions := make([]uint, 0, len(dks))
+	for i, dk := range dks {
+		versions = append(versions, dk.Version)
+		if i == 0 || u.keeper.Has(dk.Version) {
+			continue
+		}
+		key, err := u.keeper.OpenKey(dks[i-1].Version, dk.SealedKey)
+		if err != nil {
+			u.log.Error(help.Pad("failed to open data key version", fmt.Sprint(dk.Version)+":", err.Error()))
+			return
+		}
+		if err = u.keeper.Add(dk.Version, key); err != nil {
+			// locked meanwhile, so it's picked up on the next unlock
+			return
+		}
+	}
+	u.keeper.Retain(versions...)
+}
+
+// rekey return the given passwords, whose secret is not encrypted using the
+// data key of given version, along with their secret that's re-encrypted using
+// it.
+func (u *useCase) rekey(pws []*entity.Password, version uint) ([]vaultRepo.Secret, error) {
+	var res []vaultRepo.Secret
+	for _, p := range pws {
+		if p.Password == "" {
+			continue
+		}
+		if v, ok := vk.KeyVersion(p.Password); ok && v == version {
+			continue
+		}
+		pt, err := u.keeper.Decrypt(p.Password)
+		if err != nil {
+			return nil, fmt.Errorf("password %d: %w", p.ID, err)
+		}
+		ct, err := u.keeper.Encrypt(pt)
+		if err != nil {
+			return nil, fmt.Errorf("password %d: %w", p.ID, err)
+		}
+		res = append(res, vaultRepo.Secret{ID: p.ID, Old: p.Password, New: ct})
+	}
+	return res, nil
+}
+
+// verify return the number of passwords whose secret is not encrypted using
+// the data key of given version, or can not be decrypted using it, by checking
+// given number of them at a time.
+func (u *useCase) verify(ctx context.Context, batch int, version uint) (int, error) {
+	var stale int
+	var cursor uint
+	for {
+		pws, err := u.repo.FindSecrets(ctx, repo.Where("id > ?", cursor), repo.Order("id ASC"), repo.Limit(batch))
+		if err != nil {
+			return 0, err
+		}
+		if len(pws) == 0 {
+			return stale, nil
+		}
+		for _, p := range pws {
+			if p.Password == "" {
+				continue
+			}
+			if v, ok := vk.KeyVersion(p.Password); !ok || v != version {
+				stale++
+				continue
+			}
+			if _, err = u.keeper.Decrypt(p.Password); err != nil {
+				stale++
+			}
+		}
+		cursor = pws[len(pws)-1].ID
+	}
+}
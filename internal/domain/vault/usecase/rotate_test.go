@@ -0,0 +1,319 @@
+package vault_test
+
+import (
+	"context"
+	"testing"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	vaultRepo "github.com/mdanialr/pwman_backend/internal/domain/vault/repository"
+	vaultUC "github.com/mdanialr/pwman_backend/internal/domain/vault/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	"github.com/mdanialr/pwman_backend/pkg/seal"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newRotation return the data key of version 1 and 2 along with the keyring
+// that's in the middle of the rotation.
+func newRotation(t *testing.T, master string) ([]byte, []byte, []*entity.DataKey) {
+	oldKey, oldDK := newDataKey(t, master)
+	newKey, newDK := newDataKey(t, master)
+	sealed, err := seal.Encrypt(newKey, oldKey)
+	require.NoError(t, err)
+	newDK.ID, newDK.Version, newDK.SealedKey = 2, 2, sealed
+
+	return oldKey, newKey, []*entity.DataKey{oldDK, newDK}
+}
+
+// encrypt return given secret that's encrypted using given data key of given
+// version.
+func encrypt(t *testing.T, version uint, key []byte, secret string) string {
+	k := vk.NewKeeper(0)
+	k.Unlock(map[uint][]byte{version: append([]byte{}, key...)})
+	ct, err := k.Encrypt(secret)
+	require.NoError(t, err)
+	return ct
+}
+
+func TestUseCase_RotateKey(t *testing.T) {
+	t.Run("Given wrong master password should return UC instance, INVALID_PAYLOAD as code and invalid "+
+		"master password as message", func(t *testing.T) {
+		_, dk := newDataKey(t, "correct horse battery")
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		_, err := newUC.RotateKey(context.Background(), vault.RequestRotate{Master: "wrong", Batch: 10})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "invalid master password", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given single data key should add the next version that's sealed using the current one", func(t *testing.T) {
+		key, dk := newDataKey(t, "correct horse battery")
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+		var saved entity.DataKey
+		h.Dep.repo.EXPECT().
+			SaveDataKey(mock.Anything, mock.Anything).
+			Run(func(_ context.Context, obj entity.DataKey) { saved = obj }).
+			Return(&entity.DataKey{}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		res, err := newUC.RotateKey(context.Background(), vault.RequestRotate{Master: "correct horse battery", Batch: 10})
+		require.NoError(t, err)
+
+		assert.Equal(t, &vault.ResponseRotate{Version: 2, Created: true}, res)
+		assert.Equal(t, uint(0), saved.ID)
+		assert.Equal(t, uint(2), saved.Version)
+		assert.Equal(t, uint(2), h.Dep.keeper.Current())
+		assert.True(t, h.Dep.keeper.Has(1))
+
+		// whoever hold the old data key may open the new one
+		newKey, err := seal.Open(saved.SealedKey, key)
+		require.NoError(t, err)
+		assert.Equal(t, saved.KeyHash, keyHashOf(newKey))
+	})
+
+	t.Run("Given data key that's split into shares should return UC instance, CONFLICT as code and keep "+
+		"the data key unless the shares are discarded", func(t *testing.T) {
+		key, dk := newDataKey(t, "correct horse battery")
+		splitDataKey(t, key, dk, 5, 3)
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Twice()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		_, err := newUC.RotateKey(context.Background(), vault.RequestRotate{Master: "correct horse battery", Batch: 10})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "CONFLICT", err.(*stderr.UC).Code)
+		assert.Equal(t, cons.ErrSplitRotate.Error(), err.(*stderr.UC).Msg)
+		h.Dep.repo.AssertNotCalled(t, "SaveDataKey", mock.Anything, mock.Anything)
+
+		h.Dep.repo.EXPECT().
+			SaveDataKey(mock.Anything, mock.Anything).
+			Return(&entity.DataKey{}, nil).
+			Once()
+		res, err := newUC.RotateKey(context.Background(), vault.RequestRotate{Master: "correct horse battery", Batch: 10, DiscardShares: true})
+		require.NoError(t, err)
+		assert.Equal(t, &vault.ResponseRotate{Version: 2, Created: true}, res)
+	})
+
+	t.Run("Given interrupted rotation should resume it without adding another data key", func(t *testing.T) {
+		_, _, dks := newRotation(t, "correct horse battery")
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return(dks, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		res, err := newUC.RotateKey(context.Background(), vault.RequestRotate{Master: "correct horse battery", Batch: 10})
+		require.NoError(t, err)
+
+		assert.Equal(t, &vault.ResponseRotate{Version: 2}, res)
+		assert.Equal(t, uint(2), h.Dep.keeper.Current())
+		h.Dep.repo.AssertExpectations(t)
+	})
+}
+
+func TestUseCase_Reencrypt(t *testing.T) {
+	oldKey, newKey, dks := newRotation(t, "correct horse battery")
+	oldCT := encrypt(t, 1, oldKey, "old secret")
+	newCT := encrypt(t, 2, newKey, "new secret")
+
+	// setup return the use case whose keeper hold both data keys
+	setup := func(t *testing.T) (*helperSetup, vaultUC.UseCase) {
+		h := setupTestHelper(t)
+		h.Dep.keeper.Unlock(map[uint][]byte{1: append([]byte{}, oldKey...), 2: append([]byte{}, newKey...)})
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return(dks, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			CountSecrets(mock.Anything).
+			Return(4, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			CountSecrets(mock.Anything, mock.Anything).
+			Return(0, nil).
+			Once()
+		return h, vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+	}
+
+	t.Run("Given passwords of both versions should re-encrypt the old ones then retire the old data key", func(t *testing.T) {
+		h, newUC := setup(t)
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Password{{ID: 1, Password: "plain secret"}, {ID: 2, Password: oldCT}, {ID: 3, Password: newCT}, {ID: 4}}, nil).
+			Once()
+		var rekeyed []vaultRepo.Secret
+		h.Dep.repo.EXPECT().
+			RekeySecrets(mock.Anything, uint(2), uint(4), mock.Anything).
+			Run(func(_ context.Context, _, _ uint, secrets []vaultRepo.Secret) { rekeyed = secrets }).
+			Return(nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		// the verification pass see the re-encrypted ones
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(context.Context, ...repo.Options) ([]*entity.Password, error) {
+				return []*entity.Password{{ID: 1, Password: rekeyed[0].New}, {ID: 2, Password: rekeyed[1].New}, {ID: 3, Password: newCT}, {ID: 4}}, nil
+			}).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			DeleteDataKeys(mock.Anything, mock.Anything).
+			Return(nil).
+			Once()
+
+		var reports []int64
+		res, err := newUC.Reencrypt(context.Background(), 10, func(p *vault.ResponseRotate) {
+			reports = append(reports, p.Done)
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, &vault.ResponseRotate{Version: 2, Total: 4, Done: 4, Rekeyed: 2, Retired: []uint{1}}, res)
+		assert.Equal(t, []int64{4}, reports)
+		require.Len(t, rekeyed, 2)
+		assert.Equal(t, []uint{1, 2}, []uint{rekeyed[0].ID, rekeyed[1].ID})
+		assert.Equal(t, oldCT, rekeyed[1].Old)
+
+		// only the new data key is left and it decrypt the re-encrypted ones
+		assert.False(t, h.Dep.keeper.Has(1))
+		for i, expect := range []string{"plain secret", "old secret"} {
+			v, _ := vk.KeyVersion(rekeyed[i].New)
+			assert.Equal(t, uint(2), v)
+			pt, err := h.Dep.keeper.Decrypt(rekeyed[i].New)
+			require.NoError(t, err)
+			assert.Equal(t, expect, pt)
+		}
+	})
+
+	t.Run("Given old data key that's split into shares should report the shares as discarded once it's "+
+		"retired", func(t *testing.T) {
+		oldKey, newKey, dks := newRotation(t, "correct horse battery")
+		shares := splitDataKey(t, oldKey, dks[0], 5, 3)
+		h := setupTestHelper(t)
+		h.Dep.keeper.Unlock(map[uint][]byte{1: append([]byte{}, oldKey...), 2: append([]byte{}, newKey...)})
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return(dks, nil).
+			Twice()
+		h.Dep.repo.EXPECT().
+			CountSecrets(mock.Anything, mock.Anything).
+			Return(0, nil)
+		h.Dep.repo.EXPECT().
+			CountSecrets(mock.Anything).
+			Return(0, nil)
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil)
+		h.Dep.repo.EXPECT().
+			DeleteDataKeys(mock.Anything, mock.Anything).
+			Return(nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		// a share is already submitted before the rotation is done
+		h.Dep.keeper.Lock()
+		_, err := newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[0]})
+		require.NoError(t, err)
+		h.Dep.keeper.Unlock(map[uint][]byte{1: append([]byte{}, oldKey...), 2: append([]byte{}, newKey...)})
+
+		res, err := newUC.Reencrypt(context.Background(), 10, func(*vault.ResponseRotate) {})
+		require.NoError(t, err)
+
+		assert.True(t, res.Discarded)
+		assert.Equal(t, []uint{1}, res.Retired)
+		// the vault can no longer be unsealed until the new data key is split
+		st := newUC.Status(context.Background())
+		assert.Equal(t, 0, st.Threshold)
+		assert.Equal(t, 0, st.Progress)
+	})
+
+	t.Run("Given password that still use the old data key after the rotation should return UC instance, "+
+		"CONFLICT as code and keep the old data key", func(t *testing.T) {
+		h, newUC := setup(t)
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		// changed meanwhile using the old data key
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Password{{ID: 1, Password: oldCT}, {ID: 2, Password: newCT}}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		// start over the next time
+		h.Dep.repo.EXPECT().
+			RekeySecrets(mock.Anything, uint(2), uint(0), mock.Anything).
+			Return(nil).
+			Once()
+
+		_, err := newUC.Reencrypt(context.Background(), 10, func(*vault.ResponseRotate) {})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "CONFLICT", err.(*stderr.UC).Code)
+		assert.Equal(t, "1 passwords are not re-encrypted using the new data key yet, run the rotation again", err.(*stderr.UC).Msg)
+		assert.True(t, h.Dep.keeper.Has(1))
+		h.Dep.repo.AssertExpectations(t)
+	})
+}
+
+func TestUseCase_Sync(t *testing.T) {
+	oldKey, newKey, dks := newRotation(t, "correct horse battery")
+	h := setupTestHelper(t)
+	h.Dep.keeper.Unlock(map[uint][]byte{1: append([]byte{}, oldKey...)})
+	h.Dep.repo.EXPECT().
+		FindDataKeys(mock.Anything, mock.Anything).
+		Return(dks, nil).
+		Once()
+
+	newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+	newUC.Sync(context.Background())
+
+	// the new secrets are encrypted using the new data key
+	assert.Equal(t, uint(2), h.Dep.keeper.Current())
+	ct, err := h.Dep.keeper.Encrypt("secret")
+	require.NoError(t, err)
+	other := vk.NewKeeper(0)
+	other.Unlock(map[uint][]byte{2: append([]byte{}, newKey...)})
+	pt, err := other.Decrypt(ct)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", pt)
+
+	// the old data key is retired by the rotation
+	h.Dep.repo.EXPECT().
+		FindDataKeys(mock.Anything, mock.Anything).
+		Return(dks[1:], nil).
+		Once()
+	newUC.Sync(context.Background())
+	assert.False(t, h.Dep.keeper.Has(1))
+}
@@ -0,0 +1,176 @@
+package vault
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/shamir"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+)
+
+func (u *useCase) SplitKey(ctx context.Context, req vault.RequestSplit) ([]string, error) {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(dks) == 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNoMaster)
+	}
+	// the shares of the new data key can not open the old one, so wait until
+	// the old one is retired
+	if len(dks) > 1 {
+		return nil, stderr.NewUCErr(cons.Conflict, cons.ErrRotating)
+	}
+	dk := dks[0]
+	key, err := vk.Unwrap(wrapped(dk), req.Master)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrWrongMaster)
+	}
+
+	shares, err := shamir.Split(key, req.Shares, req.Threshold)
+	if err != nil {
+		return nil, stderr.NewUCErrDetail(cons.InvalidPayload, cons.ErrInvalidShares, err.Error())
+	}
+	// keep the hash to verify the recovered key, and the threshold to know
+	// when to combine the submitted shares
+	dk.KeyHash, dk.Shares, dk.Threshold = keyHash(key), req.Shares, req.Threshold
+	if _, err = u.repo.SaveDataKey(ctx, *dk); err != nil {
+		u.log.Error(help.Pad("failed to save data key:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	u.mu.Lock()
+	u.threshold = req.Threshold
+	u.mu.Unlock()
+
+	res := make([]string, 0, len(shares))
+	for _, s := range shares {
+		res = append(res, base64.StdEncoding.EncodeToString(s))
+	}
+	return res, nil
+}
+
+func (u *useCase) RecoverKey(ctx context.Context, req vault.RequestRecover) error {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(dks) == 0 {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNoMaster)
+	}
+	i := split(dks)
+	if i < 0 {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotSplit)
+	}
+
+	shares := make([][]byte, 0, len(req.Shares))
+	for _, s := range req.Shares {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return stderr.NewUCErr(cons.InvalidPayload, cons.ErrInvalidShares)
+		}
+		shares = append(shares, b)
+	}
+	key, err := combine(dks[i], shares)
+	if err != nil {
+		return err
+	}
+	keys, err := openChain(dks, i, key)
+	if err != nil {
+		u.log.Error(help.Pad("failed to open newer data key:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	return u.rewrap(ctx, dks[i:], keys, req.New)
+}
+
+func (u *useCase) Unseal(ctx context.Context, req vault.RequestUnseal) (*vault.Response, error) {
+	if !u.keeper.Locked() {
+		return u.Status(ctx), nil
+	}
+	share, err := base64.StdEncoding.DecodeString(req.Share)
+	if err != nil || len(share) < 2 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrInvalidShares)
+	}
+
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	i := split(dks)
+	if i < 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotSplit)
+	}
+	dk := dks[i]
+
+	u.mu.Lock()
+	u.threshold = dk.Threshold
+	// the same share that's submitted again is not counted twice, but a
+	// different one with the same index should not replace it
+	x := share[len(share)-1]
+	if prev, ok := u.shares[x]; ok && subtle.ConstantTimeCompare(prev, share) != 1 {
+		u.mu.Unlock()
+		return nil, stderr.NewUCErr(cons.Conflict, cons.ErrShareTaken)
+	}
+	u.shares[x] = share
+	if len(u.shares) < dk.Threshold {
+		u.mu.Unlock()
+		return u.Status(ctx), nil
+	}
+	shares := make([][]byte, 0, len(u.shares))
+	for _, s := range u.shares {
+		shares = append(shares, s)
+	}
+	u.shares = make(map[byte][]byte)
+	u.mu.Unlock()
+
+	// start over if any of them is wrong, since there is no way to tell which
+	// one is
+	key, err := combine(dk, shares)
+	if err != nil {
+		return nil, err
+	}
+	// the newer data key that's added by the ongoing rotation is opened
+	// using this one
+	keys, err := openChain(dks, i, key)
+	if err != nil {
+		u.log.Error(help.Pad("failed to open newer data key:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	u.keeper.Unlock(keys)
+
+	return u.Status(ctx), nil
+}
+
+func (u *useCase) ResetUnseal(ctx context.Context) *vault.Response {
+	u.resetShares()
+	return u.Status(ctx)
+}
+
+// resetShares discard the shares that's submitted so far.
+func (u *useCase) resetShares() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.shares = make(map[byte][]byte)
+}
+
+// combine recover the data key from given shares and make sure it's the one
+// of given entity.DataKey.
+func combine(dk *entity.DataKey, shares [][]byte) ([]byte, error) {
+	if len(dk.KeyHash) == 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNotSplit)
+	}
+	key, err := shamir.Combine(shares)
+	if err != nil || subtle.ConstantTimeCompare(keyHash(key), dk.KeyHash) != 1 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrInvalidShares)
+	}
+	return key, nil
+}
@@ -0,0 +1,241 @@
+package vault_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	vaultUC "github.com/mdanialr/pwman_backend/internal/domain/vault/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	"github.com/mdanialr/pwman_backend/pkg/shamir"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// splitDataKey return the base64 shares of the data key in given
+// entity.DataKey that's marked as split.
+func splitDataKey(t *testing.T, key []byte, dk *entity.DataKey, shares, threshold int) []string {
+	ss, err := shamir.Split(key, shares, threshold)
+	require.NoError(t, err)
+
+	dk.Shares, dk.Threshold = shares, threshold
+	dk.KeyHash = keyHashOf(key)
+	var res []string
+	for _, s := range ss {
+		res = append(res, base64.StdEncoding.EncodeToString(s))
+	}
+	return res
+}
+
+func TestUseCase_SplitKey(t *testing.T) {
+	key, dk := newDataKey(t, "correct horse battery")
+	h := setupTestHelper(t)
+	h.Dep.repo.EXPECT().
+		FindDataKeys(mock.Anything, mock.Anything).
+		Return([]*entity.DataKey{dk}, nil).
+		Once()
+	var saved entity.DataKey
+	h.Dep.repo.EXPECT().
+		SaveDataKey(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, obj entity.DataKey) { saved = obj }).
+		Return(&entity.DataKey{}, nil).
+		Once()
+
+	newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+	res, err := newUC.SplitKey(context.Background(), vault.RequestSplit{Master: "correct horse battery", Shares: 5, Threshold: 3})
+	require.NoError(t, err)
+	require.Len(t, res, 5)
+
+	assert.Equal(t, 5, saved.Shares)
+	assert.Equal(t, 3, saved.Threshold)
+	assert.Equal(t, keyHashOf(key), saved.KeyHash)
+
+	var shares [][]byte
+	for _, s := range res[2:] {
+		b, err := base64.StdEncoding.DecodeString(s)
+		require.NoError(t, err)
+		shares = append(shares, b)
+	}
+	recovered, err := shamir.Combine(shares)
+	require.NoError(t, err)
+	assert.Equal(t, key, recovered)
+}
+
+func TestUseCase_Unseal(t *testing.T) {
+	t.Run("Given shares up to the threshold should unlock the vault once the last one is submitted", func(t *testing.T) {
+		key, dk := newDataKey(t, "correct horse battery")
+		shares := splitDataKey(t, key, dk, 5, 3)
+		h := setupTestHelper(t)
+		h.Dep.keeper.Enable()
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Times(3)
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		res, err := newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[4]})
+		require.NoError(t, err)
+		assert.Equal(t, &vault.Response{Enabled: true, Locked: true, Threshold: 3, Progress: 1}, res)
+
+		// the same share again is not counted twice
+		res, err = newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[4]})
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Progress)
+
+		_, err = newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[0]})
+		require.NoError(t, err)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+		res, err = newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[2]})
+		require.NoError(t, err)
+		assert.Equal(t, &vault.Response{Enabled: true, Locked: false, Threshold: 3}, res)
+	})
+
+	t.Run("Given shares of other key should return UC instance, INVALID_PAYLOAD as code and invalid "+
+		"shares as message then start over", func(t *testing.T) {
+		key, dk := newDataKey(t, "correct horse battery")
+		splitDataKey(t, key, dk, 3, 2)
+		other, _ := newDataKey(t, "correct horse battery")
+		shares := splitDataKey(t, other, &entity.DataKey{}, 3, 2)
+		h := setupTestHelper(t)
+		h.Dep.keeper.Enable()
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Times(2)
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		_, err := newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[0]})
+		require.NoError(t, err)
+		_, err = newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[1]})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "invalid shares", err.(*stderr.UC).Msg)
+		assert.True(t, h.Dep.keeper.Locked())
+		assert.Zero(t, newUC.Status(context.Background()).Progress)
+	})
+
+	t.Run("Given different share with the same index as the submitted one should return UC instance, "+
+		"CONFLICT as code and another share with the same index is already submitted as message then "+
+		"keep the submitted one", func(t *testing.T) {
+		key, dk := newDataKey(t, "correct horse battery")
+		shares := splitDataKey(t, key, dk, 3, 2)
+		forged, err := base64.StdEncoding.DecodeString(shares[0])
+		require.NoError(t, err)
+		forged[0] ^= 0xff
+		h := setupTestHelper(t)
+		h.Dep.keeper.Enable()
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Times(3)
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		_, err = newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[0]})
+		require.NoError(t, err)
+		_, err = newUC.Unseal(context.Background(), vault.RequestUnseal{Share: base64.StdEncoding.EncodeToString(forged)})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "CONFLICT", err.(*stderr.UC).Code)
+		assert.Equal(t, "another share with the same index is already submitted", err.(*stderr.UC).Msg)
+		assert.Equal(t, 1, newUC.Status(context.Background()).Progress)
+
+		// the submitted one is still used to unlock the vault
+		res, err := newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[1]})
+		require.NoError(t, err)
+		assert.False(t, res.Locked)
+	})
+
+	t.Run("Given reset after some shares are submitted should discard them", func(t *testing.T) {
+		key, dk := newDataKey(t, "correct horse battery")
+		shares := splitDataKey(t, key, dk, 3, 2)
+		h := setupTestHelper(t)
+		h.Dep.keeper.Enable()
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		res, err := newUC.Unseal(context.Background(), vault.RequestUnseal{Share: shares[0]})
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Progress)
+
+		res = newUC.ResetUnseal(context.Background())
+		assert.Zero(t, res.Progress)
+		assert.True(t, res.Locked)
+	})
+
+	t.Run("Given data key that's never split should return UC instance, INVALID_PAYLOAD as code and "+
+		"data key is not split into shares yet as message", func(t *testing.T) {
+		_, dk := newDataKey(t, "correct horse battery")
+		h := setupTestHelper(t)
+		h.Dep.keeper.Enable()
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		_, err := newUC.Unseal(context.Background(), vault.RequestUnseal{Share: base64.StdEncoding.EncodeToString([]byte{1, 2, 3})})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "data key is not split into shares yet", err.(*stderr.UC).Msg)
+	})
+}
+
+func TestUseCase_RecoverKey(t *testing.T) {
+	key, dk := newDataKey(t, "correct horse battery")
+	shares := splitDataKey(t, key, dk, 3, 2)
+
+	t.Run("Given too few shares should return UC instance, INVALID_PAYLOAD as code and invalid shares "+
+		"as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		err := newUC.RecoverKey(context.Background(), vault.RequestRecover{Shares: shares[:1], New: "staple battery horse"})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "invalid shares", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given enough shares should wrap the recovered data key using the new master password", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+		var saved entity.DataKey
+		h.Dep.repo.EXPECT().
+			SaveDataKey(mock.Anything, mock.Anything).
+			Run(func(_ context.Context, obj entity.DataKey) { saved = obj }).
+			Return(&entity.DataKey{}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		err := newUC.RecoverKey(context.Background(), vault.RequestRecover{Shares: shares[1:], New: "staple battery horse"})
+		require.NoError(t, err)
+
+		res, err := vk.Unwrap(vk.Wrapped{
+			Key:    saved.WrappedKey,
+			Salt:   saved.Salt,
+			Params: vk.Params{Time: saved.KdfTime, Memory: saved.KdfMemory, Threads: saved.KdfThreads},
+		}, "staple battery horse")
+		require.NoError(t, err)
+		assert.Equal(t, key, res)
+	})
+}
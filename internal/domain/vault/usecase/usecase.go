@@ -0,0 +1,222 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	cons "github.com/mdanialr/pwman_backend/internal/constant"
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	vaultRepo "github.com/mdanialr/pwman_backend/internal/domain/vault/repository"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+	"github.com/mdanialr/pwman_backend/pkg/seal"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// NewUseCase return concrete implementation of UseCase in vault domain that
+// keep the data keys in given vk.Keeper.
+func NewUseCase(conf *viper.Viper, log *zap.Logger, k *vk.Keeper, repo vaultRepo.Repository) UseCase {
+	return &useCase{conf: conf, log: log, keeper: k, repo: repo, shares: make(map[byte][]byte)}
+}
+
+type useCase struct {
+	conf   *viper.Viper
+	log    *zap.Logger
+	keeper *vk.Keeper
+	repo   vaultRepo.Repository
+
+	// mu guard the unseal progress below.
+	mu sync.Mutex
+	// threshold the number of shares that's needed to unseal the vault.
+	threshold int
+	// shares the shares that's submitted so far by their x.
+	shares map[byte][]byte
+}
+
+func (u *useCase) Setup(ctx context.Context) error {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		return err
+	}
+	if len(dks) > 0 {
+		u.keeper.Enable()
+		u.mu.Lock()
+		u.threshold = threshold(dks)
+		u.mu.Unlock()
+	}
+	return nil
+}
+
+func (u *useCase) Status(_ context.Context) *vault.Response {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return &vault.Response{
+		Enabled:   u.keeper.Enabled(),
+		Locked:    u.keeper.Locked(),
+		Threshold: u.threshold,
+		Progress:  len(u.shares),
+	}
+}
+
+func (u *useCase) Unlock(ctx context.Context, req vault.RequestUnlock) (*vault.Response, error) {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return nil, stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	if len(dks) == 0 {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrNoMaster)
+	}
+
+	keys, err := unwrapAll(dks, req.MasterPassword)
+	if err != nil {
+		return nil, stderr.NewUCErr(cons.InvalidPayload, cons.ErrWrongMaster)
+	}
+	u.keeper.Unlock(keys)
+
+	return u.Status(ctx), nil
+}
+
+func (u *useCase) Lock(ctx context.Context) *vault.Response {
+	u.keeper.Lock()
+	return u.Status(ctx)
+}
+
+func (u *useCase) ChangeMaster(ctx context.Context, req vault.RequestChangeMaster) error {
+	dks, err := u.keyring(ctx)
+	if err != nil {
+		u.log.Error(help.Pad("failed to retrieve data keys:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+
+	// the master password is set for the first time, so there is nothing to
+	// unwrap yet
+	if len(dks) == 0 {
+		key, err := vk.NewDataKey()
+		if err != nil {
+			u.log.Error(help.Pad("failed to generate data key:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		keys := map[uint][]byte{1: key}
+		if err = u.rewrap(ctx, []*entity.DataKey{{Version: 1}}, keys, req.New); err != nil {
+			return err
+		}
+		// the existing secrets are still stored as is, so keep the data key to
+		// encrypt them using Reencrypt
+		u.keeper.Unlock(keys)
+		return nil
+	}
+	keys, err := unwrapAll(dks, req.Current)
+	if err != nil {
+		return stderr.NewUCErr(cons.InvalidPayload, cons.ErrWrongMaster)
+	}
+
+	// only the wrapping key is changed, so the stored secrets stay as is
+	return u.rewrap(ctx, dks, keys, req.New)
+}
+
+// rewrap wrap the data key of each of given entity.DataKey, which is taken
+// from given keys by its version, using given master password.
+func (u *useCase) rewrap(ctx context.Context, dks []*entity.DataKey, keys map[uint][]byte, password string) error {
+	for _, dk := range dks {
+		w, err := vk.Wrap(keys[dk.Version], password, vk.DefaultParams)
+		if err != nil {
+			u.log.Error(help.Pad("failed to wrap data key:", err.Error()))
+			return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+		}
+		if err = u.saveWrapped(ctx, dk, keys[dk.Version], w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveWrapped replace the wrapped key of given entity.DataKey with given
+// vk.Wrapped of given data key.
+func (u *useCase) saveWrapped(ctx context.Context, dk *entity.DataKey, key []byte, w *vk.Wrapped) error {
+	dk.WrappedKey, dk.Salt = w.Key, w.Salt
+	dk.KdfTime, dk.KdfMemory, dk.KdfThreads = w.Params.Time, w.Params.Memory, w.Params.Threads
+	dk.KeyHash = keyHash(key)
+	if _, err := u.repo.SaveDataKey(ctx, *dk); err != nil {
+		u.log.Error(help.Pad("failed to save data key:", err.Error()))
+		return stderr.NewUCErr(cons.DepsErr, cons.ErrInternalServer)
+	}
+	return nil
+}
+
+// keyring return all entity.DataKey from the oldest version. Return empty
+// without error if the master password is not set yet.
+func (u *useCase) keyring(ctx context.Context) ([]*entity.DataKey, error) {
+	return u.repo.FindDataKeys(ctx, repo.Order("version ASC"))
+}
+
+// unwrapAll unwrap the data key of each of given entity.DataKey using given
+// master password. Return them by their version.
+func unwrapAll(dks []*entity.DataKey, password string) (map[uint][]byte, error) {
+	keys := make(map[uint][]byte, len(dks))
+	for _, dk := range dks {
+		key, err := vk.Unwrap(wrapped(dk), password)
+		if err != nil {
+			return nil, err
+		}
+		keys[dk.Version] = key
+	}
+	return keys, nil
+}
+
+// openChain return given data key of the entity.DataKey at given index along
+// with the newer ones, which are opened one by one using the previous
+// version. Return them by their version.
+func openChain(dks []*entity.DataKey, from int, key []byte) (map[uint][]byte, error) {
+	keys := map[uint][]byte{dks[from].Version: key}
+	for _, dk := range dks[from+1:] {
+		next, err := seal.Open(dk.SealedKey, key)
+		if err != nil {
+			return nil, err
+		}
+		keys[dk.Version], key = next, next
+	}
+	return keys, nil
+}
+
+// split return the index of the entity.DataKey that's split into shares.
+// Return -1 if none of them is.
+func split(dks []*entity.DataKey) int {
+	for i, dk := range dks {
+		if dk.Threshold > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// threshold return the number of shares that's needed to unseal the vault.
+// Return zero if the data key is never split.
+func threshold(dks []*entity.DataKey) int {
+	if i := split(dks); i >= 0 {
+		return dks[i].Threshold
+	}
+	return 0
+}
+
+// wrapped return given entity.DataKey as vk.Wrapped.
+func wrapped(dk *entity.DataKey) vk.Wrapped {
+	return vk.Wrapped{
+		Key:    dk.WrappedKey,
+		Salt:   dk.Salt,
+		Params: vk.Params{Time: dk.KdfTime, Memory: dk.KdfMemory, Threads: dk.KdfThreads},
+	}
+}
+
+// keyHash return the SHA-256 of given data key.
+func keyHash(key []byte) []byte {
+	h := sha256.Sum256(key)
+	return h[:]
+}
@@ -0,0 +1,249 @@
+package vault_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/internal/domain/vault"
+	vaultRepo "github.com/mdanialr/pwman_backend/internal/domain/vault/repository"
+	vaultMock "github.com/mdanialr/pwman_backend/internal/domain/vault/repository/mocks"
+	vaultUC "github.com/mdanialr/pwman_backend/internal/domain/vault/usecase"
+	"github.com/mdanialr/pwman_backend/internal/entity"
+	stderr "github.com/mdanialr/pwman_backend/internal/err"
+	repo "github.com/mdanialr/pwman_backend/internal/repository"
+	vk "github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newDataKey return new data key along with the entity.DataKey that's wrapped
+// using given master password.
+func newDataKey(t *testing.T, master string) ([]byte, *entity.DataKey) {
+	key, err := vk.NewDataKey()
+	require.NoError(t, err)
+	w, err := vk.Wrap(key, master, vk.Params{Time: 1, Memory: 64, Threads: 1})
+	require.NoError(t, err)
+
+	return key, &entity.DataKey{ID: 1, Version: 1, WrappedKey: w.Key, Salt: w.Salt, KdfTime: 1, KdfMemory: 64, KdfThreads: 1}
+}
+
+// keyHashOf return the SHA-256 of given data key.
+func keyHashOf(key []byte) []byte {
+	h := sha256.Sum256(key)
+	return h[:]
+}
+
+func TestUseCase_Unlock(t *testing.T) {
+	key, dk := newDataKey(t, "correct horse battery")
+
+	testCases := []struct {
+		name       string
+		setup      func(repo *vaultMock.MockvaultRepository)
+		sample     vault.RequestUnlock
+		expectCode string
+		expectMsg  string
+		wantErr    bool
+	}{
+		{
+			name: "Given master password that's not set yet should return UC instance, INVALID_PAYLOAD as " +
+				"code and master password is not set yet as message",
+			setup: func(repo *vaultMock.MockvaultRepository) {
+				repo.EXPECT().
+					FindDataKeys(mock.Anything, mock.Anything).
+					Return(nil, nil).
+					Once()
+			},
+			sample:     vault.RequestUnlock{MasterPassword: "correct horse battery"},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "master password is not set yet",
+			wantErr:    true,
+		},
+		{
+			name: "Given wrong master password should return UC instance, INVALID_PAYLOAD as code and " +
+				"invalid master password as message",
+			setup: func(repo *vaultMock.MockvaultRepository) {
+				repo.EXPECT().
+					FindDataKeys(mock.Anything, mock.Anything).
+					Return([]*entity.DataKey{dk}, nil).
+					Once()
+			},
+			sample:     vault.RequestUnlock{MasterPassword: "wrong horse battery"},
+			expectCode: "INVALID_PAYLOAD",
+			expectMsg:  "invalid master password",
+			wantErr:    true,
+		},
+		{
+			name: "Given the right master password should unlock the vault using the data key",
+			setup: func(repo *vaultMock.MockvaultRepository) {
+				repo.EXPECT().
+					FindDataKeys(mock.Anything, mock.Anything).
+					Return([]*entity.DataKey{dk}, nil).
+					Once()
+			},
+			sample: vault.RequestUnlock{MasterPassword: "correct horse battery"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := setupTestHelper(t)
+			tc.setup(h.Dep.repo)
+
+			newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+			res, err := newUC.Unlock(context.Background(), tc.sample)
+
+			if tc.wantErr {
+				require.IsType(t, &stderr.UC{}, err)
+				assert.Equal(t, tc.expectCode, err.(*stderr.UC).Code)
+				assert.Equal(t, tc.expectMsg, err.(*stderr.UC).Msg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, &vault.Response{Enabled: true, Locked: false}, res)
+
+			// the secret that's encrypted using the unlocked key can be
+			// decrypted using the original data key
+			ct, err := h.Dep.keeper.Encrypt("secret")
+			require.NoError(t, err)
+			other := vk.NewKeeper(0)
+			other.Unlock(map[uint][]byte{1: append([]byte{}, key...)})
+			pt, err := other.Decrypt(ct)
+			require.NoError(t, err)
+			assert.Equal(t, "secret", pt)
+		})
+	}
+}
+
+func TestUseCase_Lock(t *testing.T) {
+	key, _ := newDataKey(t, "correct horse battery")
+	h := setupTestHelper(t)
+	h.Dep.keeper.Unlock(map[uint][]byte{1: key})
+
+	newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+	res := newUC.Lock(context.Background())
+
+	assert.Equal(t, &vault.Response{Enabled: true, Locked: true}, res)
+	assert.Equal(t, make([]byte, len(key)), key, "the data key should be wiped")
+}
+
+func TestUseCase_ChangeMaster(t *testing.T) {
+	key, dk := newDataKey(t, "correct horse battery")
+
+	t.Run("Given wrong current master password should return UC instance, INVALID_PAYLOAD as code and "+
+		"invalid master password as message", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		err := newUC.ChangeMaster(context.Background(), vault.RequestChangeMaster{Current: "wrong", New: "staple battery horse"})
+
+		require.IsType(t, &stderr.UC{}, err)
+		assert.Equal(t, "INVALID_PAYLOAD", err.(*stderr.UC).Code)
+		assert.Equal(t, "invalid master password", err.(*stderr.UC).Msg)
+	})
+
+	t.Run("Given the right current master password should wrap the same data key using the new one", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{dk}, nil).
+			Once()
+		var saved entity.DataKey
+		h.Dep.repo.EXPECT().
+			SaveDataKey(mock.Anything, mock.Anything).
+			Run(func(_ context.Context, obj entity.DataKey) { saved = obj }).
+			Return(&entity.DataKey{}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		err := newUC.ChangeMaster(context.Background(), vault.RequestChangeMaster{Current: "correct horse battery", New: "staple battery horse"})
+		require.NoError(t, err)
+
+		assert.Equal(t, dk.ID, saved.ID)
+		res, err := vk.Unwrap(vk.Wrapped{
+			Key:    saved.WrappedKey,
+			Salt:   saved.Salt,
+			Params: vk.Params{Time: saved.KdfTime, Memory: saved.KdfMemory, Threads: saved.KdfThreads},
+		}, "staple battery horse")
+		require.NoError(t, err)
+		assert.Equal(t, key, res)
+	})
+
+	t.Run("Given master password that's not set yet should create new data key then encrypt the existing "+
+		"passwords using it", func(t *testing.T) {
+		h := setupTestHelper(t)
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		var saved entity.DataKey
+		h.Dep.repo.EXPECT().
+			SaveDataKey(mock.Anything, mock.MatchedBy(func(obj entity.DataKey) bool {
+				return obj.ID == 0 && obj.Version == 1 && len(obj.WrappedKey) > 0 && len(obj.Salt) > 0
+			})).
+			Run(func(_ context.Context, obj entity.DataKey) { saved = obj }).
+			Return(&entity.DataKey{}, nil).
+			Once()
+
+		newUC := vaultUC.NewUseCase(h.Dep.config, h.Dep.log, h.Dep.keeper, h.Dep.repo)
+		err := newUC.ChangeMaster(context.Background(), vault.RequestChangeMaster{New: "staple battery horse"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), h.Dep.keeper.Current())
+
+		// the passwords that's saved before are stored as is
+		saved.ID = 1
+		h.Dep.repo.EXPECT().
+			FindDataKeys(mock.Anything, mock.Anything).
+			Return([]*entity.DataKey{&saved}, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			CountSecrets(mock.Anything).
+			Return(1, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			CountSecrets(mock.Anything, mock.Anything).
+			Return(0, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]*entity.Password{{ID: 1, Password: "plain secret"}}, nil).
+			Once()
+		var rekeyed []vaultRepo.Secret
+		h.Dep.repo.EXPECT().
+			RekeySecrets(mock.Anything, uint(1), uint(1), mock.Anything).
+			Run(func(_ context.Context, _, _ uint, secrets []vaultRepo.Secret) { rekeyed = secrets }).
+			Return(nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(context.Context, ...repo.Options) ([]*entity.Password, error) {
+				return []*entity.Password{{ID: 1, Password: rekeyed[0].New}}, nil
+			}).
+			Once()
+		h.Dep.repo.EXPECT().
+			FindSecrets(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+
+		res, err := newUC.Reencrypt(context.Background(), 10, func(*vault.ResponseRotate) {})
+		require.NoError(t, err)
+
+		assert.Equal(t, &vault.ResponseRotate{Version: 1, Total: 1, Done: 1, Rekeyed: 1}, res)
+		require.Len(t, rekeyed, 1)
+		v, ok := vk.KeyVersion(rekeyed[0].New)
+		assert.True(t, ok)
+		assert.Equal(t, uint(1), v)
+		h.Dep.repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+const (
+	// AuditExportPlain action when the vault is exported in plain format.
+	AuditExportPlain = "EXPORT_PLAIN"
+	// AuditExportAll action when every vault, including those of the other
+	// users and the organizations, is exported as encrypted backup.
+	AuditExportAll = "EXPORT_ALL"
+)
+
+// AuditLog object for table `audit_log` that record sensitive actions.
+type AuditLog struct {
+	ID uint `gorm:"primaryKey"`
+	// UserID the id of the User who did the action.
+	UserID uint `gorm:"index"`
+	// Action what was done, one of the Audit constants.
+	Action string `gorm:"index"`
+	// Detail additional information about the action.
+	Detail string
+	// IP the address where the action was requested from.
+	IP        string
+	CreatedAt time.Time
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Blob object for table `blob` that keep an item of the zero-knowledge vault
+// which is encrypted by the client before it's sent.
+type Blob struct {
+	ID      uint `gorm:"primaryKey"`
+	OwnerID uint `gorm:"index"`
+	// Ciphertext the opaque encrypted item.
+	Ciphertext []byte
+	// Meta the unencrypted fields that the client choose to expose for
+	// search.
+	Meta map[string]string `gorm:"type:jsonb;serializer:json;index:,type:gin"`
+	// Revision incremented whenever this is changed by the client, so stale
+	// changes can be detected.
+	Revision  uint `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
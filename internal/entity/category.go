@@ -7,11 +7,22 @@ import (
 )
 
 type Category struct {
-	ID        uint   `gorm:"primarykey"`
-	Name      string `gorm:"unique"`
+	ID uint `gorm:"primarykey"`
+	// OwnerID the id of the User who own this category. Zero if it belongs
+	// to an Organization.
+	OwnerID uint `gorm:"uniqueIndex:idx_category_owner_parent_name"`
+	// OrgID the id of the Organization that own this category. Nil if it's in
+	// the personal vault of the owner.
+	OrgID *uint `gorm:"index"`
+	// ParentID the id of the parent Category. Nil means it's a root category.
+	ParentID  *uint  `gorm:"uniqueIndex:idx_category_owner_parent_name"`
+	Name      string `gorm:"uniqueIndex:idx_category_owner_parent_name"`
 	ImagePath string
 	IconPath  string
+	// Revision incremented whenever this is changed by the user, so stale
+	// changes can be detected.
+	Revision  uint `gorm:"not null;default:1"`
 	CreatedAt time.Time
-	UpdatedAt time.Time
+	UpdatedAt time.Time      `gorm:"index"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
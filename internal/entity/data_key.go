@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+// DataKey object for table `data_key` that keep the key which encrypt the
+// secrets at rest. The key is wrapped using the key that's derived from the
+// master password with Argon2id, so it's useless without the master password.
+// There is more than one only while the key is rotated, and the newest one
+// encrypt the new secrets.
+type DataKey struct {
+	ID uint `gorm:"primaryKey"`
+	// Version the version of the data key that's written along with the
+	// secrets it encrypt.
+	Version uint `gorm:"not null;default:1;uniqueIndex"`
+	// WrappedKey the encrypted data key.
+	WrappedKey []byte
+	// Salt the random salt of Argon2id.
+	Salt []byte
+	// KdfTime the time cost of Argon2id.
+	KdfTime uint32
+	// KdfMemory the memory cost of Argon2id in KiB.
+	KdfMemory uint32
+	// KdfThreads the parallelism of Argon2id.
+	KdfThreads uint8
+	// KeyHash the SHA-256 of the data key, so the key that's recovered from
+	// the shares can be verified.
+	KeyHash []byte
+	// Shares the number of shares the data key is split into. Zero if it's
+	// never split.
+	Shares int
+	// Threshold the number of shares that's needed to recover the data key.
+	Threshold int
+	// SealedKey the data key that's encrypted using the data key of the
+	// previous version, so whoever hold that one may also get this one while
+	// the key is rotated.
+	SealedKey []byte
+	// RotatedID the id of the last password that's re-encrypted using this
+	// data key, so the interrupted rotation resume after it.
+	RotatedID uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,57 @@
+package entity
+
+import "time"
+
+// Emergency access levels that's given once the waiting period is over.
+const (
+	// EmergencyView allow to see and reveal all passwords of the grantor.
+	EmergencyView = "view"
+	// EmergencyTakeover allow to do anything the grantor may do to their
+	// vault.
+	EmergencyTakeover = "takeover"
+)
+
+// Emergency access statuses.
+const (
+	// EmergencyIdle the access is granted but not requested yet.
+	EmergencyIdle = "idle"
+	// EmergencyWaiting the access is requested and the waiting period is
+	// running.
+	EmergencyWaiting = "waiting"
+	// EmergencyApproved the waiting period is over without being rejected.
+	EmergencyApproved = "approved"
+	// EmergencyRejected the request is rejected by the grantor during the
+	// waiting period.
+	EmergencyRejected = "rejected"
+)
+
+// EmergencyAccess object for table `emergency_access` that let a trusted
+// User reach the vault of the grantor if the grantor does not reject their
+// request within the waiting period.
+type EmergencyAccess struct {
+	ID uint `gorm:"primaryKey"`
+	// GrantorID the id of the User who own the vault.
+	GrantorID uint  `gorm:"uniqueIndex:idx_emergency_grantor_grantee"`
+	Grantor   *User `gorm:"foreignKey:GrantorID"`
+	// GranteeID the id of the trusted User.
+	GranteeID uint  `gorm:"uniqueIndex:idx_emergency_grantor_grantee;index"`
+	Grantee   *User `gorm:"foreignKey:GranteeID"`
+	// Access one of the Emergency access level constants.
+	Access string
+	// WaitHours the waiting period in hours before the request is approved.
+	WaitHours int
+	// Status one of the Emergency status constants.
+	Status string
+	// RequestedAt when the access is requested the last time.
+	RequestedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// WaitUntil return when the waiting period of the last request is over.
+func (e *EmergencyAccess) WaitUntil() time.Time {
+	if e.RequestedAt == nil {
+		return time.Time{}
+	}
+	return e.RequestedAt.Add(time.Duration(e.WaitHours) * time.Hour)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// Job object for table `job` that persist the scheduled jobs, so they are
+// still run after the app is restarted.
+type Job struct {
+	ID uint `gorm:"primaryKey"`
+	// Kind which handler should run this job.
+	Kind string
+	// RefID the id of the data this job is about.
+	RefID uint
+	// RunAt when this job should be run.
+	RunAt time.Time `gorm:"index"`
+	// Attempts how many times this job has failed.
+	Attempts int
+	// LastError the error of the last failed attempt.
+	LastError string
+	CreatedAt time.Time
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Member roles in an Organization, ordered from the most to the least allowed.
+const (
+	// RoleOwner allow to do anything including deleting the organization.
+	RoleOwner = "owner"
+	// RoleAdmin allow to manage the members and the categories.
+	RoleAdmin = "admin"
+	// RoleEditor allow to create, update and delete the passwords.
+	RoleEditor = "editor"
+	// RoleViewer allow to see and reveal the passwords.
+	RoleViewer = "viewer"
+)
+
+// Organization object for table `organization` that own the team vault which
+// is shared by all of its members.
+type Organization struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"unique"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// Member object for table `member` that grant a User the access to the vault
+// of an Organization.
+type Member struct {
+	ID     uint          `gorm:"primaryKey"`
+	OrgID  uint          `gorm:"uniqueIndex:idx_member_org_user"`
+	Org    *Organization `gorm:"foreignKey:OrgID"`
+	UserID uint          `gorm:"uniqueIndex:idx_member_org_user"`
+	User   *User         `gorm:"foreignKey:UserID"`
+	// Role one of the Role constants.
+	Role      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -10,8 +10,35 @@ type Password struct {
 	ID         uint `gorm:"primarykey"`
 	Username   string
 	Password   string
+	URL        string
+	Notes      string
 	CategoryID uint
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	// OwnerID the id of the User who own this password, which is always the
+	// owner of its category.
+	OwnerID uint `gorm:"index"`
+	// OrgID the id of the Organization that own this password, which is
+	// always the organization of its category.
+	OrgID    *uint `gorm:"index"`
+	Strength int
+	Breached bool
+	// Favorite whether this password is pinned by the user.
+	Favorite bool
+	// Tags labels of this password across categories.
+	Tags []*Tag `gorm:"many2many:password_tag"`
+	// ExpiresAt when this password should be rotated.
+	ExpiresAt *time.Time
+	// RotationDays rotation interval in days that's used to reset ExpiresAt
+	// whenever the password is rotated.
+	RotationDays int
+	// NotifiedAt when the rotation reminder of current ExpiresAt was sent.
+	NotifiedAt *time.Time
+	// Relevance how similar this password is to the search query. Only
+	// filled when searching.
+	Relevance float64 `gorm:"->;-:migration"`
+	// Revision incremented whenever this is changed by the user, so stale
+	// changes can be detected.
+	Revision  uint `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time      `gorm:"index"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
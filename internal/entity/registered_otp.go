@@ -8,7 +8,9 @@ import (
 
 // RegisteredOTP object for table `registered_otp`.
 type RegisteredOTP struct {
-	ID        uint `gorm:"primaryKey"`
+	ID uint `gorm:"primaryKey"`
+	// UserID the id of the User who used this code.
+	UserID    uint `gorm:"index"`
 	Code      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
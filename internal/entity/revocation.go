@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Revocation object for table `revocation` that record the access of a User
+// that's lost, either to a single password, a whole category including its
+// descendants or the vault of an organization, so they are removed from the
+// devices of the user on the next sync.
+type Revocation struct {
+	ID uint `gorm:"primaryKey"`
+	// UserID the id of the User who lost the access.
+	UserID uint `gorm:"index"`
+	// PasswordID the id of the Password that's no longer accessible.
+	PasswordID *uint
+	// CategoryID the id of the Category that's no longer accessible along
+	// with its descendants.
+	CategoryID *uint
+	// OrgID the id of the Organization whose vault is no longer accessible.
+	OrgID     *uint
+	CreatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// Share permissions. Each one also grant the ones before it.
+const (
+	// ShareView allow to see the password except the secret.
+	ShareView = "view"
+	// ShareReveal allow to see the secret of the password too.
+	ShareReveal = "reveal"
+	// ShareEdit allow to update and delete the password too.
+	ShareEdit = "edit"
+)
+
+// Share object for table `share` that grant other user the access to either a
+// single password or a whole category including its descendants.
+type Share struct {
+	ID uint `gorm:"primaryKey"`
+	// OwnerID the id of the User who own the shared password or category.
+	OwnerID uint  `gorm:"index"`
+	Owner   *User `gorm:"foreignKey:OwnerID"`
+	// GranteeID the id of the User who is granted the access.
+	GranteeID uint  `gorm:"index"`
+	Grantee   *User `gorm:"foreignKey:GranteeID"`
+	// PasswordID the id of the shared Password. Nil if a category is shared.
+	PasswordID *uint `gorm:"index"`
+	// CategoryID the id of the shared Category. Nil if a password is shared.
+	CategoryID *uint `gorm:"index"`
+	// Permission one of the Share constants.
+	Permission string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
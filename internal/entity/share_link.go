@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// ShareLink object for table `share_link` that hand the secret of a password
+// to someone without an account. The secret is encrypted with a key that's
+// only known by the link, so it can not be decrypted using this alone.
+type ShareLink struct {
+	// ID random unguessable identifier that's used in the link.
+	ID string `gorm:"primaryKey"`
+	// PasswordID the id of the shared Password.
+	PasswordID uint `gorm:"index"`
+	// CreatorID the id of the User who create the link.
+	CreatorID uint `gorm:"index"`
+	// Ciphertext the encrypted secret prefixed by the nonce.
+	Ciphertext []byte
+	// ViewsLeft how many times the link can still be opened. The link is
+	// destroyed once it reach zero.
+	ViewsLeft int
+	// ExpiresAt when the link is destroyed even if it's never opened.
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tag object for table `tag` that label passwords across categories. Each user
+// has their own tags.
+type Tag struct {
+	ID uint `gorm:"primarykey"`
+	// OwnerID the id of the User who own this tag.
+	OwnerID uint `gorm:"uniqueIndex:idx_tag_owner_name,where:deleted_at IS NULL"`
+	// Name unique among the tags of the same owner that's not deleted.
+	Name string `gorm:"uniqueIndex:idx_tag_owner_name,where:deleted_at IS NULL"`
+	// Color hex color code such as #ff0000 that's used to display the tag.
+	Color     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// User object for table `user` who own the passwords and categories.
+type User struct {
+	ID       uint   `gorm:"primaryKey"`
+	Username string `gorm:"unique"`
+	// Secret the OTP secret that's used to log in as this user.
+	Secret string
+	// IsAdmin whether this user may do the vault-wide actions such as
+	// exporting the whole vault.
+	IsAdmin bool
+	// SrpSalt the salt of SrpVerifier.
+	SrpSalt []byte
+	// SrpVerifier the SRP-6a verifier of the password of this user. Once it's
+	// set, this user should log in using the password along with the OTP.
+	SrpVerifier []byte
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
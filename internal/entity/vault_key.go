@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// KDF algorithms that the clients may use to derive the key from the master
+// password.
+const (
+	// KdfPBKDF2 PBKDF2 with HMAC-SHA256.
+	KdfPBKDF2 = "pbkdf2-sha256"
+	// KdfArgon2id Argon2id.
+	KdfArgon2id = "argon2id"
+)
+
+// VaultKey object for table `vault_key` that keep what a client need to
+// unlock the zero-knowledge vault of a User. The server never see neither the
+// master password nor the plain symmetric key.
+type VaultKey struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"unique"`
+	// KdfAlgorithm one of the Kdf constants.
+	KdfAlgorithm string
+	// KdfIterations the number of iterations, or the time cost of Argon2id.
+	KdfIterations int
+	// KdfMemory the memory cost of Argon2id in KiB.
+	KdfMemory int
+	// KdfParallelism the parallelism of Argon2id.
+	KdfParallelism int
+	// Salt the random salt of the KDF.
+	Salt []byte
+	// ProtectedKey the symmetric key that encrypt the items, encrypted by the
+	// client using the key that's derived from the master password.
+	ProtectedKey []byte
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
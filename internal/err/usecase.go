@@ -13,10 +13,18 @@ func NewUC(c, m string) error {
 	return &UC{Code: c, Msg: m}
 }
 
+// NewUCErrDetail same as NewUCErr but also attach given detail that should be
+// sent along with the error, such as the current copy of the data.
+func NewUCErrDetail(c string, err error, detail any) error {
+	return &UC{Code: c, Msg: err.Error(), Detail: detail}
+}
+
 // UC standard error object that may be returned by use case layer.
 type UC struct {
 	Code string
 	Msg  string
+	// Detail optional additional data of the error.
+	Detail any
 }
 
 // Error implement error interface.
@@ -0,0 +1,36 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// User the identity of the caller that's taken from the access token.
+type User struct {
+	ID uint
+	// Admin whether the caller is the admin user.
+	Admin bool
+}
+
+// key the context key of User. The fiber locals share the same keys with the
+// context of each request, so the usecase can read what's set by Set.
+type key struct{}
+
+// NewContext return a copy of given ctx that carry given User.
+func NewContext(ctx context.Context, usr User) context.Context {
+	return context.WithValue(ctx, key{}, usr)
+}
+
+// FromContext retrieve the User from given ctx. Return zero User if there is
+// none, which has no access to anything.
+func FromContext(ctx context.Context) User {
+	usr, _ := ctx.Value(key{}).(User)
+	return usr
+}
+
+// Set attach given User to the request, so it's available through
+// FromContext(c.Context()).
+func Set(c *fiber.Ctx, usr User) {
+	c.Locals(key{}, usr)
+}
@@ -1,25 +1,87 @@
 package middleware
 
 import (
+	"strconv"
+
+	"github.com/mdanialr/pwman_backend/internal/identity"
 	resp "github.com/mdanialr/pwman_backend/pkg/response"
 
 	"github.com/gofiber/fiber/v2"
 	jwtMiddleware "github.com/gofiber/jwt/v3"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/spf13/viper"
 )
 
 const (
 	InvalidToken = "Invalid or Expired Token"
+	// AdminOnly message when the endpoint is called by non-admin user.
+	AdminOnly = "Only admin is allowed to do this"
 )
 
-// JWT middleware that use JSON Web Token as access token.
+// JWT middleware that use JSON Web Token as access token. The user in the
+// token is attached to the request using identity.Set.
 func JWT(v *viper.Viper) fiber.Handler {
-	return jwtMiddleware.New(jwtMiddleware.Config{
+	return jwtMiddleware.New(jwtConfig(v))
+}
+
+// JWTStream same as JWT but also accept the access token from query token,
+// since EventSource in browsers can not set the header. Should only be used
+// in streaming endpoints, because the query may be written to the access log.
+func JWTStream(v *viper.Viper) fiber.Handler {
+	conf := jwtConfig(v)
+	conf.TokenLookup = "header:Authorization,query:token"
+	conf.AuthScheme = "Bearer"
+	return jwtMiddleware.New(conf)
+}
+
+// Admin middleware that only allow the admin user. Should be placed after
+// JWT.
+func Admin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !identity.FromContext(c.Context()).Admin {
+			return resp.ErrorCode(c, fiber.StatusForbidden, resp.WithErrMsg(AdminOnly))
+		}
+		return c.Next()
+	}
+}
+
+// jwtConfig return the config of JWT middleware using given viper.
+func jwtConfig(v *viper.Viper) jwtMiddleware.Config {
+	return jwtMiddleware.Config{
 		ContextKey:    "jwt",
 		SigningMethod: "HS256",
 		SigningKey:    []byte(v.GetString("jwt.secret")),
+		// reject the token that does not belong to any user, such as the one
+		// that's issued before there are users
+		SuccessHandler: func(c *fiber.Ctx) error {
+			usr, ok := userFromToken(c.Locals("jwt"))
+			if !ok {
+				return resp.ErrorCode(c, fiber.StatusUnauthorized, resp.WithErrMsg(InvalidToken))
+			}
+			identity.Set(c, usr)
+			return c.Next()
+		},
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			return resp.ErrorCode(c, fiber.StatusUnauthorized, resp.WithErrMsg(InvalidToken))
 		},
-	})
+	}
+}
+
+// userFromToken retrieve the user from the claims of given jwt token.
+func userFromToken(tk any) (identity.User, bool) {
+	token, ok := tk.(*jwt.Token)
+	if !ok {
+		return identity.User{}, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return identity.User{}, false
+	}
+	sub, _ := claims["sub"].(string)
+	id, err := strconv.ParseUint(sub, 10, 64)
+	if err != nil || id == 0 {
+		return identity.User{}, false
+	}
+	adm, _ := claims["adm"].(bool)
+	return identity.User{ID: uint(id), Admin: adm}, true
 }
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"time"
+
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// TooManyRequests message when the endpoint is called too often.
+const TooManyRequests = "Too many requests, try again later"
+
+// RateLimit middleware that only allow given max number of requests from the
+// same ip in every given period.
+func RateLimit(max int, period time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: period,
+		LimitReached: func(c *fiber.Ctx) error {
+			return resp.ErrorCode(c, fiber.StatusTooManyRequests, resp.WithErrMsg(TooManyRequests))
+		},
+	})
+}
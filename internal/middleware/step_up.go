@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// StepUpHeader the header that should contain fresh otp code for
+	// endpoints that require step-up authentication.
+	StepUpHeader = "X-OTP-Code"
+	// StepUpRequired message when the otp code is not provided.
+	StepUpRequired = "Fresh OTP code is required in header " + StepUpHeader
+)
+
+// StepUp middleware that require fresh otp code in StepUpHeader on top of the
+// access token. Should be placed after JWT. Given verify is used to check the
+// code, usually the VerifyStepUp from auth use case.
+func StepUp(verify func(ctx context.Context, code string) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		code := c.Get(StepUpHeader)
+		if code == "" {
+			return resp.ErrorCode(c, fiber.StatusUnauthorized, resp.WithErrMsg(StepUpRequired))
+		}
+		if err := verify(c.Context(), code); err != nil {
+			return resp.ErrorCode(c, fiber.StatusUnauthorized, resp.WithErr(err))
+		}
+		return c.Next()
+	}
+}
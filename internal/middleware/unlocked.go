@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	resp "github.com/mdanialr/pwman_backend/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VaultLocked message when the endpoint is called while the vault is locked.
+const VaultLocked = "Vault is locked, unlock it using the master password first"
+
+// Unlocked middleware that reject the request while given locked return true,
+// usually the Locked of vault.Keeper. Should be placed in the endpoints that
+// use the secrets of the passwords.
+func Unlocked(locked func() bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if locked() {
+			return resp.ErrorCode(c, fiber.StatusLocked, resp.WithErrMsg(VaultLocked))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,7 @@
+package repo
+
+import "errors"
+
+// ErrNotAffected returned by the repository when there is no row that match
+// the condition of an update or a delete. Useful to detect stale changes.
+var ErrNotAffected = errors.New("no row is affected")
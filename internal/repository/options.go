@@ -4,6 +4,7 @@ import (
 	"github.com/mdanialr/pwman_backend/pkg/pagination"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Options signature that should be used to optionally add query to each
@@ -22,6 +23,30 @@ func Cols(cols ...string) Options {
 	}
 }
 
+// Select add query Select using given expression along with its args. Useful
+// to select computed columns.
+//
+// Example:
+//
+//	repo.Select("*, similarity(name, ?) AS score", q)
+func Select(query string, args ...any) Options {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Select(query, args...)
+	}
+}
+
+// Omit add query Omit. Useful to prevent auto-updated columns such as
+// updated_at from being changed.
+//
+// Example:
+//
+//	repo.Omit("updated_at")
+func Omit(cols ...string) Options {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Omit(cols...)
+	}
+}
+
 // Order add query Order.
 //
 // Example:
@@ -51,6 +76,18 @@ func Cons(cons ...string) Options {
 	}
 }
 
+// Where add parameterized query Where. Multiple Where will be combined by
+// GORM using AND.
+//
+// Example:
+//
+//	repo.Where("created_at >= ? AND created_at < ?", from, to)
+func Where(query string, args ...any) Options {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
 // Ors add query Where for each given cons. Each given conditions will be
 // combined by GORM using OR.
 //
@@ -66,6 +103,17 @@ func Ors(cons ...string) Options {
 	}
 }
 
+// Limit add query Limit.
+//
+// Example:
+//
+//	repo.Limit(100)
+func Limit(n int) Options {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Limit(n)
+	}
+}
+
 // Trx wrap given function inside database transaction. Commit the transaction
 // if no error returned by function otherwise will do roll back instead.
 //
@@ -115,3 +163,28 @@ func Paginate(p *paginate.M) Options {
 	}
 	return p.Set
 }
+
+// Unscoped include soft deleted rows in the query. Useful to find out which
+// rows have been deleted.
+//
+// Example:
+//
+//	repo.Unscoped()
+func Unscoped() Options {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+}
+
+// ForUpdate lock the selected rows until the end of the transaction, so other
+// transactions can not change them meanwhile. Should be used inside a
+// transaction.
+//
+// Example:
+//
+//	repo.ForUpdate()
+func ForUpdate() Options {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/mdanialr/pwman_backend/internal/app"
+	"github.com/mdanialr/pwman_backend/pkg/breach"
 	"github.com/mdanialr/pwman_backend/pkg/migration"
 	"github.com/mdanialr/pwman_backend/pkg/otp"
 	"github.com/mdanialr/pwman_backend/pkg/twofa"
@@ -15,9 +17,19 @@ import (
 
 var (
 	isGenerateSecret          bool
+	isSplitKey, isRecoverKey  bool
+	shares, threshold         int
 	isMigrate, isDrop, isSeed bool
 	generateQR                string
 	verify                    string
+	breachDump                string
+	exportPath, backupPath    string
+	isExportAll               bool
+	conflict                  string
+	addUser                   string
+	isChangeMaster            bool
+	isRotateKey, isDiscard    bool
+	batch                     int
 )
 
 func init() {
@@ -25,8 +37,22 @@ func init() {
 	flag.BoolVar(&isSeed, "seed", false, "Run available seeders. This can only be used with -migrate")
 	flag.BoolVar(&isDrop, "drop", false, "Drop all tables! WARNING! this will delete all data inside the database. This can only be used with -migrate")
 	flag.BoolVar(&isGenerateSecret, "gen", false, "Generate secret that can be placed in app config")
+	flag.BoolVar(&isSplitKey, "split-key", false, "Split the data key of the vault into shares using Shamir's scheme, any -threshold of which may recover it. The master password is read from "+app.MasterEnv+" or asked from stdin")
+	flag.IntVar(&shares, "shares", 5, "Number of shares. This can only be used with -split-key")
+	flag.IntVar(&threshold, "threshold", 3, "Number of shares that's needed to recover the data key. This can only be used with -split-key")
+	flag.BoolVar(&isRecoverKey, "recover-key", false, "Recover the data key from the shares that's asked from stdin, then set new master password that's read from "+app.NewMasterEnv+" or asked from stdin")
 	flag.StringVar(&generateQR, "qr", "", "Generate QR code to given readable directory or full path")
 	flag.StringVar(&verify, "verify", "", "Verify the given code")
+	flag.StringVar(&breachDump, "breach-rebuild", "", "Rebuild the breach file that's set in app config from the given downloaded Pwned Passwords SHA-1 dump")
+	flag.StringVar(&exportPath, "export", "", "Export the personal vault of the owner as encrypted backup to the given path. The passphrase is read from "+app.PassphraseEnv+" or asked from stdin")
+	flag.BoolVar(&isExportAll, "all", false, "Export every vault instead, including those of the other users and the organizations. This can only be used with -export")
+	flag.StringVar(&backupPath, "import-backup", "", "Restore the encrypted backup from the given path. The passphrase is read from "+app.PassphraseEnv+" or asked from stdin")
+	flag.StringVar(&conflict, "conflict", "skip", "What to do with password from backup that has the same category and username with existing one. Either skip, overwrite or duplicate. This can only be used with -import-backup")
+	flag.StringVar(&addUser, "add-user", "", "Register new user with the given username then print the OTP secret of the user")
+	flag.BoolVar(&isChangeMaster, "change-master", false, "Change the master password that unlock the vault, or set it for the first time. The passwords are read from "+app.MasterEnv+" and "+app.NewMasterEnv+" or asked from stdin")
+	flag.BoolVar(&isRotateKey, "rotate-key", false, "Replace the data key of the vault with new one, then re-encrypt every password using it. Resume the previous rotation if it's interrupted. The master password is read from "+app.MasterEnv+" or asked from stdin")
+	flag.IntVar(&batch, "batch", 500, "Number of passwords that's re-encrypted in each transaction. This can only be used with -rotate-key or -change-master")
+	flag.BoolVar(&isDiscard, "discard-shares", false, "Rotate the data key even though it's split into shares, which stop working once the rotation is done, so -split-key should be run again. This can only be used with -rotate-key")
 	flag.Parse()
 }
 
@@ -39,6 +65,23 @@ func main() {
 		fmt.Println("Your secret:", sec)
 		return
 	}
+	if isSplitKey || isRecoverKey {
+		cli, err := app.NewCLI()
+		if err != nil {
+			log.Fatalln("failed to init cli:", err)
+		}
+		if isSplitKey {
+			if err = cli.SplitKey(shares, threshold); err != nil {
+				log.Fatalln("failed to split key:", err)
+			}
+			return
+		}
+		if err = cli.RecoverKey(); err != nil {
+			log.Fatalln("failed to recover key:", err)
+		}
+		fmt.Println("DONE")
+		return
+	}
 	if verify != "" {
 		if !twofa.Verify(verify) {
 			fmt.Println("ERR: INVALID")
@@ -52,6 +95,60 @@ func main() {
 		os.WriteFile(strings.TrimSuffix(generateQR, "/")+"/qr.png", qr, 0660)
 		return
 	}
+	if breachDump != "" {
+		if err := breach.RebuildWithConfig(breachDump); err != nil {
+			log.Fatalln("failed to rebuild breach file:", err)
+		}
+		fmt.Println("DONE")
+		return
+	}
+	if addUser != "" {
+		cli, err := app.NewCLI()
+		if err != nil {
+			log.Fatalln("failed to init cli:", err)
+		}
+		if err = cli.AddUser(addUser); err != nil {
+			log.Fatalln("failed to add user:", err)
+		}
+		return
+	}
+	if isChangeMaster {
+		cli, err := app.NewCLI()
+		if err != nil {
+			log.Fatalln("failed to init cli:", err)
+		}
+		if err = cli.ChangeMaster(batch); err != nil {
+			log.Fatalln("failed to change master password:", err)
+		}
+		fmt.Println("DONE")
+		return
+	}
+	if isRotateKey {
+		cli, err := app.NewCLI()
+		if err != nil {
+			log.Fatalln("failed to init cli:", err)
+		}
+		if err = cli.RotateKey(batch, isDiscard); err != nil {
+			log.Fatalln("failed to rotate key:", err)
+		}
+		fmt.Println("DONE")
+		return
+	}
+	if exportPath != "" || backupPath != "" {
+		cli, err := app.NewCLI()
+		if err != nil {
+			log.Fatalln("failed to init cli:", err)
+		}
+		if exportPath != "" {
+			if err = cli.Export(exportPath, isExportAll); err != nil {
+				log.Fatalln("failed to export:", err)
+			}
+		} else if err = cli.ImportBackup(backupPath, conflict); err != nil {
+			log.Fatalln("failed to import backup:", err)
+		}
+		fmt.Println("DONE")
+		return
+	}
 	if isMigrate {
 		migration.Run(isSeed, isDrop)
 		return
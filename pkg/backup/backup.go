@@ -0,0 +1,216 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// FormatVersion the current version of the archive content. Bump this
+// whenever the Manifest is changed in incompatible way.
+const FormatVersion = 1
+
+const (
+	// manifestName the name of the manifest inside the archive.
+	manifestName = "manifest.json"
+	// filesDir the directory inside the archive where the media files are
+	// stored.
+	filesDir = "files/"
+)
+
+// Manifest the content of a backup beside the media files.
+type Manifest struct {
+	Version    int        `json:"version"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Categories []Category `json:"categories"`
+	Tags       []Tag      `json:"tags,omitempty"`
+	Passwords  []Password `json:"passwords"`
+}
+
+// Category a category in the backup. The ImagePath and IconPath are the name
+// of the media files inside the backup. ParentID refer to the ID of another
+// Category in the same backup.
+type Category struct {
+	ID        uint      `json:"id"`
+	ParentID  *uint     `json:"parent_id,omitempty"`
+	Name      string    `json:"name"`
+	ImagePath string    `json:"image_path"`
+	IconPath  string    `json:"icon_path"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Tag a tag in the backup.
+type Tag struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// Password a password in the backup. CategoryID and TagIDs refer to the ID of
+// Category and Tag in the same backup.
+type Password struct {
+	ID           uint       `json:"id"`
+	Username     string     `json:"username"`
+	Password     string     `json:"password"`
+	URL          string     `json:"url"`
+	Notes        string     `json:"notes"`
+	CategoryID   uint       `json:"category_id"`
+	Strength     int        `json:"strength"`
+	Breached     bool       `json:"breached"`
+	Favorite     bool       `json:"favorite,omitempty"`
+	TagIDs       []uint     `json:"tag_ids,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RotationDays int        `json:"rotation_days"`
+	NotifiedAt   *time.Time `json:"notified_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Writer write a password-protected backup. The archive is a gzipped tar
+// that's encrypted using AES-GCM with key derived by Argon2id.
+type Writer struct {
+	enc io.WriteCloser
+	gz  *gzip.Writer
+	tw  *tar.Writer
+}
+
+// NewWriter return new Writer that write the backup to given w using given
+// passphrase. Close must be called to finish the backup.
+func NewWriter(w io.Writer, pass string) (*Writer, error) {
+	return NewWriterWithParams(w, pass, DefaultParams)
+}
+
+// NewWriterWithParams same as NewWriter but use given Argon2id parameters.
+func NewWriterWithParams(w io.Writer, pass string, p Params) (*Writer, error) {
+	enc, err := newEncrypter(w, pass, p)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(enc)
+	return &Writer{enc: enc, gz: gz, tw: tar.NewWriter(gz)}, nil
+}
+
+// WriteManifest write given Manifest to the backup. Version is always set to
+// FormatVersion.
+func (w *Writer) WriteManifest(m Manifest) error {
+	m.Version = FormatVersion
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return w.write(manifestName, int64(len(b)), strings.NewReader(string(b)))
+}
+
+// WriteFile write a media file with given name and size to the backup.
+func (w *Writer) WriteFile(name string, size int64, r io.Reader) error {
+	name, ok := cleanName(name)
+	if !ok {
+		return errors.New("invalid file name: " + name)
+	}
+	return w.write(filesDir+name, size, r)
+}
+
+// write write a single tar entry.
+func (w *Writer) write(name string, size int64, r io.Reader) error {
+	hd := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: time.Now(),
+	}
+	if err := w.tw.WriteHeader(hd); err != nil {
+		return err
+	}
+	_, err := io.CopyN(w.tw, r, size)
+	return err
+}
+
+// Close finish the backup. This does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+	return w.enc.Close()
+}
+
+// Read decrypt the backup from given r using given passphrase then return the
+// Manifest. Every media file inside the backup is passed to given fn along
+// with its name.
+func Read(r io.Reader, pass string, fn func(name string, r io.Reader) error) (*Manifest, error) {
+	dec, err := newDecrypter(r, pass)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(dec)
+	if err != nil {
+		return nil, wrapReadErr(err)
+	}
+	defer gz.Close()
+
+	var m *Manifest
+	tr := tar.NewReader(gz)
+	for {
+		hd, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, wrapReadErr(err)
+		}
+
+		switch {
+		case hd.Name == manifestName:
+			m = &Manifest{}
+			if err = json.NewDecoder(tr).Decode(m); err != nil {
+				return nil, ErrInvalidFormat
+			}
+			if m.Version > FormatVersion {
+				return nil, ErrUnsupportedVersion
+			}
+		case strings.HasPrefix(hd.Name, filesDir):
+			name, ok := cleanName(strings.TrimPrefix(hd.Name, filesDir))
+			if !ok {
+				return nil, ErrInvalidFormat
+			}
+			if err = fn(name, tr); err != nil {
+				return nil, wrapReadErr(err)
+			}
+		}
+	}
+
+	if m == nil {
+		return nil, ErrInvalidFormat
+	}
+	return m, nil
+}
+
+// wrapReadErr make sure errors from the decrypter are returned as is, and the
+// errors from malformed content are returned as ErrInvalidFormat.
+func wrapReadErr(err error) error {
+	if errors.Is(err, ErrDecrypt) {
+		return ErrDecrypt
+	}
+	if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) || errors.Is(err, tar.ErrHeader) {
+		return ErrInvalidFormat
+	}
+	return err
+}
+
+// cleanName make sure given name of media file does not escape the storage
+// directory. Return false if it does.
+func cleanName(name string) (string, bool) {
+	cl := path.Clean("/" + name)[1:]
+	if cl == "" || cl != name || strings.Contains(cl, "..") {
+		return name, false
+	}
+	return cl, true
+}
@@ -0,0 +1,157 @@
+package backup_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/pkg/backup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastParams cheap Argon2id parameters, so the tests run fast.
+var fastParams = backup.Params{Time: 1, Memory: 64, Threads: 1}
+
+// newBackup create a backup that contain given manifest and files.
+func newBackup(t *testing.T, pass string, m backup.Manifest, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	w, err := backup.NewWriterWithParams(&buf, pass, fastParams)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteManifest(m))
+	for name, b := range files {
+		require.NoError(t, w.WriteFile(name, int64(len(b)), bytes.NewReader(b)))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	m := backup.Manifest{
+		CreatedAt:  now,
+		Categories: []backup.Category{{ID: 1, Name: "FAKE", ImagePath: "img.png", IconPath: "icons/ico.png"}},
+		Passwords:  []backup.Password{{ID: 2, Username: "alice", Password: "secret", CategoryID: 1, CreatedAt: now}},
+	}
+	// make sure the media files span multiple segments, including the exact
+	// multiple of the segment size
+	big := make([]byte, 3*64*1024)
+	rand.Read(big)
+	files := map[string][]byte{
+		"img.png":       big,
+		"icons/ico.png": []byte("icon"),
+	}
+	bak := newBackup(t, "correct horse", m, files)
+
+	t.Run("Given the correct passphrase should return the manifest and all the media files", func(t *testing.T) {
+		got := make(map[string][]byte)
+		res, err := backup.Read(bytes.NewReader(bak), "correct horse", func(name string, r io.Reader) error {
+			b, err := io.ReadAll(r)
+			got[name] = b
+			return err
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, backup.FormatVersion, res.Version)
+		assert.Equal(t, m.Categories, res.Categories)
+		assert.Equal(t, m.Passwords, res.Passwords)
+		assert.Equal(t, files, got)
+	})
+
+	testCases := []struct {
+		name      string
+		sample    func() []byte
+		pass      string
+		expectErr error
+	}{
+		{
+			name:      "Given wrong passphrase should return ErrDecrypt",
+			sample:    func() []byte { return bak },
+			pass:      "wrong horse",
+			expectErr: backup.ErrDecrypt,
+		},
+		{
+			name:      "Given truncated backup at the segment boundary should return ErrDecrypt",
+			sample:    func() []byte { return bak[:len(bak)-(len(bak)-39)%(64*1024+16)] },
+			pass:      "correct horse",
+			expectErr: backup.ErrDecrypt,
+		},
+		{
+			name: "Given tampered backup should return ErrDecrypt",
+			sample: func() []byte {
+				b := bytes.Clone(bak)
+				b[len(b)-1] ^= 1
+				return b
+			},
+			pass:      "correct horse",
+			expectErr: backup.ErrDecrypt,
+		},
+		{
+			name:      "Given file that's not a backup should return ErrInvalidFormat",
+			sample:    func() []byte { return []byte("username,password\nalice,secret\n") },
+			pass:      "correct horse",
+			expectErr: backup.ErrInvalidFormat,
+		},
+		{
+			name: "Given backup from newer version should return ErrUnsupportedVersion",
+			sample: func() []byte {
+				b := bytes.Clone(bak)
+				b[6] = 99
+				return b
+			},
+			pass:      "correct horse",
+			expectErr: backup.ErrUnsupportedVersion,
+		},
+		{
+			name: "Given backup whose header ask for too much memory should return ErrParamsTooHigh",
+			sample: func() []byte {
+				b := bytes.Clone(bak)
+				// the memory follow the magic, the version and the time
+				b[11] = 0xff
+				return b
+			},
+			pass:      "correct horse",
+			expectErr: backup.ErrParamsTooHigh,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := backup.Read(bytes.NewReader(tc.sample()), tc.pass, func(string, io.Reader) error {
+				return nil
+			})
+			assert.ErrorIs(t, err, tc.expectErr)
+		})
+	}
+}
+
+func TestNewWriterWithParams(t *testing.T) {
+	t.Run("Given parameters above MaxParams should return ErrParamsTooHigh", func(t *testing.T) {
+		p := backup.MaxParams
+		p.Time++
+		_, err := backup.NewWriterWithParams(io.Discard, "pass", p)
+		assert.ErrorIs(t, err, backup.ErrParamsTooHigh)
+	})
+}
+
+func TestWriter_WriteFile(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sample string
+	}{
+		{name: "Given name that escape the storage directory should return error", sample: "../etc/passwd"},
+		{name: "Given absolute name should return error", sample: "/etc/passwd"},
+		{name: "Given empty name should return error", sample: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := backup.NewWriterWithParams(io.Discard, "pass", fastParams)
+			require.NoError(t, err)
+			assert.Error(t, w.WriteFile(tc.sample, 1, bytes.NewReader([]byte("x"))))
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// containerVersion version of the encrypted container layout.
+	containerVersion = 1
+	// segmentSize the size of plaintext that's sealed at once.
+	segmentSize = 64 * 1024
+	// saltSize the size of random salt for the key derivation.
+	saltSize = 16
+	// noncePrefixSize the size of random nonce prefix. The rest of the nonce
+	// is the segment counter and the last segment flag.
+	noncePrefixSize = 7
+	// keySize the size of derived key, AES-256.
+	keySize = 32
+)
+
+// magic the first bytes of every backup file.
+var magic = []byte("PWMBAK")
+
+var (
+	// ErrInvalidFormat the given file is not a backup file.
+	ErrInvalidFormat = errors.New("not a valid backup file")
+	// ErrUnsupportedVersion the backup file is created by newer version of
+	// this app.
+	ErrUnsupportedVersion = errors.New("unsupported backup version")
+	// ErrDecrypt the passphrase is wrong or the backup file is corrupted.
+	ErrDecrypt = errors.New("wrong passphrase or corrupted backup")
+)
+
+// Params Argon2id parameters that's used to derive the key from passphrase.
+type Params struct {
+	// Time number of passes over the memory.
+	Time uint32
+	// Memory the size of memory in KiB.
+	Memory uint32
+	// Threads number of threads.
+	Threads uint8
+}
+
+// DefaultParams Argon2id parameters that's used when creating new backup.
+var DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// MaxParams the highest Argon2id parameters that's accepted, so the header of
+// a crafted backup can not make the key derivation exhaust the memory or run
+// for too long.
+var MaxParams = Params{Time: 16, Memory: 1024 * 1024, Threads: 16}
+
+// ErrParamsTooHigh the Argon2id parameters exceed MaxParams.
+var ErrParamsTooHigh = errors.New("argon2 parameters of the backup are too high")
+
+// valid check that given p is within MaxParams and none of them is zero.
+func (p Params) valid() error {
+	if p.Time == 0 || p.Memory == 0 || p.Threads == 0 {
+		return ErrInvalidFormat
+	}
+	if p.Time > MaxParams.Time || p.Memory > MaxParams.Memory || p.Threads > MaxParams.Threads {
+		return ErrParamsTooHigh
+	}
+	return nil
+}
+
+// header the plaintext header of the encrypted container. It's also used as
+// the additional data for every segment, so it can't be tampered.
+type header struct {
+	params      Params
+	salt        [saltSize]byte
+	noncePrefix [noncePrefixSize]byte
+}
+
+// headerSize the size of the encoded header.
+var headerSize = len(magic) + 1 + 4 + 4 + 1 + saltSize + noncePrefixSize
+
+// encode return the binary form of the header.
+func (h *header) encode() []byte {
+	b := make([]byte, 0, headerSize)
+	b = append(b, magic...)
+	b = append(b, containerVersion)
+	b = binary.BigEndian.AppendUint32(b, h.params.Time)
+	b = binary.BigEndian.AppendUint32(b, h.params.Memory)
+	b = append(b, h.params.Threads)
+	b = append(b, h.salt[:]...)
+	return append(b, h.noncePrefix[:]...)
+}
+
+// decodeHeader parse the binary form of the header.
+func decodeHeader(b []byte) (*header, error) {
+	if len(b) != headerSize || !bytes.Equal(b[:len(magic)], magic) {
+		return nil, ErrInvalidFormat
+	}
+	b = b[len(magic):]
+	if b[0] != containerVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	b = b[1:]
+
+	h := &header{}
+	h.params.Time = binary.BigEndian.Uint32(b)
+	h.params.Memory = binary.BigEndian.Uint32(b[4:])
+	h.params.Threads = b[8]
+	b = b[9:]
+	copy(h.salt[:], b)
+	copy(h.noncePrefix[:], b[saltSize:])
+
+	// check before anything is derived using them
+	if err := h.params.valid(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// aead derive the key from given passphrase then return the AES-GCM.
+func (h *header) aead(pass string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(pass), h.salt[:], h.params.Time, h.params.Memory, h.params.Threads, keySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce return the nonce for given segment counter.
+func (h *header) nonce(counter uint32, last bool) []byte {
+	n := make([]byte, 0, noncePrefixSize+5)
+	n = append(n, h.noncePrefix[:]...)
+	n = binary.BigEndian.AppendUint32(n, counter)
+	if last {
+		return append(n, 1)
+	}
+	return append(n, 0)
+}
+
+// newEncrypter return writer that encrypt everything written to it using the
+// key derived from given passphrase, then write the result to given w. Close
+// must be called to write the last segment.
+func newEncrypter(w io.Writer, pass string, p Params) (io.WriteCloser, error) {
+	// never write a backup that can not be read
+	if err := p.valid(); err != nil {
+		return nil, err
+	}
+	h := &header{params: p}
+	if _, err := rand.Read(h.salt[:]); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(h.noncePrefix[:]); err != nil {
+		return nil, err
+	}
+	aead, err := h.aead(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	hd := h.encode()
+	if _, err = w.Write(hd); err != nil {
+		return nil, err
+	}
+	return &encrypter{w: w, h: h, ad: hd, aead: aead}, nil
+}
+
+// encrypter encrypt the plaintext in segments. A full segment is only sealed
+// once there is more data after it, so the last segment is always known.
+type encrypter struct {
+	w       io.Writer
+	h       *header
+	ad      []byte
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint32
+	closed  bool
+}
+
+func (e *encrypter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed backup")
+	}
+	e.buf = append(e.buf, p...)
+	for len(e.buf) > segmentSize {
+		if err := e.seal(e.buf[:segmentSize], false); err != nil {
+			return 0, err
+		}
+		e.buf = e.buf[segmentSize:]
+	}
+	return len(p), nil
+}
+
+func (e *encrypter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(e.buf, true)
+}
+
+// seal encrypt given segment then write it.
+func (e *encrypter) seal(seg []byte, last bool) error {
+	ct := e.aead.Seal(nil, e.h.nonce(e.counter, last), seg, e.ad)
+	e.counter++
+	_, err := e.w.Write(ct)
+	return err
+}
+
+// newDecrypter return reader that decrypt given r using the key derived from
+// given passphrase.
+func newDecrypter(r io.Reader, pass string) (io.Reader, error) {
+	hd := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, hd); err != nil {
+		return nil, ErrInvalidFormat
+	}
+	h, err := decodeHeader(hd)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := h.aead(pass)
+	if err != nil {
+		return nil, err
+	}
+	return &decrypter{r: bufio.NewReader(r), h: h, ad: hd, aead: aead}, nil
+}
+
+// decrypter decrypt the segments that's written by encrypter.
+type decrypter struct {
+	r       *bufio.Reader
+	h       *header
+	ad      []byte
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint32
+	done    bool
+}
+
+func (d *decrypter) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// open read then decrypt the next segment.
+func (d *decrypter) open() error {
+	ct := make([]byte, segmentSize+d.aead.Overhead())
+	n, err := io.ReadFull(d.r, ct)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// short segment is always the last one
+		d.done = true
+	case errors.Is(err, io.EOF):
+		// the last segment is missing
+		return ErrDecrypt
+	case err != nil:
+		return err
+	default:
+		// full segment is the last one only if nothing left after it
+		if _, err = d.r.Peek(1); errors.Is(err, io.EOF) {
+			d.done = true
+		}
+	}
+
+	pt, err := d.aead.Open(nil, d.h.nonce(d.counter, d.done), ct[:n], d.ad)
+	if err != nil {
+		return ErrDecrypt
+	}
+	d.counter++
+	d.buf = pt
+	return nil
+}
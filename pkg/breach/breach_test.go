@@ -0,0 +1,88 @@
+package breach_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/breach"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sha1Hex return upper-cased hex of SHA-1 hash from given s.
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestFile_Count(t *testing.T) {
+	// unsorted dump with duplicated hash and mixed case & line break
+	dump := strings.Join([]string{
+		sha1Hex("password") + ":100",
+		strings.ToLower(sha1Hex("123456")) + ":37",
+		sha1Hex("qwerty") + ":5\r",
+		"",
+		sha1Hex("letmein"),
+		sha1Hex("password") + ":20",
+		"0000000000000000000000000000000000000001:1",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:2",
+	}, "\n")
+
+	dst := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, breach.Rebuild(strings.NewReader(dump), dst))
+
+	br, err := breach.NewFile(dst)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		sample string
+		expect int
+	}{
+		{
+			name:   "Given password that's duplicated in the dump should return the sum of the count",
+			sample: "password",
+			expect: 120,
+		},
+		{
+			name:   "Given password which hash is lower-cased in the dump should return the count",
+			sample: "123456",
+			expect: 37,
+		},
+		{
+			name:   "Given password that's followed by carriage return in the dump should return the count",
+			sample: "qwerty",
+			expect: 5,
+		},
+		{
+			name:   "Given password without count in the dump should return 1",
+			sample: "letmein",
+			expect: 1,
+		},
+		{
+			name:   "Given password that never seen in the dump should return 0",
+			sample: "x7#Lq!9vR2@m",
+			expect: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cnt, err := br.Count(tc.sample)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, cnt)
+		})
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	t.Run("Given malformed hash in the dump should return error", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "pwned.txt")
+		err := breach.Rebuild(strings.NewReader("NOT-A-HASH:1"), dst)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,137 @@
+// Package breach check whether a password has been exposed in known data
+// breaches using local copy of Pwned Passwords list, so no password or even
+// part of its hash ever leave this app.
+//
+// REF: https://haveibeenpwned.com/Passwords
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxLineLen the longest possible line in a Pwned Passwords file. 40 hex
+// SHA-1 characters, a colon, the count and the line break.
+const maxLineLen = 64
+
+// NewFile return implementation of Port that use given sorted Pwned Passwords
+// SHA-1 file as the source. Each line in the file should be in format
+// HASH:COUNT and sorted by the hash in ascending order.
+func NewFile(path string) (Port, error) {
+	fl, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := fl.Stat()
+	if err != nil {
+		fl.Close()
+		return nil, err
+	}
+	return &fileBreach{fl: fl, size: st.Size()}, nil
+}
+
+// NewNoop return implementation of Port that never report any password as
+// breached. Useful when no Pwned Passwords file is provided.
+func NewNoop() Port {
+	return noopBreach{}
+}
+
+type fileBreach struct {
+	fl   *os.File
+	size int64
+}
+
+func (f *fileBreach) Count(pw string) (int, error) {
+	sum := sha1.Sum([]byte(pw))
+	return f.search([]byte(strings.ToUpper(hex.EncodeToString(sum[:]))))
+}
+
+// search do binary search for given hash in the file by seeking to the
+// middle of the remaining range then read the line that contain that offset.
+func (f *fileBreach) search(hash []byte) (int, error) {
+	// lo should always point to the start of a line and hi to either the
+	// start of a line or the end of the file
+	lo, hi := int64(0), f.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, err := f.lineStart(lo, mid)
+		if err != nil {
+			return 0, err
+		}
+		line, next, err := f.readLine(start)
+		if err != nil {
+			return 0, err
+		}
+
+		h, cnt, err := parseLine(line)
+		if err != nil {
+			return 0, err
+		}
+		switch bytes.Compare(hash, h) {
+		case 0:
+			return cnt, nil
+		case -1:
+			hi = start
+		default:
+			lo = next
+		}
+	}
+	return 0, nil
+}
+
+// lineStart find the start offset of the line that contain given offset but
+// never go back before given lo.
+func (f *fileBreach) lineStart(lo, off int64) (int64, error) {
+	from := off - maxLineLen
+	if from < lo {
+		from = lo
+	}
+	buf := make([]byte, off-from)
+	if _, err := f.fl.ReadAt(buf, from); err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		return from + int64(i) + 1, nil
+	}
+	return from, nil
+}
+
+// readLine read a line that start from given offset and return the line
+// without the line break along with the start offset of the next line.
+func (f *fileBreach) readLine(off int64) ([]byte, int64, error) {
+	buf := make([]byte, maxLineLen)
+	n, err := f.fl.ReadAt(buf, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, 0, err
+	}
+	buf = buf[:n]
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		return bytes.TrimSuffix(buf[:i], []byte("\r")), off + int64(i) + 1, nil
+	}
+	return buf, off + int64(n), nil
+}
+
+// parseLine parse given line in format HASH:COUNT.
+func parseLine(line []byte) ([]byte, int, error) {
+	h, c, ok := bytes.Cut(line, []byte(":"))
+	if !ok || len(h) != sha1.Size*2 {
+		return nil, 0, errors.New("malformed line: " + string(line))
+	}
+	cnt, err := strconv.Atoi(string(bytes.TrimSpace(c)))
+	if err != nil {
+		return nil, 0, errors.New("malformed count: " + string(line))
+	}
+	return h, cnt, nil
+}
+
+type noopBreach struct{}
+
+func (noopBreach) Count(string) (int, error) {
+	return 0, nil
+}
@@ -0,0 +1,8 @@
+package breach
+
+// Port signature for breach pkg.
+type Port interface {
+	// Count return how many times given plaintext password has been seen in
+	// known data breaches. Zero means never seen in any breaches.
+	Count(pw string) (int, error)
+}
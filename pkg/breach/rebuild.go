@@ -0,0 +1,237 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	conf "github.com/mdanialr/pwman_backend/pkg/config"
+)
+
+// chunkSize the number of lines that's sorted in memory before being written
+// to a temporary file while rebuilding.
+const chunkSize = 1 << 20
+
+// RebuildWithConfig rebuild the Pwned Passwords file from given downloaded
+// dump and write the result to the path that's set in app config.
+func RebuildWithConfig(dump string) error {
+	v, err := conf.InitConfigYml()
+	if err != nil {
+		return err
+	}
+	dst := v.GetString("breach.path")
+	if dst == "" {
+		return errors.New("breach.path is required to rebuild the breach file")
+	}
+
+	src, err := os.Open(dump)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return Rebuild(src, dst)
+}
+
+// Rebuild read Pwned Passwords SHA-1 dump from given src then write it to
+// given dst as a file that's ready to be used by NewFile. The dump does not
+// need to be sorted, it will be sorted using external merge sort so large
+// dump never need to be fully loaded into memory. Duplicated hashes will be
+// merged by summing their count.
+func Rebuild(src io.Reader, dst string) error {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), "breach-rebuild-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// sort the dump in chunks then write each of them to temporary file
+	chunks, err := sortChunks(src, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	// merge all sorted chunks to temporary destination then replace the dst
+	tmpDst := filepath.Join(tmpDir, "merged")
+	if err = mergeChunks(chunks, tmpDst); err != nil {
+		return err
+	}
+	return os.Rename(tmpDst, dst)
+}
+
+// entry a single parsed line from the dump.
+type entry struct {
+	hash  string
+	count int
+}
+
+// sortChunks read given src and split it to sorted chunk files inside given
+// dir. Return the path of all chunk files.
+func sortChunks(src io.Reader, dir string) ([]string, error) {
+	var chunks []string
+	buf := make([]entry, 0, chunkSize)
+
+	flush := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		sort.Slice(buf, func(i, j int) bool { return buf[i].hash < buf[j].hash })
+
+		fn := filepath.Join(dir, "chunk-"+strconv.Itoa(len(chunks)))
+		if err := writeEntries(fn, buf); err != nil {
+			return err
+		}
+		chunks = append(chunks, fn)
+		buf = buf[:0]
+		return nil
+	}
+
+	sc := bufio.NewScanner(src)
+	for ln := 1; sc.Scan(); ln++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		e, err := parseDumpLine(line)
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(ln) + ": " + err.Error())
+		}
+		buf = append(buf, e)
+
+		if len(buf) == chunkSize {
+			if err = flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return chunks, flush()
+}
+
+// parseDumpLine parse a line from the dump. Line without the count is
+// regarded as seen once.
+func parseDumpLine(line []byte) (entry, error) {
+	h, c, _ := bytes.Cut(line, []byte(":"))
+	hash := strings.ToUpper(string(h))
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != sha1.Size {
+		return entry{}, errors.New("invalid SHA-1 hash")
+	}
+
+	cnt := 1
+	if len(c) > 0 {
+		var err error
+		if cnt, err = strconv.Atoi(string(c)); err != nil {
+			return entry{}, errors.New("invalid count")
+		}
+	}
+	return entry{hash: hash, count: cnt}, nil
+}
+
+// writeEntries write given sorted entries to a new file.
+func writeEntries(fn string, es []entry) error {
+	fl, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	w := bufio.NewWriter(fl)
+	for _, e := range es {
+		w.WriteString(e.hash + ":" + strconv.Itoa(e.count) + "\n")
+	}
+	return w.Flush()
+}
+
+// mergeChunks do k-way merge for all given sorted chunk files into given dst.
+func mergeChunks(chunks []string, dst string) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+
+	// open all chunks and put their first entry to the heap
+	h := &mergeHeap{}
+	for _, ch := range chunks {
+		fl, err := os.Open(ch)
+		if err != nil {
+			return err
+		}
+		defer fl.Close()
+
+		c := &mergeCursor{sc: bufio.NewScanner(fl)}
+		if c.next() {
+			heap.Push(h, c)
+		}
+	}
+
+	var last *entry
+	for h.Len() > 0 {
+		c := heap.Pop(h).(*mergeCursor)
+		// merge the same hash from different chunks
+		if last != nil && last.hash == c.cur.hash {
+			last.count += c.cur.count
+		} else {
+			if last != nil {
+				w.WriteString(last.hash + ":" + strconv.Itoa(last.count) + "\n")
+			}
+			e := c.cur
+			last = &e
+		}
+		if c.next() {
+			heap.Push(h, c)
+		}
+	}
+	if last != nil {
+		w.WriteString(last.hash + ":" + strconv.Itoa(last.count) + "\n")
+	}
+
+	return w.Flush()
+}
+
+// mergeCursor current position of a chunk file while merging.
+type mergeCursor struct {
+	sc  *bufio.Scanner
+	cur entry
+}
+
+// next advance the cursor and report whether there is still an entry.
+func (m *mergeCursor) next() bool {
+	if !m.sc.Scan() {
+		return false
+	}
+	e, err := parseDumpLine(m.sc.Bytes())
+	if err != nil {
+		return false
+	}
+	m.cur = e
+	return true
+}
+
+// mergeHeap min heap of mergeCursor ordered by the current hash.
+type mergeHeap []*mergeCursor
+
+func (m mergeHeap) Len() int           { return len(m) }
+func (m mergeHeap) Less(i, j int) bool { return m[i].cur.hash < m[j].cur.hash }
+func (m mergeHeap) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m *mergeHeap) Push(x any)        { *m = append(*m, x.(*mergeCursor)) }
+func (m *mergeHeap) Pop() any {
+	old := *m
+	n := len(old)
+	x := old[n-1]
+	*m = old[:n-1]
+	return x
+}
@@ -0,0 +1,170 @@
+package event
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHistory default number of the latest events that are kept to be
+	// replayed.
+	DefaultHistory = 1000
+	// bufferSize the number of events that can be queued for a subscriber
+	// before it's regarded as too slow and dropped.
+	bufferSize = 64
+)
+
+// NewBus return implementation of Port that keep given number of the latest
+// events in memory to be replayed. Fallback to DefaultHistory if it's not
+// positive.
+func NewBus(history int) Port {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &bus{
+		// ids from previous run can not be resumed
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:  history,
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+type bus struct {
+	mu      sync.Mutex
+	epoch   string
+	seq     uint64
+	size    int
+	history []Event
+	subs    map[*Subscription]struct{}
+}
+
+func (b *bus) Publish(typ string, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	ev := Event{
+		ID:   b.epoch + "-" + strconv.FormatUint(b.seq, 10),
+		Type: typ,
+		Data: data,
+		Time: time.Now(),
+	}
+	b.history = append(b.history, ev)
+	if len(b.history) > b.size {
+		b.history = append(b.history[:0:0], b.history[len(b.history)-b.size:]...)
+	}
+
+	for s := range b.subs {
+		select {
+		case s.c <- ev:
+		default:
+			// too slow, let it reconnect and replay instead of blocking
+			b.remove(s)
+		}
+	}
+	return ev
+}
+
+func (b *bus) Subscribe(lastID string, allow func(Event) bool) *Subscription {
+	b.mu.Lock()
+	s := &Subscription{c: make(chan Event, bufferSize), done: make(chan struct{}), bus: b}
+	if lastID != "" {
+		s.Replay, s.Reset = b.after(lastID)
+	}
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	if allow == nil {
+		s.C = s.c
+		return s
+	}
+	// allow may be slow, such as querying the database, so the events are
+	// filtered outside the lock to not hold up the publishers
+	var replay []Event
+	for _, ev := range s.Replay {
+		if allow(ev) {
+			replay = append(replay, ev)
+		}
+	}
+	s.Replay = replay
+	out := make(chan Event)
+	s.C = out
+	go s.forward(out, allow)
+	return s
+}
+
+// after return the kept events after given id. Also return true if some of
+// them are no longer kept or the id is unknown.
+func (b *bus) after(id string) ([]Event, bool) {
+	epoch, seqStr, ok := strings.Cut(id, "-")
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if !ok || err != nil || epoch != b.epoch || seq > b.seq {
+		return nil, true
+	}
+	if seq == b.seq {
+		return nil, false
+	}
+
+	// the first kept event should be right after given id
+	first := b.seq - uint64(len(b.history)) + 1
+	if seq+1 < first {
+		return nil, true
+	}
+	replay := make([]Event, len(b.history)-int(seq+1-first))
+	copy(replay, b.history[seq+1-first:])
+	return replay, false
+}
+
+// remove stop sending events to given Subscription then close its channel.
+// Should be called while holding the lock.
+func (b *bus) remove(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Subscription the events that's received by a subscriber.
+type Subscription struct {
+	// Replay the kept events after the last id that should be sent before
+	// those from C.
+	Replay []Event
+	// Reset whether some events after the last id are no longer kept, or the
+	// last id is from previous run. The subscriber should retrieve the whole
+	// state again.
+	Reset bool
+	// C receive the new events. Closed after Close is called or when the
+	// subscriber can not keep up, so it should subscribe again.
+	C <-chan Event
+
+	c    chan Event
+	done chan struct{}
+	once sync.Once
+	bus  *bus
+}
+
+// Close stop receiving the events.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	s.bus.remove(s)
+	s.bus.mu.Unlock()
+	s.once.Do(func() { close(s.done) })
+}
+
+// forward send the events that given allow return true for to given out
+// channel, then close it once no more events are received from the bus.
+func (s *Subscription) forward(out chan<- Event, allow func(Event) bool) {
+	defer close(out)
+	for ev := range s.c {
+		if !allow(ev) {
+			continue
+		}
+		select {
+		case out <- ev:
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/event"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// types return the type of each given events.
+func types(evs []event.Event) []string {
+	var res []string
+	for _, ev := range evs {
+		res = append(res, ev.Type)
+	}
+	return res
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	testCases := []struct {
+		name         string
+		history      int
+		lastID       func(evs []event.Event) string
+		expectReplay []string
+		expectReset  bool
+	}{
+		{
+			name:    "Given no last id should only receive the new events",
+			history: 10,
+			lastID:  func([]event.Event) string { return "" },
+		},
+		{
+			name:         "Given last id that's still kept should replay the events after it",
+			history:      10,
+			lastID:       func(evs []event.Event) string { return evs[0].ID },
+			expectReplay: []string{"b", "c"},
+		},
+		{
+			name:    "Given the latest id should have nothing to replay",
+			history: 10,
+			lastID:  func(evs []event.Event) string { return evs[2].ID },
+		},
+		{
+			name:        "Given last id that's no longer kept should reset",
+			history:     1,
+			lastID:      func(evs []event.Event) string { return evs[0].ID },
+			expectReset: true,
+		},
+		{
+			name:        "Given last id from previous run should reset",
+			history:     10,
+			lastID:      func([]event.Event) string { return "abc-1" },
+			expectReset: true,
+		},
+		{
+			name:        "Given malformed last id should reset",
+			history:     10,
+			lastID:      func([]event.Event) string { return "whatever" },
+			expectReset: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := event.NewBus(tc.history)
+			var evs []event.Event
+			for _, typ := range []string{"a", "b", "c"} {
+				evs = append(evs, b.Publish(typ, nil))
+			}
+
+			s := b.Subscribe(tc.lastID(evs), nil)
+			defer s.Close()
+			assert.Equal(t, tc.expectReplay, types(s.Replay))
+			assert.Equal(t, tc.expectReset, s.Reset)
+
+			// then receive the new events
+			ev := b.Publish("d", 1)
+			got := <-s.C
+			assert.Equal(t, ev, got)
+			assert.Equal(t, 1, got.Data)
+		})
+	}
+}
+
+func TestBus_Publish_SlowSubscriber(t *testing.T) {
+	b := event.NewBus(0)
+	slow := b.Subscribe("", nil)
+	fast := b.Subscribe("", nil)
+	defer fast.Close()
+
+	var last event.Event
+	for i := 0; i < 100; i++ {
+		last = b.Publish("a", i)
+		<-fast.C
+	}
+
+	// the slow one is dropped once its buffer is full, so it can resume using
+	// the last received id
+	var received []event.Event
+	for ev := range slow.C {
+		received = append(received, ev)
+	}
+	require.NotEmpty(t, received)
+	assert.Less(t, len(received), 100)
+
+	resumed := b.Subscribe(received[len(received)-1].ID, nil)
+	defer resumed.Close()
+	assert.False(t, resumed.Reset)
+	require.NotEmpty(t, resumed.Replay)
+	assert.Equal(t, last, resumed.Replay[len(resumed.Replay)-1])
+
+	// closing twice should not panic
+	slow.Close()
+	slow.Close()
+}
+
+func TestBus_Subscribe_Allow(t *testing.T) {
+	b := event.NewBus(0)
+	first := b.Publish("a", 1)
+	b.Publish("b", 2)
+	b.Publish("a", 3)
+
+	s := b.Subscribe(first.ID, func(ev event.Event) bool { return ev.Type == "a" })
+	defer s.Close()
+	// both the replayed and the new events are filtered
+	require.Len(t, s.Replay, 1)
+	assert.Equal(t, 3, s.Replay[0].Data)
+
+	b.Publish("b", 4)
+	ev := b.Publish("a", 5)
+	assert.Equal(t, ev, <-s.C)
+
+	// closed even though the pending event is never received
+	b.Publish("a", 6)
+	s.Close()
+	for range s.C {
+	}
+}
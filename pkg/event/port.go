@@ -0,0 +1,30 @@
+// Package event publish changes to the subscribers in the same process, such
+// as the clients that listen to the server-sent events.
+package event
+
+import "time"
+
+// Event a change that's published to the subscribers.
+type Event struct {
+	// ID unique identifier of the event that can be used to resume the
+	// subscription after reconnecting.
+	ID string `json:"id"`
+	// Type what kind of change this is, such as password.created.
+	Type string `json:"type"`
+	// Data the payload of the event.
+	Data any `json:"data,omitempty"`
+	// Time when the event is published.
+	Time time.Time `json:"time"`
+}
+
+// Port signature for event pkg.
+type Port interface {
+	// Publish send an event of given type and data to all subscribers then
+	// return it.
+	Publish(typ string, data any) Event
+	// Subscribe start receiving the events. Events after given last id that
+	// are still kept will be replayed first. Empty last id means only the new
+	// events are received. Only the events that given allow return true for
+	// are received, nil allow means all of them.
+	Subscribe(lastID string, allow func(Event) bool) *Subscription
+}
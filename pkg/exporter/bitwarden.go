@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// bitwardenLoginType item type for login in Bitwarden export.
+const bitwardenLoginType = 1
+
+// bitwardenFolder a folder in Bitwarden export.
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// bitwardenItem a login item in Bitwarden export.
+type bitwardenItem struct {
+	Type     int            `json:"type"`
+	Name     string         `json:"name"`
+	FolderID *string        `json:"folderId"`
+	Notes    *string        `json:"notes"`
+	Favorite bool           `json:"favorite"`
+	Login    bitwardenLogin `json:"login"`
+}
+
+// bitwardenLogin the login detail of bitwardenItem.
+type bitwardenLogin struct {
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	URIs     []bitwardenURI `json:"uris"`
+}
+
+// bitwardenURI an uri of bitwardenLogin.
+type bitwardenURI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+// newBitwarden return Writer that write Bitwarden unencrypted JSON export.
+func newBitwarden(w io.Writer) Writer {
+	return &bitwarden{w: w}
+}
+
+type bitwarden struct {
+	w     io.Writer
+	begun bool
+	items int
+}
+
+func (b *bitwarden) WriteFolders(fs []Folder) error {
+	if b.begun {
+		return errors.New("folders are already written")
+	}
+	b.begun = true
+
+	folders := make([]bitwardenFolder, 0, len(fs))
+	for _, f := range fs {
+		folders = append(folders, bitwardenFolder{ID: folderID(f.ID), Name: f.Name})
+	}
+	js, err := json.Marshal(folders)
+	if err != nil {
+		return err
+	}
+
+	// write the opening until the beginning of items, so the items can be
+	// streamed one by one
+	_, err = io.WriteString(b.w, `{"encrypted":false,"folders":`+string(js)+`,"items":[`)
+	return err
+}
+
+func (b *bitwarden) WriteItem(it Item) error {
+	if !b.begun {
+		if err := b.WriteFolders(nil); err != nil {
+			return err
+		}
+	}
+
+	item := bitwardenItem{
+		Type:     bitwardenLoginType,
+		Name:     it.Name,
+		Favorite: it.Favorite,
+		Login: bitwardenLogin{
+			Username: it.Username,
+			Password: it.Password,
+			URIs:     []bitwardenURI{},
+		},
+	}
+	if it.FolderID != 0 {
+		id := folderID(it.FolderID)
+		item.FolderID = &id
+	}
+	if it.Notes != "" {
+		item.Notes = &it.Notes
+	}
+	if it.URL != "" {
+		item.Login.URIs = append(item.Login.URIs, bitwardenURI{URI: it.URL})
+	}
+	js, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	if b.items > 0 {
+		js = append([]byte(","), js...)
+	}
+	b.items++
+	_, err = b.w.Write(js)
+	return err
+}
+
+func (b *bitwarden) Close() error {
+	if !b.begun {
+		if err := b.WriteFolders(nil); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(b.w, "]}")
+	return err
+}
+
+// folderID return the folder id that's used in the export.
+func folderID(id uint) string {
+	return "folder-" + strconv.Itoa(int(id))
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvHeader the header of generic CSV export.
+var csvHeader = []string{"folder", "name", "username", "password", "url", "notes"}
+
+// newCSV return Writer that write generic CSV export.
+func newCSV(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w), folders: make(map[uint]string)}
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	folders map[uint]string
+	begun   bool
+}
+
+func (c *csvWriter) WriteFolders(fs []Folder) error {
+	for _, f := range fs {
+		c.folders[f.ID] = f.Name
+	}
+	return c.begin()
+}
+
+func (c *csvWriter) WriteItem(it Item) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	return c.w.Write([]string{c.folders[it.FolderID], it.Name, it.Username, it.Password, it.URL, it.Notes})
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// begin write the header if it's not written yet.
+func (c *csvWriter) begin() error {
+	if c.begun {
+		return nil
+	}
+	c.begun = true
+	return c.w.Write(csvHeader)
+}
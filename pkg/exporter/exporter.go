@@ -0,0 +1,84 @@
+// Package exporter write passwords in plain formats that can be imported by
+// other password managers. Every Writer stream the items as soon as they are
+// written, so the whole vault never need to be held in memory.
+package exporter
+
+import (
+	"errors"
+	"io"
+)
+
+// Format supported plain export format.
+type Format string
+
+const (
+	// Bitwarden unencrypted JSON export that's compatible with Bitwarden.
+	Bitwarden Format = "bitwarden"
+	// CSV generic CSV with folder, name, username, password, url and notes
+	// columns.
+	CSV Format = "csv"
+)
+
+// ErrUnsupportedFormat returned when the given format is not supported.
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Folder a folder that group the items.
+type Folder struct {
+	ID   uint
+	Name string
+}
+
+// Item a single credential that's exported.
+type Item struct {
+	// FolderID the ID of Folder where this item belong to.
+	FolderID uint
+	Name     string
+	Username string
+	Password string
+	URL      string
+	Notes    string
+	Favorite bool
+}
+
+// Writer signature of the plain export writer. WriteFolders must be called
+// once before any WriteItem, and Close must be called to finish the export.
+type Writer interface {
+	// WriteFolders write all folders that may be referenced by the items.
+	WriteFolders(fs []Folder) error
+	// WriteItem write a single item.
+	WriteItem(it Item) error
+	// Close finish the export. This does not close the underlying writer.
+	Close() error
+}
+
+// Supported whether given format is supported by NewWriter.
+func Supported(f Format) bool {
+	return f == Bitwarden || f == CSV
+}
+
+// NewWriter return Writer for given format that write to given w.
+func NewWriter(f Format, w io.Writer) (Writer, error) {
+	switch f {
+	case Bitwarden:
+		return newBitwarden(w), nil
+	case CSV:
+		return newCSV(w), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ContentType return the MIME type of given format.
+func ContentType(f Format) string {
+	if f == CSV {
+		return "text/csv"
+	}
+	return "application/json"
+}
+
+// Ext return the file extension of given format.
+func Ext(f Format) string {
+	if f == CSV {
+		return ".csv"
+	}
+	return ".json"
+}
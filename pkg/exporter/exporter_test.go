@@ -0,0 +1,77 @@
+package exporter_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/exporter"
+	"github.com/mdanialr/pwman_backend/pkg/importer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWriter(t *testing.T) {
+	folders := []exporter.Folder{{ID: 1, Name: "Google"}, {ID: 2, Name: "Work, Inc"}}
+	items := []exporter.Item{
+		{FolderID: 1, Name: "Google", Username: "alice", Password: "s3cret", URL: "https://accounts.google.com", Notes: "first\nsecond"},
+		{FolderID: 2, Name: "Work, Inc", Username: "bob", Password: `with "quote"`},
+	}
+
+	testCases := []struct {
+		name        string
+		format      exporter.Format
+		parseFormat importer.Format
+	}{
+		{
+			name:        "Given bitwarden format should write export that can be imported back as bitwarden",
+			format:      exporter.Bitwarden,
+			parseFormat: importer.Bitwarden,
+		},
+		{
+			name:        "Given csv format should write export that can be imported back as generic csv",
+			format:      exporter.CSV,
+			parseFormat: importer.KeePassCSV,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := exporter.NewWriter(tc.format, &buf)
+			require.NoError(t, err)
+			require.NoError(t, w.WriteFolders(folders))
+			for _, it := range items {
+				require.NoError(t, w.WriteItem(it))
+			}
+			require.NoError(t, w.Close())
+
+			recs, err := importer.Parse(tc.parseFormat, buf.Bytes())
+			require.NoError(t, err)
+			require.Len(t, recs, len(items))
+			for i, rec := range recs {
+				assert.NoError(t, rec.Err)
+				assert.Equal(t, folders[i].Name, rec.Folder)
+				assert.Equal(t, items[i].Name, rec.Name)
+				assert.Equal(t, items[i].Username, rec.Username)
+				assert.Equal(t, items[i].Password, rec.Password)
+				assert.Equal(t, items[i].URL, rec.URL)
+				assert.Equal(t, items[i].Notes, rec.Notes)
+			}
+		})
+	}
+
+	t.Run("Given bitwarden format without any item should still write a valid JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := exporter.NewWriter(exporter.Bitwarden, &buf)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		assert.True(t, json.Valid(buf.Bytes()))
+	})
+
+	t.Run("Given unknown format should return ErrUnsupportedFormat", func(t *testing.T) {
+		_, err := exporter.NewWriter("lastpass", &bytes.Buffer{})
+		assert.ErrorIs(t, err, exporter.ErrUnsupportedFormat)
+	})
+}
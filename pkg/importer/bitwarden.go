@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// bitwardenLoginType item type for login in Bitwarden export.
+const bitwardenLoginType = 1
+
+// bitwardenExport the structure of Bitwarden unencrypted JSON export.
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []struct {
+		Type     int    `json:"type"`
+		Name     string `json:"name"`
+		FolderID string `json:"folderId"`
+		Notes    string `json:"notes"`
+		Login    *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+	} `json:"items"`
+}
+
+// parseBitwarden parse Bitwarden unencrypted JSON export.
+func parseBitwarden(data []byte) ([]Record, error) {
+	var exp bitwardenExport
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return nil, err
+	}
+	if exp.Encrypted {
+		return nil, errors.New("encrypted bitwarden export is not supported")
+	}
+
+	folders := make(map[string]string, len(exp.Folders))
+	for _, f := range exp.Folders {
+		folders[f.ID] = f.Name
+	}
+
+	recs := make([]Record, 0, len(exp.Items))
+	for _, it := range exp.Items {
+		rec := Record{Folder: folders[it.FolderID], Name: it.Name, Notes: it.Notes}
+		if it.Type != bitwardenLoginType || it.Login == nil {
+			rec.Err = errors.New("unsupported item type " + strconv.Itoa(it.Type))
+			recs = append(recs, rec)
+			continue
+		}
+
+		rec.Username = it.Login.Username
+		rec.Password = it.Login.Password
+		if len(it.Login.URIs) > 0 {
+			rec.URL = it.Login.URIs[0].URI
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// csvAliases known header names of each Record field in CSV exports from
+// KeePass, KeePassXC, 1Password, Chrome and Firefox. All lower-cased.
+var csvAliases = map[string][]string{
+	"folder":   {"group", "folder", "vault"},
+	"name":     {"title", "name", "account"},
+	"username": {"username", "user name", "login", "login_username"},
+	"password": {"password", "login_password"},
+	"url":      {"url", "website", "web site", "login_uri", "urls"},
+	"notes":    {"notes", "note", "notesplain", "comments"},
+}
+
+// parseCSV parse CSV export that has header in the first line. The columns are
+// mapped to Record by their header name, so it may be used for any CSV exports
+// as long as the header names are known in csvAliases.
+func parseCSV(data []byte) ([]Record, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("empty csv export")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// map each field to the column index
+	idx := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		for field, aliases := range csvAliases {
+			if _, ok := idx[field]; ok {
+				continue
+			}
+			for _, alias := range aliases {
+				if h == alias {
+					idx[field] = i
+					break
+				}
+			}
+		}
+	}
+	if _, ok := idx["password"]; !ok {
+		return nil, errors.New("password column not found in csv header")
+	}
+
+	var recs []Record
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// use the line in the file, so the header and the quoted fields that
+		// span multiple lines are counted as well
+		line, _ := r.FieldPos(0)
+
+		col := func(field string) string {
+			if i, ok := idx[field]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		recs = append(recs, Record{
+			Row:      line,
+			Folder:   col("folder"),
+			Name:     col("name"),
+			Username: col("username"),
+			Password: col("password"),
+			URL:      col("url"),
+			Notes:    col("notes"),
+		})
+	}
+	return recs, nil
+}
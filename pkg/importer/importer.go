@@ -0,0 +1,100 @@
+// Package importer parse exported vault from other password managers into a
+// common Record, so they can be saved as passwords in this app.
+package importer
+
+import (
+	"errors"
+	"strings"
+)
+
+// Format supported export format of other password managers.
+type Format string
+
+const (
+	// Bitwarden unencrypted JSON export of Bitwarden.
+	Bitwarden Format = "bitwarden"
+	// KeePassXML XML export of KeePass 2.x.
+	KeePassXML Format = "keepass-xml"
+	// KeePassCSV CSV export of KeePass or KeePassXC.
+	KeePassCSV Format = "keepass-csv"
+	// OnePassword1PUX 1PUX export of 1Password 8.
+	OnePassword1PUX Format = "1password-1pux"
+	// OnePasswordCSV CSV export of 1Password.
+	OnePasswordCSV Format = "1password-csv"
+	// Chrome CSV export of Chrome or other Chromium based browsers.
+	Chrome Format = "chrome"
+	// Firefox CSV export of Firefox.
+	Firefox Format = "firefox"
+)
+
+// ErrUnsupportedFormat returned when the given format is not supported.
+var ErrUnsupportedFormat = errors.New("unsupported import format")
+
+// Record a single credential that's parsed from the export.
+type Record struct {
+	// Row the position of this record in the export starting from 1. It's
+	// the line in the file for CSV exports, so the first record is in row 2
+	// after the header.
+	Row int
+	// Folder the folder, group or vault name where this record belong to.
+	// May be empty if the export does not support folders.
+	Folder string
+	// Name the title of this record.
+	Name     string
+	Username string
+	Password string
+	URL      string
+	Notes    string
+	// Err the reason why this record can not be imported, if any.
+	Err error
+}
+
+// Parse parse given exported data based on given format.
+func Parse(f Format, data []byte) ([]Record, error) {
+	var (
+		recs []Record
+		err  error
+	)
+	switch f {
+	case Bitwarden:
+		recs, err = parseBitwarden(data)
+	case KeePassXML:
+		recs, err = parseKeePassXML(data)
+	case OnePassword1PUX:
+		recs, err = parse1PUX(data)
+	case KeePassCSV, OnePasswordCSV, Chrome, Firefox:
+		recs, err = parseCSV(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure every record is usable
+	for i := range recs {
+		if recs[i].Row == 0 {
+			recs[i].Row = i + 1
+		}
+		recs[i].normalize()
+	}
+	return recs, nil
+}
+
+// normalize trim all fields then mark this record as error if it's missing
+// the required fields.
+func (r *Record) normalize() {
+	r.Folder = strings.TrimSpace(r.Folder)
+	r.Name = strings.TrimSpace(r.Name)
+	r.Username = strings.TrimSpace(r.Username)
+	r.URL = strings.TrimSpace(r.URL)
+	if r.Err != nil {
+		return
+	}
+	switch {
+	case r.Username == "":
+		r.Err = errors.New("username is required")
+	case r.Password == "":
+		r.Err = errors.New("password is required")
+	}
+}
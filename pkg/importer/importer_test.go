@@ -0,0 +1,167 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/importer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// new1PUX create 1PUX zip that contain given export.data.
+func new1PUX(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("export.data")
+	require.NoError(t, err)
+	w.Write([]byte(data))
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name       string
+		format     importer.Format
+		sample     []byte
+		expect     []importer.Record
+		expectErrs []bool
+		wantErr    bool
+	}{
+		{
+			name:    "Given unknown format should return error",
+			format:  "lastpass",
+			sample:  []byte("url,username,password"),
+			wantErr: true,
+		},
+		{
+			name:   "Given bitwarden export should map folder id to folder name and mark non-login item as error",
+			format: importer.Bitwarden,
+			sample: []byte(`{"encrypted":false,"folders":[{"id":"f1","name":"Work"}],"items":[
+				{"type":1,"name":"Mail","folderId":"f1","notes":"n","login":{"username":"john","password":"s3cret","uris":[{"uri":"https://mail.example.com"}]}},
+				{"type":2,"name":"Secure Note","notes":"hello"}
+			]}`),
+			expect: []importer.Record{
+				{Row: 1, Folder: "Work", Name: "Mail", Username: "john", Password: "s3cret", URL: "https://mail.example.com", Notes: "n"},
+				{Row: 2, Name: "Secure Note", Notes: "hello"},
+			},
+			expectErrs: []bool{false, true},
+		},
+		{
+			name:    "Given encrypted bitwarden export should return error",
+			format:  importer.Bitwarden,
+			sample:  []byte(`{"encrypted":true}`),
+			wantErr: true,
+		},
+		{
+			name:   "Given keepass xml export should use the nearest group name as the folder",
+			format: importer.KeePassXML,
+			sample: []byte(`<KeePassFile><Root><Group><Name>Database</Name>
+				<Entry><String><Key>Title</Key><Value>Root</Value></String><String><Key>UserName</Key><Value>admin</Value></String><String><Key>Password</Key><Value>p1</Value></String></Entry>
+				<Group><Name>Servers</Name>
+					<Entry><String><Key>Title</Key><Value>DB</Value></String><String><Key>UserName</Key><Value>pg</Value></String><String><Key>Password</Key><Value>p2</Value></String><String><Key>URL</Key><Value>db.local</Value></String></Entry>
+				</Group>
+			</Group></Root></KeePassFile>`),
+			expect: []importer.Record{
+				{Row: 1, Folder: "Database", Name: "Root", Username: "admin", Password: "p1"},
+				{Row: 2, Folder: "Servers", Name: "DB", Username: "pg", Password: "p2", URL: "db.local"},
+			},
+			expectErrs: []bool{false, false},
+		},
+		{
+			name:   "Given keepass csv export should map the columns by the header and mark row without password as error",
+			format: importer.KeePassCSV,
+			sample: []byte("\"Group\",\"Title\",\"Username\",\"Password\",\"URL\",\"Notes\"\n" +
+				"\"Root/Mail\",\"Mail\",\"john\",\"s3cret\",\"https://mail\",\"\"\n" +
+				"\"Root\",\"Empty\",\"doe\",\"\",\"\",\"\"\n"),
+			expect: []importer.Record{
+				{Row: 2, Folder: "Root/Mail", Name: "Mail", Username: "john", Password: "s3cret", URL: "https://mail"},
+				{Row: 3, Folder: "Root", Name: "Empty", Username: "doe"},
+			},
+			expectErrs: []bool{false, true},
+		},
+		{
+			name:   "Given 1password 1pux export should use the vault name as the folder",
+			format: importer.OnePassword1PUX,
+			sample: new1PUX(t, `{"accounts":[{"vaults":[{"attrs":{"name":"Private"},"items":[
+				{"categoryUuid":"001","overview":{"title":"Bank","url":"https://bank"},"details":{"notesPlain":"pin","loginFields":[{"designation":"username","value":"jane"},{"designation":"password","value":"b4nk"}]}}
+			]}]}]}`),
+			expect: []importer.Record{
+				{Row: 1, Folder: "Private", Name: "Bank", Username: "jane", Password: "b4nk", URL: "https://bank", Notes: "pin"},
+			},
+			expectErrs: []bool{false},
+		},
+		{
+			name:   "Given 1password csv export should map the columns by the header",
+			format: importer.OnePasswordCSV,
+			sample: []byte("Title,Website,Username,Password,Notes\nShop,https://shop,jim,sh0p,\n"),
+			expect: []importer.Record{
+				{Row: 2, Name: "Shop", Username: "jim", Password: "sh0p", URL: "https://shop"},
+			},
+			expectErrs: []bool{false},
+		},
+		{
+			name:   "Given chrome csv export should map the columns by the header",
+			format: importer.Chrome,
+			sample: []byte("name,url,username,password,note\nexample.com,https://example.com/,amy,pw,\n"),
+			expect: []importer.Record{
+				{Row: 2, Name: "example.com", Username: "amy", Password: "pw", URL: "https://example.com/"},
+			},
+			expectErrs: []bool{false},
+		},
+		{
+			name:   "Given firefox csv export should map the columns by the header",
+			format: importer.Firefox,
+			sample: []byte("\"url\",\"username\",\"password\",\"httpRealm\",\"formActionOrigin\",\"guid\"\n" +
+				"\"https://example.org\",\"bob\",\"pw2\",,\"https://example.org\",\"{x}\"\n"),
+			expect: []importer.Record{
+				{Row: 2, Username: "bob", Password: "pw2", URL: "https://example.org"},
+			},
+			expectErrs: []bool{false},
+		},
+		{
+			name:   "Given csv export with quoted field that span multiple lines should use the line in the file as the row",
+			format: importer.KeePassCSV,
+			sample: []byte("Title,Username,Password,Notes\nA,al,p1,\"line 1\nline 2\"\nB,bo,,\n"),
+			expect: []importer.Record{
+				{Row: 2, Name: "A", Username: "al", Password: "p1", Notes: "line 1\nline 2"},
+				{Row: 4, Name: "B", Username: "bo"},
+			},
+			expectErrs: []bool{false, true},
+		},
+		{
+			name:    "Given 1password 1pux export that's too large once uncompressed should return error",
+			format:  importer.OnePassword1PUX,
+			sample:  new1PUX(t, strings.Repeat(" ", 64<<20+1)),
+			wantErr: true,
+		},
+		{
+			name:    "Given csv export without password column should return error",
+			format:  importer.Chrome,
+			sample:  []byte("name,url,username\nexample.com,https://example.com/,amy\n"),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recs, err := importer.Parse(tc.format, tc.sample)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, recs, len(tc.expect))
+
+			for i := range recs {
+				assert.Equal(t, tc.expectErrs[i], recs[i].Err != nil)
+				recs[i].Err = nil
+			}
+			assert.Equal(t, tc.expect, recs)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+)
+
+// keepassFile the structure of KeePass 2.x XML export.
+type keepassFile struct {
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// keepassGroup a group in KeePass that may contain entries and nested groups.
+type keepassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+// keepassEntry an entry in KeePass which fields are stored as key-value.
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+// parseKeePassXML parse KeePass 2.x XML export. Each entry use the name of the
+// group that directly contain it as the folder.
+func parseKeePassXML(data []byte) ([]Record, error) {
+	var kp keepassFile
+	if err := xml.Unmarshal(data, &kp); err != nil {
+		return nil, err
+	}
+	if len(kp.Root.Groups) == 0 {
+		return nil, errors.New("no group found in keepass export")
+	}
+
+	var recs []Record
+	var walk func(g keepassGroup)
+	walk = func(g keepassGroup) {
+		for _, e := range g.Entries {
+			rec := Record{Folder: g.Name}
+			for _, s := range e.Strings {
+				switch s.Key {
+				case "Title":
+					rec.Name = s.Value
+				case "UserName":
+					rec.Username = s.Value
+				case "Password":
+					rec.Password = s.Value
+				case "URL":
+					rec.URL = s.Value
+				case "Notes":
+					rec.Notes = s.Value
+				}
+			}
+			recs = append(recs, rec)
+		}
+		for _, sub := range g.Groups {
+			walk(sub)
+		}
+	}
+	for _, g := range kp.Root.Groups {
+		walk(g)
+	}
+	return recs, nil
+}
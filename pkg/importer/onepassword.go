@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const (
+	// onePasswordLoginCategory category uuid for login item in 1PUX export.
+	onePasswordLoginCategory = "001"
+	// maxOnePUXData the maximum uncompressed size of export.data that's
+	// read from 1PUX export, so a zip bomb can not exhaust the memory.
+	maxOnePUXData = 64 << 20
+)
+
+// errOnePUXTooLarge returned when export.data inside 1PUX export exceed
+// maxOnePUXData.
+var errOnePUXTooLarge = errors.New("export.data in 1pux export is too large")
+
+// onePUXData the structure of export.data inside 1PUX export.
+type onePUXData struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []struct {
+				CategoryUUID string `json:"categoryUuid"`
+				Overview     struct {
+					Title string `json:"title"`
+					URL   string `json:"url"`
+				} `json:"overview"`
+				Details struct {
+					NotesPlain  string `json:"notesPlain"`
+					LoginFields []struct {
+						Designation string `json:"designation"`
+						Value       string `json:"value"`
+					} `json:"loginFields"`
+				} `json:"details"`
+			} `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+// parse1PUX parse 1Password 1PUX export which is a zip file that contain
+// export.data as JSON. Each item use its vault name as the folder.
+func parse1PUX(data []byte) ([]Record, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	var zf *zip.File
+	for _, f := range zr.File {
+		if f.Name == "export.data" {
+			zf = f
+			break
+		}
+	}
+	if zf == nil {
+		return nil, errors.New("export.data not found in 1pux export")
+	}
+	if zf.UncompressedSize64 > maxOnePUXData {
+		return nil, errOnePUXTooLarge
+	}
+	fl, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fl.Close()
+
+	// the declared size may lie, so never read more than the limit anyway
+	raw, err := io.ReadAll(io.LimitReader(fl, maxOnePUXData+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxOnePUXData {
+		return nil, errOnePUXTooLarge
+	}
+	var exp onePUXData
+	if err = json.Unmarshal(raw, &exp); err != nil {
+		return nil, err
+	}
+
+	var recs []Record
+	for _, acc := range exp.Accounts {
+		for _, vault := range acc.Vaults {
+			for _, it := range vault.Items {
+				rec := Record{
+					Folder: vault.Attrs.Name,
+					Name:   it.Overview.Title,
+					URL:    it.Overview.URL,
+					Notes:  it.Details.NotesPlain,
+				}
+				if it.CategoryUUID != onePasswordLoginCategory {
+					rec.Err = errors.New("unsupported item category " + it.CategoryUUID)
+				}
+				for _, f := range it.Details.LoginFields {
+					switch f.Designation {
+					case "username":
+						rec.Username = f.Value
+					case "password":
+						rec.Password = f.Value
+					}
+				}
+				recs = append(recs, rec)
+			}
+		}
+	}
+	return recs, nil
+}
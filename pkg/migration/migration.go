@@ -5,19 +5,21 @@ import (
 	"log"
 	"os"
 
+	"github.com/mdanialr/pwman_backend/internal/domain/auth"
 	"github.com/mdanialr/pwman_backend/internal/entity"
 	conf "github.com/mdanialr/pwman_backend/pkg/config"
 	gl "github.com/mdanialr/pwman_backend/pkg/gorm"
 	"github.com/mdanialr/pwman_backend/pkg/migration/seeder"
 	"github.com/mdanialr/pwman_backend/pkg/postgresql"
 
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
 // Run do run migration (creating all tables) and optionally run seeder if
 // given param is true.
 func Run(isSeeder, isDrop bool) {
-	db := initGorm()
+	db, v := initGorm()
 	// get the sql db
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -26,6 +28,54 @@ func Run(isSeeder, isDrop bool) {
 	}
 	defer sqlDB.Close()
 
+	Migrate(db, isDrop)
+
+	// seed the tables with fake data from seeders
+	if isSeeder {
+		seeder.Run(db)
+	}
+
+	// make sure the owner exists and owns all data that belong to nobody
+	if err = EnsureOwner(db, auth.OwnerUsername(v), v.GetString("cred.secret")); err != nil {
+		log.Fatalln("failed to set up the owner user:", err)
+	}
+}
+
+// EnsureOwner create the admin user with given username and OTP secret if it
+// does not exist yet, otherwise just update the secret. Then assign all
+// passwords, categories and tags that do not have any owner nor organization to
+// that user, such as those that's created before there are users.
+func EnsureOwner(db *gorm.DB, username, secret string) error {
+	fmt.Println("Setting Up Owner", username)
+	usr := entity.User{Username: username}
+	err := db.Where(&usr).Attrs(entity.User{Secret: secret, IsAdmin: true}).FirstOrCreate(&usr).Error
+	if err != nil {
+		return err
+	}
+	if usr.Secret != secret || !usr.IsAdmin {
+		if err = db.Model(&usr).Updates(map[string]any{"secret": secret, "is_admin": true}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, model := range []any{&entity.Category{}, &entity.Password{}} {
+		err = db.Model(model).Unscoped().Where("(owner_id = 0 OR owner_id IS NULL) AND org_id IS NULL").UpdateColumn("owner_id", usr.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	// tags do not belong to any organization
+	err = db.Model(&entity.Tag{}).Unscoped().Where("owner_id = 0 OR owner_id IS NULL").UpdateColumn("owner_id", usr.ID).Error
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done Setting Up Owner")
+	return nil
+}
+
+// Migrate create all tables along with the indexes using given db. Optionally
+// drop all tables first if given isDrop is true.
+func Migrate(db *gorm.DB, isDrop bool) {
 	// drop tables
 	if isDrop {
 		fmt.Println("Dropping All Tables")
@@ -33,26 +83,86 @@ func Run(isSeeder, isDrop bool) {
 			&entity.RegisteredOTP{},
 			&entity.Category{},
 			&entity.Password{},
+			&entity.AuditLog{},
+			&entity.Tag{},
+			"password_tag",
+			&entity.User{},
+			&entity.Share{},
+			&entity.Organization{},
+			&entity.Member{},
+			&entity.ShareLink{},
+			&entity.EmergencyAccess{},
+			&entity.Job{},
+			&entity.VaultKey{},
+			&entity.Blob{},
+			&entity.DataKey{},
+			&entity.Revocation{},
 		)
 		fmt.Println("Done Dropping All Tables")
 	}
 
+	// category name used to be unique globally, now it's only unique among
+	// the siblings
+	if db.Migrator().HasConstraint(&entity.Category{}, "category_name_key") {
+		db.Migrator().DropConstraint(&entity.Category{}, "category_name_key")
+	}
+	// then it's only unique among the siblings of the same owner
+	if db.Migrator().HasIndex(&entity.Category{}, "idx_category_parent_name") {
+		db.Migrator().DropIndex(&entity.Category{}, "idx_category_parent_name")
+	}
+
+	// tag name used to be unique globally, now it's only unique among the
+	// tags of the same owner
+	if db.Migrator().HasConstraint(&entity.Tag{}, "tag_name_key") {
+		db.Migrator().DropConstraint(&entity.Tag{}, "tag_name_key")
+	}
+
 	// create tables
 	fmt.Println("Creating All Tables")
 	db.Migrator().AutoMigrate(
 		&entity.RegisteredOTP{},
 		&entity.Category{},
 		&entity.Password{},
+		&entity.AuditLog{},
+		&entity.Tag{},
+		&entity.User{},
+		&entity.Share{},
+		&entity.Organization{},
+		&entity.Member{},
+		&entity.ShareLink{},
+		&entity.EmergencyAccess{},
+		&entity.Job{},
+		&entity.VaultKey{},
+		&entity.Blob{},
+		&entity.DataKey{},
+		&entity.Revocation{},
 	)
 	fmt.Println("Done Creating All Tables")
 
-	// seed the tables with fake data from seeders
-	if isSeeder {
-		seeder.Run(db)
+	// create trigram indexes for the fuzzy search
+	fmt.Println("Creating Search Indexes")
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Println("failed to enable pg_trgm extension:", err)
+		return
 	}
+	for _, idx := range trigramIndexes {
+		q := "CREATE INDEX IF NOT EXISTS idx_" + idx[0] + "_" + idx[1] + "_trgm ON " + idx[0] + " USING gin (" + idx[1] + " gin_trgm_ops)"
+		if err := db.Exec(q).Error; err != nil {
+			log.Println("failed to create trigram index on", idx[0]+"."+idx[1]+":", err)
+		}
+	}
+	fmt.Println("Done Creating Search Indexes")
+}
+
+// trigramIndexes pairs of table and column that's searched using trigram.
+var trigramIndexes = [][2]string{
+	{"password", "username"},
+	{"password", "url"},
+	{"password", "notes"},
+	{"category", "name"},
 }
 
-func initGorm() *gorm.DB {
+func initGorm() (*gorm.DB, *viper.Viper) {
 	// init viper config
 	v, err := conf.InitConfigYml()
 	if err != nil {
@@ -73,8 +183,8 @@ func initGorm() *gorm.DB {
 	)
 	if err != nil {
 		log.Fatalln("failed to init gorm with mysql as the DB:", err)
-		return nil
+		return nil, nil
 	}
 
-	return db
+	return db, v
 }
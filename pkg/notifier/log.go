@@ -0,0 +1,28 @@
+package notifier
+
+import (
+	"context"
+
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+
+	"go.uber.org/zap"
+)
+
+// NewLog return implementation of Port that only write the notification to
+// given logger.
+func NewLog(zap *zap.Logger) Port {
+	return &logNotifier{zap}
+}
+
+type logNotifier struct {
+	zap *zap.Logger
+}
+
+func (l *logNotifier) Notify(_ context.Context, msg Message) error {
+	if msg.Recipient != "" {
+		l.zap.Info(help.Pad("notification for", msg.Recipient+":", msg.Subject+":", msg.Body))
+		return nil
+	}
+	l.zap.Info(help.Pad("notification:", msg.Subject+":", msg.Body))
+	return nil
+}
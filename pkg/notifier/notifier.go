@@ -0,0 +1,19 @@
+// Package notifier send notification to the admin through the configured
+// channel such as log or email.
+package notifier
+
+import (
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// NewWithConfig return implementation of Port based on the driver that's set
+// in config section notifier.driver. Fallback to the one that only write to
+// given logger if the driver is not set.
+func NewWithConfig(v *viper.Viper, zap *zap.Logger) Port {
+	switch v.GetString("notifier.driver") {
+	case "smtp":
+		return NewSMTP(NewSMTPConfig(v))
+	}
+	return NewLog(zap)
+}
@@ -0,0 +1,21 @@
+package notifier
+
+import "context"
+
+// Message a notification that should be sent.
+type Message struct {
+	// Subject short summary of the notification.
+	Subject string
+	// Body the full content of the notification in plain text.
+	Body string
+	// Recipient optional username of the user this notification is for.
+	// Empty means it's for the admin.
+	Recipient string
+}
+
+// Port signature for notifier pkg.
+type Port interface {
+	// Notify send given Message. Depends on the implementation this may be
+	// written to log or sent as an email etc.
+	Notify(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SMTPConfig the configuration that's needed to send email through SMTP.
+type SMTPConfig struct {
+	// Host the SMTP server host.
+	Host string
+	// Port the SMTP server port.
+	Port int
+	// Username optional username for PLAIN authentication. No authentication
+	// is used if this is empty.
+	Username string
+	// Password the password that belong to the Username.
+	Password string
+	// From the sender email address.
+	From string
+	// To the recipient email addresses.
+	To []string
+}
+
+// NewSMTPConfig return SMTPConfig from given viper config that's reside in
+// section notifier.smtp.
+func NewSMTPConfig(v *viper.Viper) SMTPConfig {
+	return SMTPConfig{
+		Host:     v.GetString("notifier.smtp.host"),
+		Port:     v.GetInt("notifier.smtp.port"),
+		Username: v.GetString("notifier.smtp.user"),
+		Password: v.GetString("notifier.smtp.pass"),
+		From:     v.GetString("notifier.smtp.from"),
+		To:       v.GetStringSlice("notifier.smtp.to"),
+	}
+}
+
+// NewSMTP return implementation of Port that send the notification as an
+// email using given SMTP config.
+func NewSMTP(conf SMTPConfig) Port {
+	return &smtpNotifier{conf: conf}
+}
+
+type smtpNotifier struct {
+	conf SMTPConfig
+}
+
+func (s *smtpNotifier) Notify(_ context.Context, msg Message) error {
+	if len(s.conf.To) == 0 {
+		return errors.New("no recipient is set for smtp notifier")
+	}
+	addr := net.JoinHostPort(s.conf.Host, strconv.Itoa(s.conf.Port))
+
+	var auth smtp.Auth
+	if s.conf.Username != "" {
+		auth = smtp.PlainAuth("", s.conf.Username, s.conf.Password, s.conf.Host)
+	}
+
+	return smtp.SendMail(addr, auth, s.conf.From, s.conf.To, s.compose(msg))
+}
+
+// compose build the raw email from given Message.
+func (s *smtpNotifier) compose(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.conf.From + "\r\n")
+	b.WriteString("To: " + strings.Join(s.conf.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	// users do not have their own email address, so tell who it's for
+	if msg.Recipient != "" {
+		b.WriteString("For: " + msg.Recipient + "\r\n\r\n")
+	}
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
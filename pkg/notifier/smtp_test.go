@@ -0,0 +1,99 @@
+package notifier_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/notifier"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP minimal local stand-in of SMTP server that accept a single
+// message then send the received envelope and data to the returned channel.
+func fakeSMTP(t *testing.T) (*net.TCPAddr, <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost fake smtp")
+		for inData := false; ; {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+
+			if inData {
+				if line == "." {
+					inData = false
+					reply("250 OK")
+					continue
+				}
+				lines = append(lines, line)
+				continue
+			}
+
+			lines = append(lines, line)
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				inData = true
+				reply("354 go ahead")
+			case "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr), received
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	t.Run("Given config without any recipient should return error", func(t *testing.T) {
+		n := notifier.NewSMTP(notifier.SMTPConfig{Host: "127.0.0.1", Port: 25})
+		assert.Error(t, n.Notify(context.Background(), notifier.Message{Subject: "hi"}))
+	})
+
+	t.Run("Given local stand-in SMTP server should send the message to all recipients", func(t *testing.T) {
+		addr, received := fakeSMTP(t)
+		n := notifier.NewSMTP(notifier.SMTPConfig{
+			Host: addr.IP.String(),
+			Port: addr.Port,
+			From: "pwman@example.com",
+			To:   []string{"admin@example.com", "ops@example.com"},
+		})
+
+		msg := notifier.Message{Subject: "Password rotation", Body: "2 passwords\nneed rotation"}
+		require.NoError(t, n.Notify(context.Background(), msg))
+
+		lines := <-received
+		assert.Contains(t, lines, "MAIL FROM:<pwman@example.com>")
+		assert.Contains(t, lines, "RCPT TO:<admin@example.com>")
+		assert.Contains(t, lines, "RCPT TO:<ops@example.com>")
+		assert.Contains(t, lines, "Subject: Password rotation")
+		assert.Contains(t, lines, "2 passwords")
+		assert.Contains(t, lines, "need rotation")
+	})
+}
@@ -0,0 +1,234 @@
+package paginate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ModeCursor the value of M.Mode to use the cursor mode.
+const ModeCursor = "cursor"
+
+// ErrInvalidCursor returned when the given cursor can not be decoded or was
+// created for another sort key.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor the content of the opaque cursor. It hold the sort key and the id of
+// the last (or the first when going backward) row of the page.
+type cursor struct {
+	// Col the column name of the sort key.
+	Col string `json:"c"`
+	// Key the value of the sort key. Nil when the sort key is the id.
+	Key json.RawMessage `json:"k,omitempty"`
+	// ID the value of the primary key.
+	ID json.RawMessage `json:"i"`
+	// Prev whether the cursor point to the previous page.
+	Prev bool `json:"p,omitempty"`
+}
+
+// keyset the state of the cursor mode while querying.
+type keyset struct {
+	// col the column name of the sort key that's set by SetKey.
+	col string
+	// desc whether the sort key is sorted descending.
+	desc bool
+	// cur the decoded cursor from the request.
+	cur *cursor
+	// field and pk the schema of the sort key and the primary key.
+	field, pk *schema.Field
+	// hasMore whether there are more rows after the page.
+	hasMore bool
+}
+
+// IsCursor whether the cursor mode is used instead of the page number.
+func (m *M) IsCursor() bool {
+	return m.Mode == ModeCursor || m.Cursor != ""
+}
+
+// SetKey set the column name and the direction of the sort key for the cursor
+// mode. Unknown column fallback to the primary key. The sort key should not be
+// nullable, since rows with null key can not be compared.
+func (m *M) SetKey(col string, desc bool) {
+	m.ks.col, m.ks.desc = col, desc
+}
+
+// Parse decode the Cursor if any. Return ErrInvalidCursor if it's malformed.
+func (m *M) Parse() error {
+	m.ks.cur = nil
+	if m.Cursor == "" {
+		return nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(m.Cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	var cur cursor
+	if err = json.Unmarshal(b, &cur); err != nil || cur.Col == "" || len(cur.ID) == 0 {
+		return ErrInvalidCursor
+	}
+	m.ks.cur = &cur
+	return nil
+}
+
+// setCursor implementation of Set in the cursor mode. Replace the order with
+// the sort key and the primary key, then only retrieve the rows after the
+// cursor. Any other order such as the search relevance is dropped, so the
+// callers should reject it along with the cursor mode instead.
+func (m *M) setCursor(db *gorm.DB) *gorm.DB {
+	if err := db.Statement.Parse(db.Statement.Model); err != nil {
+		db.AddError(err)
+		return db
+	}
+	sch := db.Statement.Schema
+	m.ks.pk = sch.PrioritizedPrimaryField
+	m.ks.field = sch.LookUpField(m.ks.col)
+	if m.ks.field == nil || m.ks.field.DBName == "" {
+		m.ks.field = m.ks.pk
+	}
+
+	// count before the cursor condition is added
+	if !m.NoCount {
+		db.Count(&m.count)
+	}
+
+	if err := m.Parse(); err != nil {
+		db.AddError(err)
+		return db
+	}
+	// going backward flip the direction, the rows are restored in Trim
+	desc := m.ks.desc
+	if m.ks.cur != nil && m.ks.cur.Prev {
+		desc = !desc
+	}
+
+	if cur := m.ks.cur; cur != nil {
+		if cur.Col != m.ks.field.DBName {
+			db.AddError(ErrInvalidCursor)
+			return db
+		}
+		id, err := decodeValue(cur.ID, m.ks.pk)
+		if err != nil {
+			db.AddError(ErrInvalidCursor)
+			return db
+		}
+		op := " > ?"
+		if desc {
+			op = " < ?"
+		}
+		pk := db.Statement.Quote(clause.Column{Table: clause.CurrentTable, Name: m.ks.pk.DBName})
+		if m.ks.field == m.ks.pk {
+			db = db.Where(pk+op, id)
+		} else {
+			key, err := decodeValue(cur.Key, m.ks.field)
+			if err != nil {
+				db.AddError(ErrInvalidCursor)
+				return db
+			}
+			col := db.Statement.Quote(clause.Column{Table: clause.CurrentTable, Name: m.ks.field.DBName})
+			db = db.Where("("+col+op+" OR ("+col+" = ? AND "+pk+op+"))", key, key, id)
+		}
+	}
+
+	// the previous order can not be used along with the keyset
+	delete(db.Statement.Clauses, "ORDER BY")
+	if m.ks.field != m.ks.pk {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: m.ks.field.DBName}, Desc: desc})
+	}
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: m.ks.pk.DBName}, Desc: desc})
+
+	if m.Limit != 0 {
+		// retrieve one more row to know whether there is the next page
+		db = db.Limit(m.Limit + 1)
+	}
+	return db
+}
+
+// Trim remove the extra row that's retrieved to know whether there is the
+// next page, then set up the cursors in the cursor mode. Should be called
+// right after retrieving the rows in the cursor mode or when the count is
+// turned off. Otherwise, return given rows as is.
+func Trim[T any](m *M, rows []T) []T {
+	if m.Limit == 0 || (!m.IsCursor() && !m.NoCount) {
+		return rows
+	}
+	m.ks.hasMore = len(rows) > m.Limit
+	if m.ks.hasMore {
+		rows = rows[:m.Limit]
+	}
+	if !m.IsCursor() || len(rows) == 0 || m.ks.pk == nil {
+		return rows
+	}
+
+	// the rows are retrieved in reverse when going backward
+	prev := m.ks.cur != nil && m.ks.cur.Prev
+	if prev {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if !prev && m.ks.hasMore || prev {
+		m.NextCursor = m.encode(reflect.ValueOf(rows[len(rows)-1]), false)
+	}
+	if prev && m.ks.hasMore || !prev && m.ks.cur != nil {
+		m.PrevCursor = m.encode(reflect.ValueOf(rows[0]), true)
+	}
+	return rows
+}
+
+// encode create the opaque cursor from given row.
+func (m *M) encode(row reflect.Value, prev bool) string {
+	ctx := context.Background()
+	id, _ := m.ks.pk.ValueOf(ctx, row)
+	cur := cursor{Col: m.ks.field.DBName, Prev: prev}
+	cur.ID, _ = json.Marshal(id)
+	if m.ks.field != m.ks.pk {
+		key, _ := m.ks.field.ValueOf(ctx, row)
+		cur.Key, _ = json.Marshal(key)
+	}
+
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeValue decode given raw value from the cursor using the data type of
+// given field, so it can be compared in the query.
+func decodeValue(raw json.RawMessage, f *schema.Field) (any, error) {
+	var err error
+	switch f.DataType {
+	case schema.Bool:
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case schema.Int:
+		var v int64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case schema.Uint:
+		var v uint64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case schema.Float:
+		var v float64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case schema.Time:
+		var v *time.Time
+		err = json.Unmarshal(raw, &v)
+		if v == nil {
+			return nil, err
+		}
+		return *v, err
+	default:
+		var v string
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
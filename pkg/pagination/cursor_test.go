@@ -0,0 +1,152 @@
+package paginate_test
+
+import (
+	"testing"
+	"time"
+
+	paginate "github.com/mdanialr/pwman_backend/pkg/pagination"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type item struct {
+	ID        uint
+	Name      string
+	CreatedAt time.Time
+}
+
+// dryRun render the query of given M to SQL without touching any database.
+// The count is turned off since dry run keep the SQL of the count.
+func dryRun(t *testing.T, m *paginate.M) *gorm.Statement {
+	m.NoCount = true
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		NamingStrategy:       schema.NamingStrategy{SingularTable: true},
+	})
+	require.NoError(t, err)
+
+	var items []*item
+	q := db.Model(&item{}).Order("name ASC")
+	return m.Set(q).Find(&items).Statement
+}
+
+// sampleItems return items with given ids, the bigger the id the older it is.
+func sampleItems(ids ...uint) []*item {
+	now := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	var res []*item
+	for _, id := range ids {
+		res = append(res, &item{ID: id, CreatedAt: now.AddDate(0, 0, -int(id))})
+	}
+	return res
+}
+
+func TestM_Set_Cursor(t *testing.T) {
+	// walk through the pages of created_at DESC with 2 items per page
+	first := &paginate.M{Limit: 2, Mode: paginate.ModeCursor}
+	first.SetKey("created_at", true)
+	stmt := dryRun(t, first)
+	assert.Contains(t, stmt.SQL.String(), `ORDER BY "item"."created_at" DESC,"item"."id" DESC LIMIT 3`)
+	assert.NotContains(t, stmt.SQL.String(), "name ASC")
+
+	rows := paginate.Trim(first, sampleItems(1, 2, 3))
+	assert.Len(t, rows, 2)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+
+	// the next page start after the last row
+	second := &paginate.M{Limit: 2, Cursor: first.NextCursor}
+	second.SetKey("created_at", true)
+	stmt = dryRun(t, second)
+	assert.Contains(t, stmt.SQL.String(), `("item"."created_at" < $1 OR ("item"."created_at" = $2 AND "item"."id" < $3))`)
+	require.Len(t, stmt.Vars, 3)
+	assert.Equal(t, sampleItems(2)[0].CreatedAt, stmt.Vars[0])
+	assert.Equal(t, uint64(2), stmt.Vars[2])
+
+	rows = paginate.Trim(second, sampleItems(3))
+	assert.Len(t, rows, 1)
+	assert.Empty(t, second.NextCursor)
+	require.NotEmpty(t, second.PrevCursor)
+
+	// going back flip the order then restore it
+	back := &paginate.M{Limit: 2, Cursor: second.PrevCursor}
+	back.SetKey("created_at", true)
+	stmt = dryRun(t, back)
+	assert.Contains(t, stmt.SQL.String(), `("item"."created_at" > $1 OR ("item"."created_at" = $2 AND "item"."id" > $3))`)
+	assert.Contains(t, stmt.SQL.String(), `ORDER BY "item"."created_at","item"."id" LIMIT 3`)
+
+	rows = paginate.Trim(back, sampleItems(2, 1))
+	require.Len(t, rows, 2)
+	assert.Equal(t, uint(1), rows[0].ID)
+	assert.NotEmpty(t, back.NextCursor)
+	assert.Empty(t, back.PrevCursor)
+}
+
+func TestM_Set_CursorKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		key       string
+		cursor    func() string
+		expectSQL string
+		wantErr   bool
+	}{
+		{
+			name:      "Given unknown sort key should fallback to the id",
+			key:       "unknown",
+			expectSQL: `ORDER BY "item"."id" LIMIT 3`,
+		},
+		{
+			name:    "Given malformed cursor should return error",
+			key:     "id",
+			cursor:  func() string { return "not a cursor" },
+			wantErr: true,
+		},
+		{
+			name: "Given cursor of another sort key should return error",
+			key:  "name",
+			cursor: func() string {
+				m := &paginate.M{Limit: 2, Mode: paginate.ModeCursor}
+				m.SetKey("created_at", false)
+				dryRun(t, m)
+				paginate.Trim(m, sampleItems(1, 2, 3))
+				return m.NextCursor
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &paginate.M{Limit: 2, Mode: paginate.ModeCursor}
+			if tc.cursor != nil {
+				m.Cursor = tc.cursor()
+			}
+			m.SetKey(tc.key, false)
+			stmt := dryRun(t, m)
+
+			if tc.wantErr {
+				assert.ErrorIs(t, stmt.Error, paginate.ErrInvalidCursor)
+				return
+			}
+			assert.NoError(t, stmt.Error)
+			assert.Contains(t, stmt.SQL.String(), tc.expectSQL)
+		})
+	}
+}
+
+func TestM_Set_NoCount(t *testing.T) {
+	m := &paginate.M{Limit: 2, Page: 2, NoCount: true}
+	stmt := dryRun(t, m)
+	assert.Contains(t, stmt.SQL.String(), "ORDER BY name ASC LIMIT 3 OFFSET 2")
+
+	rows := paginate.Trim(m, sampleItems(3, 4, 5))
+	m.Paginate()
+	assert.Len(t, rows, 2)
+	assert.Equal(t, 3, m.Next)
+	assert.Equal(t, 1, m.Prev)
+	assert.Zero(t, m.TotalPage)
+}
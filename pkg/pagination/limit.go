@@ -0,0 +1,48 @@
+package paginate
+
+import "github.com/spf13/viper"
+
+// Limits the default and the maximum page size of an endpoint.
+type Limits struct {
+	// Default the page size when the limit is not given.
+	Default int
+	// Max the biggest page size that's allowed.
+	Max int
+}
+
+// DefaultLimits the page sizes that's used when nothing is configured.
+var DefaultLimits = Limits{Default: 20, Max: 100}
+
+// NewLimits return Limits of given endpoint from the pagination section in
+// given config. The config of the endpoint take precedence over the global
+// one, then DefaultLimits.
+//
+// Example:
+//
+//	pagination:
+//	  default_limit: 20
+//	  max_limit: 100
+//	  password:
+//	    default_limit: 50
+func NewLimits(v *viper.Viper, endpoint string) Limits {
+	l := DefaultLimits
+	for _, prefix := range []string{"pagination.", "pagination." + endpoint + "."} {
+		if n := v.GetInt(prefix + "default_limit"); n > 0 {
+			l.Default = n
+		}
+		if n := v.GetInt(prefix + "max_limit"); n > 0 {
+			l.Max = n
+		}
+	}
+	if l.Default > l.Max {
+		l.Default = l.Max
+	}
+	return l
+}
+
+// SetDefaultLimit set Limit to the default of given Limits if it's not given.
+func (m *M) SetDefaultLimit(l Limits) {
+	if m.Limit == 0 {
+		m.Limit = l.Default
+	}
+}
@@ -21,39 +21,91 @@ type M struct {
 	// TotalPage based on given Limit how many page that can be divided from
 	// total available data.
 	TotalPage int `json:"total_page,omitempty"`
+	// Mode either page (default) to use the page number or cursor to use the
+	// cursor instead.
+	Mode string `json:"-" query:"mode"`
+	// Cursor the opaque cursor from NextCursor or PrevCursor of the previous
+	// response. Setting it implies the cursor mode.
+	Cursor string `json:"-" query:"cursor"`
+	// NoCount turn off counting the total number of data. Without the count,
+	// TotalPage and Total are left out.
+	NoCount bool `json:"-" query:"no_count"`
+	// NextCursor the cursor to retrieve the next page in the cursor mode.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// PrevCursor the cursor to retrieve the previous page in the cursor mode.
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Total the total number of data in the cursor mode.
+	Total *int64 `json:"total,omitempty"`
 	// count just a placeholder to count the total retrieved number of data.
 	count int64
+	// ks the state of the cursor mode.
+	ks keyset
 }
 
 // Paginate setup current, previous and next page based on the count & Limit
 // fields. This should be called after retrieving the actual data from DB.
 func (m *M) Paginate() {
-	m.TotalPage = int(math.Ceil(float64(m.count) / float64(m.Limit)))
-	if m.TotalPage > 0 {
-		switch {
-		case m.Page == 1 && m.TotalPage > 1: // 1st page
-			m.Next = m.Page + 1
-		case m.Page > 1 && m.Page < m.TotalPage: // 2nd page until before last page
+	if m.IsCursor() {
+		if !m.NoCount {
+			m.Total = &m.count
+		}
+		return
+	}
+	// without the count, rely on the extra row from Trim instead
+	if m.NoCount {
+		if m.ks.hasMore {
 			m.Next = m.Page + 1
-			m.Prev = m.Page - 1
-		case m.Page == m.TotalPage && m.TotalPage > 1: // last page
+		}
+		if m.Page > 1 {
 			m.Prev = m.Page - 1
 		}
+		return
+	}
+
+	// without the limit, everything is in a single page
+	m.TotalPage = 1
+	if m.Limit > 0 {
+		m.TotalPage = int(math.Ceil(float64(m.count) / float64(m.Limit)))
 	}
 	if m.TotalPage <= 0 {
 		m.TotalPage = 1
 	}
+	if m.Page < 1 {
+		m.Page = 1
+	}
+
+	m.Next, m.Prev = 0, 0
+	if m.Page < m.TotalPage {
+		m.Next = m.Page + 1
+	}
+	if m.Page > 1 {
+		// page beyond the last one point back to the last page
+		m.Prev = m.Page - 1
+		if m.Prev > m.TotalPage {
+			m.Prev = m.TotalPage
+		}
+	}
 }
 
 // Set implementation of repository.IOptions.
 func (m *M) Set(db *gorm.DB) *gorm.DB {
-	db.Count(&m.count)
+	if m.IsCursor() {
+		return m.setCursor(db)
+	}
+	if !m.NoCount {
+		db.Count(&m.count)
+	}
 
-	if m.Page == 0 { // set to first page if only has one page
+	if m.Page < 1 { // set to first page if only has one page
 		m.Page = 1
 	}
 	if m.Limit != 0 {
-		db = db.Limit(m.Limit)
+		limit := m.Limit
+		if m.NoCount {
+			// retrieve one more row to know whether there is the next page
+			limit++
+		}
+		db = db.Limit(limit)
 	}
 	db = db.Offset((m.Page - 1) * m.Limit)
 
@@ -0,0 +1,184 @@
+package paginate
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestM_Paginate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sample      M
+		count       int64
+		expectTotal int
+		expectNext  int
+		expectPrev  int
+		expectPage  int
+	}{
+		{
+			name:        "Given zero limit should not divide by zero and regard everything as a single page",
+			sample:      M{Page: 1},
+			count:       25,
+			expectTotal: 1,
+			expectPage:  1,
+		},
+		{
+			name:        "Given no data should still have a single page",
+			sample:      M{Limit: 10, Page: 1},
+			expectTotal: 1,
+			expectPage:  1,
+		},
+		{
+			name:        "Given the first of many pages should only have the next page",
+			sample:      M{Limit: 10, Page: 1},
+			count:       25,
+			expectTotal: 3,
+			expectNext:  2,
+			expectPage:  1,
+		},
+		{
+			name:        "Given a middle page should have both the next and the previous pages",
+			sample:      M{Limit: 10, Page: 2},
+			count:       25,
+			expectTotal: 3,
+			expectNext:  3,
+			expectPrev:  1,
+			expectPage:  2,
+		},
+		{
+			name:        "Given the last page should only have the previous page",
+			sample:      M{Limit: 10, Page: 3},
+			count:       25,
+			expectTotal: 3,
+			expectPrev:  2,
+			expectPage:  3,
+		},
+		{
+			name:        "Given exactly full pages should not have an empty extra page",
+			sample:      M{Limit: 10, Page: 2},
+			count:       20,
+			expectTotal: 2,
+			expectPrev:  1,
+			expectPage:  2,
+		},
+		{
+			name:        "Given a single page should have neither the next nor the previous page",
+			sample:      M{Limit: 10, Page: 1},
+			count:       10,
+			expectTotal: 1,
+			expectPage:  1,
+		},
+		{
+			name:        "Given page beyond the last one should point back to the last page",
+			sample:      M{Limit: 10, Page: 7},
+			count:       25,
+			expectTotal: 3,
+			expectPrev:  3,
+			expectPage:  7,
+		},
+		{
+			name:        "Given zero page should be regarded as the first page",
+			sample:      M{Limit: 10},
+			count:       25,
+			expectTotal: 3,
+			expectNext:  2,
+			expectPage:  1,
+		},
+		{
+			name:       "Given no count and more rows should rely on the extra row for the next page",
+			sample:     M{Limit: 10, Page: 2, NoCount: true, ks: keyset{hasMore: true}},
+			expectNext: 3,
+			expectPrev: 1,
+			expectPage: 2,
+		},
+		{
+			name:       "Given no count and no more rows should not have the next page",
+			sample:     M{Limit: 10, Page: 2, NoCount: true},
+			expectPrev: 1,
+			expectPage: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.sample
+			m.count = tc.count
+			m.Paginate()
+
+			assert.Equal(t, tc.expectTotal, m.TotalPage)
+			assert.Equal(t, tc.expectNext, m.Next)
+			assert.Equal(t, tc.expectPrev, m.Prev)
+			assert.Equal(t, tc.expectPage, m.Page)
+		})
+	}
+}
+
+func TestNewLimits(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config map[string]any
+		expect Limits
+	}{
+		{
+			name:   "Given no config should use the default limits",
+			expect: DefaultLimits,
+		},
+		{
+			name:   "Given global config should use it for every endpoint",
+			config: map[string]any{"pagination.default_limit": 10, "pagination.max_limit": 50},
+			expect: Limits{Default: 10, Max: 50},
+		},
+		{
+			name: "Given config of the endpoint should take precedence over the global one",
+			config: map[string]any{
+				"pagination.default_limit":          10,
+				"pagination.max_limit":              50,
+				"pagination.password.default_limit": 30,
+			},
+			expect: Limits{Default: 30, Max: 50},
+		},
+		{
+			name:   "Given default bigger than the max should be capped to the max",
+			config: map[string]any{"pagination.password.max_limit": 5},
+			expect: Limits{Default: 5, Max: 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := viper.New()
+			for k, val := range tc.config {
+				v.Set(k, val)
+			}
+			assert.Equal(t, tc.expect, NewLimits(v, "password"))
+		})
+	}
+}
+
+func TestM_SetDefaultLimit(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sample M
+		expect int
+	}{
+		{
+			name:   "Given no limit should use the default",
+			expect: 20,
+		},
+		{
+			name:   "Given limit should keep it",
+			sample: M{Limit: 5},
+			expect: 5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.sample
+			m.SetDefaultLimit(Limits{Default: 20, Max: 100})
+			assert.Equal(t, tc.expect, m.Limit)
+		})
+	}
+}
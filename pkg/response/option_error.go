@@ -17,6 +17,9 @@ func WithErr(err error) AppErrorOption {
 		case *stderr.UC:
 			a.Code = e.Code
 			a.Message = e.Msg
+			if e.Detail != nil {
+				a.Detail = e.Detail
+			}
 		}
 	}
 }
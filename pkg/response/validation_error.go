@@ -45,6 +45,18 @@ func errMsgMapping(fe validator.FieldError) string {
 		return "should only contain alphabet and numeric characters"
 	case "image":
 		return "only accept valid image mime type (jpg|jpeg|png)"
+	case "oneof":
+		return "should be one of " + fe.Param()
+	case "hexcolor":
+		return "should be a hex color code such as #ff0000"
+	case "datetime":
+		return "should be a date (YYYY-MM-DD) or RFC3339 timestamp"
+	case "gtefield":
+		return "should be equal or after " + fe.Param()
+	case "cursor":
+		return "invalid cursor"
+	case "strength":
+		return "too weak, strength score should be at least " + fe.Param()
 	}
 	return fe.Error()
 }
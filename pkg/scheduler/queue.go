@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	help "github.com/mdanialr/pwman_backend/pkg/helper"
+
+	"go.uber.org/zap"
+)
+
+// maxAttempts how many times a failed job is retried before it's dropped.
+const maxAttempts = 5
+
+// Job a task that should be run once its time is come.
+type Job struct {
+	ID uint
+	// Kind which Handler should run this job.
+	Kind string
+	// RefID the id of the data this job is about.
+	RefID uint
+	// RunAt when this job should be run.
+	RunAt time.Time
+	// Attempts how many times this job has failed.
+	Attempts int
+}
+
+// Handler run given Job. Return error to retry it later.
+type Handler func(ctx context.Context, job Job) error
+
+// Store signature of the persistence that keep the jobs, so they survive
+// restarts.
+type Store interface {
+	// DueJobs return all jobs that should be run at given time.
+	DueJobs(ctx context.Context, now time.Time) ([]Job, error)
+	// DoneJob remove the job that match given id, so it's never run again.
+	DoneJob(ctx context.Context, id uint) error
+	// RetryJob record the failed attempt of the job that match given id along
+	// with the reason, then run it again at given time.
+	RetryJob(ctx context.Context, id uint, at time.Time, reason string) error
+}
+
+// NewQueue return Queue that run the jobs kept in given Store.
+func NewQueue(store Store, log *zap.Logger) *Queue {
+	return &Queue{store: store, log: log, handlers: make(map[string]Handler)}
+}
+
+// Queue run the persisted jobs using the Handler that's registered for their
+// kind. Use it along with Every to poll for the due jobs.
+type Queue struct {
+	store    Store
+	log      *zap.Logger
+	handlers map[string]Handler
+}
+
+// Handle register given Handler to run the jobs of given kind. Should be done
+// before the Queue is run.
+func (q *Queue) Handle(kind string, h Handler) {
+	q.handlers[kind] = h
+}
+
+// Run run all due jobs once. Failed jobs are retried later with increasing
+// delay and dropped after several attempts.
+func (q *Queue) Run(ctx context.Context) {
+	now := time.Now()
+	jobs, err := q.store.DueJobs(ctx, now)
+	if err != nil {
+		q.log.Error(help.Pad("failed to retrieve due jobs:", err.Error()))
+		return
+	}
+
+	for _, job := range jobs {
+		h, ok := q.handlers[job.Kind]
+		if !ok {
+			q.log.Error(help.Pad("no handler for job kind:", job.Kind, "the job is dropped"))
+			q.done(ctx, job)
+			continue
+		}
+
+		if err = h(ctx, job); err == nil {
+			q.done(ctx, job)
+			continue
+		}
+		if job.Attempts+1 >= maxAttempts {
+			q.log.Error(help.Pad("job", job.Kind, "is dropped after too many attempts and err:", err.Error()))
+			q.done(ctx, job)
+			continue
+		}
+		// wait longer after each attempt
+		at := now.Add(time.Duration(job.Attempts+1) * time.Minute)
+		if err = q.store.RetryJob(ctx, job.ID, at, err.Error()); err != nil {
+			q.log.Error(help.Pad("failed to reschedule job", job.Kind, "and err:", err.Error()))
+		}
+	}
+}
+
+// done remove given job from the store and just log if there is any error.
+func (q *Queue) done(ctx context.Context, job Job) {
+	if err := q.store.DoneJob(ctx, job.ID); err != nil {
+		q.log.Error(help.Pad("failed to remove job", job.Kind, "and err:", err.Error()))
+	}
+}
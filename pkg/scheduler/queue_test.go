@@ -0,0 +1,103 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/pkg/scheduler"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+// memStore in memory Store that keep the jobs in a map.
+type memStore struct {
+	jobs map[uint]*scheduler.Job
+	errs map[uint]string
+}
+
+func newMemStore(jobs ...scheduler.Job) *memStore {
+	s := &memStore{jobs: make(map[uint]*scheduler.Job), errs: make(map[uint]string)}
+	for i := range jobs {
+		s.jobs[jobs[i].ID] = &jobs[i]
+	}
+	return s
+}
+
+func (m *memStore) DueJobs(_ context.Context, now time.Time) ([]scheduler.Job, error) {
+	var due []scheduler.Job
+	for _, j := range m.jobs {
+		if !j.RunAt.After(now) {
+			due = append(due, *j)
+		}
+	}
+	return due, nil
+}
+
+func (m *memStore) DoneJob(_ context.Context, id uint) error {
+	delete(m.jobs, id)
+	return nil
+}
+
+func (m *memStore) RetryJob(_ context.Context, id uint, at time.Time, reason string) error {
+	m.jobs[id].Attempts++
+	m.jobs[id].RunAt = at
+	m.errs[id] = reason
+	return nil
+}
+
+func TestQueue_Run(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	t.Run("Given due job should run it using the handler of its kind then remove it", func(t *testing.T) {
+		st := newMemStore(scheduler.Job{ID: 1, Kind: "a", RefID: 7, RunAt: past}, scheduler.Job{ID: 2, Kind: "a", RefID: 8, RunAt: future})
+		q := scheduler.NewQueue(st, zaptest.NewLogger(t))
+		var ran []uint
+		q.Handle("a", func(_ context.Context, job scheduler.Job) error {
+			ran = append(ran, job.RefID)
+			return nil
+		})
+
+		q.Run(context.Background())
+
+		assert.Equal(t, []uint{7}, ran)
+		assert.NotContains(t, st.jobs, uint(1))
+		assert.Contains(t, st.jobs, uint(2))
+	})
+
+	t.Run("Given job whose handler failed should keep it to be retried later along with the reason", func(t *testing.T) {
+		st := newMemStore(scheduler.Job{ID: 1, Kind: "a", RunAt: past})
+		q := scheduler.NewQueue(st, zaptest.NewLogger(t))
+		q.Handle("a", func(context.Context, scheduler.Job) error { return errors.New("oops") })
+
+		q.Run(context.Background())
+
+		if assert.Contains(t, st.jobs, uint(1)) {
+			assert.Equal(t, 1, st.jobs[1].Attempts)
+			assert.True(t, st.jobs[1].RunAt.After(time.Now()))
+			assert.Equal(t, "oops", st.errs[1])
+		}
+	})
+
+	t.Run("Given job that failed too many times should drop it", func(t *testing.T) {
+		st := newMemStore(scheduler.Job{ID: 1, Kind: "a", RunAt: past, Attempts: 4})
+		q := scheduler.NewQueue(st, zaptest.NewLogger(t))
+		q.Handle("a", func(context.Context, scheduler.Job) error { return errors.New("oops") })
+
+		q.Run(context.Background())
+
+		assert.Empty(t, st.jobs)
+	})
+
+	t.Run("Given job of unknown kind should drop it", func(t *testing.T) {
+		st := newMemStore(scheduler.Job{ID: 1, Kind: "b", RunAt: past})
+		q := scheduler.NewQueue(st, zaptest.NewLogger(t))
+
+		q.Run(context.Background())
+
+		assert.Empty(t, st.jobs)
+	})
+}
@@ -0,0 +1,24 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Every call given fn once right away then repeatedly every given interval
+// until given context is done. This will block, so should be run in its own
+// goroutine.
+func Every(ctx context.Context, interval time.Duration, fn func(context.Context)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package seal encrypt small secrets with a new random key that's handed to the
+// caller, so whoever only keeps the ciphertext can not decrypt it.
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// KeySize the size of the key, AES-256.
+const KeySize = 32
+
+// ErrDecrypt the key is wrong or the ciphertext is corrupted.
+var ErrDecrypt = errors.New("wrong key or corrupted ciphertext")
+
+// ErrKeySize the given key is not of KeySize.
+var ErrKeySize = errors.New("key should be 32 bytes")
+
+// Seal encrypt given plaintext with AES-256-GCM using a new random key. Return
+// the ciphertext that's prefixed by the nonce along with the key.
+func Seal(plaintext []byte) (ciphertext, key []byte, err error) {
+	key = make([]byte, KeySize)
+	if _, err = rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err = Encrypt(plaintext, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, key, nil
+}
+
+// Encrypt same as Seal but use given key instead of a new one, for the key
+// that's kept by the caller. Should be opened using Open.
+func Encrypt(plaintext, key []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypt given ciphertext that's sealed by Seal using given key.
+func Open(ciphertext, key []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, ErrDecrypt
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	n := aead.NonceSize()
+	pt, err := aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return pt, nil
+}
+
+// newAEAD return AES-GCM using given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,81 @@
+package seal_test
+
+import (
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/seal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeal(t *testing.T) {
+	t.Run("Given sealed secret should be opened using the returned key", func(t *testing.T) {
+		ct, key, err := seal.Seal([]byte("secret"))
+		require.NoError(t, err)
+		assert.Len(t, key, seal.KeySize)
+		assert.NotContains(t, string(ct), "secret")
+
+		pt, err := seal.Open(ct, key)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(pt))
+	})
+
+	t.Run("Given the same secret should use different key and ciphertext every time", func(t *testing.T) {
+		ct1, key1, err := seal.Seal([]byte("secret"))
+		require.NoError(t, err)
+		ct2, key2, err := seal.Seal([]byte("secret"))
+		require.NoError(t, err)
+
+		assert.NotEqual(t, key1, key2)
+		assert.NotEqual(t, ct1, ct2)
+	})
+}
+
+func TestOpen(t *testing.T) {
+	ct, key, err := seal.Seal([]byte("secret"))
+	require.NoError(t, err)
+	other, _, err := seal.Seal([]byte("secret"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		ct   []byte
+		key  []byte
+	}{
+		{name: "Given the key of other ciphertext should return error", ct: ct, key: func() []byte {
+			_, k, _ := seal.Seal(nil)
+			return k
+		}()},
+		{name: "Given the key of short size should return error", ct: ct, key: key[:16]},
+		{name: "Given tampered ciphertext should return error", ct: append(append([]byte{}, ct[:len(ct)-1]...), ct[len(ct)-1]^1), key: key},
+		{name: "Given ciphertext that's shorter than the nonce should return error", ct: ct[:4], key: key},
+		{name: "Given other ciphertext should return error", ct: other, key: key},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := seal.Open(tc.ct, tc.key)
+			assert.ErrorIs(t, err, seal.ErrDecrypt)
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	_, key, err := seal.Seal(nil)
+	require.NoError(t, err)
+
+	t.Run("Given secret that's encrypted using given key should be opened using the same key", func(t *testing.T) {
+		ct, err := seal.Encrypt([]byte("secret"), key)
+		require.NoError(t, err)
+
+		pt, err := seal.Open(ct, key)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(pt))
+	})
+
+	t.Run("Given the key of short size should return error", func(t *testing.T) {
+		_, err := seal.Encrypt([]byte("secret"), key[:16])
+		assert.ErrorIs(t, err, seal.ErrKeySize)
+	})
+}
@@ -0,0 +1,125 @@
+// Package shamir split a secret into shares using Shamir's secret sharing over
+// GF(256), so any threshold of them recover the secret while fewer reveal
+// nothing about it.
+//
+// Each byte of the secret is the constant term of its own random polynomial of
+// degree threshold-1. A share is the value of every polynomial at the same
+// non-zero x, followed by that x.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+var (
+	// ErrInvalidParams the number of shares or the threshold is out of range.
+	ErrInvalidParams = errors.New("threshold should be at least 2 and not more than the shares, which is at most 255")
+	// ErrInvalidShares the shares are too few, not of the same size or have
+	// the same x.
+	ErrInvalidShares = errors.New("invalid shares")
+)
+
+// Split split given secret into given number of shares, any given threshold of
+// which recover the secret using Combine.
+func Split(secret []byte, shares, threshold int) ([][]byte, error) {
+	if threshold < 2 || shares < threshold || shares > 255 || len(secret) == 0 {
+		return nil, ErrInvalidParams
+	}
+
+	// the coefficients of every polynomial, except the constant term
+	coef := make([]byte, len(secret)*(threshold-1))
+	if _, err := rand.Read(coef); err != nil {
+		return nil, err
+	}
+
+	res := make([][]byte, shares)
+	for i := range res {
+		x := byte(i + 1)
+		share := make([]byte, len(secret)+1)
+		for j, s := range secret {
+			share[j] = eval(s, coef[j*(threshold-1):(j+1)*(threshold-1)], x)
+		}
+		share[len(secret)] = x
+		res[i] = share
+	}
+	return res, nil
+}
+
+// Combine recover the secret from given shares. Fewer shares than the
+// threshold return a wrong secret without error, so the caller should verify
+// it.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, ErrInvalidShares
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, ErrInvalidShares
+	}
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]bool)
+	for i, s := range shares {
+		if len(s) != size {
+			return nil, ErrInvalidShares
+		}
+		x := s[size-1]
+		if x == 0 || seen[x] {
+			return nil, ErrInvalidShares
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	// Lagrange interpolation at x = 0 for every byte
+	secret := make([]byte, size-1)
+	for j := range secret {
+		var v byte
+		for i, s := range shares {
+			basis := byte(1)
+			for m := range shares {
+				if m != i {
+					// xm / (xm - xi), where subtraction is xor
+					basis = mul(basis, div(xs[m], xs[m]^xs[i]))
+				}
+			}
+			v ^= mul(s[j], basis)
+		}
+		secret[j] = v
+	}
+	return secret, nil
+}
+
+// eval return the value at given x of the polynomial with given constant term
+// and the rest of the coefficients from the lowest degree.
+func eval(constant byte, coef []byte, x byte) byte {
+	// Horner's method from the highest degree
+	var v byte
+	for i := len(coef) - 1; i >= 0; i-- {
+		v = mul(v, x) ^ coef[i]
+	}
+	return mul(v, x) ^ constant
+}
+
+// mul multiply given numbers in GF(256) using the polynomial of AES, without
+// branching on the secret.
+func mul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		carry := -(a >> 7) & 0x1b
+		a = a<<1 ^ carry
+		b >>= 1
+	}
+	return p
+}
+
+// div divide given numbers in GF(256). b should not be zero.
+func div(a, b byte) byte {
+	// b^254 is the inverse of b
+	inv := b
+	for i := 0; i < 6; i++ {
+		inv = mul(mul(inv, inv), b)
+	}
+	return mul(a, mul(inv, inv))
+}
@@ -0,0 +1,84 @@
+package shamir_test
+
+import (
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/shamir"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+
+	t.Run("Given any threshold of shares should recover the secret", func(t *testing.T) {
+		shares, err := shamir.Split(secret, 5, 3)
+		require.NoError(t, err)
+		require.Len(t, shares, 5)
+
+		for _, pick := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+			var sub [][]byte
+			for _, i := range pick {
+				sub = append(sub, shares[i])
+			}
+			res, err := shamir.Combine(sub)
+			require.NoError(t, err)
+			assert.Equal(t, secret, res, "shares %v", pick)
+		}
+	})
+
+	t.Run("Given fewer shares than the threshold should not recover the secret", func(t *testing.T) {
+		shares, err := shamir.Split(secret, 5, 3)
+		require.NoError(t, err)
+
+		res, err := shamir.Combine(shares[:2])
+		require.NoError(t, err)
+		assert.NotEqual(t, secret, res)
+	})
+
+	t.Run("Given the same secret should use different shares every time", func(t *testing.T) {
+		s1, err := shamir.Split(secret, 3, 2)
+		require.NoError(t, err)
+		s2, err := shamir.Split(secret, 3, 2)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, s1[0], s2[0])
+	})
+
+	testCases := []struct {
+		name      string
+		shares    int
+		threshold int
+	}{
+		{name: "Given threshold of 1 should return ErrInvalidParams", shares: 3, threshold: 1},
+		{name: "Given threshold more than the shares should return ErrInvalidParams", shares: 2, threshold: 3},
+		{name: "Given more than 255 shares should return ErrInvalidParams", shares: 256, threshold: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := shamir.Split(secret, tc.shares, tc.threshold)
+			assert.ErrorIs(t, err, shamir.ErrInvalidParams)
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	shares, err := shamir.Split([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		shares [][]byte
+	}{
+		{name: "Given only one share should return ErrInvalidShares", shares: shares[:1]},
+		{name: "Given the same share twice should return ErrInvalidShares", shares: [][]byte{shares[0], shares[0]}},
+		{name: "Given shares of different size should return ErrInvalidShares", shares: [][]byte{shares[0], shares[1][1:]}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := shamir.Combine(tc.shares)
+			assert.ErrorIs(t, err, shamir.ErrInvalidShares)
+		})
+	}
+}
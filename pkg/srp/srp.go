@@ -0,0 +1,232 @@
+// Package srp implement the Secure Remote Password protocol version 6a (RFC
+// 2945 and RFC 5054) using the 2048-bit group of RFC 5054 and SHA-256. The
+// server only keeps a verifier, so the password is never sent to it.
+//
+// Every number is padded to the size of N before it's hashed, and:
+//
+//	k  = H(N | g)
+//	x  = H(salt | H(username | ":" | password))
+//	v  = g^x
+//	B  = k*v + g^b
+//	u  = H(A | B)
+//	K  = H(S)
+//	M1 = H(A | B | K)
+//	M2 = H(A | M1 | K)
+package srp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+)
+
+// nHex the 2048-bit prime of RFC 5054 appendix A.
+const nHex = "AC6BDB41324A9A9BF166DE5E1389582FAF72B6651987EE07FC3192943DB56050" +
+	"A37329CBB4A099ED8193E0757767A13DD52312AB4B03310DCD7F48A9DA04FD50" +
+	"E8083969EDB767B0CF6095179A163AB3661A05FBD5FAAAE82918A9962F0B93B8" +
+	"55F97993EC975EEAA80D740ADBF4FF747359D041D5C33EA71D281E446B14773B" +
+	"CA97B43A23FB801676BD207A436C6481F1D2B9078717461A5B9D32E688F87748" +
+	"544523B524B0D57D5EA77A2775D2ECFA032CFBDBF52FB3786160279004E57AE6" +
+	"AF874E7303CE53299CCC041C7BC308D82A5698F3A8D0C38271AE35F8E9DBFBB6" +
+	"94B5C803D89F7AE435DE236D525F54759B65E372FCD68EF20FA7111F9E4AFF73"
+
+const (
+	// SaltSize the size of the random salt of the verifier.
+	SaltSize = 16
+	// secretSize the size of the random private ephemeral.
+	secretSize = 32
+)
+
+var (
+	// ErrInvalidPublic the public ephemeral of the other side is not between
+	// zero and N, which should be rejected.
+	ErrInvalidPublic = errors.New("invalid public ephemeral")
+	// ErrProof the proof of the other side does not match, either the
+	// password is wrong or the exchange is tampered.
+	ErrProof = errors.New("invalid proof")
+	// ErrInvalidVerifier the verifier is not between zero and N.
+	ErrInvalidVerifier = errors.New("invalid verifier")
+)
+
+var (
+	n = func() *big.Int {
+		i, _ := new(big.Int).SetString(nHex, 16)
+		return i
+	}()
+	g = big.NewInt(2)
+	k = hashInt(pad(n), pad(g))
+)
+
+// NewVerifier return new random salt along with the verifier of given username
+// and password. Should be computed by the client, then only the salt and the
+// verifier are sent to the server.
+func NewVerifier(username, password string) (salt, verifier []byte, err error) {
+	salt = make([]byte, SaltSize)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	v := new(big.Int).Exp(g, privateKey(username, password, salt), n)
+	return salt, pad(v), nil
+}
+
+// NewServer return Server with new random ephemeral for the user whose
+// verifier is given verifier.
+func NewServer(verifier []byte) (*Server, error) {
+	v := new(big.Int).SetBytes(verifier)
+	if !inGroup(v) {
+		return nil, ErrInvalidVerifier
+	}
+	b, err := randomInt()
+	if err != nil {
+		return nil, err
+	}
+
+	// B = k*v + g^b
+	pub := new(big.Int).Mul(k, v)
+	pub.Add(pub, new(big.Int).Exp(g, b, n))
+	pub.Mod(pub, n)
+	return &Server{v: v, b: b, pub: pub}, nil
+}
+
+// Server the server side of a single exchange.
+type Server struct {
+	v   *big.Int
+	b   *big.Int
+	pub *big.Int
+}
+
+// Public return the public ephemeral B that should be sent to the client
+// along with the salt.
+func (s *Server) Public() []byte {
+	return pad(s.pub)
+}
+
+// Verify check given proof M1 of the client that send given public ephemeral
+// A. Return the proof M2 of the server that should be sent back to the
+// client, or ErrProof if the client does not know the password.
+func (s *Server) Verify(clientPublic, proof []byte) ([]byte, error) {
+	a := new(big.Int).SetBytes(clientPublic)
+	if !inGroup(a) {
+		return nil, ErrInvalidPublic
+	}
+	u := hashInt(pad(a), pad(s.pub))
+	if u.Sign() == 0 {
+		return nil, ErrInvalidPublic
+	}
+
+	// S = (A * v^u)^b
+	sec := new(big.Int).Exp(s.v, u, n)
+	sec.Mul(sec, a)
+	sec.Exp(sec, s.b, n)
+	key := hash(pad(sec))
+
+	m1 := hash(pad(a), pad(s.pub), key)
+	if subtle.ConstantTimeCompare(m1, proof) != 1 {
+		return nil, ErrProof
+	}
+	return hash(pad(a), m1, key), nil
+}
+
+// NewClient return Client with new random ephemeral for given username and
+// password.
+func NewClient(username, password string) (*Client, error) {
+	a, err := randomInt()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{username: username, password: password, a: a, pub: new(big.Int).Exp(g, a, n)}, nil
+}
+
+// Client the client side of a single exchange.
+type Client struct {
+	username string
+	password string
+	a        *big.Int
+	pub      *big.Int
+	// m2 the expected proof of the server.
+	m2 []byte
+}
+
+// Public return the public ephemeral A that should be sent to the server.
+func (c *Client) Public() []byte {
+	return pad(c.pub)
+}
+
+// Proof return the proof M1 using the salt and the public ephemeral B that's
+// sent by the server.
+func (c *Client) Proof(salt, serverPublic []byte) ([]byte, error) {
+	b := new(big.Int).SetBytes(serverPublic)
+	if !inGroup(b) {
+		return nil, ErrInvalidPublic
+	}
+	u := hashInt(pad(c.pub), pad(b))
+	if u.Sign() == 0 {
+		return nil, ErrInvalidPublic
+	}
+	x := privateKey(c.username, c.password, salt)
+
+	// S = (B - k*g^x)^(a + u*x)
+	base := new(big.Int).Exp(g, x, n)
+	base.Mul(base, k)
+	base.Sub(b, base)
+	base.Mod(base, n)
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, c.a)
+	sec := new(big.Int).Exp(base, exp, n)
+	key := hash(pad(sec))
+
+	m1 := hash(pad(c.pub), pad(b), key)
+	c.m2 = hash(pad(c.pub), m1, key)
+	return m1, nil
+}
+
+// VerifyServer whether given proof M2 show that the server know the verifier.
+// Should be called after Proof.
+func (c *Client) VerifyServer(proof []byte) bool {
+	return c.m2 != nil && subtle.ConstantTimeCompare(c.m2, proof) == 1
+}
+
+// ValidVerifier whether given verifier may be used by NewServer.
+func ValidVerifier(verifier []byte) bool {
+	return inGroup(new(big.Int).SetBytes(verifier))
+}
+
+// inGroup whether given number is between zero and N exclusively.
+func inGroup(i *big.Int) bool {
+	return i.Sign() > 0 && i.Cmp(n) < 0
+}
+
+// privateKey return x of given username, password and salt.
+func privateKey(username, password string, salt []byte) *big.Int {
+	return hashInt(salt, hash([]byte(username+":"+password)))
+}
+
+// randomInt return new random private ephemeral.
+func randomInt() (*big.Int, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// pad return the bytes of given number that's left padded to the size of N.
+func pad(i *big.Int) []byte {
+	return i.FillBytes(make([]byte, (n.BitLen()+7)/8))
+}
+
+// hash return the SHA-256 of the concatenation of given bytes.
+func hash(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// hashInt same as hash but return it as a number.
+func hashInt(parts ...[]byte) *big.Int {
+	return new(big.Int).SetBytes(hash(parts...))
+}
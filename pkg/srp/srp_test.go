@@ -0,0 +1,84 @@
+package srp_test
+
+import (
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/srp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exchange run a whole exchange using given password on the client side and
+// the verifier of given registered password on the server side.
+func exchange(t *testing.T, registered, password string) (m2 []byte, client *srp.Client, err error) {
+	salt, verifier, err := srp.NewVerifier("john", registered)
+	require.NoError(t, err)
+
+	client, err = srp.NewClient("john", password)
+	require.NoError(t, err)
+	server, err := srp.NewServer(verifier)
+	require.NoError(t, err)
+
+	m1, err := client.Proof(salt, server.Public())
+	require.NoError(t, err)
+	m2, err = server.Verify(client.Public(), m1)
+	return m2, client, err
+}
+
+func TestExchange(t *testing.T) {
+	t.Run("Given the right password both sides should prove each other", func(t *testing.T) {
+		m2, client, err := exchange(t, "correct horse", "correct horse")
+
+		require.NoError(t, err)
+		assert.True(t, client.VerifyServer(m2))
+	})
+
+	t.Run("Given wrong password should return ErrProof", func(t *testing.T) {
+		_, _, err := exchange(t, "correct horse", "battery staple")
+
+		assert.ErrorIs(t, err, srp.ErrProof)
+	})
+
+	t.Run("Given the proof of other server should not be trusted by the client", func(t *testing.T) {
+		m2, _, err := exchange(t, "correct horse", "correct horse")
+		require.NoError(t, err)
+		_, client, err := exchange(t, "correct horse", "correct horse")
+		require.NoError(t, err)
+
+		assert.False(t, client.VerifyServer(m2))
+	})
+}
+
+func TestServer_Verify(t *testing.T) {
+	_, verifier, err := srp.NewVerifier("john", "correct horse")
+	require.NoError(t, err)
+	server, err := srp.NewServer(verifier)
+	require.NoError(t, err)
+
+	t.Run("Given zero public ephemeral should return ErrInvalidPublic", func(t *testing.T) {
+		_, err := server.Verify(make([]byte, 256), []byte("proof"))
+		assert.ErrorIs(t, err, srp.ErrInvalidPublic)
+	})
+
+	t.Run("Given public ephemeral that's not less than N should return ErrInvalidPublic", func(t *testing.T) {
+		big := make([]byte, 257)
+		big[0] = 1
+		_, err := server.Verify(big, []byte("proof"))
+		assert.ErrorIs(t, err, srp.ErrInvalidPublic)
+	})
+}
+
+func TestNewServer(t *testing.T) {
+	t.Run("Given zero verifier should return ErrInvalidVerifier", func(t *testing.T) {
+		_, err := srp.NewServer([]byte{0})
+		assert.ErrorIs(t, err, srp.ErrInvalidVerifier)
+		assert.False(t, srp.ValidVerifier([]byte{0}))
+	})
+
+	t.Run("Given verifier from NewVerifier should be valid", func(t *testing.T) {
+		_, verifier, err := srp.NewVerifier("john", "correct horse")
+		require.NoError(t, err)
+		assert.True(t, srp.ValidVerifier(verifier))
+	})
+}
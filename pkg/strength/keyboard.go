@@ -0,0 +1,88 @@
+package strength
+
+import "math"
+
+const (
+	// keyboardStartingPositions number of keys in qwerty keyboard.
+	keyboardStartingPositions = 47
+	// keyboardAverageDegree average number of adjacent keys for each key in
+	// qwerty keyboard.
+	keyboardAverageDegree = 4.6
+)
+
+// keyPos position of a key in the keyboard. Column is the horizontal distance
+// from the left edge in key unit.
+type keyPos struct {
+	row int
+	col float64
+}
+
+// qwertyRows rows of qwerty keyboard, both the normal and the shifted
+// characters, along with their horizontal offset.
+var qwertyRows = []struct {
+	normal, shifted string
+	offset          float64
+}{
+	{"`1234567890-=", "~!@#$%^&*()_+", 0},
+	{"qwertyuiop[]\\", "QWERTYUIOP{}|", 1.5},
+	{"asdfghjkl;'", "ASDFGHJKL:\"", 1.75},
+	{"zxcvbnm,./", "ZXCVBNM<>?", 2.25},
+}
+
+// qwerty the position of each key in qwerty keyboard.
+var qwerty, qwertyShifted = func() (map[rune]keyPos, map[rune]bool) {
+	pos := make(map[rune]keyPos)
+	shifted := make(map[rune]bool)
+	for row, r := range qwertyRows {
+		sh := []rune(r.shifted)
+		for col, c := range []rune(r.normal) {
+			p := keyPos{row: row, col: r.offset + float64(col)}
+			pos[c] = p
+			pos[sh[col]] = p
+			shifted[sh[col]] = true
+		}
+	}
+	return pos, shifted
+}()
+
+// adjacent whether both given keys are next to each other in the keyboard.
+func adjacent(a, b rune) bool {
+	pa, ok := qwerty[a]
+	if !ok {
+		return false
+	}
+	pb, ok := qwerty[b]
+	if !ok || pa == pb {
+		return false
+	}
+
+	dc := math.Abs(pa.col - pb.col)
+	switch math.Abs(float64(pa.row - pb.row)) {
+	case 0:
+		return dc == 1
+	case 1:
+		return dc < 1
+	}
+	return false
+}
+
+// keyboardTurns count the number of direction changes in given run of
+// adjacent keys. A straight line has one turn.
+func keyboardTurns(tok []rune) int {
+	turns := 1
+	var last [2]float64
+	for i := 1; i < len(tok); i++ {
+		a, b := qwerty[tok[i-1]], qwerty[tok[i]]
+		dir := [2]float64{float64(b.row - a.row), math.Copysign(1, b.col-a.col)}
+		if i > 1 && dir != last {
+			turns++
+		}
+		last = dir
+	}
+	return turns
+}
+
+// isShifted whether given key need shift to be typed.
+func isShifted(r rune) bool {
+	return qwertyShifted[r]
+}
@@ -0,0 +1,363 @@
+package strength
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Pattern name of pattern that's found in a password.
+type Pattern string
+
+const (
+	// Dictionary pattern for common passwords and words, including the
+	// reversed and l33t variants.
+	Dictionary Pattern = "dictionary"
+	// Sequence pattern for sequence of characters such as abc or 9876.
+	Sequence Pattern = "sequence"
+	// Repeat pattern for repeated characters such as aaa or abcabc.
+	Repeat Pattern = "repeat"
+	// Keyboard pattern for adjacent keys in keyboard such as qwerty or zxcv.
+	Keyboard Pattern = "keyboard"
+)
+
+// Match a pattern that's found in a password.
+type Match struct {
+	// Pattern which kind of pattern that's found.
+	Pattern Pattern
+	// Token the part of the password that's matched.
+	Token string
+	// Guesses estimated number of guesses needed to crack the Token.
+	Guesses float64
+	// i and j the start and the end (inclusive) index of Token.
+	i, j int
+}
+
+//go:embed dictionary.txt
+var rawDictionary string
+
+// rankedWords common passwords and words sorted by how frequent they're used.
+// The value is the rank that start from 1.
+var rankedWords = func() map[string]int {
+	m := make(map[string]int)
+	sc := bufio.NewScanner(strings.NewReader(rawDictionary))
+	for rank := 1; sc.Scan(); {
+		w := strings.TrimSpace(sc.Text())
+		if w == "" {
+			continue
+		}
+		if _, ok := m[w]; !ok {
+			m[w] = rank
+			rank++
+		}
+	}
+	return m
+}()
+
+// l33t common substitution that's used to make a word looks stronger.
+var l33t = map[rune]rune{
+	'4': 'a',
+	'@': 'a',
+	'8': 'b',
+	'(': 'c',
+	'3': 'e',
+	'6': 'g',
+	'1': 'i',
+	'!': 'i',
+	'|': 'l',
+	'0': 'o',
+	'$': 's',
+	'5': 's',
+	'7': 't',
+	'+': 't',
+	'2': 'z',
+}
+
+// dictionary ranked words that's used to find Dictionary pattern.
+type dictionary map[string]int
+
+// newDictionary return dictionary that contain rankedWords and given inputs.
+// All inputs are ranked as the most guessable words.
+func newDictionary(inputs ...string) dictionary {
+	d := make(dictionary, len(rankedWords)+len(inputs))
+	for w, r := range rankedWords {
+		d[w] = r + 1
+	}
+	for _, in := range inputs {
+		if in = strings.ToLower(strings.TrimSpace(in)); in != "" {
+			d[in] = 1
+		}
+	}
+	return d
+}
+
+// omnimatch find all matches from all supported patterns.
+func omnimatch(pw []rune, d dictionary) []Match {
+	var ms []Match
+	ms = append(ms, dictionaryMatch(pw, d)...)
+	ms = append(ms, sequenceMatch(pw)...)
+	ms = append(ms, repeatMatch(pw, d)...)
+	ms = append(ms, keyboardMatch(pw)...)
+	return ms
+}
+
+// dictionaryMatch find every substring that's exist in given dictionary
+// either as is, reversed or after l33t substitution.
+func dictionaryMatch(pw []rune, d dictionary) []Match {
+	const minLen = 3
+	var ms []Match
+
+	n := len(pw)
+	for i := 0; i < n; i++ {
+		for j := i + minLen - 1; j < n; j++ {
+			tok := pw[i : j+1]
+			low := strings.ToLower(string(tok))
+			upper := uppercaseVariations(tok)
+
+			if rank, ok := d[low]; ok {
+				ms = append(ms, newMatch(Dictionary, tok, i, j, float64(rank)*upper))
+				continue
+			}
+			if rank, ok := d[reverse(low)]; ok {
+				ms = append(ms, newMatch(Dictionary, tok, i, j, float64(rank)*upper*2))
+				continue
+			}
+			if sub, changed := unl33t(low); changed {
+				if rank, ok := d[sub]; ok {
+					ms = append(ms, newMatch(Dictionary, tok, i, j, float64(rank)*upper*l33tVariations(low)))
+				}
+			}
+		}
+	}
+	return ms
+}
+
+// sequenceMatch find run of characters that has the same distance between
+// each of them, such as abcd, 2468 or zyx.
+func sequenceMatch(pw []rune) []Match {
+	const maxDelta = 2
+	var ms []Match
+
+	n := len(pw)
+	for i := 0; i < n-2; {
+		delta := pw[i+1] - pw[i]
+		if delta == 0 || delta > maxDelta || delta < -maxDelta || !sameClass(pw[i], pw[i+1]) {
+			i++
+			continue
+		}
+		j := i + 1
+		for j+1 < n && pw[j+1]-pw[j] == delta && sameClass(pw[j], pw[j+1]) {
+			j++
+		}
+		if j-i+1 >= 3 {
+			ms = append(ms, newMatch(Sequence, pw[i:j+1], i, j, sequenceGuesses(pw[i:j+1], delta)))
+		}
+		i = j
+	}
+	return ms
+}
+
+// sequenceGuesses estimated guesses for sequence that start with the first
+// character of given token.
+func sequenceGuesses(tok []rune, delta rune) float64 {
+	var base float64
+	switch first := unicode.ToLower(tok[0]); {
+	case strings.ContainsRune("az019", first):
+		// obvious starting point
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if delta < 0 {
+		base *= 2
+	}
+	return base * float64(len(tok))
+}
+
+// repeatMatch find the longest run of repeated base token at each index such
+// as aaaa or abcabc.
+func repeatMatch(pw []rune, d dictionary) []Match {
+	var ms []Match
+
+	n := len(pw)
+	for i := 0; i < n-1; {
+		var bestUnit, bestCount int
+		for unit := 1; i+unit*2 <= n; unit++ {
+			count := 1
+			for i+unit*(count+1) <= n && string(pw[i:i+unit]) == string(pw[i+unit*count:i+unit*(count+1)]) {
+				count++
+			}
+			if count > 1 && unit*count > bestUnit*bestCount {
+				bestUnit, bestCount = unit, count
+			}
+		}
+		if bestCount < 2 {
+			i++
+			continue
+		}
+
+		j := i + bestUnit*bestCount - 1
+		base := pw[i : i+bestUnit]
+		baseGuesses, _ := cheapest(base, omnimatch(base, d))
+		ms = append(ms, newMatch(Repeat, pw[i:j+1], i, j, baseGuesses*float64(bestCount)))
+		i = j + 1
+	}
+	return ms
+}
+
+// keyboardMatch find run of adjacent keys in qwerty keyboard.
+func keyboardMatch(pw []rune) []Match {
+	var ms []Match
+
+	n := len(pw)
+	for i := 0; i < n-2; {
+		j := i
+		for j+1 < n && adjacent(pw[j], pw[j+1]) {
+			j++
+		}
+		if j-i+1 >= 3 {
+			ms = append(ms, newMatch(Keyboard, pw[i:j+1], i, j, keyboardGuesses(pw[i:j+1])))
+			i = j
+			continue
+		}
+		i++
+	}
+	return ms
+}
+
+// keyboardGuesses estimated guesses for keyboard pattern by considering the
+// length, number of turns and the number of shifted keys.
+func keyboardGuesses(tok []rune) float64 {
+	l := len(tok)
+	turns := keyboardTurns(tok)
+
+	var g float64
+	for i := 2; i <= l; i++ {
+		for t := 1; t <= minInt(turns, i-1); t++ {
+			g += binom(i-1, t-1) * keyboardStartingPositions * math.Pow(keyboardAverageDegree, float64(t))
+		}
+	}
+
+	// add the variations of shifted keys
+	var shifted int
+	for _, r := range tok {
+		if isShifted(r) {
+			shifted++
+		}
+	}
+	if shifted > 0 {
+		if unshifted := l - shifted; unshifted == 0 {
+			g *= 2
+		} else {
+			var v float64
+			for i := 1; i <= minInt(shifted, unshifted); i++ {
+				v += binom(l, i)
+			}
+			g *= v
+		}
+	}
+	return g
+}
+
+// uppercaseVariations the number of possible capitalization of given token.
+func uppercaseVariations(tok []rune) float64 {
+	var upper, lower int
+	for _, r := range tok {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	// common capitalization: first letter, last letter or all upper-cased
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(tok[0]) || unicode.IsUpper(tok[len(tok)-1]))) {
+		return 2
+	}
+
+	var v float64
+	for i := 1; i <= minInt(upper, lower); i++ {
+		v += binom(upper+lower, i)
+	}
+	return v
+}
+
+// l33tVariations the number of possible l33t substitution of given token.
+func l33tVariations(tok string) float64 {
+	var subbed int
+	for _, r := range tok {
+		if _, ok := l33t[r]; ok {
+			subbed++
+		}
+	}
+	return math.Max(2, math.Pow(2, float64(subbed)))
+}
+
+// unl33t replace all l33t characters in given token and report whether there
+// is any replaced character.
+func unl33t(tok string) (string, bool) {
+	var changed bool
+	res := strings.Map(func(r rune) rune {
+		if sub, ok := l33t[r]; ok {
+			changed = true
+			return sub
+		}
+		return r
+	}, tok)
+	return res, changed
+}
+
+// sameClass whether both given runes are in the same class of lower-cased,
+// upper-cased or digits.
+func sameClass(a, b rune) bool {
+	switch {
+	case unicode.IsLower(a):
+		return unicode.IsLower(b)
+	case unicode.IsUpper(a):
+		return unicode.IsUpper(b)
+	case unicode.IsDigit(a):
+		return unicode.IsDigit(b)
+	}
+	return false
+}
+
+// newMatch return new Match from given arguments.
+func newMatch(p Pattern, tok []rune, i, j int, guesses float64) Match {
+	return Match{Pattern: p, Token: string(tok), Guesses: math.Max(guesses, 1), i: i, j: j}
+}
+
+// reverse return reversed string of given s.
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// binom the binomial coefficient of n choose k.
+func binom(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r = r * float64(n-k+d) / float64(d)
+	}
+	return r
+}
+
+// minInt return the smaller one of given integers.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
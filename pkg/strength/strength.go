@@ -0,0 +1,169 @@
+// Package strength estimate how hard a password is to be guessed. The
+// estimation is heavily inspired by zxcvbn, which try to find the cheapest
+// way to guess given password by combining known patterns such as
+// dictionary words, sequences, repeats and keyboard patterns then fallback to
+// bruteforce for the rest of the characters.
+//
+// REF: https://github.com/dropbox/zxcvbn
+package strength
+
+import (
+	"math"
+	"unicode"
+)
+
+const (
+	// MinScore the lowest score that may be returned by Estimate.
+	MinScore = 0
+	// MaxScore the highest score that may be returned by Estimate.
+	MaxScore = 4
+	// minSubmatchGuesses the least number of guesses for each match that's
+	// only cover part of the password. Prevent a bunch of tiny matches from
+	// being counted as cheaper than it should be.
+	minSubmatchGuesses = 50
+	// MaxLength the number of leading characters that's estimated, the rest
+	// are ignored. The matching grow with the cube of the length, and this
+	// many are already more than enough for the highest score.
+	MaxLength = 100
+)
+
+// Result the estimation result of a password.
+type Result struct {
+	// Score integer from 0-4 that's useful to implement a strength bar.
+	//
+	//	0: too guessable, risky password (guesses < 10^3)
+	//	1: very guessable, protection from throttled online attacks (guesses < 10^6)
+	//	2: somewhat guessable, protection from unthrottled online attacks (guesses < 10^8)
+	//	3: safely unguessable, moderate protection from offline attacks (guesses < 10^10)
+	//	4: very unguessable, strong protection from offline attacks (guesses >= 10^10)
+	Score int
+	// Guesses estimated number of guesses needed to crack the password.
+	Guesses float64
+	// Matches the cheapest sequence of patterns found in the password.
+	Matches []Match
+}
+
+// Estimate the strength of given password. Optionally given inputs will be
+// treated as dictionary words that's highly guessable, such as the username
+// or the category name that belong to the password. Only the first MaxLength
+// characters are estimated.
+func Estimate(pw string, inputs ...string) Result {
+	pr := []rune(pw)
+	if len(pr) > MaxLength {
+		pr = pr[:MaxLength]
+	}
+	if len(pr) == 0 {
+		return Result{Score: MinScore, Guesses: 1}
+	}
+
+	// collect all possible matches then look for the cheapest combination
+	ms := omnimatch(pr, newDictionary(inputs...))
+	guesses, seq := cheapest(pr, ms)
+
+	return Result{
+		Score:   score(guesses),
+		Guesses: guesses,
+		Matches: seq,
+	}
+}
+
+// cheapest find a sequence of non-overlapping matches that covers the whole
+// given password with the least number of guesses. Characters that are not
+// covered by any matches will be counted as bruteforce.
+func cheapest(pw []rune, ms []Match) (float64, []Match) {
+	n := len(pw)
+	card := float64(cardinality(pw))
+
+	// best[i] hold the cheapest guesses for pw[:i] and from[i] the match that
+	// end at i which produce that number.
+	best := make([]float64, n+1)
+	from := make([]*Match, n+1)
+	best[0] = 1
+	for i := 1; i <= n; i++ {
+		// bruteforce the character at i-1
+		best[i] = best[i-1] * card
+		from[i] = nil
+
+		for k := range ms {
+			m := &ms[k]
+			if m.j+1 != i {
+				continue
+			}
+			g := m.Guesses
+			if m.i > 0 || m.j < n-1 {
+				g = math.Max(g, minSubmatchGuesses)
+			}
+			if c := best[m.i] * g; c < best[i] {
+				best[i] = c
+				from[i] = m
+			}
+		}
+	}
+
+	// walk back to collect the sequence of matches
+	var seq []Match
+	for i := n; i > 0; {
+		if m := from[i]; m != nil {
+			seq = append([]Match{*m}, seq...)
+			i = m.i
+			continue
+		}
+		i--
+	}
+
+	return best[n], seq
+}
+
+// cardinality the size of character set that's used by given password. Used
+// as the base when bruteforce the characters.
+func cardinality(pw []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range pw {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	var c int
+	if lower {
+		c += 26
+	}
+	if upper {
+		c += 26
+	}
+	if digit {
+		c += 10
+	}
+	if symbol {
+		c += 33
+	}
+	if other {
+		c += 100
+	}
+	return c
+}
+
+// score map given guesses to score.
+func score(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	}
+	return MaxScore
+}
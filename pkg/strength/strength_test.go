@@ -0,0 +1,107 @@
+package strength_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/pkg/strength"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sample      string
+		inputs      []string
+		expectScore int
+		expectMatch strength.Pattern
+	}{
+		{
+			name:        "Given empty string should return 0 as the score",
+			sample:      "",
+			expectScore: 0,
+		},
+		{
+			name:        "Given common password 'password' should return 0 as the score and dictionary as the pattern",
+			sample:      "password",
+			expectScore: 0,
+			expectMatch: strength.Dictionary,
+		},
+		{
+			name:        "Given l33t variant of common password should return 0 as the score and dictionary as the pattern",
+			sample:      "P@ssw0rd",
+			expectScore: 0,
+			expectMatch: strength.Dictionary,
+		},
+		{
+			name:        "Given reversed common password should return 0 as the score and dictionary as the pattern",
+			sample:      "drowssap",
+			expectScore: 0,
+			expectMatch: strength.Dictionary,
+		},
+		{
+			name:        "Given alphabet sequence should return 0 as the score and sequence as the pattern",
+			sample:      "lmnopq",
+			expectScore: 0,
+			expectMatch: strength.Sequence,
+		},
+		{
+			name:        "Given repeated characters should return 0 as the score and repeat as the pattern",
+			sample:      "zzzzzzzz",
+			expectScore: 0,
+			expectMatch: strength.Repeat,
+		},
+		{
+			name:        "Given adjacent keys in keyboard should return 1 as the score and keyboard as the pattern",
+			sample:      "wertyuio",
+			expectScore: 1,
+			expectMatch: strength.Keyboard,
+		},
+		{
+			name:        "Given password that's the same as the username in inputs should return 0 as the score",
+			sample:      "johnny-boy",
+			inputs:      []string{"johnny-boy"},
+			expectScore: 0,
+			expectMatch: strength.Dictionary,
+		},
+		{
+			name:        "Given a bunch of dictionary words should return 3 as the score",
+			sample:      "correcthorsebatterystaple",
+			expectScore: 3,
+		},
+		{
+			name:        "Given random characters should return 4 as the score",
+			sample:      "x7#Lq!9vR2@m",
+			expectScore: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := strength.Estimate(tc.sample, tc.inputs...)
+			assert.Equal(t, tc.expectScore, res.Score)
+
+			if tc.expectMatch != "" {
+				assert.NotEmpty(t, res.Matches)
+				assert.Equal(t, tc.expectMatch, res.Matches[0].Pattern)
+			}
+		})
+	}
+}
+
+func TestEstimate_LongPassword(t *testing.T) {
+	pw := strings.Repeat("x7#Lq!9vR2@m", 1000)
+
+	start := time.Now()
+	res := strength.Estimate(pw, pw)
+	assert.Less(t, time.Since(start), time.Second, "only the leading characters should be estimated")
+	assert.Equal(t, strength.Estimate(pw[:strength.MaxLength]).Guesses, res.Guesses)
+}
+
+func BenchmarkEstimate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		strength.Estimate("coRrecth0rseba++ery9.23.2007staple$")
+	}
+}
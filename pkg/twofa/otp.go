@@ -44,6 +44,12 @@ func InitOTPWithConfig(v *viper.Viper) (*otp.OTP, error) {
 	return initOTP(v)
 }
 
+// InitOTPWithSecret same as InitOTPWithConfig but use given secret instead of
+// the one in config. Used to verify the code of each user.
+func InitOTPWithSecret(v *viper.Viper, secret string) (*otp.OTP, error) {
+	return newOTP(v.GetString("cred.type"), secret)
+}
+
 // initOTP return pointer to otp.OTP which already initialized either using
 // TOTP or HOTP based on the config.
 func initOTP(v *viper.Viper) (*otp.OTP, error) {
@@ -56,10 +62,13 @@ func initOTP(v *viper.Viper) (*otp.OTP, error) {
 	if v != nil {
 		newV = v
 	}
-	// retrieve the secret
-	secret := newV.GetString("cred.secret")
-	// then the otp type
-	otpType := newV.GetString("cred.type")
+	// retrieve the secret and the otp type
+	return newOTP(newV.GetString("cred.type"), newV.GetString("cred.secret"))
+}
+
+// newOTP return pointer to otp.OTP using given secret, either TOTP or HOTP
+// based on given otp type.
+func newOTP(otpType, secret string) (*otp.OTP, error) {
 	// decide the otp type
 	var otpObj *otp.OTP
 	switch strings.ToLower(otpType) {
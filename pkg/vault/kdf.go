@@ -0,0 +1,93 @@
+// Package vault protect the data key that encrypt the secrets at rest. The data
+// key is wrapped using a key that's derived from the master password with
+// Argon2id, and only kept unwrapped in memory while the vault is unlocked.
+package vault
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"github.com/mdanialr/pwman_backend/pkg/seal"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// saltSize the size of the random salt of each wrapped key.
+const saltSize = 16
+
+// ErrWrongPassword the master password can not unwrap the data key.
+var ErrWrongPassword = errors.New("wrong master password")
+
+// Params the cost of Argon2id that's used to derive the key from the master
+// password.
+type Params struct {
+	// Time the number of passes over the memory.
+	Time uint32
+	// Memory the size of the memory in KiB.
+	Memory uint32
+	// Threads the degree of parallelism.
+	Threads uint8
+}
+
+// DefaultParams the recommended cost for the new wrapped key, which is the
+// second recommended option of RFC 9106.
+var DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// Wrapped the data key that's encrypted using the key derived from the master
+// password along with what's needed to derive it again.
+type Wrapped struct {
+	Key    []byte
+	Salt   []byte
+	Params Params
+}
+
+// NewDataKey return new random data key.
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, seal.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Wrap encrypt given data key using the key that's derived from given master
+// password with new random salt.
+func Wrap(dataKey []byte, password string, p Params) (*Wrapped, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	kek := derive(password, salt, p)
+	defer wipe(kek)
+	key, err := seal.Encrypt(dataKey, kek)
+	if err != nil {
+		return nil, err
+	}
+	return &Wrapped{Key: key, Salt: salt, Params: p}, nil
+}
+
+// Unwrap decrypt the data key in given Wrapped using given master password.
+// Return ErrWrongPassword if the password does not match.
+func Unwrap(w Wrapped, password string) ([]byte, error) {
+	kek := derive(password, w.Salt, w.Params)
+	defer wipe(kek)
+	key, err := seal.Open(w.Key, kek)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return key, nil
+}
+
+// derive return the key that's derived from given password and salt using
+// Argon2id.
+func derive(password string, salt []byte, p Params) []byte {
+	return argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, seal.KeySize)
+}
+
+// wipe overwrite given key with zeros, so it does not linger in memory.
+func wipe(key []byte) {
+	for i := range key {
+		key[i] = 0
+	}
+}
@@ -0,0 +1,46 @@
+package vault_test
+
+import (
+	"testing"
+
+	"github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cheap the Argon2id cost that's cheap enough for the tests.
+var cheap = vault.Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestWrap(t *testing.T) {
+	key, err := vault.NewDataKey()
+	require.NoError(t, err)
+
+	t.Run("Given wrapped key should be unwrapped using the same master password", func(t *testing.T) {
+		w, err := vault.Wrap(key, "correct horse", cheap)
+		require.NoError(t, err)
+		assert.NotContains(t, string(w.Key), string(key))
+
+		res, err := vault.Unwrap(*w, "correct horse")
+		require.NoError(t, err)
+		assert.Equal(t, key, res)
+	})
+
+	t.Run("Given the same key should use different salt every time", func(t *testing.T) {
+		w1, err := vault.Wrap(key, "correct horse", cheap)
+		require.NoError(t, err)
+		w2, err := vault.Wrap(key, "correct horse", cheap)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, w1.Salt, w2.Salt)
+		assert.NotEqual(t, w1.Key, w2.Key)
+	})
+
+	t.Run("Given wrong master password should return ErrWrongPassword", func(t *testing.T) {
+		w, err := vault.Wrap(key, "correct horse", cheap)
+		require.NoError(t, err)
+
+		_, err = vault.Unwrap(*w, "battery staple")
+		assert.ErrorIs(t, err, vault.ErrWrongPassword)
+	})
+}
@@ -0,0 +1,319 @@
+package vault
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/pkg/seal"
+)
+
+// prefix mark the secret that's encrypted by Keeper, so the plaintext that's
+// stored before the master password is set can still be read as is. It's
+// followed by the version of the data key, then a colon.
+const prefix = "vault:v"
+
+var (
+	// ErrLocked the secret can not be encrypted nor decrypted because the
+	// vault is locked.
+	ErrLocked = errors.New("vault is locked")
+	// ErrUnknownKey the secret is encrypted using the data key of a version
+	// that's not kept by Keeper.
+	ErrUnknownKey = errors.New("unknown data key version")
+)
+
+// Cipher signature of what encrypt the secrets before they're stored and
+// decrypt them after they're retrieved.
+type Cipher interface {
+	// Encrypt return the ciphertext of given secret.
+	Encrypt(secret string) (string, error)
+	// Decrypt return the plaintext of given stored secret.
+	Decrypt(stored string) (string, error)
+}
+
+// KeyVersion return the version of the data key that encrypt given stored
+// secret. Return false if it's not encrypted by Keeper.
+func KeyVersion(stored string) (uint, bool) {
+	v, _, ok := parse(stored)
+	return v, ok
+}
+
+// NewKeeper return Keeper that lock itself after it's not used for given idle
+// duration. Zero idle means it's never locked unless Lock is called.
+func NewKeeper(idle time.Duration) *Keeper {
+	return &Keeper{idle: idle}
+}
+
+// Keeper keep the unwrapped data keys in memory by their version while the
+// vault is unlocked and use them as the Cipher. The newest one encrypt, while
+// any of them decrypt, so the secrets can still be read while they're
+// re-encrypted using the new key. It's safe to be used concurrently.
+type Keeper struct {
+	mu      sync.Mutex
+	idle    time.Duration
+	enabled bool
+	keys    map[uint][]byte
+	current uint
+	used    time.Time
+	timer   *time.Timer
+}
+
+// Enable mark that the master password is set, so the secrets should be
+// encrypted and the vault should be unlocked before they can be used. Before
+// this is called Keeper just store the secrets as is.
+func (k *Keeper) Enable() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.enabled = true
+}
+
+// Enabled whether the master password is set.
+func (k *Keeper) Enabled() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.enabled
+}
+
+// Locked whether the master password is set but the vault is not unlocked
+// yet.
+func (k *Keeper) Locked() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.enabled && k.keys == nil
+}
+
+// Unlock keep given data keys by their version until the vault is locked
+// again, either by Lock or after it's idle for too long. The keys are wiped
+// once the vault is locked, so the caller should not use them afterward.
+func (k *Keeper) Unlock(keys map[uint][]byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.wipe()
+	k.keys = make(map[uint][]byte, len(keys))
+	for v, key := range keys {
+		k.add(v, key)
+	}
+	k.enabled = true
+	k.used = time.Now()
+	if k.idle > 0 && k.timer == nil {
+		k.timer = time.AfterFunc(k.idle, k.expire)
+	}
+}
+
+// Add keep given data key of given version along with the others, which
+// encrypt the new secrets if it's the newest. Return ErrLocked if the vault is
+// locked.
+func (k *Keeper) Add(version uint, key []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		return ErrLocked
+	}
+	// the same version is always the same key
+	if _, ok := k.keys[version]; ok {
+		wipe(key)
+		return nil
+	}
+	k.add(version, key)
+	return nil
+}
+
+// Retain wipe the data keys whose version is not one of given versions, such
+// as the retired ones after the rotation.
+func (k *Keeper) Retain(versions ...uint) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keep := make(map[uint]bool, len(versions))
+	for _, v := range versions {
+		keep[v] = true
+	}
+	for v, key := range k.keys {
+		if !keep[v] {
+			wipe(key)
+			delete(k.keys, v)
+		}
+	}
+}
+
+// Has whether the data key of given version is kept.
+func (k *Keeper) Has(version uint) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	_, ok := k.keys[version]
+	return ok
+}
+
+// Current return the version of the data key that encrypt the new secrets.
+// Return zero if the vault is locked.
+func (k *Keeper) Current() uint {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.keys == nil {
+		return 0
+	}
+	return k.current
+}
+
+// OpenKey decrypt given data key that's sealed using the data key of given
+// version, which is how the newer data key is shared with whoever hold the
+// previous one.
+func (k *Keeper) OpenKey(version uint, sealed []byte) ([]byte, error) {
+	var key []byte
+	_, err := k.use(version, func(prev []byte) (err error) {
+		key, err = seal.Open(sealed, prev)
+		return err
+	})
+	return key, err
+}
+
+// Lock wipe the data keys from memory.
+func (k *Keeper) Lock() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lock()
+}
+
+// Encrypt return the secret that's encrypted using the data key. Return the
+// secret as is if the master password is not set yet.
+func (k *Keeper) Encrypt(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+
+	var ct []byte
+	var version uint
+	ok, err := k.use(0, func(key []byte) (err error) {
+		version = k.current
+		ct, err = seal.Encrypt([]byte(secret), key)
+		return err
+	})
+	if !ok {
+		return secret, err
+	}
+	if err != nil {
+		return "", err
+	}
+	return prefix + strconv.FormatUint(uint64(version), 10) + ":" + base64.StdEncoding.EncodeToString(ct), nil
+}
+
+// Decrypt return the plaintext of given stored secret. Return the secret as
+// is if it's not encrypted by Keeper, unless the vault is locked, so the ones
+// that's not encrypted yet are not readable while locked either.
+func (k *Keeper) Decrypt(stored string) (string, error) {
+	version, data, ok := parse(stored)
+	if !ok {
+		if stored != "" && k.Locked() {
+			return "", ErrLocked
+		}
+		return stored, nil
+	}
+	ct, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", seal.ErrDecrypt
+	}
+
+	var pt []byte
+	ok, err = k.use(version, func(key []byte) (err error) {
+		pt, err = seal.Open(ct, key)
+		return err
+	})
+	if !ok && err == nil {
+		err = ErrLocked
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
+
+// use call given fn with the data key of given version, or the current one if
+// it's zero, and mark it as used. Return false without calling fn if the
+// master password is not set yet, along with ErrLocked if it's set but the
+// vault is locked.
+func (k *Keeper) use(version uint, fn func(key []byte) error) (bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		if k.enabled {
+			return false, ErrLocked
+		}
+		return false, nil
+	}
+	if version == 0 {
+		version = k.current
+	}
+	key, ok := k.keys[version]
+	if !ok {
+		return true, ErrUnknownKey
+	}
+	k.used = time.Now()
+	return true, fn(key)
+}
+
+// expire lock the vault if it's not used since the idle duration, otherwise
+// check again once the rest of the duration is over.
+func (k *Keeper) expire() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		k.timer = nil
+		return
+	}
+	if left := k.idle - time.Since(k.used); left > 0 {
+		k.timer.Reset(left)
+		return
+	}
+	k.lock()
+}
+
+// lock wipe the data keys and stop the idle timer. Should be called while
+// holding the mutex.
+func (k *Keeper) lock() {
+	k.wipe()
+	if k.timer != nil {
+		k.timer.Stop()
+		k.timer = nil
+	}
+}
+
+// add keep given data key of given version and make it the current one if
+// it's the newest. Should be called while holding the mutex.
+func (k *Keeper) add(version uint, key []byte) {
+	k.keys[version] = key
+	if version > k.current {
+		k.current = version
+	}
+}
+
+// wipe wipe all data keys. Should be called while holding the mutex.
+func (k *Keeper) wipe() {
+	for _, key := range k.keys {
+		wipe(key)
+	}
+	k.keys, k.current = nil, 0
+}
+
+// parse return the version of the data key and the base64 ciphertext of given
+// stored secret. Return false if it's not encrypted by Keeper.
+func parse(stored string) (uint, string, bool) {
+	if !strings.HasPrefix(stored, prefix) {
+		return 0, "", false
+	}
+	v, data, ok := strings.Cut(strings.TrimPrefix(stored, prefix), ":")
+	if !ok {
+		return 0, "", false
+	}
+	version, err := strconv.ParseUint(v, 10, 32)
+	if err != nil || version == 0 {
+		return 0, "", false
+	}
+	return uint(version), data, true
+}
@@ -0,0 +1,154 @@
+package vault_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdanialr/pwman_backend/pkg/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeeper(t *testing.T) {
+	key, err := vault.NewDataKey()
+	require.NoError(t, err)
+
+	t.Run("Given keeper whose master password is not set should store the secret as is", func(t *testing.T) {
+		k := vault.NewKeeper(0)
+
+		ct, err := k.Encrypt("secret")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", ct)
+		assert.False(t, k.Locked())
+	})
+
+	t.Run("Given unlocked keeper should encrypt the secret and decrypt it back", func(t *testing.T) {
+		k := vault.NewKeeper(0)
+		k.Unlock(map[uint][]byte{1: append([]byte{}, key...)})
+
+		ct, err := k.Encrypt("secret")
+		require.NoError(t, err)
+		assert.NotContains(t, ct, "secret")
+
+		pt, err := k.Decrypt(ct)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", pt)
+	})
+
+	t.Run("Given secret that's stored before the master password is set should return it as is", func(t *testing.T) {
+		k := vault.NewKeeper(0)
+		k.Unlock(map[uint][]byte{1: append([]byte{}, key...)})
+
+		pt, err := k.Decrypt("secret")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", pt)
+	})
+
+	t.Run("Given locked keeper should return ErrLocked", func(t *testing.T) {
+		k := vault.NewKeeper(0)
+		k.Unlock(map[uint][]byte{1: append([]byte{}, key...)})
+		ct, err := k.Encrypt("secret")
+		require.NoError(t, err)
+
+		k.Lock()
+		assert.True(t, k.Locked())
+		_, err = k.Decrypt(ct)
+		assert.ErrorIs(t, err, vault.ErrLocked)
+		// including the one that's stored before the master password is set
+		_, err = k.Decrypt("secret")
+		assert.ErrorIs(t, err, vault.ErrLocked)
+		_, err = k.Encrypt("secret")
+		assert.ErrorIs(t, err, vault.ErrLocked)
+	})
+
+	t.Run("Given secret that's encrypted using other key should return error", func(t *testing.T) {
+		other, err := vault.NewDataKey()
+		require.NoError(t, err)
+		k := vault.NewKeeper(0)
+		k.Unlock(map[uint][]byte{1: other})
+		ct, err := k.Encrypt("secret")
+		require.NoError(t, err)
+
+		k.Unlock(map[uint][]byte{1: append([]byte{}, key...)})
+		_, err = k.Decrypt(ct)
+		assert.Error(t, err)
+		_, err = k.Decrypt(strings.TrimSuffix(ct, "=") + "!")
+		assert.Error(t, err)
+	})
+
+	t.Run("Given newer data key should encrypt using it while still decrypt using the older one", func(t *testing.T) {
+		newer, err := vault.NewDataKey()
+		require.NoError(t, err)
+		k := vault.NewKeeper(0)
+		k.Unlock(map[uint][]byte{1: append([]byte{}, key...)})
+		old, err := k.Encrypt("old secret")
+		require.NoError(t, err)
+
+		require.NoError(t, k.Add(2, newer))
+		assert.Equal(t, uint(2), k.Current())
+		ct, err := k.Encrypt("new secret")
+		require.NoError(t, err)
+		v, ok := vault.KeyVersion(ct)
+		assert.True(t, ok)
+		assert.Equal(t, uint(2), v)
+
+		pt, err := k.Decrypt(old)
+		require.NoError(t, err)
+		assert.Equal(t, "old secret", pt)
+
+		// the retired key is wiped
+		k.Retain(2)
+		assert.False(t, k.Has(1))
+		_, err = k.Decrypt(old)
+		assert.ErrorIs(t, err, vault.ErrUnknownKey)
+		pt, err = k.Decrypt(ct)
+		require.NoError(t, err)
+		assert.Equal(t, "new secret", pt)
+	})
+
+	t.Run("Given locked keeper should not add the data key", func(t *testing.T) {
+		k := vault.NewKeeper(0)
+		k.Enable()
+		assert.ErrorIs(t, k.Add(2, append([]byte{}, key...)), vault.ErrLocked)
+	})
+
+	t.Run("Given keeper that's not used for the idle duration should lock itself", func(t *testing.T) {
+		k := vault.NewKeeper(50 * time.Millisecond)
+		k.Unlock(map[uint][]byte{1: append([]byte{}, key...)})
+
+		// keep using it for longer than the idle duration
+		for i := 0; i < 4; i++ {
+			time.Sleep(20 * time.Millisecond)
+			_, err := k.Encrypt("secret")
+			require.NoError(t, err)
+		}
+		assert.False(t, k.Locked())
+
+		assert.Eventually(t, k.Locked, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestKeyVersion(t *testing.T) {
+	testCases := []struct {
+		name    string
+		stored  string
+		version uint
+		ok      bool
+	}{
+		{name: "Given plaintext should return false", stored: "secret"},
+		{name: "Given the first version should return 1", stored: "vault:v1:AAAA", version: 1, ok: true},
+		{name: "Given newer version should return it", stored: "vault:v12:AAAA", version: 12, ok: true},
+		{name: "Given zero version should return false", stored: "vault:v0:AAAA"},
+		{name: "Given no version should return false", stored: "vault:vx:AAAA"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, ok := vault.KeyVersion(tc.stored)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.version, v)
+		})
+	}
+}
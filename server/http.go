@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
@@ -133,9 +134,14 @@ func HTTP() {
 		filesystem.New(filesystem.Config{Root: http.Dir(dir)}),
 	)
 
+	// init app context that will be canceled when shutting down
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// init internal http handlers
 	h := app.HttpHandler{
+		Ctx:     ctx,
 		R:       fiberApp.Group("/api"),
+		Public:  fiberApp,
 		DB:      db,
 		Config:  v,
 		Log:     zapLog,
@@ -161,6 +167,9 @@ func HTTP() {
 	// blocks main thread until an interrupt is received
 	<-c
 	zapLog.Info("gracefully shutting down...")
+	// stop all background jobs and close the event streams first, otherwise
+	// shutdown keep waiting for the streams to be closed by the clients
+	cancel()
 	fiberApp.Shutdown()
 	zapLog.Info("running cleanup tasks...")
 	// some clean up task should be done here